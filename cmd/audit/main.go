@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bybit-mcp/internal/audit"
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/storage"
+)
+
+func main() {
+	configFile := flag.String("config", "config.json", "配置文件路径")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s [-config config.json] verify\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || flag.Arg(0) != "verify" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(config.Options{File: *configFile, Env: os.Environ()})
+	if err != nil {
+		log.Fatalf("无法加载配置文件: %v", err)
+	}
+	if cfg.Storage.Driver != storage.DriverSQLite {
+		log.Fatalf("存储驱动为%q，没有可验证的持久化审计记录", cfg.Storage.Driver)
+	}
+
+	store, err := storage.Open(cfg.Storage)
+	if err != nil {
+		log.Fatalf("无法打开存储: %v", err)
+	}
+	defer store.Close()
+
+	result, verifyErr := audit.Verify(context.Background(), store)
+	data, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(data))
+
+	if verifyErr != nil {
+		store.Close()
+		log.Fatalf("审计链验证失败: %v", verifyErr)
+	}
+	log.Printf("审计链完整，共%d条记录", result.Records)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/bybit-mcp/internal/backtest"
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+func main() {
+	configFile := flag.String("config", "backtest.json", "回测配置文件路径，支持JSON和YAML")
+	offline := flag.Bool("offline", false, "只使用缓存的历史数据，不下载")
+	logLevel := flag.String("log-level", "info", "日志级别")
+	listStrategies := flag.Bool("strategies", false, "列出可用的策略后退出")
+	flag.Parse()
+
+	if *listStrategies {
+		fmt.Println(strings.Join(backtest.Strategies(), "\n"))
+		return
+	}
+
+	cfg, err := backtest.LoadConfig(*configFile)
+	if err != nil {
+		log.Fatalf("回测配置错误: %v", err)
+	}
+	if *offline {
+		cfg.Data.Offline = true
+	}
+	strategy, err := backtest.NewStrategy(cfg.Strategy, cfg.Params)
+	if err != nil {
+		log.Fatalf("回测配置错误: %v", err)
+	}
+
+	// 历史K线只调用公共接口，不需要API密钥
+	env, err := bybitapi.ResolveEnvironment(cfg.Data.Environment, cfg.Data.BaseURL, "")
+	if err != nil {
+		log.Fatalf("回测配置错误: %v", err)
+	}
+	log.Printf("从%s（%s）获取历史数据，历史行情库: %s，缓存目录: %s", env.Name, env.RESTURL, cfg.Data.HistoryPath, cfg.Data.CacheDir)
+
+	historyStore, err := marketdata.Open(cfg.Data.HistoryPath)
+	if err != nil {
+		log.Fatalf("打开历史行情库失败: %v", err)
+	}
+	defer historyStore.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	backtestLogger := logger.New(*logLevel, "stdout")
+	market := service.NewBybitService("", "", env, backtestLogger)
+	history := marketdata.NewHistory(historyStore, marketdata.NewServiceSource(market), backtestLogger)
+	loader := backtest.NewLoader(market, history, cfg.Data, backtestLogger)
+	data, err := loader.Load(ctx, cfg)
+	if err != nil {
+		log.Fatalf("加载历史数据失败: %v", err)
+	}
+	log.Printf("已加载%d根K线、%d笔逐笔成交", len(data.Bars), len(data.Trades))
+
+	result, err := backtest.Run(ctx, cfg, strategy, data, backtestLogger)
+	if err != nil {
+		log.Fatalf("回测失败: %v", err)
+	}
+	fmt.Println(result.Summary)
+
+	if err := result.WriteFiles(cfg.Output); err != nil {
+		log.Fatalf("保存回测结果失败: %v", err)
+	}
+}
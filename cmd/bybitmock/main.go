@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/bybit-mcp/internal/bybitmock"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:18080", "监听地址")
+	apiKey := flag.String("api-key", bybitmock.DefaultAPIKey, "接受的API密钥")
+	apiSecret := flag.String("api-secret", bybitmock.DefaultAPISecret, "接受的API密钥对应的密文")
+	flag.Parse()
+
+	server := bybitmock.New(*apiKey, *apiSecret)
+	log.Printf("模拟Bybit V5服务器监听%s，把服务的bybit.environment设为custom、baseUrl设为http://%s即可连接", *addr, *addr)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatalf("模拟服务器退出: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+func main() {
+	dbPath := flag.String("db", "data/history.db", "历史行情库路径")
+	environment := flag.String("env", bybitapi.EnvMainnet, "获取数据的Bybit环境")
+	baseURL := flag.String("base-url", "", "自定义REST地址，优先于-env")
+	category := flag.String("category", bybitapi.CategoryLinear, "产品类别")
+	symbols := flag.String("symbols", "", "交易对，多个用逗号分隔，例如BTCUSDT,ETHUSDT")
+	kinds := flag.String("kinds", strings.Join(marketdata.Kinds(), ","), "数据类型，多个用逗号分隔")
+	intervals := flag.String("intervals", "60", "K线周期，多个用逗号分隔")
+	oiIntervals := flag.String("oi-intervals", "1h", "持仓量周期，多个用逗号分隔")
+	start := flag.String("start", "", "开始时间，格式为2006-01-02或RFC3339（UTC）")
+	end := flag.String("end", "", "结束时间，为空时到当前时间")
+	logLevel := flag.String("log-level", "info", "日志级别")
+	list := flag.Bool("list", false, "列出库中的序列和已获取的区间后退出")
+	flag.Parse()
+
+	store, err := marketdata.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("无法打开历史行情库: %v", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if *list {
+		if err := printSeries(ctx, store); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	if *symbols == "" || *start == "" {
+		log.Fatalf("必须指定-symbols和-start")
+	}
+	from, err := parseTime(*start)
+	if err != nil {
+		log.Fatalf("开始时间格式错误: %v", err)
+	}
+	to := time.Now().UnixMilli()
+	if *end != "" {
+		if to, err = parseTime(*end); err != nil {
+			log.Fatalf("结束时间格式错误: %v", err)
+		}
+	}
+	if from >= to {
+		log.Fatalf("开始时间必须早于结束时间")
+	}
+
+	// 历史行情只调用公共接口，不需要API密钥
+	env, err := bybitapi.ResolveEnvironment(*environment, *baseURL, "")
+	if err != nil {
+		log.Fatalf("环境配置错误: %v", err)
+	}
+	log.Printf("从%s（%s）获取历史行情，保存到%s", env.Name, env.RESTURL, *dbPath)
+
+	historyLogger := logger.New(*logLevel, "stdout")
+	history := marketdata.NewHistory(store, marketdata.NewServiceSource(service.NewBybitService("", "", env, historyLogger)), historyLogger)
+	err = history.Backfill(ctx, *category, split(*symbols), split(*kinds), split(*intervals), split(*oiIntervals), from, to)
+	if err != nil {
+		log.Fatalf("获取历史行情失败: %v", err)
+	}
+	log.Println("历史行情已是最新")
+}
+
+// 打印库中的序列和已获取的区间
+func printSeries(ctx context.Context, store *marketdata.Store) error {
+	list, err := store.ListSeries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, series := range list {
+		ranges, err := store.Coverage(ctx, series)
+		if err != nil {
+			return err
+		}
+		spans := make([]string, 0, len(ranges))
+		for _, r := range ranges {
+			spans = append(spans, formatTime(r.From)+" 至 "+formatTime(r.To))
+		}
+		fmt.Printf("%s: %s\n", series, strings.Join(spans, "，"))
+	}
+	return nil
+}
+
+// 按逗号拆分参数，忽略空项
+func split(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// 解析RFC3339或日期格式的UTC时间，返回毫秒
+func parseTime(s string) (int64, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UnixMilli(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMilli(), nil
+}
+
+// 格式化毫秒时间
+func formatTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format("2006-01-02 15:04")
+}
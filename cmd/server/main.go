@@ -2,62 +2,294 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/bybit-mcp/internal/alert"
 	"github.com/bybit-mcp/internal/api"
+	"github.com/bybit-mcp/internal/audit"
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/cassette"
 	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/paper"
+	"github.com/bybit-mcp/internal/reload"
+	"github.com/bybit-mcp/internal/risk"
+	"github.com/bybit-mcp/internal/scanner"
 	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/internal/tlsconfig"
+	"github.com/bybit-mcp/internal/withdrawal"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+	"github.com/bybit-mcp/pkg/ratelimit"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // 命令行参数
 type CommandLineArgs struct {
-	ConfigFile string
-	Port       int
+	ConfigFile  string
+	Port        int
+	Overrides   overrideFlags
+	PrintConfig bool
+}
+
+// 可以重复指定的配置覆盖参数
+type overrideFlags []string
+
+func (o *overrideFlags) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrideFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
 }
 
 func main() {
 	// 解析命令行参数
 	var args CommandLineArgs
-	flag.StringVar(&args.ConfigFile, "config", "config.json", "配置文件路径")
-	flag.IntVar(&args.Port, "port", 50051, "服务监听端口（覆盖配置文件）")
+	flag.StringVar(&args.ConfigFile, "config", "config.json", "配置文件路径，支持JSON和YAML")
+	flag.IntVar(&args.Port, "port", 50051, "服务监听端口（覆盖配置文件和环境变量）")
+	flag.Var(&args.Overrides, "set", "覆盖配置项，格式为路径=值，例如--set=logger.level=debug，可以重复指定")
+	flag.BoolVar(&args.PrintConfig, "print-config", false, "打印生效的配置（密钥已脱敏）后退出")
 	flag.Parse()
 
-	// 加载配置
-	var cfg *config.Config
-	var err error
+	// 只有显式指定的参数才覆盖配置文件和环境变量
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	// 按默认值、配置文件、环境变量、命令行参数的顺序加载配置
+	opts := config.Options{
+		File:      args.ConfigFile,
+		Env:       os.Environ(),
+		Overrides: args.Overrides,
+	}
+	if explicit["port"] {
+		opts.Overrides = append(opts.Overrides, fmt.Sprintf("server.port=%d", args.Port))
+	}
+	if _, err := os.Stat(args.ConfigFile); os.IsNotExist(err) && !explicit["config"] {
+		log.Printf("配置文件%s不存在，使用默认配置", args.ConfigFile)
+		opts.File = ""
+	}
 
-	// 尝试加载配置文件
-	cfg, err = config.LoadConfig(args.ConfigFile)
+	cfg, err := config.Load(opts)
 	if err != nil {
-		log.Printf("无法加载配置文件: %v, 使用默认配置", err)
-		cfg = config.DefaultConfig()
+		log.Fatalf("无法加载配置: %v", err)
 	}
 
-	// 命令行参数覆盖配置文件
-	if args.Port != 0 {
-		cfg.Server.Port = args.Port
+	if args.PrintConfig {
+		data, err := json.MarshalIndent(cfg.Masked(), "", "  ")
+		if err != nil {
+			log.Fatalf("无法序列化配置: %v", err)
+		}
+		fmt.Println(string(data))
+		return
 	}
 
-	// 创建Bybit服务
-	bybitService := service.NewBybitService(cfg.Bybit.APIKey, cfg.Bybit.APISecret)
+	// 录制时每次启动使用新的会话目录
+	recording := cfg.Recording
+	if recording.Mode == config.RecordingRecord && recording.Session == "" {
+		recording.Session = time.Now().Format("20060102-150405")
+	}
+
+	// 为每个账户创建Bybit服务
+	// 连接主网且未允许主网交易的账户拒绝变更类调用；启用模拟交易时只从Bybit获取行情，交易在本地模拟
+	// 回放时请求不会发送到交易所，不限制变更类调用，以便重现录制时的全部操作
+	router := service.NewRouter(cfg.Bybit.DefaultAccount)
+	protected := map[string]bool{}
+	environments := map[string]bybitapi.Environment{}
+	simulators := []*paper.Service{}
+	recorders := []*cassette.Recorder{}
+	// 所有组件共用一个日志记录器，重新加载配置时修改级别和输出位置对全部组件生效
+	appLogger := logger.New(cfg.Logger.Level, cfg.Logger.Output)
+	for _, account := range cfg.Bybit.AccountList() {
+		env, err := cfg.Bybit.AccountEnvironment(account)
+		if err != nil {
+			log.Fatalf("账户%s的环境配置错误: %v", account.Name, err)
+		}
+		switch recording.Mode {
+		case config.RecordingRecord:
+			recorder, err := cassette.NewRecorder(recording.CassettePath(account.Name), nil)
+			if err != nil {
+				log.Fatalf("无法录制账户%s的请求: %v", account.Name, err)
+			}
+			recorders = append(recorders, recorder)
+			env.Transport = recorder
+		case config.RecordingReplay:
+			player, err := cassette.Load(recording.CassettePath(account.Name))
+			if err != nil {
+				log.Fatalf("无法加载账户%s的录制: %v", account.Name, err)
+			}
+			env.Transport = player
+		}
+		// 重新加载配置时新建的客户端沿用同一个录制或回放Transport
+		environments[account.Name] = env
+
+		accountService := service.NewBybitService(account.APIKey, account.APISecret, env, appLogger)
+		if cfg.Paper.Enabled {
+			simulator := paper.New(accountService, cfg.Paper, appLogger)
+			simulators = append(simulators, simulator)
+			accountService = simulator
+		} else if env.Mainnet && !cfg.Bybit.AllowMainnetTrading && recording.Mode != config.RecordingReplay {
+			protected[account.Name] = true
+		}
+
+		var limiter *ratelimit.Limiter
+		if account.RateLimit > 0 {
+			limiter = ratelimit.New(account.RateLimit, account.Burst)
+		}
+		router.Add(account.Name, accountService, limiter, account.Master)
+	}
+	log.Printf("已加载%d个账户，默认账户: %s", len(router.Accounts()), router.DefaultAccount())
+	printEnvironmentBanner(router.Accounts(), environments, protected)
+	if cfg.Paper.Enabled {
+		log.Println("已启用模拟交易: 订单、仓位和余额只在本地模拟，不会发送到交易所，服务重启后清空")
+	}
+	switch recording.Mode {
+	case config.RecordingRecord:
+		log.Printf("正在录制Bybit请求到%s，签名和API密钥不会写入文件", filepath.Join(recording.Dir, recording.Session))
+	case config.RecordingReplay:
+		log.Printf("回放%s中录制的Bybit请求，不会访问交易所", filepath.Join(recording.Dir, recording.Session))
+	}
+	var bybitService service.BybitService = router
 	// 设置调试模式
 	if cfg.Bybit.Debug {
 		// 使用bybitapi客户端的调试模式
 		log.Println("启用调试模式")
 	}
 
+	// 打开持久化存储
+	store, err := storage.Open(cfg.Storage)
+	if err != nil {
+		log.Fatalf("无法打开存储: %v", err)
+	}
+	defer store.Close()
+	log.Printf("使用存储驱动: %s", cfg.Storage.Driver)
+
+	bybitService = service.NewPersistingService(bybitService, store, appLogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 模拟交易定时刷新行情，撮合挂单、触发止盈止损、收取资金费用和检查强平
+	for _, simulator := range simulators {
+		go simulator.Run(ctx, simulator.UpdateInterval())
+	}
+
+	// 定时保存仓位和钱包快照
+	if cfg.Storage.SnapshotInterval > 0 {
+		interval := time.Duration(cfg.Storage.SnapshotInterval) * time.Second
+		snapshotter := service.NewSnapshotter(bybitService, store, interval, appLogger)
+		go snapshotter.Run(ctx)
+	}
+
 	// 创建MCP服务器
 	mcpServer := api.NewBybitMCPServer(bybitService)
+	mcpServer.SetAuditor(audit.New(store, appLogger), store)
+	mcpServer.SetRouter(router)
+
+	// 历史行情库，缺少的区间通过请求选择的账户获取
+	if cfg.History.Path != "" {
+		historyStore, err := marketdata.Open(cfg.History.Path)
+		if err != nil {
+			log.Fatalf("无法打开历史行情库: %v", err)
+		}
+		defer historyStore.Close()
+		history := marketdata.NewHistory(historyStore, marketdata.NewServiceSource(router), appLogger)
+		history.SetOffline(cfg.History.Offline)
+		mcpServer.SetHistory(history)
+		log.Printf("使用历史行情库: %s，离线模式: %v", cfg.History.Path, cfg.History.Offline)
+	}
+
+	// 行情扫描，定时通过默认账户获取行情
+	marketScanner := scanner.New(router, cfg.Scanner.Categories, time.Duration(cfg.Scanner.Interval)*time.Second, time.Duration(cfg.Scanner.Window)*time.Minute, appLogger)
+	go marketScanner.Run(ctx)
+	mcpServer.SetScanner(marketScanner)
+
+	// 告警，规则保存在存储中，定时通过规则所属的账户获取行情、仓位和钱包余额检查条件
+	alerts := alert.New(cfg.Alerts, router, store, appLogger)
+	if err := alerts.Init(ctx); err != nil {
+		log.Fatalf("加载告警规则失败: %v", err)
+	}
+	go alerts.Run(ctx)
+	mcpServer.SetAlertManager(alerts)
+	if cfg.Alerts.Webhook.URL != "" {
+		log.Printf("告警事件同时发送到Webhook: %s", cfg.Alerts.Webhook.URL)
+	}
+
+	// 提现策略：白名单、金额上限、冷却期和二次审批
+	withdrawals := withdrawal.New(cfg.Withdrawal, store, appLogger, mcpServer.SubmitWithdrawal)
+	if err := withdrawals.Init(ctx); err != nil {
+		log.Fatalf("初始化提现白名单失败: %v", err)
+	}
+	mcpServer.SetWithdrawalManager(withdrawals)
+	log.Printf("提现白名单共%d个地址，二次审批: %v", len(cfg.Withdrawal.Whitelist), withdrawals.ApprovalRequired())
+
+	// 创建gRPC服务器，启用认证时先认证鉴权再选择账户
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Enabled {
+		authenticator, err = auth.New(cfg.Auth)
+		if err != nil {
+			log.Fatalf("认证配置错误: %v", err)
+		}
+		log.Printf("已启用调用方认证，共%d个客户端", len(cfg.Auth.Clients))
+	} else if cfg.Auth.Insecure {
+		log.Println("警告: 未启用调用方认证且设置了auth.insecure，任何能访问服务端口的客户端都可以调用全部接口")
+	} else {
+		log.Fatalf("未启用调用方认证，请配置auth.enabled和auth.clients；如果确实要允许任何客户端调用全部接口，请设置auth.insecure为true")
+	}
+	riskChecker := risk.New(cfg.Risk)
+	options := mcpServer.ServerOptions(api.Interceptors{
+		Authenticator: authenticator,
+		Router:        router,
+		Protected:     protected,
+		Withdrawals:   withdrawals,
+		Risk:          riskChecker,
+	})
 
-	// 创建gRPC服务器
-	server := grpc.NewServer()
+	// 配置文件变化、收到SIGHUP或调用ReloadConfig时重新加载可以在运行中修改的配置
+	configReloader := reload.New(opts, cfg, appLogger)
+	configReloader.Register(loggerComponent(appLogger))
+	configReloader.Register(accountsComponent(router, cfg.Bybit.AccountList(), environments, cfg.Paper.Enabled, appLogger))
+	configReloader.Register(withdrawalComponent(ctx, withdrawals, appLogger))
+	configReloader.Register(riskComponent(riskChecker))
+	if authenticator != nil {
+		configReloader.Register(authComponent(authenticator))
+	}
+	mcpServer.SetConfigReloader(configReloader)
+	go configReloader.Watch(ctx, reload.DefaultWatchInterval)
+
+	// 配置了证书时启用TLS，证书文件变化后自动重新加载
+	if cfg.Server.TLS.Enabled() {
+		reloader, err := tlsconfig.New(cfg.Server.TLS, appLogger)
+		if err != nil {
+			log.Fatalf("TLS配置错误: %v", err)
+		}
+		interval := tlsconfig.DefaultReloadInterval
+		if cfg.Server.TLS.ReloadInterval > 0 {
+			interval = time.Duration(cfg.Server.TLS.ReloadInterval) * time.Second
+		}
+		go reloader.Run(ctx, interval)
+		options = append(options, grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
+		log.Printf("已启用TLS，要求客户端证书: %v", cfg.Server.TLS.RequireClientCert)
+	} else {
+		log.Println("警告: 未配置TLS证书，使用明文连接")
+	}
+
+	server := grpc.NewServer(options...)
 
 	// 注册服务
 	api.RegisterBybitMCPServiceServer(server, mcpServer)
@@ -77,13 +309,40 @@ func main() {
 		}
 	}()
 
-	// 等待中断信号优雅地关闭服务器
+	// 收到SIGHUP时重新加载配置，收到中断信号时优雅地关闭服务器
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigCh {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		log.Println("收到SIGHUP，重新加载配置")
+		configReloader.Reload()
+	}
 
 	log.Println("正在关闭服务...")
 	// 优雅停止
 	server.GracefulStop()
+	for _, recorder := range recorders {
+		if err := recorder.Close(); err != nil {
+			log.Printf("录制文件%s: %v", recorder.Path(), err)
+		}
+	}
 	log.Println("服务已关闭")
+}
+
+// 打印每个账户连接的环境，主网账户额外提示是否允许交易
+func printEnvironmentBanner(accounts []string, environments map[string]bybitapi.Environment, protected map[string]bool) {
+	log.Println("==================== Bybit环境 ====================")
+	for _, name := range accounts {
+		env := environments[name]
+		log.Printf("账户%s: 环境=%s, REST=%s, WebSocket=%s", name, env.Name, env.RESTURL, env.PrivateWSURL)
+		switch {
+		case protected[name]:
+			log.Printf("账户%s连接主网（真实资金），未启用allowMainnetTrading，只允许查询", name)
+		case env.Mainnet:
+			log.Printf("警告: 账户%s连接主网（真实资金），已允许交易、划转和提现", name)
+		}
+	}
+	log.Println("===================================================")
 }
\ No newline at end of file
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/reload"
+	"github.com/bybit-mcp/internal/risk"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/withdrawal"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 日志级别和输出位置，log是所有组件共用的日志记录器
+func loggerComponent(log *logger.Logger) reload.Component {
+	return reload.Component{
+		Name: "logger",
+		Prepare: func(cfg *config.Config) (func(), error) {
+			return func() {
+				log.Configure(cfg.Logger.Level, cfg.Logger.Output)
+				logger.SetDefaultLogger(cfg.Logger.Level, cfg.Logger.Output)
+			}, nil
+		},
+	}
+}
+
+// 账户的API密钥轮换和限流速率，密钥变化的账户会创建新的API客户端
+// 账户的环境需要重启才能修改，新客户端沿用启动时的环境和共用的日志记录器；模拟交易只使用公共行情，保留原服务以免清空模拟账户
+func accountsComponent(router *service.Router, current []config.AccountConfig, environments map[string]bybitapi.Environment, paperMode bool, log *logger.Logger) reload.Component {
+	applied := map[string]config.AccountConfig{}
+	for _, account := range current {
+		applied[account.Name] = account
+	}
+
+	return reload.Component{
+		Name: "accounts",
+		Prepare: func(cfg *config.Config) (func(), error) {
+			accounts := cfg.Bybit.AccountList()
+			services := make([]service.BybitService, len(accounts))
+			for i, account := range accounts {
+				old := applied[account.Name]
+				if !paperMode && (account.APIKey != old.APIKey || account.APISecret != old.APISecret) {
+					services[i] = service.NewBybitService(account.APIKey, account.APISecret, environments[account.Name], log)
+				}
+			}
+
+			return func() {
+				for i, account := range accounts {
+					if err := router.Update(account.Name, services[i], account.RateLimit, account.Burst); err != nil {
+						continue
+					}
+					applied[account.Name] = account
+				}
+			}, nil
+		},
+	}
+}
+
+// 提现白名单、金额上限和审批设置
+func withdrawalComponent(ctx context.Context, manager *withdrawal.Manager, log *logger.Logger) reload.Component {
+	return reload.Component{
+		Name: "withdrawal",
+		Prepare: func(cfg *config.Config) (func(), error) {
+			return func() {
+				if err := manager.Update(ctx, cfg.Withdrawal); err != nil {
+					log.Error("记录提现白名单地址失败: %v", err)
+				}
+			}, nil
+		},
+	}
+}
+
+// 交易对白名单、订单数量和金额上限以及杠杆上限
+func riskComponent(checker *risk.Checker) reload.Component {
+	return reload.Component{
+		Name: "risk",
+		Prepare: func(cfg *config.Config) (func(), error) {
+			return func() {
+				checker.Update(cfg.Risk)
+			}, nil
+		},
+	}
+}
+
+// 调用方客户端、令牌和角色
+func authComponent(authenticator *auth.Authenticator) reload.Component {
+	return reload.Component{
+		Name: "auth",
+		Prepare: func(cfg *config.Config) (func(), error) {
+			next, err := auth.New(cfg.Auth)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				authenticator.Replace(next)
+			}, nil
+		},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
 	"github.com/bybit-mcp/pkg/logger"
 )
 
@@ -23,8 +24,14 @@ func main() {
 		logger.Fatal("请设置BYBIT_API_KEY和BYBIT_API_SECRET环境变量")
 	}
 
+	// 从环境变量获取运行环境，未设置时使用主网
+	env, err := bybitapi.ResolveEnvironment(os.Getenv("BYBIT_ENVIRONMENT"), "", "")
+	if err != nil {
+		logger.Fatal("BYBIT_ENVIRONMENT无效: %v", err)
+	}
+
 	// 创建Bybit服务
-	bybitService := service.NewBybitService(apiKey, apiSecret, "info", "stdout")
+	bybitService := service.NewBybitService(apiKey, apiSecret, env, logger.New(logger.InfoLevel, "stdout"))
 
 	// 创建上下文
 	ctx := context.Background()
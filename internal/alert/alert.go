@@ -0,0 +1,306 @@
+// Package alert 按客户端注册的条件定时检查行情、仓位和钱包余额，
+// 条件满足时写入日志、推送给订阅方并发送到Webhook
+//
+// 价格穿越只在两次检查之间穿越阈值时触发；其余条件在从不满足变为满足时触发，
+// 持续满足期间不会重复触发。两次触发的间隔小于冷却时间时不再触发。
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// 最多保存的告警规则数量
+const maxAlerts = 1000
+
+// 涨跌幅告警最长的时间窗口（分钟）
+const maxWindow = 24 * 60
+
+// 每个订阅方缓冲的事件数量，订阅方处理不及时时丢弃新事件
+const subscriberBuffer = 64
+
+// ErrNotFound 表示告警规则不存在
+var ErrNotFound = errors.New("告警规则不存在")
+
+// Event 是一次告警触发
+type Event struct {
+	ID        string  `json:"id"` // 事件ID，Webhook重试时不变，可用于去重
+	AlertID   string  `json:"alertId"`
+	Name      string  `json:"name,omitempty"`
+	Account   string  `json:"account"`
+	Type      string  `json:"type"`
+	Category  string  `json:"category,omitempty"`
+	Symbol    string  `json:"symbol,omitempty"`
+	Direction string  `json:"direction,omitempty"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"` // 触发时的值
+	Message   string  `json:"message"`
+	Time      int64   `json:"time"` // 触发时间（毫秒）
+}
+
+// CreateResult 是创建告警规则的结果
+// 已有条件完全相同的规则时不重复创建，返回已有的规则且Duplicate为true
+type CreateResult struct {
+	Alert     model.Alert `json:"alert"`
+	Duplicate bool        `json:"duplicate"`
+}
+
+// 告警规则和检查状态
+type rule struct {
+	alert model.Alert
+	armed bool    // 条件上次检查时不满足，满足时可以触发
+	last  float64 // 上次检查时的值，价格穿越用于判断是否穿越阈值，NaN表示尚未检查
+}
+
+// Manager 管理告警规则并定时检查
+type Manager struct {
+	service  service.BybitService
+	store    storage.Store
+	logger   *logger.Logger
+	webhook  *Webhook
+	interval time.Duration
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	rules       map[string]*rule
+	prices      map[priceKey][]pricePoint
+	subscribers map[int]chan Event
+	nextSub     int
+}
+
+// New 创建告警管理器，配置了Webhook地址时同时发送到Webhook
+func New(cfg config.AlertsConfig, svc service.BybitService, store storage.Store, log *logger.Logger) *Manager {
+	m := &Manager{
+		service:     svc,
+		store:       store,
+		logger:      log,
+		interval:    time.Duration(cfg.Interval) * time.Second,
+		cooldown:    time.Duration(cfg.Cooldown) * time.Second,
+		now:         time.Now,
+		rules:       map[string]*rule{},
+		prices:      map[priceKey][]pricePoint{},
+		subscribers: map[int]chan Event{},
+	}
+	if cfg.Webhook.URL != "" {
+		timeout := time.Duration(cfg.Webhook.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = defaultWebhookTimeout
+		}
+		m.webhook = NewWebhook(cfg.Webhook.URL, cfg.Webhook.Secret, timeout, log)
+	}
+	return m
+}
+
+// Init 从存储中加载告警规则，应在启动时调用
+func (m *Manager) Init(ctx context.Context) error {
+	alerts, err := m.store.ListAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, alert := range alerts {
+		m.rules[alert.ID] = newRule(alert)
+	}
+	return nil
+}
+
+func newRule(alert model.Alert) *rule {
+	return &rule{alert: alert, armed: true, last: math.NaN()}
+}
+
+// Run 启动Webhook发送并按间隔检查告警条件，直到上下文结束
+func (m *Manager) Run(ctx context.Context) {
+	if m.webhook != nil {
+		go m.webhook.Run(ctx)
+	}
+	if m.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Validate 检查告警规则的条件，同时补全默认的方向
+func (m *Manager) Validate(alert *model.Alert) error {
+	if alert.Direction == "" && alert.Type != model.AlertPriceCross && alert.Type != model.AlertPriceChange {
+		alert.Direction = model.AlertAbove
+	}
+	if alert.Direction != "" && alert.Direction != model.AlertAbove && alert.Direction != model.AlertBelow {
+		return fmt.Errorf("direction只能是above或below")
+	}
+	if alert.Cooldown < 0 {
+		return fmt.Errorf("冷却时间不能为负数")
+	}
+
+	switch alert.Type {
+	case model.AlertPriceCross, model.AlertPriceChange:
+		if alert.Category != bybitapi.CategorySpot && alert.Category != bybitapi.CategoryLinear && alert.Category != bybitapi.CategoryInverse {
+			return fmt.Errorf("价格告警只支持spot、linear和inverse")
+		}
+		if alert.Symbol == "" {
+			return fmt.Errorf("价格告警必须指定交易对")
+		}
+		if alert.Threshold <= 0 {
+			return fmt.Errorf("价格告警的阈值必须大于0")
+		}
+		if alert.Type == model.AlertPriceChange && (alert.Window <= 0 || alert.Window > maxWindow) {
+			return fmt.Errorf("涨跌幅告警的时间窗口必须在1到%d分钟之间", maxWindow)
+		}
+	case model.AlertFundingRate, model.AlertPositionPnl:
+		if alert.Category != bybitapi.CategoryLinear && alert.Category != bybitapi.CategoryInverse {
+			return fmt.Errorf("%s告警只支持linear和inverse", alert.Type)
+		}
+		if alert.Type == model.AlertFundingRate && alert.Symbol == "" {
+			return fmt.Errorf("资金费率告警必须指定交易对")
+		}
+	case model.AlertMMRate:
+		if alert.Threshold <= 0 {
+			return fmt.Errorf("维持保证金率告警的阈值必须大于0")
+		}
+		alert.Category, alert.Symbol = "", ""
+	default:
+		return fmt.Errorf("不支持的告警类型%q，可选值: price_cross、price_change、funding_rate、position_pnl、mm_rate", alert.Type)
+	}
+	if alert.Type != model.AlertPriceChange {
+		alert.Window = 0
+	}
+	alert.Symbol = strings.ToUpper(alert.Symbol)
+	return nil
+}
+
+// 条件相同的两条规则视为重复
+func sameCondition(a, b *model.Alert) bool {
+	return a.Account == b.Account && a.Type == b.Type && a.Category == b.Category && a.Symbol == b.Symbol &&
+		a.Direction == b.Direction && a.Threshold == b.Threshold && a.Window == b.Window
+}
+
+// Create 检查并保存告警规则，已有条件相同的规则时返回已有的规则
+func (m *Manager) Create(ctx context.Context, alert *model.Alert) (*CreateResult, error) {
+	if err := m.Validate(alert); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.rules {
+		if sameCondition(&r.alert, alert) {
+			return &CreateResult{Alert: r.alert, Duplicate: true}, nil
+		}
+	}
+	if len(m.rules) >= maxAlerts {
+		return nil, fmt.Errorf("告警规则数量已达上限%d", maxAlerts)
+	}
+
+	alert.ID = uuid.NewString()
+	alert.CreatedAt = m.now().UnixMilli()
+	alert.LastTriggered, alert.TriggerCount = 0, 0
+	if alert.Cooldown == 0 {
+		alert.Cooldown = int(m.cooldown / time.Second)
+	}
+	if err := m.store.SaveAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+	m.rules[alert.ID] = newRule(*alert)
+	m.logger.Info("已创建告警: id=%s, account=%s, type=%s, symbol=%s, threshold=%v, caller=%s",
+		alert.ID, alert.Account, alert.Type, alert.Symbol, alert.Threshold, alert.CreatedBy)
+	return &CreateResult{Alert: *alert}, nil
+}
+
+// List 返回账户的告警规则，按创建时间正序
+func (m *Manager) List(account string) []model.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	alerts := []model.Alert{}
+	for _, r := range m.rules {
+		if r.alert.Account == account {
+			alerts = append(alerts, r.alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].CreatedAt != alerts[j].CreatedAt {
+			return alerts[i].CreatedAt < alerts[j].CreatedAt
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+	return alerts
+}
+
+// Delete 删除账户的告警规则，规则属于其他账户时视为不存在
+func (m *Manager) Delete(ctx context.Context, account, id string) (*model.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rules[id]
+	if !ok || r.alert.Account != account {
+		return nil, ErrNotFound
+	}
+	if err := m.store.DeleteAlert(ctx, id); err != nil {
+		return nil, err
+	}
+	delete(m.rules, id)
+	m.logger.Info("已删除告警: id=%s, account=%s", id, account)
+	return &r.alert, nil
+}
+
+// Subscribe 订阅告警事件，返回的取消函数停止订阅并关闭通道
+func (m *Manager) Subscribe() (<-chan Event, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextSub
+	m.nextSub++
+	ch := make(chan Event, subscriberBuffer)
+	m.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.subscribers, id)
+			close(ch)
+		})
+	}
+}
+
+// 写入日志、推送给订阅方并发送到Webhook，调用方持有m.mu
+func (m *Manager) deliver(event Event) {
+	m.logger.Warn("告警触发: id=%s, account=%s, %s", event.AlertID, event.Account, event.Message)
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+			m.logger.Warn("告警订阅方处理不及时，丢弃事件: id=%s", event.ID)
+		}
+	}
+	if m.webhook != nil {
+		m.webhook.Enqueue(event)
+	}
+}
@@ -0,0 +1,308 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bybit-mcp/internal/bybitmock"
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// Webhook接收方收到的一次请求
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// 按statuses依次返回状态码的Webhook接收方，用完后返回200
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, webhookRequest{header: req.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *webhookReceiver) received() []webhookRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]webhookRequest(nil), r.requests...)
+}
+
+// 缩短重试间隔，测试结束后恢复
+func fastRetries(t *testing.T) {
+	saved := webhookRetries
+	webhookRetries = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	t.Cleanup(func() { webhookRetries = saved })
+}
+
+func newTestWebhook(t *testing.T, statuses ...int) (*Webhook, *webhookReceiver) {
+	t.Helper()
+	receiver := &webhookReceiver{statuses: statuses}
+	srv := httptest.NewServer(receiver)
+	t.Cleanup(srv.Close)
+	return NewWebhook(srv.URL, "webhook-secret", time.Second, logger.New("fatal", "stderr")), receiver
+}
+
+func TestWebhookSignature(t *testing.T) {
+	webhook, receiver := newTestWebhook(t)
+	event := Event{ID: "event-1", AlertID: "alert-1", Account: "main", Type: model.AlertPriceCross, Symbol: "BTCUSDT", Threshold: 66000, Value: 66100, Time: 1700000000000}
+	webhook.deliver(context.Background(), event)
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("webhook requests = %d, want 1", len(requests))
+	}
+	req := requests[0]
+	if got := req.header.Get(HeaderEvent); got != "event-1" {
+		t.Errorf("%s = %q, want event-1", HeaderEvent, got)
+	}
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q: %v", HeaderTimestamp, req.header.Get(HeaderTimestamp), err)
+	}
+	want := "sha256=" + Sign([]byte("webhook-secret"), timestamp, req.body)
+	if got := req.header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if got := req.header.Get(HeaderSignature); got == "sha256="+Sign([]byte("other-secret"), timestamp, req.body) {
+		t.Error("signature does not depend on the secret")
+	}
+
+	var received Event
+	if err := json.Unmarshal(req.body, &received); err != nil {
+		t.Fatal(err)
+	}
+	if received != event {
+		t.Errorf("webhook body = %+v, want %+v", received, event)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	fastRetries(t)
+
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{"success", nil, 1},
+		{"retry on 500", []int{http.StatusInternalServerError}, 2},
+		{"retry on 503 twice", []int{http.StatusServiceUnavailable, http.StatusBadGateway}, 3},
+		{"retry on 429", []int{http.StatusTooManyRequests}, 2},
+		{"no retry on 400", []int{http.StatusBadRequest}, 1},
+		{"no retry on 401", []int{http.StatusUnauthorized}, 1},
+		{"no retry on 404", []int{http.StatusNotFound}, 1},
+		{"give up after retries", []int{500, 500, 500, 500, 500}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, receiver := newTestWebhook(t, tt.statuses...)
+			webhook.deliver(context.Background(), Event{ID: "event-1"})
+
+			requests := receiver.received()
+			if len(requests) != tt.attempts {
+				t.Fatalf("webhook requests = %d, want %d", len(requests), tt.attempts)
+			}
+			// 重试时事件ID不变，接收方可以据此去重
+			for _, req := range requests {
+				if got := req.header.Get(HeaderEvent); got != "event-1" {
+					t.Errorf("%s = %q, want event-1", HeaderEvent, got)
+				}
+			}
+		})
+	}
+}
+
+// 使用模拟Bybit服务器行情的告警管理器，now返回的时间由测试控制
+func newTestManager(t *testing.T, webhookURL string) (*Manager, *bybitmock.Server, *time.Time) {
+	t.Helper()
+	mock := bybitmock.New(bybitmock.DefaultAPIKey, bybitmock.DefaultAPISecret)
+	mock.Start()
+	t.Cleanup(mock.Close)
+
+	svc := service.NewBybitService(bybitmock.DefaultAPIKey, bybitmock.DefaultAPISecret, mock.Environment(), logger.New(logger.FatalLevel, "stderr"))
+	cfg := config.AlertsConfig{Cooldown: 60, Webhook: config.WebhookConfig{URL: webhookURL, Secret: "webhook-secret"}}
+	m := New(cfg, svc, storage.NewMemoryStore(), logger.New("fatal", "stderr"))
+	now := time.UnixMilli(1700000000000)
+	m.now = func() time.Time { return now }
+	return m, mock, &now
+}
+
+// 读取通道中已有的事件
+func drain(events <-chan Event) []Event {
+	var got []Event
+	for {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+func TestCreateDeduplicates(t *testing.T) {
+	m, _, _ := newTestManager(t, "")
+	ctx := context.Background()
+
+	first, err := m.Create(ctx, &model.Alert{Account: "main", Type: model.AlertPriceCross, Category: bybitapi.CategoryLinear, Symbol: "btcusdt", Threshold: 66000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Duplicate {
+		t.Fatal("first alert reported as duplicate")
+	}
+
+	second, err := m.Create(ctx, &model.Alert{Account: "main", Type: model.AlertPriceCross, Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT", Threshold: 66000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Duplicate || second.Alert.ID != first.Alert.ID {
+		t.Errorf("second alert = %+v, want duplicate of %s", second, first.Alert.ID)
+	}
+
+	// 其他账户的相同条件不是重复规则
+	other, err := m.Create(ctx, &model.Alert{Account: "sub", Type: model.AlertPriceCross, Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT", Threshold: 66000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Duplicate {
+		t.Error("alert of another account reported as duplicate")
+	}
+	if n := len(m.List("main")); n != 1 {
+		t.Errorf("alerts of main = %d, want 1", n)
+	}
+}
+
+func TestPriceCrossCooldown(t *testing.T) {
+	m, mock, now := newTestManager(t, "")
+	ctx := context.Background()
+	events, cancel := m.Subscribe()
+	defer cancel()
+
+	if _, err := m.Create(ctx, &model.Alert{Account: "main", Type: model.AlertPriceCross, Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT", Threshold: 66000, Direction: model.AlertAbove}); err != nil {
+		t.Fatal(err)
+	}
+	check := func(price float64, advance time.Duration) int {
+		*now = now.Add(advance)
+		mock.SetPrice(bybitapi.CategoryLinear, "BTCUSDT", price)
+		m.Check(ctx)
+		return len(drain(events))
+	}
+
+	// 第一次检查只记录价格
+	if n := check(65000, 0); n != 0 {
+		t.Fatalf("events after first check = %d, want 0", n)
+	}
+	if n := check(67000, 10*time.Second); n != 1 {
+		t.Fatalf("events after crossing = %d, want 1", n)
+	}
+	// 价格停留在阈值之上不再触发
+	if n := check(67500, 10*time.Second); n != 0 {
+		t.Errorf("events while above threshold = %d, want 0", n)
+	}
+	// 冷却时间内再次穿越不触发
+	check(65000, 10*time.Second)
+	if n := check(67000, 10*time.Second); n != 0 {
+		t.Errorf("events within cooldown = %d, want 0", n)
+	}
+	// 冷却时间结束后再次穿越触发
+	check(65000, time.Minute)
+	if n := check(67000, 10*time.Second); n != 1 {
+		t.Errorf("events after cooldown = %d, want 1", n)
+	}
+	// 向下穿越不满足above方向
+	if n := check(65000, 2*time.Minute); n != 0 {
+		t.Errorf("events when crossing below = %d, want 0", n)
+	}
+}
+
+func TestThresholdFiresOncePerBreach(t *testing.T) {
+	m, mock, now := newTestManager(t, "")
+	ctx := context.Background()
+	events, cancel := m.Subscribe()
+	defer cancel()
+
+	if _, err := m.Create(ctx, &model.Alert{Account: "main", Type: model.AlertFundingRate, Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT", Threshold: 0.001, Cooldown: 1}); err != nil {
+		t.Fatal(err)
+	}
+	check := func(rate float64) int {
+		*now = now.Add(time.Minute)
+		mock.SetFunding("BTCUSDT", rate, now.Add(time.Hour).UnixMilli())
+		m.Check(ctx)
+		return len(drain(events))
+	}
+
+	if n := check(0.002); n != 1 {
+		t.Fatalf("events on breach = %d, want 1", n)
+	}
+	// 条件持续满足时不重复触发
+	for i := 0; i < 3; i++ {
+		if n := check(0.003); n != 0 {
+			t.Fatalf("events while breached = %d, want 0", n)
+		}
+	}
+	// 恢复后再次满足时触发
+	check(0.0001)
+	if n := check(0.002); n != 1 {
+		t.Errorf("events on second breach = %d, want 1", n)
+	}
+}
+
+func TestManagerSendsWebhook(t *testing.T) {
+	receiver := &webhookReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	m, mock, now := newTestManager(t, srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.webhook.Run(ctx)
+
+	created, err := m.Create(ctx, &model.Alert{Account: "main", Type: model.AlertPriceCross, Category: bybitapi.CategoryLinear, Symbol: "ETHUSDT", Threshold: 3100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Check(ctx)
+	*now = now.Add(time.Minute)
+	mock.SetPrice(bybitapi.CategoryLinear, "ETHUSDT", 3200)
+	m.Check(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(receiver.received()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("webhook not delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var event Event
+	if err := json.Unmarshal(receiver.received()[0].body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.AlertID != created.Alert.ID || event.Value != 3200 {
+		t.Errorf("webhook event = %+v, want alert %s at 3200", event, created.Alert.ID)
+	}
+}
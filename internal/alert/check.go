@@ -0,0 +1,413 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/google/uuid"
+)
+
+// 一个产品类别中需要行情的交易对不超过这个数量时逐个获取，否则一次获取全部交易对
+const maxSymbolRequests = 5
+
+// 涨跌幅告警的价格历史
+type priceKey struct {
+	account, category, symbol string
+}
+
+type pricePoint struct {
+	time  int64
+	price float64
+}
+
+// 一个交易对的行情，缺失的字段为NaN
+type quote struct {
+	lastPrice   float64
+	fundingRate float64
+}
+
+// 仓位的查询条件
+type positionKey struct {
+	category, symbol string
+}
+
+// 一个账户在一次检查中获取的数据，获取失败的数据不存在
+type snapshot struct {
+	quotes    map[string]map[string]quote // 产品类别 -> 交易对 -> 行情
+	positions map[positionKey]float64     // 未实现盈亏合计
+	mmRate    float64                     // 统一账户维持保证金率，NaN表示未获取
+}
+
+// Check 获取各账户告警规则需要的数据并检查一次条件
+func (m *Manager) Check(ctx context.Context) {
+	m.mu.Lock()
+	byAccount := map[string][]*rule{}
+	for _, r := range m.rules {
+		byAccount[r.alert.Account] = append(byAccount[r.alert.Account], r)
+	}
+	m.mu.Unlock()
+
+	for account, rules := range byAccount {
+		data := m.fetch(service.WithAccount(ctx, account), account, rules)
+
+		m.mu.Lock()
+		now := m.now().UnixMilli()
+		m.recordPrices(account, rules, data, now)
+		for _, r := range rules {
+			// 获取数据期间被删除的规则不再检查
+			if m.rules[r.alert.ID] != r {
+				continue
+			}
+			if value, ok := m.value(r, data, now); ok {
+				m.evaluate(ctx, r, value, now)
+			}
+		}
+		m.mu.Unlock()
+	}
+	m.prunePrices(byAccount)
+}
+
+// 获取一个账户的规则需要的行情、仓位和钱包余额，每种数据只获取一次
+func (m *Manager) fetch(ctx context.Context, account string, rules []*rule) *snapshot {
+	symbols := map[string]map[string]bool{}
+	positions := map[positionKey]bool{}
+	wallet := false
+	for _, r := range rules {
+		switch r.alert.Type {
+		case model.AlertPositionPnl:
+			positions[positionKey{r.alert.Category, r.alert.Symbol}] = true
+		case model.AlertMMRate:
+			wallet = true
+		default:
+			if symbols[r.alert.Category] == nil {
+				symbols[r.alert.Category] = map[string]bool{}
+			}
+			symbols[r.alert.Category][r.alert.Symbol] = true
+		}
+	}
+
+	data := &snapshot{quotes: map[string]map[string]quote{}, positions: map[positionKey]float64{}, mmRate: math.NaN()}
+	for category, wanted := range symbols {
+		quotes, err := m.fetchQuotes(ctx, category, wanted)
+		if err != nil {
+			m.logger.Warn("告警获取%s行情失败: account=%s, err=%v", category, account, err)
+			continue
+		}
+		data.quotes[category] = quotes
+	}
+	for key := range positions {
+		pnl, err := m.fetchPnl(ctx, key)
+		if err != nil {
+			m.logger.Warn("告警获取%s仓位失败: account=%s, err=%v", key.category, account, err)
+			continue
+		}
+		data.positions[key] = pnl
+	}
+	if wallet {
+		rate, err := m.fetchMMRate(ctx)
+		if err != nil {
+			m.logger.Warn("告警获取钱包余额失败: account=%s, err=%v", account, err)
+		} else {
+			data.mmRate = rate
+		}
+	}
+	return data
+}
+
+// 获取交易对的行情，交易对较多时一次获取产品类别的全部行情
+func (m *Manager) fetchQuotes(ctx context.Context, category string, wanted map[string]bool) (map[string]quote, error) {
+	requests := []string{""}
+	if len(wanted) <= maxSymbolRequests {
+		requests = requests[:0]
+		for symbol := range wanted {
+			requests = append(requests, symbol)
+		}
+	}
+
+	quotes := map[string]quote{}
+	for _, symbol := range requests {
+		resp, err := m.service.GetTickers(ctx, category, symbol)
+		if err != nil {
+			return nil, err
+		}
+		var tickers []map[string]interface{}
+		if err := decodeList(resp, &tickers); err != nil {
+			return nil, err
+		}
+		for _, ticker := range tickers {
+			if symbol, _ := ticker["symbol"].(string); wanted[symbol] {
+				quotes[symbol] = quote{lastPrice: field(ticker, "lastPrice"), fundingRate: field(ticker, "fundingRate")}
+			}
+		}
+	}
+	return quotes, nil
+}
+
+// 获取仓位的未实现盈亏合计，linear未指定交易对时统计USDT结算的仓位
+func (m *Manager) fetchPnl(ctx context.Context, key positionKey) (float64, error) {
+	settleCoin := ""
+	if key.symbol == "" && key.category == bybitapi.CategoryLinear {
+		settleCoin = "USDT"
+	}
+	resp, err := m.service.GetPositions(ctx, key.category, key.symbol, settleCoin, "")
+	if err != nil {
+		return 0, err
+	}
+	var positions []model.Position
+	if err := decodeList(resp, &positions); err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, position := range positions {
+		if size, _ := strconv.ParseFloat(position.Size, 64); size == 0 {
+			continue
+		}
+		pnl, _ := strconv.ParseFloat(position.UnrealisedPnl, 64)
+		total += pnl
+	}
+	return total, nil
+}
+
+// 获取统一账户的维持保证金率，非统一账户返回NaN
+func (m *Manager) fetchMMRate(ctx context.Context) (float64, error) {
+	resp, err := m.service.GetWalletBalance(ctx, "UNIFIED", "")
+	if err != nil {
+		return 0, err
+	}
+	var balances []model.WalletBalance
+	if err := decodeList(resp, &balances); err != nil {
+		return 0, err
+	}
+	if len(balances) == 0 || balances[0].AccountMMRate == "" {
+		return math.NaN(), nil
+	}
+	rate, err := strconv.ParseFloat(balances[0].AccountMMRate, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的维持保证金率%q", balances[0].AccountMMRate)
+	}
+	return rate, nil
+}
+
+// 把响应中的list解析到v
+func decodeList(resp *model.Response, v interface{}) error {
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return err
+	}
+	if len(page.List) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(page.List, v); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// 读取行情中的数值字段，缺失或为空时返回NaN
+func field(ticker map[string]interface{}, name string) float64 {
+	s, _ := ticker[name].(string)
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
+
+// 记录涨跌幅告警需要的最新价，调用方持有m.mu
+func (m *Manager) recordPrices(account string, rules []*rule, data *snapshot, now int64) {
+	for _, r := range rules {
+		if r.alert.Type != model.AlertPriceChange {
+			continue
+		}
+		q, ok := data.quotes[r.alert.Category][r.alert.Symbol]
+		if !ok || math.IsNaN(q.lastPrice) {
+			continue
+		}
+		key := priceKey{account, r.alert.Category, r.alert.Symbol}
+		points := m.prices[key]
+		if len(points) == 0 || points[len(points)-1].time < now {
+			m.prices[key] = append(points, pricePoint{time: now, price: q.lastPrice})
+		}
+	}
+}
+
+// 去掉不再需要的价格历史，每个交易对保留最长的时间窗口再多一个检查间隔
+func (m *Manager) prunePrices(byAccount map[string][]*rule) {
+	windows := map[priceKey]int64{}
+	for account, rules := range byAccount {
+		for _, r := range rules {
+			if r.alert.Type != model.AlertPriceChange {
+				continue
+			}
+			key := priceKey{account, r.alert.Category, r.alert.Symbol}
+			window := (time.Duration(r.alert.Window)*time.Minute + m.interval).Milliseconds()
+			if window > windows[key] {
+				windows[key] = window
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now().UnixMilli()
+	for key, points := range m.prices {
+		window, ok := windows[key]
+		if !ok {
+			delete(m.prices, key)
+			continue
+		}
+		// 保留窗口开始之前的最后一个价格，作为计算涨跌幅的基准
+		i := sort.Search(len(points), func(i int) bool { return points[i].time > now-window })
+		if i > 0 {
+			i--
+		}
+		m.prices[key] = append([]pricePoint(nil), points[i:]...)
+	}
+}
+
+// 返回规则当前的值，数据缺失或价格历史不足时返回false，调用方持有m.mu
+func (m *Manager) value(r *rule, data *snapshot, now int64) (float64, bool) {
+	a := &r.alert
+	var v float64
+	switch a.Type {
+	case model.AlertPriceCross, model.AlertFundingRate:
+		q, ok := data.quotes[a.Category][a.Symbol]
+		if !ok {
+			return 0, false
+		}
+		v = q.lastPrice
+		if a.Type == model.AlertFundingRate {
+			v = q.fundingRate
+		}
+	case model.AlertPriceChange:
+		v = m.change(priceKey{a.Account, a.Category, a.Symbol}, time.Duration(a.Window)*time.Minute, now)
+	case model.AlertPositionPnl:
+		pnl, ok := data.positions[positionKey{a.Category, a.Symbol}]
+		if !ok {
+			return 0, false
+		}
+		v = pnl
+	case model.AlertMMRate:
+		v = data.mmRate
+	}
+	return v, !math.IsNaN(v)
+}
+
+// 最新价相对window之前价格的涨跌幅，历史不足window时返回NaN
+func (m *Manager) change(key priceKey, window time.Duration, now int64) float64 {
+	points := m.prices[key]
+	if len(points) == 0 || points[len(points)-1].time != now {
+		return math.NaN()
+	}
+	i := sort.Search(len(points), func(i int) bool { return points[i].time > now-window.Milliseconds() })
+	if i == 0 || points[i-1].price == 0 {
+		return math.NaN()
+	}
+	return points[len(points)-1].price/points[i-1].price - 1
+}
+
+// 检查条件，满足时在冷却时间之外触发告警，调用方持有m.mu
+func (m *Manager) evaluate(ctx context.Context, r *rule, value float64, now int64) {
+	a := &r.alert
+	switch a.Type {
+	case model.AlertPriceCross:
+		last := r.last
+		r.last = value
+		if math.IsNaN(last) {
+			return
+		}
+		up := last < a.Threshold && value >= a.Threshold
+		down := last > a.Threshold && value <= a.Threshold
+		if !(up && a.Direction != model.AlertBelow) && !(down && a.Direction != model.AlertAbove) {
+			return
+		}
+	default:
+		if !satisfied(a, value) {
+			r.armed = true
+			return
+		}
+		if !r.armed {
+			return
+		}
+		r.armed = false
+	}
+
+	if a.LastTriggered > 0 && now-a.LastTriggered < int64(a.Cooldown)*1000 {
+		m.logger.Debug("告警在冷却时间内，不再触发: id=%s, value=%v", a.ID, value)
+		return
+	}
+	a.LastTriggered = now
+	a.TriggerCount++
+	if err := m.store.SaveAlert(ctx, a); err != nil {
+		m.logger.Error("保存告警规则失败: id=%s, err=%v", a.ID, err)
+	}
+	m.deliver(Event{
+		ID:        uuid.NewString(),
+		AlertID:   a.ID,
+		Name:      a.Name,
+		Account:   a.Account,
+		Type:      a.Type,
+		Category:  a.Category,
+		Symbol:    a.Symbol,
+		Direction: a.Direction,
+		Threshold: a.Threshold,
+		Value:     value,
+		Message:   describe(a, value),
+		Time:      now,
+	})
+}
+
+// 判断阈值类条件是否满足
+func satisfied(a *model.Alert, value float64) bool {
+	if a.Type == model.AlertPriceChange {
+		switch a.Direction {
+		case model.AlertAbove:
+			return value >= a.Threshold
+		case model.AlertBelow:
+			return value <= -a.Threshold
+		default:
+			return math.Abs(value) >= a.Threshold
+		}
+	}
+	if a.Direction == model.AlertBelow {
+		return value <= a.Threshold
+	}
+	return value >= a.Threshold
+}
+
+// 告警事件的描述
+func describe(a *model.Alert, value float64) string {
+	side := "高于"
+	if a.Direction == model.AlertBelow {
+		side = "低于"
+	}
+	switch a.Type {
+	case model.AlertPriceCross:
+		cross := "向上穿越"
+		if value <= a.Threshold {
+			cross = "向下穿越"
+		}
+		return fmt.Sprintf("%s最新价%v%s%v", a.Symbol, value, cross, a.Threshold)
+	case model.AlertPriceChange:
+		return fmt.Sprintf("%s最新价%d分钟涨跌幅%.2f%%，阈值%.2f%%", a.Symbol, a.Window, value*100, a.Threshold*100)
+	case model.AlertFundingRate:
+		return fmt.Sprintf("%s资金费率%.4f%%%s阈值%.4f%%", a.Symbol, value*100, side, a.Threshold*100)
+	case model.AlertPositionPnl:
+		symbol := a.Symbol
+		if symbol == "" {
+			symbol = a.Category + "全部"
+		}
+		return fmt.Sprintf("%s仓位未实现盈亏%.2f%s阈值%v", symbol, value, side, a.Threshold)
+	default:
+		return fmt.Sprintf("账户维持保证金率%.2f%%%s阈值%.2f%%", value*100, side, a.Threshold*100)
+	}
+}
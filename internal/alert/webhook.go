@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// Webhook请求头
+const (
+	HeaderEvent     = "X-Alert-Event"     // 事件ID，重试时不变
+	HeaderTimestamp = "X-Alert-Timestamp" // 发送时间（毫秒）
+	HeaderSignature = "X-Alert-Signature" // "sha256="加上Sign的结果
+)
+
+// 单次请求的默认超时时间
+const defaultWebhookTimeout = 10 * time.Second
+
+// 等待发送的事件数量上限，超过时丢弃新事件
+const webhookQueue = 256
+
+// 发送失败后的重试间隔，依次使用，用完后放弃
+var webhookRetries = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+// Webhook 把告警事件以JSON格式POST到指定地址
+// 网络错误、429和5xx响应会重试，其他4xx响应不重试
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+	logger *logger.Logger
+	queue  chan Event
+}
+
+// NewWebhook 创建Webhook发送器，需要调用Run才会发送
+func NewWebhook(url, secret string, timeout time.Duration, log *logger.Logger) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+		logger: log,
+		queue:  make(chan Event, webhookQueue),
+	}
+}
+
+// Sign 返回时间戳和请求体的HMAC-SHA256签名（十六进制），签名内容为"时间戳.请求体"
+// 接收方应使用相同的密钥计算签名并比较，同时检查时间戳以拒绝重放的请求
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue 把事件加入发送队列，队列已满时丢弃
+func (w *Webhook) Enqueue(event Event) {
+	select {
+	case w.queue <- event:
+	default:
+		w.logger.Error("告警Webhook队列已满，丢弃事件: id=%s, alert=%s", event.ID, event.AlertID)
+	}
+}
+
+// Run 按顺序发送队列中的事件，直到上下文结束
+func (w *Webhook) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.queue:
+			w.deliver(ctx, event)
+		}
+	}
+}
+
+// 发送一个事件，失败时按重试间隔重试
+func (w *Webhook) deliver(ctx context.Context, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		w.logger.Error("序列化告警事件失败: id=%s, err=%v", event.ID, err)
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := w.send(ctx, event.ID, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= len(webhookRetries) {
+			w.logger.Error("发送告警Webhook失败，放弃发送: id=%s, attempts=%d, err=%v", event.ID, attempt+1, err)
+			return
+		}
+		w.logger.Warn("发送告警Webhook失败，稍后重试: id=%s, err=%v", event.ID, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(webhookRetries[attempt]):
+		}
+	}
+}
+
+// 发送一次请求，返回失败时是否可以重试
+func (w *Webhook) send(ctx context.Context, eventID string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := time.Now().UnixMilli()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(w.secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
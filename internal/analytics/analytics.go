@@ -0,0 +1,369 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+)
+
+// 一年的交易天数，加密货币市场全年无休
+const tradingDaysPerYear = 365
+
+// 日期格式
+const dateLayout = "2006-01-02"
+
+// Input 是绩效分析的输入数据
+type Input struct {
+	Executions []model.Execution // 成交记录（包括资金费用）
+	ClosedPnl  []model.ClosedPnl // 平仓盈亏记录
+	Transfers  []model.Transfer  // 划转记录
+	Positions  []model.Position  // 当前仓位，用于计算未实现盈亏
+	MemberId   string            // 本账户UID，用于判断母子账户间划转的方向
+
+	// 按交易对和日期（UTC）的日收盘价，用于计算每日未实现盈亏，可由LoadDailyCloses填充
+	DailyCloses map[string]map[string]float64
+}
+
+// 报告生成过程中的中间状态
+type builder struct {
+	query    *model.PerformanceQuery
+	report   *model.PerformanceReport
+	memberId string
+	bySymbol map[string]*model.SymbolPnl
+	daily    map[string]*model.DailyPnl
+}
+
+// BuildReport 根据成交、平仓盈亏、资金费用和划转记录生成绩效报告
+// 所有金额按计价币种（USDT/USDC）统计，不同结算币种之间不做汇率换算
+func BuildReport(query *model.PerformanceQuery, input *Input) *model.PerformanceReport {
+	b := &builder{
+		query:    query,
+		memberId: input.MemberId,
+		report: &model.PerformanceReport{
+			Category:     query.Category,
+			StartTime:    query.StartTime,
+			EndTime:      query.EndTime,
+			Fees:         model.FeeBreakdown{ByCurrency: map[string]float64{}},
+			NetTransfers: map[string]float64{},
+			BySymbol:     []model.SymbolPnl{},
+			Daily:        []model.DailyPnl{},
+		},
+		bySymbol: map[string]*model.SymbolPnl{},
+		daily:    map[string]*model.DailyPnl{},
+	}
+
+	for i := range input.Executions {
+		b.addExecution(&input.Executions[i])
+	}
+	for i := range input.ClosedPnl {
+		b.addClosedPnl(&input.ClosedPnl[i])
+	}
+	for i := range input.Transfers {
+		b.addTransfer(&input.Transfers[i])
+	}
+	for i := range input.Positions {
+		b.addPosition(&input.Positions[i])
+	}
+	b.addDailyUnrealized(input)
+
+	b.finish()
+	return b.report
+}
+
+// 判断时间是否在查询区间内
+func (b *builder) inRange(ts int64) bool {
+	if b.query.StartTime > 0 && ts < b.query.StartTime {
+		return false
+	}
+	if b.query.EndTime > 0 && ts > b.query.EndTime {
+		return false
+	}
+	return true
+}
+
+// 获取交易对统计，不存在时创建
+func (b *builder) symbol(symbol string) *model.SymbolPnl {
+	item, ok := b.bySymbol[symbol]
+	if !ok {
+		item = &model.SymbolPnl{Symbol: symbol}
+		b.bySymbol[symbol] = item
+	}
+	return item
+}
+
+// 获取时间所在日期的统计，不存在时创建
+func (b *builder) day(ts int64) *model.DailyPnl {
+	return b.date(time.UnixMilli(ts).UTC().Format(dateLayout))
+}
+
+// 获取单日统计，不存在时创建
+func (b *builder) date(date string) *model.DailyPnl {
+	item, ok := b.daily[date]
+	if !ok {
+		item = &model.DailyPnl{Date: date}
+		b.daily[date] = item
+	}
+	return item
+}
+
+// FeeValue 将手续费换算为计价币种金额
+// 现货买单的手续费以基础币种收取，需要按成交价换算
+func FeeValue(exec *model.Execution) float64 {
+	switch exec.FeeCurrency {
+	case "", "USDT", "USDC", "USD":
+		return exec.ExecFee
+	}
+	if strings.HasPrefix(exec.Symbol, exec.FeeCurrency) && exec.ExecPrice > 0 {
+		return exec.ExecFee * exec.ExecPrice
+	}
+	return exec.ExecFee
+}
+
+// 累加一条成交记录
+func (b *builder) addExecution(exec *model.Execution) {
+	if !b.inRange(exec.ExecTime) {
+		return
+	}
+
+	sym := b.symbol(exec.Symbol)
+	day := b.day(exec.ExecTime)
+	fee := FeeValue(exec)
+
+	// 资金费用：execFee为正表示支付，为负表示收到
+	if exec.ExecType == model.ExecTypeFunding {
+		if fee >= 0 {
+			b.report.Funding.Paid += fee
+		} else {
+			b.report.Funding.Received += -fee
+		}
+		b.report.Funding.Net += fee
+		sym.Funding += fee
+		day.Funding += fee
+		return
+	}
+
+	b.report.Fees.ByCurrency[exec.FeeCurrency] += exec.ExecFee
+	b.report.Fees.Total += fee
+	if fee < 0 {
+		b.report.Fees.Rebates += -fee
+	}
+	if exec.IsMaker {
+		b.report.Fees.Maker += fee
+	} else {
+		b.report.Fees.Taker += fee
+	}
+
+	sym.TradingFee += fee
+	day.TradingFee += fee
+
+	// 成交额只统计实际成交
+	switch exec.ExecType {
+	case model.ExecTypeTrade, model.ExecTypeBustTrade, model.ExecTypeAdlTrade, model.ExecTypeBlockTrade:
+		b.report.Turnover += exec.ExecValue
+		sym.Turnover += exec.ExecValue
+		day.Turnover += exec.ExecValue
+	}
+}
+
+// 累加一条平仓盈亏记录
+func (b *builder) addClosedPnl(record *model.ClosedPnl) {
+	if !b.inRange(record.UpdatedTime) {
+		return
+	}
+
+	sym := b.symbol(record.Symbol)
+	day := b.day(record.UpdatedTime)
+
+	b.report.RealizedPnl += record.ClosedPnl
+	b.report.Trades++
+	sym.RealizedPnl += record.ClosedPnl
+	sym.Trades++
+	day.RealizedPnl += record.ClosedPnl
+
+	if record.ClosedPnl > 0 {
+		sym.Wins++
+		b.report.AvgWin += record.ClosedPnl
+	} else if record.ClosedPnl < 0 {
+		b.report.AvgLoss += record.ClosedPnl
+	}
+}
+
+// 累加一条划转记录，转入统一/合约账户为正，转出为负，与统一/合约账户无关的划转不计入
+func (b *builder) addTransfer(transfer *model.Transfer) {
+	if !b.inRange(transfer.Timestamp) {
+		return
+	}
+
+	amount := 0.0
+	if b.own(transfer.ToMemberId) && tradingAccount(transfer.ToAccountType) {
+		amount += transfer.Amount
+	}
+	if b.own(transfer.FromMemberId) && tradingAccount(transfer.FromAccountType) {
+		amount -= transfer.Amount
+	}
+	if amount == 0 {
+		return
+	}
+	b.report.NetTransfers[transfer.Coin] += amount
+	b.day(transfer.Timestamp).Transfers += amount
+}
+
+// 是否为本账户，账户内划转不返回UID，视为本账户
+func (b *builder) own(memberId string) bool {
+	return memberId == "" || memberId == b.memberId
+}
+
+// 是否为统一账户或合约账户
+func tradingAccount(accountType string) bool {
+	return accountType == "UNIFIED" || accountType == "CONTRACT"
+}
+
+// 累加当前仓位的未实现盈亏
+func (b *builder) addPosition(position *model.Position) {
+	pnl, err := strconv.ParseFloat(position.UnrealisedPnl, 64)
+	if err != nil || pnl == 0 {
+		return
+	}
+	b.report.UnrealizedPnl += pnl
+	b.symbol(position.Symbol).UnrealizedPnl += pnl
+}
+
+// 汇总统计指标
+func (b *builder) finish() {
+	report := b.report
+	// 平仓盈亏已经扣除开平仓手续费和资金费用，资金费用只作为明细展示，不再重复扣除
+	report.NetPnl = report.RealizedPnl
+
+	// 胜率、平均盈亏和盈亏比
+	wins := 0
+	for _, sym := range b.bySymbol {
+		wins += sym.Wins
+	}
+	losses := report.Trades - wins
+	if report.Trades > 0 {
+		report.WinRate = float64(wins) / float64(report.Trades)
+	}
+	grossWin, grossLoss := report.AvgWin, -report.AvgLoss
+	if wins > 0 {
+		report.AvgWin = grossWin / float64(wins)
+	}
+	if losses > 0 {
+		report.AvgLoss = -grossLoss / float64(losses)
+	}
+	if grossLoss > 0 {
+		report.ProfitFactor = grossWin / grossLoss
+	}
+
+	// 按交易对排序，已实现盈亏高的在前
+	for _, sym := range b.bySymbol {
+		report.BySymbol = append(report.BySymbol, *sym)
+	}
+	sort.Slice(report.BySymbol, func(i, j int) bool {
+		if report.BySymbol[i].RealizedPnl != report.BySymbol[j].RealizedPnl {
+			return report.BySymbol[i].RealizedPnl > report.BySymbol[j].RealizedPnl
+		}
+		return report.BySymbol[i].Symbol < report.BySymbol[j].Symbol
+	})
+
+	// 按日期排序并计算累计盈亏和回撤
+	// 权益按盯市计算：期初权益加累计已实现盈亏、划转和日终未实现盈亏
+	dates := b.dates()
+
+	equity := b.query.InitialEquity
+	peak := equity
+	cum := 0.0
+	unrealized := 0.0
+	returns := make([]float64, 0, len(dates))
+	for _, date := range dates {
+		day, ok := b.daily[date]
+		if !ok {
+			// 无交易的日期收益为0，同样计入夏普比率
+			day = &model.DailyPnl{Date: date}
+		}
+		day.NetPnl = day.RealizedPnl
+		cum += day.NetPnl
+
+		// 当日盈亏包括未实现盈亏的变化，收益率以当日期初权益为基数，划转不计入收益
+		change := day.NetPnl + day.UnrealizedPnl - unrealized
+		unrealized = day.UnrealizedPnl
+		if b.query.InitialEquity > 0 && equity > 0 {
+			returns = append(returns, change/equity)
+		} else {
+			returns = append(returns, change)
+		}
+		equity += change + day.Transfers
+		peak += day.Transfers
+
+		day.CumPnl = cum
+		if equity > peak {
+			peak = equity
+		}
+		// 回撤金额和回撤比例分别取最大值，两者可能出现在不同日期
+		day.Drawdown = peak - equity
+		if day.Drawdown > report.MaxDrawdown {
+			report.MaxDrawdown = day.Drawdown
+		}
+		if peak > 0 && day.Drawdown/peak > report.MaxDrawdownPct {
+			report.MaxDrawdownPct = day.Drawdown / peak
+		}
+
+		report.Daily = append(report.Daily, *day)
+	}
+
+	report.Sharpe = Sharpe(returns)
+}
+
+// 返回从第一天到最后一天的连续日期
+func (b *builder) dates() []string {
+	if len(b.daily) == 0 {
+		return nil
+	}
+
+	var first, last string
+	for date := range b.daily {
+		if first == "" || date < first {
+			first = date
+		}
+		if date > last {
+			last = date
+		}
+	}
+
+	start, _ := time.Parse(dateLayout, first)
+	end, _ := time.Parse(dateLayout, last)
+	var dates []string
+	for t := start; !t.After(end); t = t.AddDate(0, 0, 1) {
+		dates = append(dates, t.Format(dateLayout))
+	}
+	return dates
+}
+
+// Sharpe 计算年化夏普比率（无风险利率视为0）
+// 未提供期初权益时returns为每日盈亏金额，结果仅用于相对比较
+func Sharpe(returns []float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	std := math.Sqrt(variance)
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(tradingDaysPerYear)
+}
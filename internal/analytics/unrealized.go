@@ -0,0 +1,216 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/errors"
+)
+
+// 单次查询日K线的最大数量
+const maxDailyBars = 1000
+
+// KlineFunc 查询K线，与service.MarketDataService.GetKline的签名相同
+type KlineFunc func(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error)
+
+// LoadDailyCloses 查询成交和仓位中出现的交易对在时间区间内的日收盘价，写入input.DailyCloses
+// 现货没有仓位，不计算未实现盈亏
+func LoadDailyCloses(ctx context.Context, kline KlineFunc, category string, input *Input, start, end int64) error {
+	if category == bybitapi.CategorySpot {
+		return nil
+	}
+
+	symbols := map[string]bool{}
+	for i := range input.Positions {
+		symbols[input.Positions[i].Symbol] = true
+	}
+	for i := range input.Executions {
+		if isTrade(&input.Executions[i]) {
+			symbols[input.Executions[i].Symbol] = true
+		}
+	}
+
+	closes := map[string]map[string]float64{}
+	for symbol := range symbols {
+		resp, err := kline(ctx, category, symbol, "D", maxDailyBars, start, end)
+		if err != nil {
+			return err
+		}
+		if err := errors.FromBybitAPIError(resp.RetCode, resp.RetMsg); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(resp.Result)
+		if err != nil {
+			return errors.Wrap(errors.ErrAPIResponseInvalid, "序列化K线失败", err)
+		}
+		var result struct {
+			List [][]string `json:"list"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return errors.Wrap(errors.ErrAPIResponseInvalid, "解析K线失败", err)
+		}
+
+		// K线行格式：[开始时间, 开盘价, 最高价, 最低价, 收盘价, ...]
+		byDate := map[string]float64{}
+		for _, row := range result.List {
+			if len(row) < 5 {
+				continue
+			}
+			ts, err1 := strconv.ParseInt(row[0], 10, 64)
+			price, err2 := strconv.ParseFloat(row[4], 64)
+			if err1 != nil || err2 != nil || price <= 0 {
+				continue
+			}
+			byDate[time.UnixMilli(ts).UTC().Format(dateLayout)] = price
+		}
+		if len(byDate) > 0 {
+			closes[symbol] = byDate
+		}
+	}
+
+	input.DailyCloses = closes
+	return nil
+}
+
+// 是否为实际成交（不包括资金费用等）
+func isTrade(exec *model.Execution) bool {
+	switch exec.ExecType {
+	case model.ExecTypeTrade, model.ExecTypeBustTrade, model.ExecTypeAdlTrade, model.ExecTypeBlockTrade:
+		return exec.Side != ""
+	}
+	return false
+}
+
+// 按平均成本法跟踪的仓位，开仓均价表示为 a*e0 + b，e0为区间开始时未知的开仓均价
+type costBasis struct {
+	size float64 // 带方向的仓位数量，空头为负
+	a, b float64
+}
+
+// 按成交更新仓位：加仓时按数量加权开仓均价，减仓时均价不变，反向开仓时均价为成交价
+func (c *costBasis) fill(qty, price float64) {
+	switch {
+	case c.size == 0 || (c.size > 0) == (qty > 0):
+		total := math.Abs(c.size) + math.Abs(qty)
+		if c.size == 0 {
+			c.a, c.b = 0, price
+		} else {
+			c.a = c.a * math.Abs(c.size) / total
+			c.b = (c.b*math.Abs(c.size) + math.Abs(qty)*price) / total
+		}
+		c.size += qty
+	case math.Abs(qty) <= math.Abs(c.size):
+		c.size += qty
+		if c.size == 0 {
+			c.a, c.b = 0, 0
+		}
+	default:
+		c.size += qty
+		c.a, c.b = 0, price
+	}
+}
+
+// 按日终仓位和当日收盘价计算每日未实现盈亏
+// 区间开始时的仓位由当前仓位减去区间内的成交得到，当前仓位应为区间结束时的仓位；
+// 开始时的开仓均价由当前开仓均价反推，无法反推时按区间第一天的收盘价计算
+func (b *builder) addDailyUnrealized(input *Input) {
+	if len(input.DailyCloses) == 0 {
+		return
+	}
+	inverse := b.query.Category == bybitapi.CategoryInverse
+
+	type current struct {
+		size  float64
+		entry float64
+	}
+	positions := map[string]current{}
+	for i := range input.Positions {
+		p := &input.Positions[i]
+		size, _ := strconv.ParseFloat(p.Size, 64)
+		entry, _ := strconv.ParseFloat(p.EntryPrice, 64)
+		if p.Side == "Sell" {
+			size = -size
+		}
+		pos := positions[p.Symbol]
+		pos.size += size
+		if entry > 0 {
+			pos.entry = entry
+		}
+		positions[p.Symbol] = pos
+	}
+
+	trades := map[string][]*model.Execution{}
+	for i := range input.Executions {
+		exec := &input.Executions[i]
+		if isTrade(exec) && b.inRange(exec.ExecTime) {
+			trades[exec.Symbol] = append(trades[exec.Symbol], exec)
+		}
+	}
+
+	for symbol, closes := range input.DailyCloses {
+		execs := trades[symbol]
+		sort.SliceStable(execs, func(i, j int) bool { return execs[i].ExecTime < execs[j].ExecTime })
+
+		start := positions[symbol].size
+		for _, exec := range execs {
+			start -= signedQty(exec)
+		}
+
+		dates := make([]string, 0, len(closes))
+		for date := range closes {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+
+		// 逐日处理当天的成交，记录日终仓位
+		basis := costBasis{size: start, a: 1}
+		if start == 0 {
+			basis.a = 0
+		}
+		eod := make([]costBasis, len(dates))
+		next := 0
+		for i, date := range dates {
+			for next < len(execs) && time.UnixMilli(execs[next].ExecTime).UTC().Format(dateLayout) <= date {
+				basis.fill(signedQty(execs[next]), execs[next].ExecPrice)
+				next++
+			}
+			eod[i] = basis
+		}
+
+		e0 := closes[dates[0]]
+		if pos := positions[symbol]; start != 0 && pos.size != 0 && pos.entry > 0 && basis.a != 0 {
+			e0 = (pos.entry - basis.b) / basis.a
+		}
+
+		for i, date := range dates {
+			c := eod[i]
+			if c.size == 0 {
+				continue
+			}
+			entry, price := c.a*e0+c.b, closes[date]
+			pnl := c.size * (price - entry)
+			if inverse {
+				if entry <= 0 {
+					continue
+				}
+				pnl = c.size * (1/entry - 1/price)
+			}
+			b.date(date).UnrealizedPnl += pnl
+		}
+	}
+}
+
+// 带方向的成交数量，卖出为负
+func signedQty(exec *model.Execution) float64 {
+	if exec.Side == "Sell" {
+		return -exec.ExecQty
+	}
+	return exec.ExecQty
+}
@@ -3,7 +3,6 @@ package account
 import (
 	"context"
 	"encoding/json"
-	"strconv"
 
 	"github.com/bybit-mcp/internal/model"
 	"github.com/bybit-mcp/pkg/bybitapi"
@@ -18,10 +17,10 @@ type AccountService struct {
 }
 
 // NewAccountService 创建一个新的账户管理服务
-func NewAccountService(client *bybitapi.Client, logLevel, logOutput string) *AccountService {
+func NewAccountService(client *bybitapi.Client, log *logger.Logger) *AccountService {
 	return &AccountService{
 		client: client,
-		logger: logger.New(logLevel, logOutput),
+		logger: log,
 	}
 }
 
@@ -4,24 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/bybit-mcp/internal/model"
 	"github.com/bybit-mcp/pkg/bybitapi"
 	"github.com/bybit-mcp/pkg/errors"
 	"github.com/bybit-mcp/pkg/logger"
+	"github.com/bybit-mcp/pkg/ratelimit"
+	"github.com/google/uuid"
 )
 
+// 翻页查询的默认限流：每秒5次请求
+const defaultPageRate = 5
+
 // AssetService 提供资产管理相关的API服务
 type AssetService struct {
-	client *bybitapi.Client
-	logger *logger.Logger
+	client  *bybitapi.Client
+	logger  *logger.Logger
+	limiter *ratelimit.Limiter
 }
 
 // NewAssetService 创建一个新的资产管理服务
-func NewAssetService(client *bybitapi.Client, logLevel, logOutput string) *AssetService {
+func NewAssetService(client *bybitapi.Client, log *logger.Logger) *AssetService {
 	return &AssetService{
-		client: client,
-		logger: logger.New(logLevel, logOutput),
+		client:  client,
+		logger:  log,
+		limiter: ratelimit.New(defaultPageRate, 1),
 	}
 }
 
@@ -103,6 +111,52 @@ func (s *AssetService) TransferAsset(ctx context.Context, transferId, coin, amou
 	return &resp, nil
 }
 
+// UniversalTransfer 母子账户之间划转，只能使用母账户API密钥调用
+func (s *AssetService) UniversalTransfer(ctx context.Context, req *model.UniversalTransferRequest) (*model.Response, error) {
+	s.logger.Debug("母子账户划转: coin=%s, amount=%s, fromMemberId=%s, toMemberId=%s", req.Coin, req.Amount, req.FromMemberId, req.ToMemberId)
+
+	// 划转ID用于幂等，未指定时生成
+	transferId := req.TransferId
+	if transferId == "" {
+		transferId = uuid.NewString()
+	}
+
+	// 构建请求参数
+	params := map[string]string{
+		"transferId":      transferId,
+		"coin":            req.Coin,
+		"amount":          req.Amount,
+		"fromMemberId":    req.FromMemberId,
+		"toMemberId":      req.ToMemberId,
+		"fromAccountType": req.FromAccountType,
+		"toAccountType":   req.ToAccountType,
+	}
+
+	// 发送请求
+	response, err := s.client.Post("asset/transfer/universal-transfer", params, true)
+	if err != nil {
+		s.logger.Error("母子账户划转失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "母子账户划转失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析母子账户划转响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析母子账户划转响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
 // GetTransferHistory 获取划转历史
 func (s *AssetService) GetTransferHistory(ctx context.Context, transferId, coin, status string, startTime, endTime int64, limit int) (*model.Response, error) {
 	s.logger.Debug("获取划转历史: coin=%s, status=%s", coin, status)
@@ -159,12 +213,13 @@ func (s *AssetService) GetTransferHistory(ctx context.Context, transferId, coin,
 func (s *AssetService) Withdraw(ctx context.Context, coin, chain, address, tag, amount string, options map[string]string) (*model.Response, error) {
 	s.logger.Debug("提现: coin=%s, chain=%s, address=%s, amount=%s", coin, chain, address, amount)
 
-	// 构建请求参数
+	// 构建请求参数，Bybit要求提现请求携带当前时间戳
 	params := map[string]string{
-		"coin":    coin,
-		"chain":   chain,
-		"address": address,
-		"amount":  amount,
+		"coin":      coin,
+		"chain":     chain,
+		"address":   address,
+		"amount":    amount,
+		"timestamp": strconv.FormatInt(time.Now().UnixMilli(), 10),
 	}
 
 	// 添加可选参数
@@ -199,5 +254,97 @@ func (s *AssetService) Withdraw(ctx context.Context, coin, chain, address, tag,
 		}
 	}
 
+	return &resp, nil
+}
+
+// GetDepositHistory 获取充值记录
+func (s *AssetService) GetDepositHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	s.logger.Debug("获取充值记录: coin=%s", coin)
+
+	// 构建请求参数
+	params := map[string]string{}
+
+	// 添加可选参数
+	if coin != "" {
+		params["coin"] = coin
+	}
+	if startTime > 0 {
+		params["startTime"] = strconv.FormatInt(startTime, 10)
+	}
+	if endTime > 0 {
+		params["endTime"] = strconv.FormatInt(endTime, 10)
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	// 发送请求
+	response, err := s.client.Get("asset/deposit/query-record", params, true)
+	if err != nil {
+		s.logger.Error("获取充值记录失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "获取充值记录失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析获取充值记录响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析获取充值记录响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
+// GetWithdrawalHistory 获取提现记录
+func (s *AssetService) GetWithdrawalHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	s.logger.Debug("获取提现记录: coin=%s", coin)
+
+	// 构建请求参数
+	params := map[string]string{}
+
+	// 添加可选参数
+	if coin != "" {
+		params["coin"] = coin
+	}
+	if startTime > 0 {
+		params["startTime"] = strconv.FormatInt(startTime, 10)
+	}
+	if endTime > 0 {
+		params["endTime"] = strconv.FormatInt(endTime, 10)
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	// 发送请求
+	response, err := s.client.Get("asset/withdraw/query-record", params, true)
+	if err != nil {
+		s.logger.Error("获取提现记录失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "获取提现记录失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析获取提现记录响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析获取提现记录响应失败",
+			Cause:   err,
+		}
+	}
+
 	return &resp, nil
 }
\ No newline at end of file
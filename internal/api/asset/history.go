@@ -0,0 +1,159 @@
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/errors"
+)
+
+// 划转记录接口单次查询的最大时间跨度：7天
+const maxTransferWindow = 7 * 24 * 60 * 60 * 1000
+
+// 划转记录接口
+const (
+	interTransferListEndpoint     = "asset/transfer/query-inter-transfer-list"
+	universalTransferListEndpoint = "asset/transfer/query-universal-transfer-list"
+)
+
+// Bybit返回的原始划转记录
+type rawTransfer struct {
+	TransferId      string `json:"transferId"`
+	Coin            string `json:"coin"`
+	Amount          string `json:"amount"`
+	FromMemberId    string `json:"fromMemberId"`
+	ToMemberId      string `json:"toMemberId"`
+	FromAccountType string `json:"fromAccountType"`
+	ToAccountType   string `json:"toAccountType"`
+	Status          string `json:"status"`
+	Timestamp       string `json:"timestamp"`
+}
+
+// ListTransfers 翻页获取时间区间内的全部账户内划转记录，超过7天的区间会自动分段
+func (s *AssetService) ListTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	s.logger.Debug("获取全部划转记录: coin=%s, startTime=%d, endTime=%d", coin, startTime, endTime)
+	return s.listTransfers(ctx, interTransferListEndpoint, model.TransferTypeInternal, coin, status, startTime, endTime)
+}
+
+// ListUniversalTransfers 翻页获取时间区间内的全部母子账户间划转记录
+func (s *AssetService) ListUniversalTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	s.logger.Debug("获取全部母子账户划转记录: coin=%s, startTime=%d, endTime=%d", coin, startTime, endTime)
+	return s.listTransfers(ctx, universalTransferListEndpoint, model.TransferTypeUniversal, coin, status, startTime, endTime)
+}
+
+// ListAllTransfers 获取账户内划转和母子账户间划转记录，按时间倒序合并
+func (s *AssetService) ListAllTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	transfers, err := s.ListTransfers(ctx, coin, status, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	universal, err := s.ListUniversalTransfers(ctx, coin, status, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers = append(transfers, universal...)
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].Timestamp > transfers[j].Timestamp
+	})
+	return transfers, nil
+}
+
+// 翻页获取指定接口的划转记录
+func (s *AssetService) listTransfers(ctx context.Context, endpoint, transferType, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	transfers := []model.Transfer{}
+	for _, window := range pagination.SplitTimeRange(startTime, endTime, maxTransferWindow) {
+		window := window
+		fetch := func(ctx context.Context, cursor string) (*model.Response, error) {
+			return s.getTransferPage(ctx, endpoint, coin, status, window.Start, window.End, cursor)
+		}
+
+		err := pagination.Walk(ctx, fetch, "", s.limiter, func(page *model.CursorResult) error {
+			var raws []rawTransfer
+			if len(page.List) > 0 {
+				if err := json.Unmarshal(page.List, &raws); err != nil {
+					return &errors.Error{
+						Code:    errors.ErrAPIResponseInvalid,
+						Message: "解析划转记录失败",
+						Cause:   err,
+					}
+				}
+			}
+			for _, raw := range raws {
+				amount, _ := strconv.ParseFloat(raw.Amount, 64)
+				timestamp, _ := strconv.ParseInt(raw.Timestamp, 10, 64)
+				transfers = append(transfers, model.Transfer{
+					TransferId:      raw.TransferId,
+					Type:            transferType,
+					Coin:            raw.Coin,
+					Amount:          amount,
+					FromMemberId:    raw.FromMemberId,
+					ToMemberId:      raw.ToMemberId,
+					FromAccountType: raw.FromAccountType,
+					ToAccountType:   raw.ToAccountType,
+					Status:          raw.Status,
+					Timestamp:       timestamp,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return transfers, nil
+}
+
+// 获取一页划转记录
+func (s *AssetService) getTransferPage(ctx context.Context, endpoint, coin, status string, startTime, endTime int64, cursor string) (*model.Response, error) {
+	// 构建请求参数
+	params := map[string]string{
+		"limit": "50",
+	}
+
+	// 添加可选参数
+	if coin != "" {
+		params["coin"] = coin
+	}
+	if status != "" {
+		params["status"] = status
+	}
+	if startTime > 0 {
+		params["startTime"] = strconv.FormatInt(startTime, 10)
+	}
+	if endTime > 0 {
+		params["endTime"] = strconv.FormatInt(endTime, 10)
+	}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+
+	// 发送请求
+	response, err := s.client.Get(endpoint, params, true)
+	if err != nil {
+		s.logger.Error("获取划转记录失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "获取划转记录失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析划转记录响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析划转记录响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
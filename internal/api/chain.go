@@ -0,0 +1,43 @@
+package api
+
+import (
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/risk"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/withdrawal"
+	"google.golang.org/grpc"
+)
+
+// Interceptors 是服务端拦截器链用到的组件
+type Interceptors struct {
+	Authenticator *auth.Authenticator // 调用方认证，为nil时不认证
+	Router        *service.Router     // 账户路由，用于选择账户
+	Protected     map[string]bool     // 受主网保护的账户，为空时不检查
+	Withdrawals   *withdrawal.Manager // 提现管理器，审批提现时按申请中的账户做主网保护检查
+	Risk          *risk.Checker       // 风控检查，为nil时不检查
+}
+
+// ServerOptions 按认证鉴权、选择账户、主网保护、风控检查的顺序创建拦截器链
+// 被拒绝的请求写入审计日志
+func (s *BybitMCPServer) ServerOptions(i Interceptors) []grpc.ServerOption {
+	unary := []grpc.UnaryServerInterceptor{}
+	stream := []grpc.StreamServerInterceptor{}
+	if i.Authenticator != nil {
+		unary = append(unary, auth.UnaryInterceptor(i.Authenticator, s.AuditDenied))
+		stream = append(stream, auth.StreamInterceptor(i.Authenticator, s.AuditDenied))
+	}
+	unary = append(unary, AccountInterceptor(i.Router, s.AuditDenied))
+	stream = append(stream, AccountStreamInterceptor(i.Router, s.AuditDenied))
+	if len(i.Protected) > 0 {
+		unary = append(unary, MainnetGuardInterceptor(i.Router, i.Protected, i.Withdrawals, s.AuditRiskRejected))
+		stream = append(stream, MainnetGuardStreamInterceptor(i.Router, i.Protected, s.AuditRiskRejected))
+	}
+	if i.Risk != nil {
+		unary = append(unary, RiskInterceptor(i.Risk, s.AuditRiskRejected))
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
@@ -0,0 +1,506 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bybit-mcp/internal/api"
+	"github.com/bybit-mcp/internal/audit"
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/bybitmock"
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/risk"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/internal/withdrawal"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// e2e测试中的账户和客户端令牌
+const (
+	defaultAccount   = "testnet" // 默认账户
+	protectedAccount = "mainnet" // 受主网保护的账户，与默认账户连接同一个模拟服务器
+	adminToken       = "admin-token"
+	readerToken      = "reader-token"
+	treasurerToken   = "treasurer-token"
+)
+
+// 风控允许的BTCUSDT单笔最大数量
+const maxBTCQty = 1
+
+// 提现白名单中的地址
+const whitelistAddress = "TMockWhitelistAddress"
+
+// e2e测试环境
+type e2eEnv struct {
+	mock        *bybitmock.Server
+	client      api.BybitMCPServiceClient
+	store       storage.Store
+	withdrawals *withdrawal.Manager
+}
+
+// 启动模拟Bybit服务器和使用secret签名的gRPC服务，返回连接到gRPC服务的客户端
+// gRPC服务使用与cmd/server相同的拦截器链：认证鉴权、选择账户、主网保护和风控检查
+// 客户端默认以admin身份调用，可以用asClient切换身份
+func startE2E(t *testing.T, secret string) *e2eEnv {
+	t.Helper()
+	mock := bybitmock.New(bybitmock.DefaultAPIKey, bybitmock.DefaultAPISecret)
+	mock.Start()
+	t.Cleanup(mock.Close)
+
+	log := logger.New(logger.FatalLevel, "stderr")
+	svc := service.NewBybitService(bybitmock.DefaultAPIKey, secret, mock.Environment(), log)
+	router := service.NewRouter(defaultAccount)
+	router.Add(defaultAccount, svc, nil, true)
+	router.Add(protectedAccount, svc, nil, false)
+
+	store := storage.NewMemoryStore()
+	mcpServer := api.NewBybitMCPServer(router)
+	mcpServer.SetRouter(router)
+	mcpServer.SetAuditor(audit.New(store, log), store)
+
+	withdrawals := withdrawal.New(config.WithdrawalConfig{
+		Whitelist: []config.WhitelistEntry{{Coin: "USDT", Chain: "TRX", Address: whitelistAddress}},
+	}, store, log, mcpServer.SubmitWithdrawal)
+	if err := withdrawals.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mcpServer.SetWithdrawalManager(withdrawals)
+
+	authenticator, err := auth.New(config.AuthConfig{
+		Enabled: true,
+		Clients: []config.ClientConfig{
+			{Name: "admin", Token: adminToken, Role: auth.RoleAdmin},
+			{Name: "reader", Token: readerToken, Role: auth.RoleReadOnly},
+			{Name: "treasurer", Token: treasurerToken, Role: auth.RoleTreasurer},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(mcpServer.ServerOptions(api.Interceptors{
+		Authenticator: authenticator,
+		Router:        router,
+		Protected:     map[string]bool{protectedAccount: true},
+		Withdrawals:   withdrawals,
+		Risk:          risk.New(config.RiskConfig{MaxOrderQty: map[string]float64{"BTCUSDT": maxBTCQty}}),
+	})...)
+	api.RegisterBybitMCPServiceServer(srv, mcpServer)
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(defaultToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &e2eEnv{
+		mock:        mock,
+		client:      api.NewBybitMCPServiceClient(conn),
+		store:       store,
+		withdrawals: withdrawals,
+	}
+}
+
+// 未指定身份的请求以admin身份调用
+func defaultToken(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if md, ok := metadata.FromOutgoingContext(ctx); !ok || len(md.Get("authorization")) == 0 {
+		ctx = asClient(ctx, adminToken)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// 使用token对应的客户端身份调用，token为空时不携带令牌
+func asClient(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "")
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// 选择请求使用的账户
+func onAccount(ctx context.Context, account string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "x-bybit-account", account)
+}
+
+// 解析响应中的result字段
+func decodeResult(t *testing.T, resp *api.MCPResponse, v interface{}) {
+	t.Helper()
+	var body struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Data, &body); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if err := json.Unmarshal(body.Result, v); err != nil {
+		t.Fatalf("解析result失败: %v", err)
+	}
+}
+
+// 模拟服务器收到的某个接口的请求数
+func countRequests(mock *bybitmock.Server, endpoint string) int {
+	n := 0
+	for _, req := range mock.Requests() {
+		if req.Endpoint == endpoint {
+			n++
+		}
+	}
+	return n
+}
+
+func TestE2EOrderFlow(t *testing.T) {
+	env := startE2E(t, bybitmock.DefaultAPISecret)
+	mock, client := env.mock, env.client
+	ctx := context.Background()
+
+	tickers, err := client.GetTickers(ctx, &api.TickersRequest{Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tickers.Code != 0 {
+		t.Fatalf("GetTickers code = %d, message = %s", tickers.Code, tickers.Message)
+	}
+	var tickerResult struct {
+		List []struct {
+			Symbol    string `json:"symbol"`
+			LastPrice string `json:"lastPrice"`
+		} `json:"list"`
+	}
+	decodeResult(t, tickers, &tickerResult)
+	if len(tickerResult.List) != 1 || tickerResult.List[0].LastPrice != "65000" {
+		t.Fatalf("GetTickers list = %+v, want BTCUSDT at 65000", tickerResult.List)
+	}
+
+	order, err := client.CreateOrder(ctx, &api.CreateOrderRequest{
+		RequestId: "req-1",
+		Category:  bybitapi.CategoryLinear,
+		Symbol:    "BTCUSDT",
+		Side:      "Buy",
+		OrderType: "Market",
+		Qty:       0.01,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Code != 0 || order.RequestId != "req-1" {
+		t.Fatalf("CreateOrder code = %d, message = %s, requestId = %s", order.Code, order.Message, order.RequestId)
+	}
+
+	var created *bybitmock.Request
+	for _, req := range mock.Requests() {
+		if req.Endpoint == "order/create" {
+			req := req
+			created = &req
+		}
+	}
+	if created == nil {
+		t.Fatal("模拟服务器没有收到order/create请求")
+	}
+	if created.APIKey != bybitmock.DefaultAPIKey || created.Params["qty"] != "0.01" || created.Params["side"] != "Buy" {
+		t.Errorf("order/create request = %+v", created)
+	}
+
+	positions, err := client.GetPositions(ctx, &api.GetPositionsRequest{Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if positions.Code != 0 {
+		t.Fatalf("GetPositions code = %d, message = %s", positions.Code, positions.Message)
+	}
+	var positionResult struct {
+		List []struct {
+			Symbol string `json:"symbol"`
+			Side   string `json:"side"`
+			Size   string `json:"size"`
+		} `json:"list"`
+	}
+	decodeResult(t, positions, &positionResult)
+	if len(positionResult.List) != 1 || positionResult.List[0].Side != "Buy" || positionResult.List[0].Size != "0.01" {
+		t.Errorf("GetPositions list = %+v, want Buy 0.01", positionResult.List)
+	}
+}
+
+func TestE2ESignatureRejected(t *testing.T) {
+	env := startE2E(t, "wrong-secret")
+	mock, client := env.mock, env.client
+	ctx := context.Background()
+
+	resp, err := client.GetWalletBalance(ctx, &api.GetWalletBalanceRequest{AccountType: "UNIFIED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != 10004 {
+		t.Errorf("GetWalletBalance code = %d, message = %s, want 10004", resp.Code, resp.Message)
+	}
+
+	resp, err = client.CreateOrder(ctx, &api.CreateOrderRequest{
+		Category:  bybitapi.CategoryLinear,
+		Symbol:    "BTCUSDT",
+		Side:      "Buy",
+		OrderType: "Market",
+		Qty:       0.01,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code == 0 {
+		t.Fatal("CreateOrder with wrong secret succeeded")
+	}
+	if resp.Code != 10004 {
+		t.Errorf("CreateOrder code = %d, message = %s, want 10004", resp.Code, resp.Message)
+	}
+
+	// 签名错误的请求不能到达撮合引擎
+	positions, err := client.GetPositions(ctx, &api.GetPositionsRequest{Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if positions.Code != 10004 {
+		t.Errorf("GetPositions code = %d, want 10004", positions.Code)
+	}
+	if n := countRequests(mock, "order/create"); n != 1 {
+		t.Errorf("order/create requests = %d, want 1", n)
+	}
+}
+
+func TestE2EInjectedFaults(t *testing.T) {
+	env := startE2E(t, bybitmock.DefaultAPISecret)
+	mock, client := env.mock, env.client
+	ctx := context.Background()
+	request := &api.TickersRequest{Category: bybitapi.CategoryLinear, Symbol: "ETHUSDT"}
+
+	// Bybit返回码原样传给调用方，故障用完后恢复正常
+	fault := bybitmock.FaultRateLimit
+	fault.Times = 1
+	mock.Fail("market/tickers", fault)
+	resp, err := client.GetTickers(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != 10006 {
+		t.Errorf("rate limited GetTickers code = %d, message = %s, want 10006", resp.Code, resp.Message)
+	}
+	resp, err = client.GetTickers(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != 0 {
+		t.Errorf("GetTickers after fault code = %d, message = %s, want 0", resp.Code, resp.Message)
+	}
+
+	// HTTP错误和断连转换为内部错误
+	for name, fault := range map[string]bybitmock.Fault{
+		"bad gateway": bybitmock.FaultBadGateway,
+		"ip banned":   bybitmock.FaultIPBanned,
+		"drop":        {Drop: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mock.Fail(bybitmock.AllEndpoints, fault)
+			defer mock.ClearFaults()
+
+			resp, err := client.GetTickers(ctx, request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code == 0 || len(resp.Data) != 0 {
+				t.Errorf("GetTickers code = %d, data = %s, want error", resp.Code, resp.Data)
+			}
+		})
+	}
+}
+
+func TestE2ELatency(t *testing.T) {
+	env := startE2E(t, bybitmock.DefaultAPISecret)
+	mock, client := env.mock, env.client
+	ctx := context.Background()
+	request := &api.TickersRequest{Category: bybitapi.CategorySpot, Symbol: "BTCUSDT"}
+
+	const latency = 200 * time.Millisecond
+	mock.SetLatency(latency)
+	start := time.Now()
+	resp, err := client.GetTickers(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("GetTickers took %v, want at least %v", elapsed, latency)
+	}
+	if resp.Code != 0 {
+		t.Errorf("GetTickers code = %d, message = %s", resp.Code, resp.Message)
+	}
+	mock.ClearFaults()
+
+	// 调用方的超时先于Bybit的响应到达
+	mock.Fail("market/tickers", bybitmock.Fault{Delay: 2 * time.Second, Times: 1})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetTickers(timeoutCtx, request); err == nil {
+		t.Error("GetTickers with short deadline succeeded, want deadline error")
+	}
+}
+
+func TestE2EPermissionDenied(t *testing.T) {
+	env := startE2E(t, bybitmock.DefaultAPISecret)
+	ctx := context.Background()
+	order := &api.CreateOrderRequest{Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT", Side: "Buy", OrderType: "Market", Qty: 0.01}
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"missing token", func() error {
+			_, err := env.client.CreateOrder(asClient(ctx, ""), order)
+			return err
+		}, codes.Unauthenticated},
+		{"unknown token", func() error {
+			_, err := env.client.CreateOrder(asClient(ctx, "forged"), order)
+			return err
+		}, codes.Unauthenticated},
+		{"read-only order", func() error {
+			_, err := env.client.CreateOrder(asClient(ctx, readerToken), order)
+			return err
+		}, codes.PermissionDenied},
+		{"read-only withdrawal requests", func() error {
+			_, err := env.client.ListWithdrawalRequests(asClient(ctx, readerToken), &api.ListWithdrawalRequestsRequest{})
+			return err
+		}, codes.PermissionDenied},
+		{"treasurer order", func() error {
+			_, err := env.client.CreateOrder(asClient(ctx, treasurerToken), order)
+			return err
+		}, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.want {
+				t.Errorf("code = %v, want %v", code, tt.want)
+			}
+		})
+	}
+
+	// 被拒绝的请求不能到达Bybit
+	if n := countRequests(env.mock, "order/create"); n != 0 {
+		t.Errorf("order/create requests = %d, want 0", n)
+	}
+
+	// 有权限的调用方可以查询
+	resp, err := env.client.GetTickers(asClient(ctx, readerToken), &api.TickersRequest{Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT"})
+	if err != nil || resp.Code != 0 {
+		t.Errorf("read-only GetTickers err = %v, resp = %+v", err, resp)
+	}
+}
+
+func TestE2ERiskRejected(t *testing.T) {
+	env := startE2E(t, bybitmock.DefaultAPISecret)
+	ctx := context.Background()
+
+	_, err := env.client.CreateOrder(ctx, &api.CreateOrderRequest{
+		Category:  bybitapi.CategoryLinear,
+		Symbol:    "BTCUSDT",
+		Side:      "Buy",
+		OrderType: "Market",
+		Qty:       maxBTCQty * 2,
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("CreateOrder over limit err = %v, want FailedPrecondition", err)
+	}
+	if n := countRequests(env.mock, "order/create"); n != 0 {
+		t.Errorf("order/create requests = %d, want 0", n)
+	}
+
+	// 只减仓的订单不检查数量上限
+	resp, err := env.client.CreateOrder(ctx, &api.CreateOrderRequest{
+		Category:   bybitapi.CategoryLinear,
+		Symbol:     "BTCUSDT",
+		Side:       "Sell",
+		OrderType:  "Market",
+		Qty:        maxBTCQty * 2,
+		ReduceOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("reduce-only CreateOrder err = %v", err)
+	}
+	if n := countRequests(env.mock, "order/create"); n != 1 {
+		t.Errorf("order/create requests = %d, want 1 (code = %d)", n, resp.Code)
+	}
+
+	records, err := env.store.ListAudit(ctx, storage.AuditFilter{Method: "CreateOrder"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].RiskResult != audit.RiskRejected || records[1].RiskResult != audit.RiskNotChecked {
+		t.Errorf("audit records = %+v, want rejected then not_checked", records)
+	}
+}
+
+func TestE2EMainnetGuard(t *testing.T) {
+	env := startE2E(t, bybitmock.DefaultAPISecret)
+	ctx := context.Background()
+
+	_, err := env.client.CreateOrder(onAccount(ctx, protectedAccount), &api.CreateOrderRequest{
+		Category:  bybitapi.CategoryLinear,
+		Symbol:    "BTCUSDT",
+		Side:      "Buy",
+		OrderType: "Market",
+		Qty:       0.01,
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("CreateOrder on protected account err = %v, want FailedPrecondition", err)
+	}
+
+	// 审批按申请中的账户检查，审批人选择的账户不受保护也不能提交
+	request, _, err := env.withdrawals.Request(ctx, &model.WithdrawalRequest{
+		Account:     protectedAccount,
+		Coin:        "USDT",
+		Chain:       "TRX",
+		Address:     whitelistAddress,
+		Amount:      "10",
+		RequestedBy: "treasurer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = env.client.ApproveWithdrawal(onAccount(ctx, defaultAccount), &api.ApproveWithdrawalRequest{Id: request.ID})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("ApproveWithdrawal on protected account err = %v, want FailedPrecondition", err)
+	}
+	if n := countRequests(env.mock, "order/create") + countRequests(env.mock, "asset/withdraw/create"); n != 0 {
+		t.Errorf("requests on protected account reached Bybit: %d", n)
+	}
+
+	// 未受保护账户上的提现审批后提交到Bybit
+	resp, err := env.client.Withdraw(asClient(ctx, treasurerToken), &api.WithdrawRequest{Coin: "USDT", Chain: "TRX", Address: whitelistAddress, Amount: "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pending model.WithdrawalRequest
+	decodeResult(t, resp, &pending)
+	resp, err = env.client.ApproveWithdrawal(ctx, &api.ApproveWithdrawalRequest{Id: pending.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var approved model.WithdrawalRequest
+	decodeResult(t, resp, &approved)
+	if approved.Status != model.WithdrawalSubmitted || approved.Account != defaultAccount {
+		t.Errorf("approved withdrawal = %+v, want submitted on %s", approved, defaultAccount)
+	}
+	if n := countRequests(env.mock, "asset/withdraw/create"); n != 1 {
+		t.Errorf("asset/withdraw/create requests = %d, want 1", n)
+	}
+}
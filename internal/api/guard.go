@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/withdrawal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 不改变交易所账户状态的RPC方法，受保护账户上只允许调用这些方法
+// 未列出的方法（包括以后新增的方法）一律视为变更类方法
+var readOnlyMethods = map[string]bool{
+	// 行情
+	"GetKline":                  true,
+	"GetOrderbook":              true,
+	"GetTickers":                true,
+	"GetRecentTrades":           true,
+	"GetMarkPriceKline":         true,
+	"GetIndexPriceKline":        true,
+	"GetPremiumIndexPriceKline": true,
+	"GetFundingRateHistory":     true,
+	"GetOpenInterest":           true,
+	"GetLongShortRatio":         true,
+	"GetHistoricalVolatility":   true,
+	"GetInsurance":              true,
+	"GetRiskLimit":              true,
+	"GetDeliveryPrice":          true,
+	"GetServerTime":             true,
+	"GetHistoricalKlines":       true,
+	"GetOptionChain":            true,
+	"GetVolSurface":             true,
+	"GetIndicators":             true,
+	"GetFundingArbitrage":       true,
+	"Scan":                      true,
+
+	// 订单、仓位、账户和资产查询
+	"GetOrders":             true,
+	"GetOpenOrders":         true,
+	"GetOrderHistory":       true,
+	"GetPositions":          true,
+	"GetExecutions":         true,
+	"GetClosedPnl":          true,
+	"GetWalletBalance":      true,
+	"GetAccountInfo":        true,
+	"GetFeeRate":            true,
+	"GetAccountMode":        true,
+	"GetAssetInfo":          true,
+	"GetAllTransferHistory": true,
+	"GetTransferHistory":    true,
+	"GetDepositHistory":     true,
+	"GetWithdrawalHistory":  true,
+	"ListSubMembers":        true,
+	"ListSubAPIKeys":        true,
+	"GetPerformanceReport":  true,
+	"GetPortfolioGreeks":    true,
+	"SimulateMargin":        true,
+
+	// 本服务保存的数据
+	"ListWithdrawalRequests": true,
+	"ListAccounts":           true,
+	"GetAggregatedPositions": true,
+	"GetAggregatedBalances":  true,
+	"QueryAuditLog":          true,
+	"ListAlerts":             true,
+	"StreamAlerts":           true,
+}
+
+// 只改变本服务的状态、不操作交易所账户的方法，不受主网保护限制
+var localMethods = map[string]bool{
+	"RejectWithdrawal": true,
+	"ReloadConfig":     true,
+	"CreateAlert":      true,
+	"DeleteAlert":      true,
+}
+
+// 不需要检查账户是否受保护的方法
+func unguarded(fullMethod string) bool {
+	method := auth.MethodName(fullMethod)
+	return readOnlyMethods[method] || localMethods[method]
+}
+
+// MainnetGuardInterceptor 拒绝在受保护账户上调用变更类方法
+// protected为连接主网且未启用allowMainnetTrading的账户，需要放在AccountInterceptor之后
+// 审批提现检查的是申请中的账户，而不是审批人选择的账户，withdrawals为nil时按所选账户检查
+// 主网保护不是风控检查，放行的请求不记录风控结果，是否检查通过由RiskInterceptor记录
+func MainnetGuardInterceptor(router *service.Router, protected map[string]bool, withdrawals *withdrawal.Manager, onDenied auth.DeniedFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if unguarded(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		account := selectedAccount(ctx, router)
+		if r, ok := req.(*ApproveWithdrawalRequest); ok && withdrawals != nil {
+			var err error
+			if account, err = withdrawals.Account(ctx, r.Id); err != nil {
+				return nil, withdrawalError(err)
+			}
+		}
+		if err := guardAccount(protected, account, info.FullMethod); err != nil {
+			if onDenied != nil {
+				onDenied(ctx, info.FullMethod, req, err)
+			}
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// MainnetGuardStreamInterceptor 是MainnetGuardInterceptor的流式版本，在收到请求消息后检查所选账户
+// 目前的流式接口都是只读的，变更类的流式接口在这里统一拦截，需要放在AccountStreamInterceptor之后
+func MainnetGuardStreamInterceptor(router *service.Router, protected map[string]bool, onDenied auth.DeniedFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if unguarded(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		return handler(srv, &guardStream{ServerStream: ss, router: router, protected: protected, fullMethod: info.FullMethod, onDenied: onDenied})
+	}
+}
+
+// 收到请求消息时检查所选账户的ServerStream
+type guardStream struct {
+	grpc.ServerStream
+	router     *service.Router
+	protected  map[string]bool
+	fullMethod string
+	onDenied   auth.DeniedFunc
+}
+
+// RecvMsg 接收请求消息，所选账户受保护时拒绝请求
+func (s *guardStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	// 所选账户在内层的AccountStreamInterceptor收到消息后才写入上下文
+	ctx := s.ServerStream.Context()
+	if err := guardAccount(s.protected, selectedAccount(ctx, s.router), s.fullMethod); err != nil {
+		if s.onDenied != nil {
+			s.onDenied(ctx, s.fullMethod, m, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// 返回请求选择的账户，未指定时为默认账户
+func selectedAccount(ctx context.Context, router *service.Router) string {
+	if account := service.AccountFromContext(ctx); account != "" {
+		return account
+	}
+	return router.DefaultAccount()
+}
+
+// 检查账户是否受保护
+func guardAccount(protected map[string]bool, account, fullMethod string) error {
+	if protected[account] {
+		return status.Errorf(codes.FailedPrecondition, "账户%s连接的是主网，未启用bybit.allowMainnetTrading时不允许调用%s", account, auth.MethodName(fullMethod))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 选择账户的gRPC元数据键
+const accountMetadataKey = "x-bybit-account"
+
+// 带有account字段的请求
+type accountRequest interface {
+	GetAccount() string
+}
+
+// AccountInterceptor 从请求字段或元数据中读取账户名称并写入上下文
+// 请求字段优先于元数据，指定了不存在的账户或调用方无权使用的账户时直接拒绝请求
+func AccountInterceptor(router *service.Router, onDenied auth.DeniedFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := selectAccount(ctx, router, req, true)
+		if err != nil {
+			if onDenied != nil && status.Code(err) == codes.PermissionDenied {
+				onDenied(ctx, info.FullMethod, req, err)
+			}
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AccountStreamInterceptor 是AccountInterceptor的流式版本，在收到请求消息后选择账户
+// 流式接口未指定账户时表示调用方可以使用的全部账户，因此只检查明确指定的账户
+func AccountStreamInterceptor(router *service.Router, onDenied auth.DeniedFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &accountStream{ServerStream: ss, ctx: ss.Context(), router: router, fullMethod: info.FullMethod, onDenied: onDenied})
+	}
+}
+
+// 收到请求消息时选择账户的ServerStream
+type accountStream struct {
+	grpc.ServerStream
+	ctx        context.Context
+	router     *service.Router
+	fullMethod string
+	onDenied   auth.DeniedFunc
+}
+
+// Context 返回带有所选账户的上下文
+func (s *accountStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg 接收请求消息，并按消息中的账户字段或元数据选择账户
+func (s *accountStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	ctx, err := selectAccount(s.ctx, s.router, m, false)
+	if err != nil {
+		if s.onDenied != nil && status.Code(err) == codes.PermissionDenied {
+			s.onDenied(ctx, s.fullMethod, m, err)
+		}
+		return err
+	}
+	s.ctx = ctx
+	return nil
+}
+
+// 从请求字段或元数据中读取账户名称并写入上下文，启用认证时检查调用方是否可以使用该账户
+// checkDefault为true时未指定账户也检查默认账户
+func selectAccount(ctx context.Context, router *service.Router, req interface{}, checkDefault bool) (context.Context, error) {
+	name := ""
+	if r, ok := req.(accountRequest); ok {
+		name = r.GetAccount()
+	}
+	if name == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(accountMetadataKey); len(values) > 0 {
+				name = values[0]
+			}
+		}
+	}
+
+	if name != "" {
+		if !router.Has(name) {
+			return ctx, status.Errorf(codes.InvalidArgument, "未知账户: %s", name)
+		}
+		ctx = service.WithAccount(ctx, name)
+	}
+
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil || (name == "" && !checkDefault) {
+		return ctx, nil
+	}
+	account := name
+	if account == "" {
+		account = router.DefaultAccount()
+	}
+	if !identity.CanUseAccount(account) {
+		return ctx, status.Errorf(codes.PermissionDenied, "客户端%s无权使用账户%s", identity.Name, account)
+	}
+	return ctx, nil
+}
@@ -3,7 +3,6 @@ package market
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"strconv"
 
 	"github.com/bybit-mcp/internal/model"
@@ -19,10 +18,10 @@ type MarketService struct {
 }
 
 // NewMarketService 创建一个新的市场数据服务
-func NewMarketService(client *bybitapi.Client, logLevel, logOutput string) *MarketService {
+func NewMarketService(client *bybitapi.Client, log *logger.Logger) *MarketService {
 	return &MarketService{
 		client: client,
-		logger: logger.New(logLevel, logOutput),
+		logger: log,
 	}
 }
 
@@ -192,4 +191,227 @@ func (s *MarketService) GetInstruments(ctx context.Context, category, symbol, st
 	}
 
 	return &resp, nil
+}
+
+// GetRecentTrades 获取最近成交
+func (s *MarketService) GetRecentTrades(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	s.logger.Debug("获取最近成交: category=%s, symbol=%s, limit=%d", category, symbol, limit)
+
+	params := map[string]string{
+		"category": category,
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	return s.get(ctx, "market/recent-trade", "最近成交", params)
+}
+
+// GetMarkPriceKline 获取标记价格K线
+func (s *MarketService) GetMarkPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	s.logger.Debug("获取标记价格K线: category=%s, symbol=%s, interval=%s", category, symbol, interval)
+	return s.get(ctx, "market/mark-price-kline", "标记价格K线", klineParams(category, symbol, interval, limit, start, end))
+}
+
+// GetIndexPriceKline 获取指数价格K线
+func (s *MarketService) GetIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	s.logger.Debug("获取指数价格K线: category=%s, symbol=%s, interval=%s", category, symbol, interval)
+	return s.get(ctx, "market/index-price-kline", "指数价格K线", klineParams(category, symbol, interval, limit, start, end))
+}
+
+// GetPremiumIndexPriceKline 获取溢价指数K线，只支持USDT和USDC合约
+func (s *MarketService) GetPremiumIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	s.logger.Debug("获取溢价指数K线: category=%s, symbol=%s, interval=%s", category, symbol, interval)
+	return s.get(ctx, "market/premium-index-price-kline", "溢价指数K线", klineParams(category, symbol, interval, limit, start, end))
+}
+
+// GetFundingRateHistory 获取资金费率历史，按结算时间倒序返回
+func (s *MarketService) GetFundingRateHistory(ctx context.Context, category, symbol string, startTime, endTime int64, limit int) (*model.Response, error) {
+	s.logger.Debug("获取资金费率历史: category=%s, symbol=%s", category, symbol)
+
+	params := map[string]string{
+		"category": category,
+		"symbol":   symbol,
+	}
+	addTimeRange(params, startTime, endTime)
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	return s.get(ctx, "market/funding/history", "资金费率历史", params)
+}
+
+// GetOpenInterest 获取持仓量，intervalTime为5min、15min、30min、1h、4h或1d
+func (s *MarketService) GetOpenInterest(ctx context.Context, category, symbol, intervalTime string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	s.logger.Debug("获取持仓量: category=%s, symbol=%s, intervalTime=%s", category, symbol, intervalTime)
+
+	params := map[string]string{
+		"category":     category,
+		"symbol":       symbol,
+		"intervalTime": intervalTime,
+	}
+	addTimeRange(params, startTime, endTime)
+	addPage(params, limit, cursor)
+	return s.get(ctx, "market/open-interest", "持仓量", params)
+}
+
+// GetLongShortRatio 获取多空持仓人数比，period为5min、15min、30min、1h、4h或1d
+func (s *MarketService) GetLongShortRatio(ctx context.Context, category, symbol, period string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	s.logger.Debug("获取多空比: category=%s, symbol=%s, period=%s", category, symbol, period)
+
+	params := map[string]string{
+		"category": category,
+		"symbol":   symbol,
+		"period":   period,
+	}
+	addTimeRange(params, startTime, endTime)
+	addPage(params, limit, cursor)
+	return s.get(ctx, "market/account-ratio", "多空比", params)
+}
+
+// GetHistoricalVolatility 获取期权历史波动率，period为统计周期（天）
+func (s *MarketService) GetHistoricalVolatility(ctx context.Context, category, baseCoin string, period int, startTime, endTime int64) (*model.Response, error) {
+	s.logger.Debug("获取历史波动率: category=%s, baseCoin=%s, period=%d", category, baseCoin, period)
+
+	params := map[string]string{
+		"category": category,
+	}
+	if baseCoin != "" {
+		params["baseCoin"] = baseCoin
+	}
+	if period > 0 {
+		params["period"] = strconv.Itoa(period)
+	}
+	addTimeRange(params, startTime, endTime)
+	return s.get(ctx, "market/historical-volatility", "历史波动率", params)
+}
+
+// GetInsurance 获取保险基金余额，coin为空时返回全部币种
+func (s *MarketService) GetInsurance(ctx context.Context, coin string) (*model.Response, error) {
+	s.logger.Debug("获取保险基金: coin=%s", coin)
+
+	params := map[string]string{}
+	if coin != "" {
+		params["coin"] = coin
+	}
+	return s.get(ctx, "market/insurance", "保险基金", params)
+}
+
+// GetRiskLimit 获取风险限额档位
+func (s *MarketService) GetRiskLimit(ctx context.Context, category, symbol, cursor string) (*model.Response, error) {
+	s.logger.Debug("获取风险限额: category=%s, symbol=%s", category, symbol)
+
+	params := map[string]string{
+		"category": category,
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	addPage(params, 0, cursor)
+	return s.get(ctx, "market/risk-limit", "风险限额", params)
+}
+
+// GetDeliveryPrice 获取交割合约和期权的交割价格
+func (s *MarketService) GetDeliveryPrice(ctx context.Context, category, symbol, baseCoin string, limit int, cursor string) (*model.Response, error) {
+	s.logger.Debug("获取交割价格: category=%s, symbol=%s, baseCoin=%s", category, symbol, baseCoin)
+
+	params := map[string]string{
+		"category": category,
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	if baseCoin != "" {
+		params["baseCoin"] = baseCoin
+	}
+	addPage(params, limit, cursor)
+	return s.get(ctx, "market/delivery-price", "交割价格", params)
+}
+
+// GetOptionTickers 获取期权行情，包括买卖价的隐含波动率和希腊值
+// expDate为到期日，格式为25DEC22，为空时返回baseCoin的全部期权
+func (s *MarketService) GetOptionTickers(ctx context.Context, baseCoin, expDate string) (*model.Response, error) {
+	s.logger.Debug("获取期权行情: baseCoin=%s, expDate=%s", baseCoin, expDate)
+
+	params := map[string]string{
+		"category": bybitapi.CategoryOption,
+	}
+	if baseCoin != "" {
+		params["baseCoin"] = baseCoin
+	}
+	if expDate != "" {
+		params["expDate"] = expDate
+	}
+	return s.get(ctx, "market/tickers", "期权行情", params)
+}
+
+// GetServerTime 获取服务器时间
+func (s *MarketService) GetServerTime(ctx context.Context) (*model.Response, error) {
+	s.logger.Debug("获取服务器时间")
+	return s.get(ctx, "market/time", "服务器时间", map[string]string{})
+}
+
+// 发送公共行情请求并解析响应，name用于日志和错误信息
+func (s *MarketService) get(ctx context.Context, endpoint, name string, params map[string]string) (*model.Response, error) {
+	response, err := s.client.Get(endpoint, params, false)
+	if err != nil {
+		s.logger.Error("获取%s失败: %v", name, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "获取" + name + "失败",
+			Cause:   err,
+		}
+	}
+
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析%s失败: %v", name, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析" + name + "失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
+// 各类价格K线的请求参数
+func klineParams(category, symbol, interval string, limit int, start, end int64) map[string]string {
+	params := map[string]string{
+		"category": category,
+		"symbol":   symbol,
+		"interval": interval,
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	if start > 0 {
+		params["start"] = strconv.FormatInt(start, 10)
+	}
+	if end > 0 {
+		params["end"] = strconv.FormatInt(end, 10)
+	}
+	return params
+}
+
+// 添加startTime和endTime参数，0表示不限制
+func addTimeRange(params map[string]string, startTime, endTime int64) {
+	if startTime > 0 {
+		params["startTime"] = strconv.FormatInt(startTime, 10)
+	}
+	if endTime > 0 {
+		params["endTime"] = strconv.FormatInt(endTime, 10)
+	}
+}
+
+// 添加分页参数
+func addPage(params map[string]string, limit int, cursor string) {
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
 }
\ No newline at end of file
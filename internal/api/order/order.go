@@ -5,23 +5,30 @@ import (
 	"encoding/json"
 	"strconv"
 
+	"github.com/bybit-mcp/internal/api/pagination"
 	"github.com/bybit-mcp/internal/model"
 	"github.com/bybit-mcp/pkg/bybitapi"
 	"github.com/bybit-mcp/pkg/errors"
 	"github.com/bybit-mcp/pkg/logger"
+	"github.com/bybit-mcp/pkg/ratelimit"
 )
 
+// 翻页查询的默认限流：每秒5次请求
+const defaultPageRate = 5
+
 // OrderService 提供订单管理相关的API服务
 type OrderService struct {
-	client *bybitapi.Client
-	logger *logger.Logger
+	client  *bybitapi.Client
+	logger  *logger.Logger
+	limiter *ratelimit.Limiter
 }
 
 // NewOrderService 创建一个新的订单管理服务
-func NewOrderService(client *bybitapi.Client, logLevel, logOutput string) *OrderService {
+func NewOrderService(client *bybitapi.Client, log *logger.Logger) *OrderService {
 	return &OrderService{
-		client: client,
-		logger: logger.New(logLevel, logOutput),
+		client:  client,
+		logger:  log,
+		limiter: ratelimit.New(defaultPageRate, 1),
 	}
 }
 
@@ -116,6 +123,48 @@ func (s *OrderService) CancelOrder(ctx context.Context, category, symbol, orderI
 	return &resp, nil
 }
 
+// CancelAllOrders 取消全部订单，symbol和settleCoin都为空时取消该类别下全部订单
+func (s *OrderService) CancelAllOrders(ctx context.Context, category, symbol, settleCoin string) (*model.Response, error) {
+	s.logger.Debug("取消全部订单: category=%s, symbol=%s, settleCoin=%s", category, symbol, settleCoin)
+
+	// 构建请求参数
+	params := map[string]string{
+		"category": category,
+	}
+
+	// 添加可选参数
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	if settleCoin != "" {
+		params["settleCoin"] = settleCoin
+	}
+
+	// 发送请求
+	response, err := s.client.Post("order/cancel-all", params, true)
+	if err != nil {
+		s.logger.Error("取消全部订单失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrOrderCancelFailed,
+			Message: "取消全部订单失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析取消全部订单响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析取消全部订单响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
 // GetOrders 获取订单列表
 func (s *OrderService) GetOrders(ctx context.Context, category, symbol, orderId, orderLinkId, orderStatus string, limit int) (*model.Response, error) {
 	s.logger.Debug("获取订单列表: category=%s, symbol=%s, status=%s", category, symbol, orderStatus)
@@ -221,4 +270,120 @@ func (s *OrderService) AmendOrder(ctx context.Context, category, symbol, orderId
 	}
 
 	return &resp, nil
-}
\ No newline at end of file
+}
+
+// 构建订单查询参数
+func orderQueryParams(query *model.OrderQuery) map[string]string {
+	params := map[string]string{
+		"category": query.Category,
+	}
+
+	// 添加可选参数
+	if query.Symbol != "" {
+		params["symbol"] = query.Symbol
+	}
+	if query.BaseCoin != "" {
+		params["baseCoin"] = query.BaseCoin
+	}
+	if query.SettleCoin != "" {
+		params["settleCoin"] = query.SettleCoin
+	}
+	if query.OrderId != "" {
+		params["orderId"] = query.OrderId
+	}
+	if query.OrderLinkId != "" {
+		params["orderLinkId"] = query.OrderLinkId
+	}
+	if query.OrderFilter != "" {
+		params["orderFilter"] = query.OrderFilter
+	}
+	if query.OrderStatus != "" {
+		params["orderStatus"] = query.OrderStatus
+	}
+	if query.StartTime > 0 {
+		params["startTime"] = strconv.FormatInt(query.StartTime, 10)
+	}
+	if query.EndTime > 0 {
+		params["endTime"] = strconv.FormatInt(query.EndTime, 10)
+	}
+	if query.Limit > 0 {
+		params["limit"] = strconv.Itoa(query.Limit)
+	}
+	if query.Cursor != "" {
+		params["cursor"] = query.Cursor
+	}
+
+	return params
+}
+
+// 查询订单列表的通用实现
+func (s *OrderService) queryOrders(ctx context.Context, endpoint, action string, query *model.OrderQuery) (*model.Response, error) {
+	// 发送请求
+	response, err := s.client.Get(endpoint, orderQueryParams(query), true)
+	if err != nil {
+		s.logger.Error("%s失败: %v", action, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: action + "失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析%s响应失败: %v", action, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析" + action + "响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
+// GetOpenOrders 获取当前委托（未成交或部分成交的订单）
+func (s *OrderService) GetOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	s.logger.Debug("获取当前委托: category=%s, symbol=%s, orderFilter=%s, cursor=%s", query.Category, query.Symbol, query.OrderFilter, query.Cursor)
+	return s.queryOrders(ctx, "order/realtime", "获取当前委托", query)
+}
+
+// GetOrderHistory 获取历史订单
+func (s *OrderService) GetOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	s.logger.Debug("获取历史订单: category=%s, symbol=%s, startTime=%d, endTime=%d, cursor=%s", query.Category, query.Symbol, query.StartTime, query.EndTime, query.Cursor)
+	return s.queryOrders(ctx, "order/history", "获取历史订单", query)
+}
+
+// WalkOpenOrders 遍历所有当前委托，每页调用一次fn
+func (s *OrderService) WalkOpenOrders(ctx context.Context, query *model.OrderQuery, fn func(orders []model.Order) error) error {
+	return s.walkOrders(ctx, query, s.GetOpenOrders, fn)
+}
+
+// WalkOrderHistory 遍历所有历史订单，每页调用一次fn
+func (s *OrderService) WalkOrderHistory(ctx context.Context, query *model.OrderQuery, fn func(orders []model.Order) error) error {
+	return s.walkOrders(ctx, query, s.GetOrderHistory, fn)
+}
+
+// 按游标遍历订单列表，请求之间按限流器间隔
+func (s *OrderService) walkOrders(ctx context.Context, query *model.OrderQuery, get func(context.Context, *model.OrderQuery) (*model.Response, error), fn func(orders []model.Order) error) error {
+	fetch := func(ctx context.Context, cursor string) (*model.Response, error) {
+		pageQuery := *query
+		pageQuery.Cursor = cursor
+		return get(ctx, &pageQuery)
+	}
+
+	return pagination.Walk(ctx, fetch, query.Cursor, s.limiter, func(page *model.CursorResult) error {
+		var orders []model.Order
+		if len(page.List) > 0 {
+			if err := json.Unmarshal(page.List, &orders); err != nil {
+				return &errors.Error{
+					Code:    errors.ErrAPIResponseInvalid,
+					Message: "解析订单列表失败",
+					Cause:   err,
+				}
+			}
+		}
+		return fn(orders)
+	})
+}
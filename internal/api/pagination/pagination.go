@@ -0,0 +1,147 @@
+package pagination
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/errors"
+	"github.com/bybit-mcp/pkg/ratelimit"
+)
+
+// FetchFunc 使用给定游标获取一页数据，游标为空表示第一页
+type FetchFunc func(ctx context.Context, cursor string) (*model.Response, error)
+
+// Pager 按nextPageCursor逐页遍历Bybit列表接口
+type Pager struct {
+	fetch   FetchFunc
+	limiter *ratelimit.Limiter
+	cursor  string
+	seen    map[string]bool
+	done    bool
+}
+
+// NewPager 创建一个新的分页器，limiter为nil时不限流
+func NewPager(fetch FetchFunc, cursor string, limiter *ratelimit.Limiter) *Pager {
+	return &Pager{
+		fetch:   fetch,
+		limiter: limiter,
+		cursor:  cursor,
+		seen:    map[string]bool{},
+	}
+}
+
+// Done 返回是否已经没有更多数据
+func (p *Pager) Done() bool {
+	return p.done
+}
+
+// Cursor 返回下一页的游标
+func (p *Pager) Cursor() string {
+	return p.cursor
+}
+
+// Next 获取下一页数据
+func (p *Pager) Next(ctx context.Context) (*model.CursorResult, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	// 等待限流令牌
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.fetch(ctx, p.cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := DecodeResult(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// 游标为空或重复出现时结束，避免死循环
+	p.seen[p.cursor] = true
+	p.cursor = page.NextPageCursor
+	if p.cursor == "" || p.seen[p.cursor] {
+		p.done = true
+	}
+
+	return page, nil
+}
+
+// Walk 遍历所有分页，对每一页调用fn
+func Walk(ctx context.Context, fetch FetchFunc, cursor string, limiter *ratelimit.Limiter, fn func(page *model.CursorResult) error) error {
+	pager := NewPager(fetch, cursor, limiter)
+	for !pager.Done() {
+		page, err := pager.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeResult 从响应中解析分页结果，Bybit返回错误码时返回错误
+func DecodeResult(resp *model.Response) (*model.CursorResult, error) {
+	if resp == nil {
+		return nil, errors.New(errors.ErrAPIResponseInvalid, "响应为空")
+	}
+	if err := errors.FromBybitAPIError(resp.RetCode, resp.RetMsg); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrAPIResponseInvalid, "序列化分页结果失败", err)
+	}
+
+	var page model.CursorResult
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, errors.Wrap(errors.ErrAPIResponseInvalid, "解析分页结果失败", err)
+	}
+
+	return &page, nil
+}
+
+// NextCursor 从响应中提取下一页游标，无法解析时返回空字符串
+func NextCursor(resp *model.Response) string {
+	if resp == nil {
+		return ""
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	cursor, _ := result["nextPageCursor"].(string)
+	return cursor
+}
+
+// TimeWindow 表示一个毫秒时间区间
+type TimeWindow struct {
+	Start int64
+	End   int64
+}
+
+// SplitTimeRange 按window（毫秒）将时间区间切分为多个连续窗口
+// 部分Bybit接口限制单次查询的时间跨度（如7天），需要分段查询
+func SplitTimeRange(start, end, window int64) []TimeWindow {
+	if start <= 0 || end <= 0 || window <= 0 || end-start <= window {
+		return []TimeWindow{{Start: start, End: end}}
+	}
+
+	var windows []TimeWindow
+	for from := start; from < end; from += window {
+		to := from + window - 1
+		if to > end {
+			to = end
+		}
+		windows = append(windows, TimeWindow{Start: from, End: to})
+	}
+	return windows
+}
@@ -0,0 +1,448 @@
+package position
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/errors"
+)
+
+// 成交记录和平仓盈亏接口单次查询的最大时间跨度：7天
+const maxHistoryWindow = 7 * 24 * 60 * 60 * 1000
+
+// Bybit返回的原始成交记录，数值字段均为字符串
+type rawExecution struct {
+	Symbol          string `json:"symbol"`
+	OrderId         string `json:"orderId"`
+	OrderLinkId     string `json:"orderLinkId"`
+	Side            string `json:"side"`
+	OrderType       string `json:"orderType"`
+	OrderPrice      string `json:"orderPrice"`
+	OrderQty        string `json:"orderQty"`
+	ExecId          string `json:"execId"`
+	ExecType        string `json:"execType"`
+	ExecPrice       string `json:"execPrice"`
+	ExecQty         string `json:"execQty"`
+	ExecValue       string `json:"execValue"`
+	ExecFee         string `json:"execFee"`
+	FeeRate         string `json:"feeRate"`
+	FeeCurrency     string `json:"feeCurrency"`
+	IsMaker         bool   `json:"isMaker"`
+	ClosedSize      string `json:"closedSize"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	UnderlyingPrice string `json:"underlyingPrice"`
+	TradeIv         string `json:"tradeIv"`
+	MarkIv          string `json:"markIv"`
+	ExecTime        string `json:"execTime"`
+}
+
+// Bybit返回的原始平仓盈亏记录
+type rawClosedPnl struct {
+	Symbol        string `json:"symbol"`
+	OrderId       string `json:"orderId"`
+	Side          string `json:"side"`
+	OrderType     string `json:"orderType"`
+	ExecType      string `json:"execType"`
+	Qty           string `json:"qty"`
+	OrderPrice    string `json:"orderPrice"`
+	ClosedSize    string `json:"closedSize"`
+	CumEntryValue string `json:"cumEntryValue"`
+	AvgEntryPrice string `json:"avgEntryPrice"`
+	CumExitValue  string `json:"cumExitValue"`
+	AvgExitPrice  string `json:"avgExitPrice"`
+	ClosedPnl     string `json:"closedPnl"`
+	FillCount     string `json:"fillCount"`
+	Leverage      string `json:"leverage"`
+	CreatedTime   string `json:"createdTime"`
+	UpdatedTime   string `json:"updatedTime"`
+}
+
+// 解析数值字符串，空值或非法值返回0
+func parseFloat(v string) float64 {
+	f, _ := strconv.ParseFloat(v, 64)
+	return f
+}
+
+// 解析整数字符串，空值或非法值返回0
+func parseInt(v string) int64 {
+	i, _ := strconv.ParseInt(v, 10, 64)
+	return i
+}
+
+// SettleCoin 推断合约的结算币种
+// 线性合约以USDT或USDC结算（USDC永续以PERP结尾，USDC交割合约形如BTC-26APR24），反向合约以标的币种结算
+func SettleCoin(category, symbol string) string {
+	switch category {
+	case bybitapi.CategoryLinear:
+		switch {
+		case strings.HasSuffix(symbol, "USDT"), strings.Contains(symbol, "USDT-"):
+			return "USDT"
+		case strings.HasSuffix(symbol, "PERP"), strings.Contains(symbol, "USDC"), strings.Contains(symbol, "-"):
+			return "USDC"
+		}
+	case bybitapi.CategoryInverse:
+		if idx := strings.Index(symbol, "USD"); idx > 0 {
+			return symbol[:idx]
+		}
+	case bybitapi.CategoryOption:
+		return "USDC"
+	}
+	return ""
+}
+
+// 转换为标准化的成交记录
+func (r *rawExecution) normalize(category string) model.Execution {
+	feeCurrency := r.FeeCurrency
+	if feeCurrency == "" {
+		// 合约的手续费以结算币种计价，接口不返回feeCurrency
+		feeCurrency = SettleCoin(category, r.Symbol)
+	}
+
+	return model.Execution{
+		Category:        category,
+		Symbol:          r.Symbol,
+		OrderId:         r.OrderId,
+		OrderLinkId:     r.OrderLinkId,
+		Side:            r.Side,
+		OrderType:       r.OrderType,
+		OrderPrice:      parseFloat(r.OrderPrice),
+		OrderQty:        parseFloat(r.OrderQty),
+		ExecId:          r.ExecId,
+		ExecType:        r.ExecType,
+		ExecPrice:       parseFloat(r.ExecPrice),
+		ExecQty:         parseFloat(r.ExecQty),
+		ExecValue:       parseFloat(r.ExecValue),
+		ExecFee:         parseFloat(r.ExecFee),
+		FeeRate:         parseFloat(r.FeeRate),
+		FeeCurrency:     feeCurrency,
+		IsMaker:         r.IsMaker,
+		ClosedSize:      parseFloat(r.ClosedSize),
+		MarkPrice:       parseFloat(r.MarkPrice),
+		IndexPrice:      parseFloat(r.IndexPrice),
+		UnderlyingPrice: parseFloat(r.UnderlyingPrice),
+		TradeIv:         parseFloat(r.TradeIv),
+		MarkIv:          parseFloat(r.MarkIv),
+		ExecTime:        parseInt(r.ExecTime),
+	}
+}
+
+// 转换为标准化的平仓盈亏记录
+func (r *rawClosedPnl) normalize(category string) model.ClosedPnl {
+	return model.ClosedPnl{
+		Category:      category,
+		Symbol:        r.Symbol,
+		OrderId:       r.OrderId,
+		Side:          r.Side,
+		OrderType:     r.OrderType,
+		ExecType:      r.ExecType,
+		Qty:           parseFloat(r.Qty),
+		OrderPrice:    parseFloat(r.OrderPrice),
+		ClosedSize:    parseFloat(r.ClosedSize),
+		CumEntryValue: parseFloat(r.CumEntryValue),
+		AvgEntryPrice: parseFloat(r.AvgEntryPrice),
+		CumExitValue:  parseFloat(r.CumExitValue),
+		AvgExitPrice:  parseFloat(r.AvgExitPrice),
+		ClosedPnl:     parseFloat(r.ClosedPnl),
+		FillCount:     int(parseInt(r.FillCount)),
+		Leverage:      parseFloat(r.Leverage),
+		SettleCoin:    SettleCoin(category, r.Symbol),
+		CreatedTime:   parseInt(r.CreatedTime),
+		UpdatedTime:   parseInt(r.UpdatedTime),
+	}
+}
+
+// 发送查询请求并解析响应
+func (s *PositionService) queryHistory(ctx context.Context, endpoint, action string, params map[string]string) (*model.Response, error) {
+	// 发送请求
+	response, err := s.client.Get(endpoint, params, true)
+	if err != nil {
+		s.logger.Error("%s失败: %v", action, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: action + "失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析%s响应失败: %v", action, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析" + action + "响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
+// GetExecutions 获取一页成交记录
+func (s *PositionService) GetExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	s.logger.Debug("获取成交记录: category=%s, symbol=%s, execType=%s, cursor=%s", query.Category, query.Symbol, query.ExecType, query.Cursor)
+
+	resp, err := s.queryHistory(ctx, "execution/list", "获取成交记录", executionParams(query))
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := decodeExecutions(page, query.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ExecutionList{
+		Category:       query.Category,
+		List:           list,
+		NextPageCursor: page.NextPageCursor,
+	}, nil
+}
+
+// 构建成交记录查询参数
+func executionParams(query *model.ExecutionQuery) map[string]string {
+	// 构建请求参数
+	params := map[string]string{
+		"category": query.Category,
+	}
+
+	// 添加可选参数
+	if query.Symbol != "" {
+		params["symbol"] = query.Symbol
+	}
+	if query.BaseCoin != "" {
+		params["baseCoin"] = query.BaseCoin
+	}
+	if query.OrderId != "" {
+		params["orderId"] = query.OrderId
+	}
+	if query.OrderLinkId != "" {
+		params["orderLinkId"] = query.OrderLinkId
+	}
+	if query.ExecType != "" {
+		params["execType"] = query.ExecType
+	}
+	if query.StartTime > 0 {
+		params["startTime"] = strconv.FormatInt(query.StartTime, 10)
+	}
+	if query.EndTime > 0 {
+		params["endTime"] = strconv.FormatInt(query.EndTime, 10)
+	}
+	if query.Limit > 0 {
+		params["limit"] = strconv.Itoa(query.Limit)
+	}
+	if query.Cursor != "" {
+		params["cursor"] = query.Cursor
+	}
+
+	return params
+}
+
+// 解析一页成交记录
+func decodeExecutions(page *model.CursorResult, category string) ([]model.Execution, error) {
+	var raws []rawExecution
+	if len(page.List) > 0 {
+		if err := json.Unmarshal(page.List, &raws); err != nil {
+			return nil, &errors.Error{
+				Code:    errors.ErrAPIResponseInvalid,
+				Message: "解析成交记录失败",
+				Cause:   err,
+			}
+		}
+	}
+
+	list := make([]model.Execution, 0, len(raws))
+	for i := range raws {
+		list = append(list, raws[i].normalize(category))
+	}
+	return list, nil
+}
+
+// WalkExecutions 遍历时间区间内的全部成交记录，超过7天的区间会自动分段
+// 查询中的游标只用于第一个时间段，之后的时间段从第一页开始
+func (s *PositionService) WalkExecutions(ctx context.Context, query *model.ExecutionQuery, fn func(executions []model.Execution) error) error {
+	cursor := query.Cursor
+	for _, window := range pagination.SplitTimeRange(query.StartTime, query.EndTime, maxHistoryWindow) {
+		windowQuery := *query
+		windowQuery.StartTime = window.Start
+		windowQuery.EndTime = window.End
+
+		fetch := func(ctx context.Context, cursor string) (*model.Response, error) {
+			pageQuery := windowQuery
+			pageQuery.Cursor = cursor
+			return s.queryHistory(ctx, "execution/list", "获取成交记录", executionParams(&pageQuery))
+		}
+		err := pagination.Walk(ctx, fetch, cursor, s.limiter, func(page *model.CursorResult) error {
+			list, err := decodeExecutions(page, query.Category)
+			if err != nil {
+				return err
+			}
+			return fn(list)
+		})
+		if err != nil {
+			return err
+		}
+		cursor = ""
+	}
+	return nil
+}
+
+// GetClosedPnl 获取一页平仓盈亏记录
+func (s *PositionService) GetClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	s.logger.Debug("获取平仓盈亏: category=%s, symbol=%s, cursor=%s", query.Category, query.Symbol, query.Cursor)
+
+	resp, err := s.queryHistory(ctx, "position/closed-pnl", "获取平仓盈亏", closedPnlParams(query))
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := decodeClosedPnl(page, query.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ClosedPnlList{
+		Category:       query.Category,
+		List:           list,
+		NextPageCursor: page.NextPageCursor,
+	}, nil
+}
+
+// 构建平仓盈亏查询参数
+func closedPnlParams(query *model.ClosedPnlQuery) map[string]string {
+	// 构建请求参数
+	params := map[string]string{
+		"category": query.Category,
+	}
+
+	// 添加可选参数
+	if query.Symbol != "" {
+		params["symbol"] = query.Symbol
+	}
+	if query.StartTime > 0 {
+		params["startTime"] = strconv.FormatInt(query.StartTime, 10)
+	}
+	if query.EndTime > 0 {
+		params["endTime"] = strconv.FormatInt(query.EndTime, 10)
+	}
+	if query.Limit > 0 {
+		params["limit"] = strconv.Itoa(query.Limit)
+	}
+	if query.Cursor != "" {
+		params["cursor"] = query.Cursor
+	}
+
+	return params
+}
+
+// 解析一页平仓盈亏记录
+func decodeClosedPnl(page *model.CursorResult, category string) ([]model.ClosedPnl, error) {
+	var raws []rawClosedPnl
+	if len(page.List) > 0 {
+		if err := json.Unmarshal(page.List, &raws); err != nil {
+			return nil, &errors.Error{
+				Code:    errors.ErrAPIResponseInvalid,
+				Message: "解析平仓盈亏失败",
+				Cause:   err,
+			}
+		}
+	}
+
+	list := make([]model.ClosedPnl, 0, len(raws))
+	for i := range raws {
+		list = append(list, raws[i].normalize(category))
+	}
+	return list, nil
+}
+
+// WalkClosedPnl 遍历时间区间内的全部平仓盈亏记录，超过7天的区间会自动分段
+// 查询中的游标只用于第一个时间段，之后的时间段从第一页开始
+func (s *PositionService) WalkClosedPnl(ctx context.Context, query *model.ClosedPnlQuery, fn func(records []model.ClosedPnl) error) error {
+	cursor := query.Cursor
+	for _, window := range pagination.SplitTimeRange(query.StartTime, query.EndTime, maxHistoryWindow) {
+		windowQuery := *query
+		windowQuery.StartTime = window.Start
+		windowQuery.EndTime = window.End
+
+		fetch := func(ctx context.Context, cursor string) (*model.Response, error) {
+			pageQuery := windowQuery
+			pageQuery.Cursor = cursor
+			return s.queryHistory(ctx, "position/closed-pnl", "获取平仓盈亏", closedPnlParams(&pageQuery))
+		}
+		err := pagination.Walk(ctx, fetch, cursor, s.limiter, func(page *model.CursorResult) error {
+			list, err := decodeClosedPnl(page, query.Category)
+			if err != nil {
+				return err
+			}
+			return fn(list)
+		})
+		if err != nil {
+			return err
+		}
+		cursor = ""
+	}
+	return nil
+}
+
+// ListPositions 翻页获取全部仓位
+func (s *PositionService) ListPositions(ctx context.Context, category, symbol, settleCoin string) ([]model.Position, error) {
+	s.logger.Debug("获取全部仓位: category=%s, symbol=%s, settleCoin=%s", category, symbol, settleCoin)
+
+	fetch := func(ctx context.Context, cursor string) (*model.Response, error) {
+		// 构建请求参数
+		params := map[string]string{
+			"category": category,
+			"limit":    "200",
+		}
+
+		// 添加可选参数
+		if symbol != "" {
+			params["symbol"] = symbol
+		}
+		if settleCoin != "" {
+			params["settleCoin"] = settleCoin
+		}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		return s.queryHistory(ctx, "position/list", "获取仓位列表", params)
+	}
+
+	positions := []model.Position{}
+	err := pagination.Walk(ctx, fetch, "", s.limiter, func(page *model.CursorResult) error {
+		var list []model.Position
+		if len(page.List) > 0 {
+			if err := json.Unmarshal(page.List, &list); err != nil {
+				return &errors.Error{
+					Code:    errors.ErrAPIResponseInvalid,
+					Message: "解析仓位列表失败",
+					Cause:   err,
+				}
+			}
+		}
+		positions = append(positions, list...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
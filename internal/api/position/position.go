@@ -9,19 +9,25 @@ import (
 	"github.com/bybit-mcp/pkg/bybitapi"
 	"github.com/bybit-mcp/pkg/errors"
 	"github.com/bybit-mcp/pkg/logger"
+	"github.com/bybit-mcp/pkg/ratelimit"
 )
 
+// 翻页查询的默认限流：每秒5次请求
+const defaultPageRate = 5
+
 // PositionService 提供仓位管理相关的API服务
 type PositionService struct {
-	client *bybitapi.Client
-	logger *logger.Logger
+	client  *bybitapi.Client
+	logger  *logger.Logger
+	limiter *ratelimit.Limiter
 }
 
 // NewPositionService 创建一个新的仓位管理服务
-func NewPositionService(client *bybitapi.Client, logLevel, logOutput string) *PositionService {
+func NewPositionService(client *bybitapi.Client, log *logger.Logger) *PositionService {
 	return &PositionService{
-		client: client,
-		logger: logger.New(logLevel, logOutput),
+		client:  client,
+		logger:  log,
+		limiter: ratelimit.New(defaultPageRate, 1),
 	}
 }
 
@@ -194,5 +200,77 @@ func (s *PositionService) SwitchPositionMode(ctx context.Context, category, symb
 		}
 	}
 
+	return &resp, nil
+}
+
+// SetTpSlMode 设置止盈止损模式，tpSlMode为Full或Partial
+func (s *PositionService) SetTpSlMode(ctx context.Context, category, symbol, tpSlMode string) (*model.Response, error) {
+	s.logger.Debug("设置止盈止损模式: category=%s, symbol=%s, tpSlMode=%s", category, symbol, tpSlMode)
+
+	// 构建请求参数
+	params := map[string]string{
+		"category": category,
+		"symbol":   symbol,
+		"tpSlMode": tpSlMode,
+	}
+
+	// 发送请求
+	response, err := s.client.Post("position/set-tpsl-mode", params, true)
+	if err != nil {
+		s.logger.Error("设置止盈止损模式失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "设置止盈止损模式失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析设置止盈止损模式响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析设置止盈止损模式响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
+
+// SetRiskLimit 设置风险限额档位
+func (s *PositionService) SetRiskLimit(ctx context.Context, category, symbol string, riskId int) (*model.Response, error) {
+	s.logger.Debug("设置风险限额: category=%s, symbol=%s, riskId=%d", category, symbol, riskId)
+
+	// 构建请求参数
+	params := map[string]string{
+		"category": category,
+		"symbol":   symbol,
+		"riskId":   strconv.Itoa(riskId),
+	}
+
+	// 发送请求
+	response, err := s.client.Post("position/set-risk-limit", params, true)
+	if err != nil {
+		s.logger.Error("设置风险限额失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: "设置风险限额失败",
+			Cause:   err,
+		}
+	}
+
+	// 解析响应
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析设置风险限额响应失败: %v", err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析设置风险限额响应失败",
+			Cause:   err,
+		}
+	}
+
 	return &resp, nil
 }
\ No newline at end of file
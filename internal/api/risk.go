@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bybit-mcp/internal/audit"
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/risk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RiskInterceptor 在下单、改单和修改仓位设置前执行风控检查
+// 只减仓的订单用于平仓，不检查；撤单不增加风险，也不检查
+// 通过检查的请求在上下文中记录风控检查通过，被拒绝的请求通过onDenied写入审计日志
+func RiskInterceptor(checker *risk.Checker, onDenied auth.DeniedFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var err error
+		switch r := req.(type) {
+		case *CreateOrderRequest:
+			if r.ReduceOnly {
+				return handler(ctx, req)
+			}
+			err = checker.CheckOrder(r.Symbol, r.Qty, r.Price)
+		case *AmendOrderRequest:
+			err = checker.CheckOrder(r.Symbol, r.Qty, r.Price)
+		case *SetLeverageRequest:
+			err = checker.CheckLeverage(r.Symbol, r.Leverage)
+		case *SetTpSlModeRequest:
+			err = checker.CheckSymbol(r.Symbol)
+		case *SetRiskLimitRequest:
+			err = checker.CheckSymbol(r.Symbol)
+		default:
+			return handler(ctx, req)
+		}
+
+		if err != nil {
+			err = status.Error(codes.FailedPrecondition, err.Error())
+			if onDenied != nil {
+				onDenied(ctx, info.FullMethod, req, err)
+			}
+			return nil, err
+		}
+		return handler(audit.WithRisk(ctx, audit.RiskPassed), req)
+	}
+}
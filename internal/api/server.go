@@ -3,26 +3,194 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bybit-mcp/internal/alert"
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/audit"
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/carry"
+	"github.com/bybit-mcp/internal/indicators"
+	"github.com/bybit-mcp/internal/margin"
+	"github.com/bybit-mcp/internal/marketdata"
 	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/options"
+	"github.com/bybit-mcp/internal/reload"
+	"github.com/bybit-mcp/internal/scanner"
 	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/internal/withdrawal"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
+// 调用方身份的gRPC元数据键
+const callerMetadataKey = "x-caller-id"
+
+// 未经认证的调用方身份的前缀，x-caller-id由客户端任意填写，不能作为可信身份
+const unverifiedCallerPrefix = "unverified:"
+
+// 审计记录写入失败时返回给调用方的trailer元数据键
+const auditErrorMetadataKey = "x-audit-error"
+
+// 单次查询审计记录的最大数量
+const maxAuditLimit = 500
+
+// 单次查询历史行情的默认和最大数量
+const (
+	defaultHistoryLimit = 1000
+	maxHistoryLimit     = 10000
+)
+
 // BybitMCPServer 实现了BybitMCPServiceServer接口
 type BybitMCPServer struct {
 	UnimplementedBybitMCPServiceServer
 	service service.BybitService
+	auditor *audit.Auditor
+	store   storage.Store
+	router  *service.Router
+
+	withdrawals *withdrawal.Manager
+	reloader    *reload.Manager
+	history     *marketdata.History
+	indicators  *indicators.Engine
+	carry       *carry.Analyzer
+	margin      *margin.Simulator
+	scanner     *scanner.Scanner
+	alerts      *alert.Manager
 }
 
 // NewBybitMCPServer 创建一个新的Bybit MCP服务器
 func NewBybitMCPServer(service service.BybitService) *BybitMCPServer {
 	return &BybitMCPServer{
-		service: service,
+		service:    service,
+		indicators: indicators.NewEngine(service),
+		carry:      carry.NewAnalyzer(service),
+		margin:     margin.NewSimulator(service),
+	}
+}
+
+// SetAuditor 设置审计器和审计记录所在的存储，未设置时不记录审计日志
+func (s *BybitMCPServer) SetAuditor(auditor *audit.Auditor, store storage.Store) {
+	s.auditor = auditor
+	s.store = store
+}
+
+// SetRouter 设置多账户路由，用于账户列表和跨账户汇总
+func (s *BybitMCPServer) SetRouter(router *service.Router) {
+	s.router = router
+}
+
+// SetWithdrawalManager 设置提现管理器，未设置时拒绝全部提现
+func (s *BybitMCPServer) SetWithdrawalManager(manager *withdrawal.Manager) {
+	s.withdrawals = manager
+}
+
+// SetConfigReloader 设置配置重新加载管理器，用于ReloadConfig接口
+func (s *BybitMCPServer) SetConfigReloader(reloader *reload.Manager) {
+	s.reloader = reloader
+}
+
+// SetHistory 设置历史行情库，用于GetHistoricalKlines接口
+func (s *BybitMCPServer) SetHistory(history *marketdata.History) {
+	s.history = history
+}
+
+// SetScanner 设置行情扫描器，用于Scan接口
+func (s *BybitMCPServer) SetScanner(scanner *scanner.Scanner) {
+	s.scanner = scanner
+}
+
+// SetAlertManager 设置告警管理器，用于告警接口
+func (s *BybitMCPServer) SetAlertManager(alerts *alert.Manager) {
+	s.alerts = alerts
+}
+
+// SubmitWithdrawal 使用申请中的账户把提现提交到Bybit，用作提现管理器的回调
+func (s *BybitMCPServer) SubmitWithdrawal(ctx context.Context, request *model.WithdrawalRequest) (*model.Response, error) {
+	options := map[string]string{}
+	if request.AccountType != "" {
+		options["accountType"] = request.AccountType
+	}
+	ctx = service.WithAccount(ctx, request.Account)
+	return s.service.Withdraw(ctx, request.Coin, request.Chain, request.Address, request.Tag, request.Amount, options)
+}
+
+// 检查当前请求选择的账户是否为母账户
+func (s *BybitMCPServer) requireMaster(ctx context.Context) error {
+	if s.router == nil || !s.router.IsMaster(service.AccountFromContext(ctx)) {
+		return status.Error(codes.PermissionDenied, "该操作只能使用母账户")
+	}
+	return nil
+}
+
+// 获取调用方身份：优先使用认证后的身份，其次是请求元数据，最后是对端地址
+// 请求元数据中的身份未经认证，加上unverified:前缀以免和认证后的身份混淆
+func callerFromContext(ctx context.Context) string {
+	if caller := audit.CallerFromContext(ctx); caller != "" {
+		return caller
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(callerMetadataKey); len(values) > 0 && values[0] != "" {
+			return unverifiedCallerPrefix + values[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+// 记录一次变更类调用的审计日志，风控检查结果取自风控拦截器写入上下文的结果
+func (s *BybitMCPServer) audit(ctx context.Context, method string, req interface{}, start time.Time, resp *model.Response, err error) {
+	s.auditWithRisk(ctx, method, req, start, resp, err, audit.RiskFromContext(ctx))
+}
+
+// 记录审计日志，并写入风控检查结果
+// 写入失败时通过x-audit-error trailer告知调用方，调用本身的结果不受影响
+func (s *BybitMCPServer) auditWithRisk(ctx context.Context, method string, req interface{}, start time.Time, resp *model.Response, err error, risk string) {
+	if s.auditor == nil {
+		return
+	}
+
+	record := &storage.AuditRecord{
+		Time:       start.UnixMilli(),
+		Caller:     callerFromContext(ctx),
+		Method:     method,
+		Request:    audit.Redact(req),
+		RiskResult: risk,
+		LatencyMs:  time.Since(start).Milliseconds(),
+	}
+	if resp != nil {
+		record.RetCode = resp.RetCode
+		record.RetMsg = resp.RetMsg
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if auditErr := s.auditor.Record(ctx, record); auditErr != nil {
+		// 流式调用或测试中直接调用时上下文中没有gRPC传输，忽略设置trailer的错误
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(auditErrorMetadataKey, auditErr.Error()))
 	}
 }
 
+// AuditDenied 记录被拒绝的请求，用作认证拦截器的回调
+func (s *BybitMCPServer) AuditDenied(ctx context.Context, fullMethod string, req interface{}, err error) {
+	s.audit(ctx, auth.MethodName(fullMethod), req, time.Now(), nil, err)
+}
+
+// AuditRiskRejected 记录被风控拒绝的请求，用作风控拦截器的回调
+func (s *BybitMCPServer) AuditRiskRejected(ctx context.Context, fullMethod string, req interface{}, err error) {
+	s.auditWithRisk(ctx, auth.MethodName(fullMethod), req, time.Now(), nil, err, audit.RiskRejected)
+}
+
 // 将响应转换为gRPC响应格式
 func (s *BybitMCPServer) toMCPResponse(requestID string, resp *model.Response, err error) (*MCPResponse, error) {
 	if err != nil {
@@ -44,18 +212,31 @@ func (s *BybitMCPServer) toMCPResponse(requestID string, resp *model.Response, e
 	}
 
 	return &MCPResponse{
-		RequestId: requestID,
-		Code:      int32(resp.RetCode),
-		Message:   resp.RetMsg,
-		Data:      data,
+		RequestId:  requestID,
+		Code:       int32(resp.RetCode),
+		Message:    resp.RetMsg,
+		Data:       data,
+		NextCursor: pagination.NextCursor(resp),
 	}, nil
 }
 
+// 将类型化的结果转换为gRPC响应格式，cursor为下一页游标
+func (s *BybitMCPServer) toResultResponse(requestID string, result interface{}, cursor string, err error) (*MCPResponse, error) {
+	if err != nil {
+		return s.toMCPResponse(requestID, nil, err)
+	}
+	mcpResp, err := s.toMCPResponse(requestID, &model.Response{RetCode: 0, RetMsg: "OK", Result: result}, nil)
+	if mcpResp != nil && mcpResp.NextCursor == "" {
+		mcpResp.NextCursor = cursor
+	}
+	return mcpResp, err
+}
+
 // ==================== 市场数据API实现 ====================
 
 // GetKline 获取K线数据
 func (s *BybitMCPServer) GetKline(ctx context.Context, req *KlineRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetKline(ctx, req.Category, req.Symbol, req.Interval, int(req.Limit))
+	resp, err := s.service.GetKline(ctx, req.Category, req.Symbol, req.Interval, int(req.Limit), req.Start, req.End)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
@@ -77,51 +258,190 @@ func (s *BybitMCPServer) GetRecentTrades(ctx context.Context, req *RecentTradesR
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
+// GetMarkPriceKline 获取标记价格K线
+func (s *BybitMCPServer) GetMarkPriceKline(ctx context.Context, req *PriceKlineRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetMarkPriceKline(ctx, req.Category, req.Symbol, req.Interval, int(req.Limit), req.Start, req.End)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetIndexPriceKline 获取指数价格K线
+func (s *BybitMCPServer) GetIndexPriceKline(ctx context.Context, req *PriceKlineRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetIndexPriceKline(ctx, req.Category, req.Symbol, req.Interval, int(req.Limit), req.Start, req.End)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetPremiumIndexPriceKline 获取溢价指数K线
+func (s *BybitMCPServer) GetPremiumIndexPriceKline(ctx context.Context, req *PriceKlineRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetPremiumIndexPriceKline(ctx, req.Category, req.Symbol, req.Interval, int(req.Limit), req.Start, req.End)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetFundingRateHistory 获取资金费率历史
+func (s *BybitMCPServer) GetFundingRateHistory(ctx context.Context, req *FundingRateHistoryRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetFundingRateHistory(ctx, req.Category, req.Symbol, req.StartTime, req.EndTime, int(req.Limit))
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetOpenInterest 获取持仓量
+func (s *BybitMCPServer) GetOpenInterest(ctx context.Context, req *OpenInterestRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetOpenInterest(ctx, req.Category, req.Symbol, req.IntervalTime, req.StartTime, req.EndTime, int(req.Limit), req.Cursor)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetLongShortRatio 获取多空持仓人数比
+func (s *BybitMCPServer) GetLongShortRatio(ctx context.Context, req *LongShortRatioRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetLongShortRatio(ctx, req.Category, req.Symbol, req.Period, req.StartTime, req.EndTime, int(req.Limit), req.Cursor)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetHistoricalVolatility 获取期权历史波动率
+func (s *BybitMCPServer) GetHistoricalVolatility(ctx context.Context, req *HistoricalVolatilityRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetHistoricalVolatility(ctx, req.Category, req.BaseCoin, int(req.Period), req.StartTime, req.EndTime)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetInsurance 获取保险基金余额
+func (s *BybitMCPServer) GetInsurance(ctx context.Context, req *InsuranceRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetInsurance(ctx, req.Coin)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetRiskLimit 获取风险限额档位
+func (s *BybitMCPServer) GetRiskLimit(ctx context.Context, req *RiskLimitRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetRiskLimit(ctx, req.Category, req.Symbol, req.Cursor)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetDeliveryPrice 获取交割价格
+func (s *BybitMCPServer) GetDeliveryPrice(ctx context.Context, req *DeliveryPriceRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetDeliveryPrice(ctx, req.Category, req.Symbol, req.BaseCoin, int(req.Limit), req.Cursor)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetServerTime 获取服务器时间
+func (s *BybitMCPServer) GetServerTime(ctx context.Context, req *ServerTimeRequest) (*MCPResponse, error) {
+	resp, err := s.service.GetServerTime(ctx)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
 // ==================== 订单管理API实现 ====================
 
 // CreateOrder 创建订单
 func (s *BybitMCPServer) CreateOrder(ctx context.Context, req *CreateOrderRequest) (*MCPResponse, error) {
-	// 构建订单请求
-	orderReq := &model.OrderRequest{
-		Category:       req.Category,
-		Symbol:         req.Symbol,
-		Side:           req.Side,
-		OrderType:      req.OrderType,
-		Qty:            req.Qty,
-		Price:          req.Price,
-		TimeInForce:    req.TimeInForce,
-		OrderLinkId:    req.OrderLinkId,
-		TakeProfit:     req.TakeProfit,
-		StopLoss:       req.StopLoss,
-		ReduceOnly:     req.ReduceOnly,
-		CloseOnTrigger: req.CloseOnTrigger,
+	// 可选参数按Bybit V5接口的字段名传递
+	options := map[string]string{}
+	if req.TimeInForce != "" {
+		options["timeInForce"] = req.TimeInForce
+	}
+	if req.OrderLinkId != "" {
+		options["orderLinkId"] = req.OrderLinkId
+	}
+	if req.TakeProfit > 0 {
+		options["takeProfit"] = strconv.FormatFloat(req.TakeProfit, 'f', -1, 64)
+	}
+	if req.StopLoss > 0 {
+		options["stopLoss"] = strconv.FormatFloat(req.StopLoss, 'f', -1, 64)
+	}
+	if req.ReduceOnly {
+		options["reduceOnly"] = "true"
+	}
+	if req.CloseOnTrigger {
+		options["closeOnTrigger"] = "true"
 	}
 
-	resp, err := s.service.CreateOrder(ctx, orderReq)
+	start := time.Now()
+	resp, err := s.service.CreateOrder(ctx, req.Category, req.Symbol, req.Side, req.OrderType, req.Qty, req.Price, options)
+	s.audit(ctx, "CreateOrder", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // CancelOrder 取消订单
 func (s *BybitMCPServer) CancelOrder(ctx context.Context, req *CancelOrderRequest) (*MCPResponse, error) {
-	resp, err := s.service.CancelOrder(ctx, req.Category, req.Symbol, req.OrderId)
+	start := time.Now()
+	resp, err := s.service.CancelOrder(ctx, req.Category, req.Symbol, req.OrderId, "")
+	s.audit(ctx, "CancelOrder", req, start, resp, err)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// AmendOrder 修改订单
+func (s *BybitMCPServer) AmendOrder(ctx context.Context, req *AmendOrderRequest) (*MCPResponse, error) {
+	options := map[string]string{}
+	if req.TakeProfit != "" {
+		options["takeProfit"] = req.TakeProfit
+	}
+	if req.StopLoss != "" {
+		options["stopLoss"] = req.StopLoss
+	}
+	if req.TriggerPrice != "" {
+		options["triggerPrice"] = req.TriggerPrice
+	}
+
+	start := time.Now()
+	resp, err := s.service.AmendOrder(ctx, req.Category, req.Symbol, req.OrderId, req.OrderLinkId, req.Qty, req.Price, options)
+	s.audit(ctx, "AmendOrder", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // GetOrders 获取订单列表
 func (s *BybitMCPServer) GetOrders(ctx context.Context, req *GetOrdersRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetOrders(ctx, req.Category, req.Symbol, int(req.Limit))
+	resp, err := s.service.GetOrders(ctx, req.Category, req.Symbol, "", "", "", int(req.Limit))
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetOpenOrders 获取当前委托
+func (s *BybitMCPServer) GetOpenOrders(ctx context.Context, req *GetOpenOrdersRequest) (*MCPResponse, error) {
+	query := &model.OrderQuery{
+		Category:    req.Category,
+		Symbol:      req.Symbol,
+		BaseCoin:    req.BaseCoin,
+		SettleCoin:  req.SettleCoin,
+		OrderId:     req.OrderId,
+		OrderLinkId: req.OrderLinkId,
+		OrderFilter: req.OrderFilter,
+		Limit:       int(req.Limit),
+		Cursor:      req.Cursor,
+	}
+
+	if req.AllPages {
+		result, err := s.service.GetAllOpenOrders(ctx, query)
+		return s.toResultResponse(req.RequestId, result, "", err)
+	}
+
+	resp, err := s.service.GetOpenOrders(ctx, query)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // GetOrderHistory 获取历史订单
 func (s *BybitMCPServer) GetOrderHistory(ctx context.Context, req *GetOrderHistoryRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetOrderHistory(ctx, req.Category, req.Symbol, int(req.Limit))
+	query := &model.OrderQuery{
+		Category:    req.Category,
+		Symbol:      req.Symbol,
+		BaseCoin:    req.BaseCoin,
+		SettleCoin:  req.SettleCoin,
+		OrderId:     req.OrderId,
+		OrderLinkId: req.OrderLinkId,
+		OrderFilter: req.OrderFilter,
+		OrderStatus: req.OrderStatus,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Limit:       int(req.Limit),
+		Cursor:      req.Cursor,
+	}
+
+	if req.AllPages {
+		result, err := s.service.GetAllOrderHistory(ctx, query)
+		return s.toResultResponse(req.RequestId, result, "", err)
+	}
+
+	resp, err := s.service.GetOrderHistory(ctx, query)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // CancelAllOrders 取消所有订单
 func (s *BybitMCPServer) CancelAllOrders(ctx context.Context, req *CancelAllOrdersRequest) (*MCPResponse, error) {
+	start := time.Now()
 	resp, err := s.service.CancelAllOrders(ctx, req.Category, req.Symbol, req.SettleCoin)
+	s.audit(ctx, "CancelAllOrders", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
@@ -129,28 +449,84 @@ func (s *BybitMCPServer) CancelAllOrders(ctx context.Context, req *CancelAllOrde
 
 // GetPositions 获取仓位
 func (s *BybitMCPServer) GetPositions(ctx context.Context, req *GetPositionsRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetPositions(ctx, req.Category, req.Symbol)
+	resp, err := s.service.GetPositions(ctx, req.Category, req.Symbol, "", "")
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // SetLeverage 设置杠杆
 func (s *BybitMCPServer) SetLeverage(ctx context.Context, req *SetLeverageRequest) (*MCPResponse, error) {
-	resp, err := s.service.SetLeverage(ctx, req.Category, req.Symbol, req.Leverage)
+	start := time.Now()
+	resp, err := s.service.SetLeverage(ctx, req.Category, req.Symbol, req.Leverage, req.Leverage)
+	s.audit(ctx, "SetLeverage", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // SetTpSlMode 设置止盈止损模式
 func (s *BybitMCPServer) SetTpSlMode(ctx context.Context, req *SetTpSlModeRequest) (*MCPResponse, error) {
+	start := time.Now()
 	resp, err := s.service.SetTpSlMode(ctx, req.Category, req.Symbol, req.TpSlMode)
+	s.audit(ctx, "SetTpSlMode", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // SetRiskLimit 设置风险限额
 func (s *BybitMCPServer) SetRiskLimit(ctx context.Context, req *SetRiskLimitRequest) (*MCPResponse, error) {
+	start := time.Now()
 	resp, err := s.service.SetRiskLimit(ctx, req.Category, req.Symbol, int(req.RiskId))
+	s.audit(ctx, "SetRiskLimit", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
+// GetExecutions 获取成交记录
+func (s *BybitMCPServer) GetExecutions(ctx context.Context, req *GetExecutionsRequest) (*MCPResponse, error) {
+	query := &model.ExecutionQuery{
+		Category:    req.Category,
+		Symbol:      req.Symbol,
+		BaseCoin:    req.BaseCoin,
+		OrderId:     req.OrderId,
+		OrderLinkId: req.OrderLinkId,
+		ExecType:    req.ExecType,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Limit:       int(req.Limit),
+		Cursor:      req.Cursor,
+	}
+
+	if req.AllPages {
+		result, err := s.service.GetAllExecutions(ctx, query)
+		return s.toResultResponse(req.RequestId, result, "", err)
+	}
+
+	result, err := s.service.GetExecutions(ctx, query)
+	if err != nil {
+		return s.toResultResponse(req.RequestId, nil, "", err)
+	}
+	return s.toResultResponse(req.RequestId, result, result.NextPageCursor, nil)
+}
+
+// GetClosedPnl 获取平仓盈亏
+func (s *BybitMCPServer) GetClosedPnl(ctx context.Context, req *GetClosedPnlRequest) (*MCPResponse, error) {
+	query := &model.ClosedPnlQuery{
+		Category:  req.Category,
+		Symbol:    req.Symbol,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Limit:     int(req.Limit),
+		Cursor:    req.Cursor,
+	}
+
+	if req.AllPages {
+		result, err := s.service.GetAllClosedPnl(ctx, query)
+		return s.toResultResponse(req.RequestId, result, "", err)
+	}
+
+	result, err := s.service.GetClosedPnl(ctx, query)
+	if err != nil {
+		return s.toResultResponse(req.RequestId, nil, "", err)
+	}
+	return s.toResultResponse(req.RequestId, result, result.NextPageCursor, nil)
+}
+
 // ==================== 账户管理API实现 ====================
 
 // GetWalletBalance 获取钱包余额
@@ -173,13 +549,15 @@ func (s *BybitMCPServer) GetFeeRate(ctx context.Context, req *GetFeeRateRequest)
 
 // GetAccountMode 获取账户模式
 func (s *BybitMCPServer) GetAccountMode(ctx context.Context, req *GetAccountModeRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetAccountMode(ctx)
+	resp, err := s.service.GetAccountInfo(ctx)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // SetAccountMode 设置账户模式
 func (s *BybitMCPServer) SetAccountMode(ctx context.Context, req *SetAccountModeRequest) (*MCPResponse, error) {
-	resp, err := s.service.SetAccountMode(ctx, req.AccountMode)
+	start := time.Now()
+	resp, err := s.service.SetMarginMode(ctx, req.AccountMode)
+	s.audit(ctx, "SetAccountMode", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
@@ -187,30 +565,672 @@ func (s *BybitMCPServer) SetAccountMode(ctx context.Context, req *SetAccountMode
 
 // GetAssetInfo 获取资产信息
 func (s *BybitMCPServer) GetAssetInfo(ctx context.Context, req *GetAssetInfoRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetAssetInfo(ctx, req.AccountType)
+	resp, err := s.service.GetCoinBalance(ctx, "", req.AccountType)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // AssetTransfer 资产划转
 func (s *BybitMCPServer) AssetTransfer(ctx context.Context, req *AssetTransferRequest) (*MCPResponse, error) {
-	resp, err := s.service.AssetTransfer(ctx, req.FromAccountType, req.ToAccountType, req.Coin, req.Amount)
+	start := time.Now()
+	resp, err := s.service.TransferAsset(ctx, uuid.NewString(), req.Coin, strconv.FormatFloat(req.Amount, 'f', -1, 64), req.FromAccountType, req.ToAccountType)
+	s.audit(ctx, "AssetTransfer", req, start, resp, err)
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
+// Withdraw 提现
+// 通过提现策略检查后创建待审批的提现申请，关闭审批时直接提交
+func (s *BybitMCPServer) Withdraw(ctx context.Context, req *WithdrawRequest) (*MCPResponse, error) {
+	start := time.Now()
+	identity, err := s.withdrawalIdentity(ctx)
+	if err != nil {
+		s.auditWithRisk(ctx, "Withdraw", req, start, nil, err, audit.RiskRejected)
+		return nil, err
+	}
+
+	account := service.AccountFromContext(ctx)
+	if account == "" && s.router != nil {
+		account = s.router.DefaultAccount()
+	}
+
+	request, resp, err := s.withdrawals.Request(ctx, &model.WithdrawalRequest{
+		Account:     account,
+		Coin:        req.Coin,
+		Chain:       req.Chain,
+		Address:     req.Address,
+		Tag:         req.Tag,
+		Amount:      req.Amount,
+		AccountType: req.AccountType,
+		RequestedBy: identity.Name,
+	})
+	s.auditWithRisk(ctx, "Withdraw", req, start, resp, err, withdrawalRisk(err))
+	if err != nil {
+		return nil, withdrawalError(err)
+	}
+	return s.toResultResponse(req.RequestId, request, "", nil)
+}
+
+// ApproveWithdrawal 审批并提交待审批的提现申请，审批人不能是申请人
+func (s *BybitMCPServer) ApproveWithdrawal(ctx context.Context, req *ApproveWithdrawalRequest) (*MCPResponse, error) {
+	start := time.Now()
+	identity, err := s.withdrawalIdentity(ctx)
+	if err != nil {
+		s.auditWithRisk(ctx, "ApproveWithdrawal", req, start, nil, err, audit.RiskRejected)
+		return nil, err
+	}
+
+	request, resp, err := s.withdrawals.Approve(ctx, req.Id, identity)
+	s.auditWithRisk(ctx, "ApproveWithdrawal", req, start, resp, err, withdrawalRisk(err))
+	if err != nil {
+		return nil, withdrawalError(err)
+	}
+	return s.toResultResponse(req.RequestId, request, "", nil)
+}
+
+// RejectWithdrawal 拒绝待审批的提现申请
+func (s *BybitMCPServer) RejectWithdrawal(ctx context.Context, req *RejectWithdrawalRequest) (*MCPResponse, error) {
+	start := time.Now()
+	identity, err := s.withdrawalIdentity(ctx)
+	if err != nil {
+		s.audit(ctx, "RejectWithdrawal", req, start, nil, err)
+		return nil, err
+	}
+
+	request, err := s.withdrawals.Reject(ctx, req.Id, identity, req.Reason)
+	s.audit(ctx, "RejectWithdrawal", req, start, nil, err)
+	if err != nil {
+		return nil, withdrawalError(err)
+	}
+	return s.toResultResponse(req.RequestId, request, "", nil)
+}
+
+// ListWithdrawalRequests 查询提现申请
+func (s *BybitMCPServer) ListWithdrawalRequests(ctx context.Context, req *ListWithdrawalRequestsRequest) (*MCPResponse, error) {
+	if s.withdrawals == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未配置提现策略")
+	}
+
+	requests, err := s.withdrawals.List(ctx, storage.WithdrawalFilter{
+		Status:    req.Status,
+		Coin:      req.Coin,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Limit:     int(req.Limit),
+	})
+	return s.toResultResponse(req.RequestId, requests, "", err)
+}
+
+// 提现和审批只接受认证后的身份，x-caller-id可以任意填写，不能用来区分申请人和审批人
+func (s *BybitMCPServer) withdrawalIdentity(ctx context.Context) (*auth.Identity, error) {
+	if s.withdrawals == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未配置提现策略")
+	}
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用调用方认证，不能提现或审批提现")
+	}
+	return identity, nil
+}
+
+// 提现策略检查结果
+func withdrawalRisk(err error) string {
+	var policyErr *withdrawal.PolicyError
+	if errors.As(err, &policyErr) {
+		return audit.RiskRejected
+	}
+	return audit.RiskPassed
+}
+
+// 把提现管理器的错误转换为gRPC错误
+func withdrawalError(err error) error {
+	var policyErr *withdrawal.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, withdrawal.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, withdrawal.ErrSelfApproval), errors.Is(err, withdrawal.ErrAccountDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
 // GetTransferHistory 获取划转历史
 func (s *BybitMCPServer) GetTransferHistory(ctx context.Context, req *GetTransferHistoryRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetTransferHistory(ctx, req.Coin, int(req.Limit))
+	resp, err := s.service.GetTransferHistory(ctx, "", req.Coin, "", 0, 0, int(req.Limit))
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // GetDepositHistory 获取充值历史
 func (s *BybitMCPServer) GetDepositHistory(ctx context.Context, req *GetDepositHistoryRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetDepositHistory(ctx, req.Coin, int(req.Limit))
+	resp, err := s.service.GetDepositHistory(ctx, req.Coin, 0, 0, int(req.Limit))
 	return s.toMCPResponse(req.RequestId, resp, err)
 }
 
 // GetWithdrawalHistory 获取提现历史
 func (s *BybitMCPServer) GetWithdrawalHistory(ctx context.Context, req *GetWithdrawalHistoryRequest) (*MCPResponse, error) {
-	resp, err := s.service.GetWithdrawalHistory(ctx, req.Coin, int(req.Limit))
+	resp, err := s.service.GetWithdrawalHistory(ctx, req.Coin, 0, 0, int(req.Limit))
 	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// UniversalTransfer 母子账户之间划转
+func (s *BybitMCPServer) UniversalTransfer(ctx context.Context, req *UniversalTransferRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	transferReq := &model.UniversalTransferRequest{
+		TransferId:      req.TransferId,
+		Coin:            req.Coin,
+		Amount:          req.Amount,
+		FromMemberId:    req.FromMemberId,
+		ToMemberId:      req.ToMemberId,
+		FromAccountType: req.FromAccountType,
+		ToAccountType:   req.ToAccountType,
+	}
+
+	start := time.Now()
+	resp, err := s.service.UniversalTransfer(ctx, transferReq)
+	s.audit(ctx, "UniversalTransfer", req, start, resp, err)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// GetAllTransferHistory 获取账户内划转和母子账户间划转记录
+func (s *BybitMCPServer) GetAllTransferHistory(ctx context.Context, req *GetAllTransferHistoryRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+	if req.StartTime > 0 && req.EndTime > 0 && req.StartTime > req.EndTime {
+		return nil, status.Error(codes.InvalidArgument, "开始时间不能晚于结束时间")
+	}
+
+	transfers, err := s.service.ListAllTransfers(ctx, req.Coin, req.Status, req.StartTime, req.EndTime)
+	return s.toResultResponse(req.RequestId, transfers, "", err)
+}
+
+// ==================== 子账户管理API实现 ====================
+
+// CreateSubMember 创建子账户
+func (s *BybitMCPServer) CreateSubMember(ctx context.Context, req *CreateSubMemberRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	memberReq := &model.SubMemberRequest{
+		Username:   req.Username,
+		Password:   req.Password,
+		MemberType: int(req.MemberType),
+		Note:       req.Note,
+	}
+	if memberReq.MemberType == 0 {
+		memberReq.MemberType = 1
+	}
+	if req.QuickLogin {
+		memberReq.Switch = 1
+	}
+
+	start := time.Now()
+	resp, err := s.service.CreateSubMember(ctx, memberReq)
+	s.audit(ctx, "CreateSubMember", req, start, resp, err)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// ListSubMembers 获取子账户列表
+func (s *BybitMCPServer) ListSubMembers(ctx context.Context, req *ListSubMembersRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.service.ListSubMembers(ctx)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// CreateSubAPIKey 为子账户创建API密钥
+func (s *BybitMCPServer) CreateSubAPIKey(ctx context.Context, req *CreateSubAPIKeyRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	// 权限以逗号分隔，例如 ContractTrade: "Order,Position"
+	permissions := map[string][]string{}
+	for group, items := range req.Permissions {
+		permissions[group] = []string{}
+		for _, item := range strings.Split(items, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				permissions[group] = append(permissions[group], item)
+			}
+		}
+	}
+
+	keyReq := &model.SubAPIKeyRequest{
+		Subuid:      req.Subuid,
+		Note:        req.Note,
+		IPs:         req.Ips,
+		Permissions: permissions,
+	}
+	if req.ReadOnly {
+		keyReq.ReadOnly = 1
+	}
+
+	start := time.Now()
+	resp, err := s.service.CreateSubAPIKey(ctx, keyReq)
+	s.audit(ctx, "CreateSubAPIKey", req, start, resp, err)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// ListSubAPIKeys 获取子账户的API密钥列表
+func (s *BybitMCPServer) ListSubAPIKeys(ctx context.Context, req *ListSubAPIKeysRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.service.ListSubAPIKeys(ctx, req.SubMemberId)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// DeleteSubAPIKey 吊销子账户的API密钥
+func (s *BybitMCPServer) DeleteSubAPIKey(ctx context.Context, req *DeleteSubAPIKeyRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := s.service.DeleteSubAPIKey(ctx, req.ApiKey)
+	s.audit(ctx, "DeleteSubAPIKey", req, start, resp, err)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// FreezeSubMember 冻结或解冻子账户
+func (s *BybitMCPServer) FreezeSubMember(ctx context.Context, req *FreezeSubMemberRequest) (*MCPResponse, error) {
+	if err := s.requireMaster(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := s.service.FreezeSubMember(ctx, req.Subuid, req.Frozen)
+	s.audit(ctx, "FreezeSubMember", req, start, resp, err)
+	return s.toMCPResponse(req.RequestId, resp, err)
+}
+
+// ==================== 绩效分析API实现 ====================
+
+// GetPerformanceReport 获取绩效报告
+func (s *BybitMCPServer) GetPerformanceReport(ctx context.Context, req *PerformanceReportRequest) (*MCPResponse, error) {
+	if req.StartTime > 0 && req.EndTime > 0 && req.StartTime > req.EndTime {
+		return nil, status.Error(codes.InvalidArgument, "开始时间不能晚于结束时间")
+	}
+
+	query := &model.PerformanceQuery{
+		Category:      req.Category,
+		Symbol:        req.Symbol,
+		SettleCoin:    req.SettleCoin,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		InitialEquity: req.InitialEquity,
+	}
+
+	report, err := s.service.GetPerformanceReport(ctx, query)
+	return s.toResultResponse(req.RequestId, report, "", err)
+}
+
+// ==================== 多账户API实现 ====================
+
+// 返回调用方可以使用的账户，未启用认证时返回全部账户
+func (s *BybitMCPServer) visibleAccounts(ctx context.Context) []string {
+	accounts := s.router.Accounts()
+	identity := auth.IdentityFromContext(ctx)
+	if identity == nil {
+		return accounts
+	}
+	visible := []string{}
+	for _, name := range accounts {
+		if identity.CanUseAccount(name) {
+			visible = append(visible, name)
+		}
+	}
+	return visible
+}
+
+// ListAccounts 列出已配置的账户
+func (s *BybitMCPServer) ListAccounts(ctx context.Context, req *ListAccountsRequest) (*MCPResponse, error) {
+	if s.router == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用多账户路由")
+	}
+
+	result := map[string]interface{}{
+		"accounts":       s.visibleAccounts(ctx),
+		"defaultAccount": s.router.DefaultAccount(),
+	}
+	return s.toResultResponse(req.RequestId, result, "", nil)
+}
+
+// GetAggregatedPositions 汇总全部账户的仓位
+func (s *BybitMCPServer) GetAggregatedPositions(ctx context.Context, req *AggregatedPositionsRequest) (*MCPResponse, error) {
+	if s.router == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用多账户路由")
+	}
+
+	result, err := s.router.AggregatePositions(ctx, s.visibleAccounts(ctx), req.Category, req.SettleCoin)
+	return s.toResultResponse(req.RequestId, result, "", err)
+}
+
+// GetAggregatedBalances 汇总全部账户的钱包余额
+func (s *BybitMCPServer) GetAggregatedBalances(ctx context.Context, req *AggregatedBalancesRequest) (*MCPResponse, error) {
+	if s.router == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用多账户路由")
+	}
+
+	accountType := req.AccountType
+	if accountType == "" {
+		accountType = "UNIFIED"
+	}
+	result, err := s.router.AggregateBalances(ctx, s.visibleAccounts(ctx), accountType)
+	return s.toResultResponse(req.RequestId, result, "", err)
+}
+
+// ==================== 审计API实现 ====================
+
+// QueryAuditLog 查询审计记录
+func (s *BybitMCPServer) QueryAuditLog(ctx context.Context, req *QueryAuditLogRequest) (*MCPResponse, error) {
+	if s.store == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用审计日志")
+	}
+	if req.StartTime > 0 && req.EndTime > 0 && req.StartTime > req.EndTime {
+		return nil, status.Error(codes.InvalidArgument, "开始时间不能晚于结束时间")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+
+	records, err := s.store.ListAudit(ctx, storage.AuditFilter{
+		AfterID:   req.AfterId,
+		Caller:    req.Caller,
+		Method:    req.Method,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Limit:     limit,
+	})
+
+	// 返回满一页时以最后一条记录的ID作为下一页游标
+	cursor := ""
+	if len(records) == limit {
+		cursor = strconv.FormatInt(records[len(records)-1].ID, 10)
+	}
+	return s.toResultResponse(req.RequestId, records, cursor, err)
+}
+
+// ReloadConfig 重新加载配置文件，返回已生效和需要重启才能生效的配置项
+func (s *BybitMCPServer) ReloadConfig(ctx context.Context, req *ReloadConfigRequest) (*MCPResponse, error) {
+	if s.reloader == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用配置重新加载")
+	}
+
+	start := time.Now()
+	result, err := s.reloader.Reload()
+	s.audit(ctx, "ReloadConfig", req, start, nil, err)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return s.toResultResponse(req.RequestId, result, "", nil)
+}
+
+// ==================== 历史行情API实现 ====================
+
+// GetHistoricalKlines 从历史行情库查询K线、资金费率或持仓量，库中缺少的区间从Bybit获取后保存
+func (s *BybitMCPServer) GetHistoricalKlines(ctx context.Context, req *HistoricalKlinesRequest) (*MCPResponse, error) {
+	if s.history == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用历史行情库")
+	}
+	series := marketdata.Series{Kind: req.Kind, Category: req.Category, Symbol: req.Symbol, Interval: req.Interval}
+	if series.Kind == "" {
+		series.Kind = marketdata.KindKline
+	}
+	if err := series.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	end := req.EndTime
+	if end <= 0 {
+		end = time.Now().UnixMilli()
+	}
+	if req.StartTime <= 0 || req.StartTime >= end {
+		return nil, status.Error(codes.InvalidArgument, "必须指定开始时间，且开始时间早于结束时间")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	} else if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	// 还有更多数据时以下一页的开始时间作为游标，翻页时作为start_time传入
+	result, next, err := s.history.Query(ctx, series, req.StartTime, end, limit)
+	cursor := ""
+	if next > 0 {
+		cursor = strconv.FormatInt(next, 10)
+	}
+	return s.toResultResponse(req.RequestId, result, cursor, err)
+}
+
+// ==================== 期权API实现 ====================
+
+// GetOptionChain 获取按到期日和行权价分组的期权链
+func (s *BybitMCPServer) GetOptionChain(ctx context.Context, req *OptionChainRequest) (*MCPResponse, error) {
+	if req.BaseCoin == "" {
+		return nil, status.Error(codes.InvalidArgument, "必须指定币种")
+	}
+	chain, err := options.NewAnalyzer(s.service).Chain(ctx, req.BaseCoin, req.ExpDate, req.SettleCoin)
+	return s.toResultResponse(req.RequestId, chain, "", err)
+}
+
+// GetVolSurface 获取插值后的隐含波动率曲面
+func (s *BybitMCPServer) GetVolSurface(ctx context.Context, req *VolSurfaceRequest) (*MCPResponse, error) {
+	if req.BaseCoin == "" {
+		return nil, status.Error(codes.InvalidArgument, "必须指定币种")
+	}
+	for _, m := range req.Moneyness {
+		if m <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "moneyness必须大于0")
+		}
+	}
+	for _, days := range req.TenorDays {
+		if days <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "期限必须大于0")
+		}
+	}
+	surface, err := options.NewAnalyzer(s.service).Surface(ctx, req.BaseCoin, req.SettleCoin, req.Moneyness, req.TenorDays)
+	return s.toResultResponse(req.RequestId, surface, "", err)
+}
+
+// GetPortfolioGreeks 按期权仓位计算持仓希腊值
+func (s *BybitMCPServer) GetPortfolioGreeks(ctx context.Context, req *PortfolioGreeksRequest) (*MCPResponse, error) {
+	greeks, err := options.NewAnalyzer(s.service).PortfolioGreeks(ctx, req.BaseCoin)
+	return s.toResultResponse(req.RequestId, greeks, "", err)
+}
+
+// ==================== 技术指标API实现 ====================
+
+// GetIndicators 用已经收盘的K线计算技术指标
+func (s *BybitMCPServer) GetIndicators(ctx context.Context, req *IndicatorsRequest) (*MCPResponse, error) {
+	specs := make([]indicators.Spec, 0, len(req.Indicators))
+	for _, item := range req.Indicators {
+		spec := indicators.Spec{Type: item.Type, Params: item.Params}
+		if _, err := spec.Normalize(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "至少需要一个指标")
+	}
+	result, err := s.indicators.Query(ctx, req.Category, req.Symbol, req.Interval, specs, int(req.Limit))
+	return s.toResultResponse(req.RequestId, result, "", err)
+}
+
+// ==================== 行情扫描API实现 ====================
+
+// Scan 按表达式筛选和排序一个产品类别的全部交易对
+func (s *BybitMCPServer) Scan(ctx context.Context, req *ScanRequest) (*MCPResponse, error) {
+	if s.scanner == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用行情扫描")
+	}
+	query := scanner.Query{
+		Category:  req.Category,
+		Filter:    req.Filter,
+		Sort:      req.Sort,
+		Ascending: req.Ascending,
+		Limit:     int(req.Limit),
+	}
+	if err := s.scanner.Validate(query); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result, err := s.scanner.Scan(ctx, query)
+	return s.toResultResponse(req.RequestId, result, "", err)
+}
+
+// ==================== 套利分析API实现 ====================
+
+// GetFundingArbitrage 按扣除手续费后的年化收益排序资金费率和期现套利机会，并计算当前仓位的预计资金费用
+func (s *BybitMCPServer) GetFundingArbitrage(ctx context.Context, req *FundingArbitrageRequest) (*MCPResponse, error) {
+	query := carry.Query{
+		Categories:  req.Categories,
+		BaseCoin:    req.BaseCoin,
+		HoldDays:    req.HoldDays,
+		MinTurnover: req.MinTurnover,
+		Maker:       req.Maker,
+		Limit:       int(req.Limit),
+	}
+	if err := carry.Validate(query); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result, err := s.carry.Analyze(ctx, query)
+	return s.toResultResponse(req.RequestId, result, "", err)
+}
+
+// ==================== 告警API实现 ====================
+
+// 请求使用的账户名称，未指定时为默认账户
+func (s *BybitMCPServer) accountName(ctx context.Context) string {
+	account := service.AccountFromContext(ctx)
+	if account == "" && s.router != nil {
+		account = s.router.DefaultAccount()
+	}
+	return account
+}
+
+// CreateAlert 为请求的账户创建告警规则，已有条件相同的规则时返回已有的规则
+func (s *BybitMCPServer) CreateAlert(ctx context.Context, req *CreateAlertRequest) (*MCPResponse, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用告警")
+	}
+	rule := &model.Alert{
+		Name:      req.Name,
+		Account:   s.accountName(ctx),
+		Type:      req.Type,
+		Category:  req.Category,
+		Symbol:    req.Symbol,
+		Direction: req.Direction,
+		Threshold: req.Threshold,
+		Window:    int(req.Window),
+		Cooldown:  int(req.Cooldown),
+		CreatedBy: callerFromContext(ctx),
+	}
+	if err := s.alerts.Validate(rule); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	start := time.Now()
+	result, err := s.alerts.Create(ctx, rule)
+	s.audit(ctx, "CreateAlert", req, start, nil, err)
+	return s.toResultResponse(req.RequestId, result, "", err)
+}
+
+// ListAlerts 查询请求的账户的告警规则
+func (s *BybitMCPServer) ListAlerts(ctx context.Context, req *ListAlertsRequest) (*MCPResponse, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用告警")
+	}
+	return s.toResultResponse(req.RequestId, s.alerts.List(s.accountName(ctx)), "", nil)
+}
+
+// DeleteAlert 删除请求的账户的告警规则
+func (s *BybitMCPServer) DeleteAlert(ctx context.Context, req *DeleteAlertRequest) (*MCPResponse, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.FailedPrecondition, "未启用告警")
+	}
+
+	start := time.Now()
+	deleted, err := s.alerts.Delete(ctx, s.accountName(ctx), req.Id)
+	s.audit(ctx, "DeleteAlert", req, start, nil, err)
+	if errors.Is(err, alert.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return s.toResultResponse(req.RequestId, deleted, "", err)
+}
+
+// StreamAlerts 推送告警事件，直到客户端断开连接
+// 流式请求不经过账户拦截器，这里检查账户是否存在以及调用方是否可以使用
+func (s *BybitMCPServer) StreamAlerts(req *StreamAlertsRequest, stream BybitMCPService_StreamAlertsServer) error {
+	if s.alerts == nil {
+		return status.Error(codes.FailedPrecondition, "未启用告警")
+	}
+	ctx := stream.Context()
+	identity := auth.IdentityFromContext(ctx)
+	if req.Account != "" {
+		if s.router != nil && !s.router.Has(req.Account) {
+			return status.Errorf(codes.InvalidArgument, "未知账户: %s", req.Account)
+		}
+		if identity != nil && !identity.CanUseAccount(req.Account) {
+			return status.Errorf(codes.PermissionDenied, "客户端%s无权使用账户%s", identity.Name, req.Account)
+		}
+	}
+
+	events, cancel := s.alerts.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if req.Account != "" && event.Account != req.Account {
+				continue
+			}
+			if identity != nil && !identity.CanUseAccount(event.Account) {
+				continue
+			}
+			resp, err := s.toResultResponse(req.RequestId, event, "", nil)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ==================== 保证金API实现 ====================
+
+// SimulateMargin 估算当前仓位的保证金和强平价格，并模拟成交、调整杠杆和价格变动之后的结果
+func (s *BybitMCPServer) SimulateMargin(ctx context.Context, req *SimulateMarginRequest) (*MCPResponse, error) {
+	query := margin.Query{SettleCoin: req.SettleCoin, Mode: req.Mode}
+	for _, order := range req.Orders {
+		query.Orders = append(query.Orders, margin.Order{Symbol: order.Symbol, Side: order.Side, Qty: order.Qty, Price: order.Price})
+	}
+	for _, leverage := range req.Leverages {
+		query.Leverages = append(query.Leverages, margin.Leverage{Symbol: leverage.Symbol, Leverage: leverage.Leverage})
+	}
+	for _, move := range req.PriceMoves {
+		query.PriceMoves = append(query.PriceMoves, margin.PriceMove{Symbol: move.Symbol, Change: move.Change})
+	}
+	if err := margin.Validate(&query); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result, err := s.margin.Simulate(ctx, query)
+	if errors.Is(err, margin.ErrPortfolioMargin) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return s.toResultResponse(req.RequestId, result, "", err)
 }
\ No newline at end of file
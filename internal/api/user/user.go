@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/errors"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// UserService 提供子账户和API密钥管理相关的API服务
+// 这些接口只能使用母账户的API密钥调用
+type UserService struct {
+	client *bybitapi.Client
+	logger *logger.Logger
+}
+
+// NewUserService 创建一个新的子账户管理服务
+func NewUserService(client *bybitapi.Client, log *logger.Logger) *UserService {
+	return &UserService{
+		client: client,
+		logger: log,
+	}
+}
+
+// CreateSubMember 创建子账户
+func (s *UserService) CreateSubMember(ctx context.Context, req *model.SubMemberRequest) (*model.Response, error) {
+	s.logger.Debug("创建子账户: username=%s, memberType=%d", req.Username, req.MemberType)
+
+	// 构建请求参数
+	params := map[string]string{
+		"username":   req.Username,
+		"memberType": strconv.Itoa(req.MemberType),
+	}
+
+	// 添加可选参数
+	if req.Password != "" {
+		params["password"] = req.Password
+	}
+	if req.Switch != 0 {
+		params["switch"] = strconv.Itoa(req.Switch)
+	}
+	if req.Note != "" {
+		params["note"] = req.Note
+	}
+
+	response, err := s.client.Post("user/create-sub-member", params, true)
+	return s.parse(response, err, "创建子账户")
+}
+
+// ListSubMembers 获取子账户列表
+func (s *UserService) ListSubMembers(ctx context.Context) (*model.Response, error) {
+	s.logger.Debug("获取子账户列表")
+
+	response, err := s.client.Get("user/query-sub-members", map[string]string{}, true)
+	return s.parse(response, err, "获取子账户列表")
+}
+
+// GetMemberId 查询当前API密钥所属账户的UID
+func (s *UserService) GetMemberId(ctx context.Context) (string, error) {
+	s.logger.Debug("查询API密钥信息")
+
+	response, err := s.client.Get("user/query-api", map[string]string{}, true)
+	resp, err := s.parse(response, err, "查询API密钥信息")
+	if err != nil {
+		return "", err
+	}
+	if err := errors.FromBybitAPIError(resp.RetCode, resp.RetMsg); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrAPIResponseInvalid, "序列化API密钥信息失败", err)
+	}
+	var info struct {
+		UserID json.Number `json:"userID"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", errors.Wrap(errors.ErrAPIResponseInvalid, "解析API密钥信息失败", err)
+	}
+	return info.UserID.String(), nil
+}
+
+// CreateSubAPIKey 为子账户创建API密钥
+// 返回结果中包含密钥，只在创建时返回一次
+func (s *UserService) CreateSubAPIKey(ctx context.Context, req *model.SubAPIKeyRequest) (*model.Response, error) {
+	s.logger.Debug("创建子账户API密钥: subuid=%d, readOnly=%d", req.Subuid, req.ReadOnly)
+
+	// 权限是嵌套对象，需要按JSON原样发送
+	response, err := s.client.PostJSON("user/create-sub-api", req, true)
+	return s.parse(response, err, "创建子账户API密钥")
+}
+
+// ListSubAPIKeys 获取子账户的API密钥列表
+func (s *UserService) ListSubAPIKeys(ctx context.Context, subMemberId string) (*model.Response, error) {
+	s.logger.Debug("获取子账户API密钥列表: subMemberId=%s", subMemberId)
+
+	params := map[string]string{
+		"subMemberId": subMemberId,
+	}
+
+	response, err := s.client.Get("user/sub-apikeys", params, true)
+	return s.parse(response, err, "获取子账户API密钥列表")
+}
+
+// DeleteSubAPIKey 吊销子账户的API密钥
+func (s *UserService) DeleteSubAPIKey(ctx context.Context, apiKey string) (*model.Response, error) {
+	s.logger.Debug("吊销子账户API密钥")
+
+	// 母账户管理子账户密钥时必须传入apikey
+	params := map[string]string{
+		"apikey": apiKey,
+	}
+
+	response, err := s.client.Post("user/delete-sub-api", params, true)
+	return s.parse(response, err, "吊销子账户API密钥")
+}
+
+// FreezeSubMember 冻结或解冻子账户
+func (s *UserService) FreezeSubMember(ctx context.Context, subuid string, frozen bool) (*model.Response, error) {
+	s.logger.Debug("冻结子账户: subuid=%s, frozen=%v", subuid, frozen)
+
+	params := map[string]string{
+		"subuid": subuid,
+		"frozen": "0",
+	}
+	if frozen {
+		params["frozen"] = "1"
+	}
+
+	response, err := s.client.Post("user/frozen-sub-member", params, true)
+	return s.parse(response, err, "冻结子账户")
+}
+
+// 处理请求错误并解析响应，action用于日志和错误信息
+func (s *UserService) parse(response []byte, err error, action string) (*model.Response, error) {
+	if err != nil {
+		s.logger.Error("%s失败: %v", action, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIRequestFailed,
+			Message: action + "失败",
+			Cause:   err,
+		}
+	}
+
+	var resp model.Response
+	if err := json.Unmarshal(response, &resp); err != nil {
+		s.logger.Error("解析%s响应失败: %v", action, err)
+		return nil, &errors.Error{
+			Code:    errors.ErrAPIResponseInvalid,
+			Message: "解析" + action + "响应失败",
+			Cause:   err,
+		}
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,257 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 风控检查结果
+const (
+	RiskNotChecked = "not_checked" // 未经过风控检查
+	RiskPassed     = "passed"      // 风控检查通过
+	RiskRejected   = "rejected"    // 被风控拒绝
+)
+
+// 脱敏后的占位符
+const redacted = "***"
+
+// 字段名包含这些关键字时值会被脱敏（不区分大小写）
+var secretKeys = []string{"secret", "password", "passphrase", "token", "signature", "apikey", "privatekey"}
+
+// 验证时每次读取的记录数量
+const verifyPageSize = 1000
+
+// 写入一条审计记录的超时时间
+const recordTimeout = 5 * time.Second
+
+// Auditor 负责写入哈希链式的审计记录
+// 每条记录的哈希包含上一条记录的哈希，删除或修改任意一条记录都会导致链断裂
+type Auditor struct {
+	store  storage.Store
+	logger *logger.Logger
+
+	mu       sync.Mutex
+	loaded   bool   // 是否已从存储中读取链尾
+	lastHash string // 链尾记录的哈希
+}
+
+// New 创建一个新的审计器
+func New(store storage.Store, log *logger.Logger) *Auditor {
+	return &Auditor{
+		store:  store,
+		logger: log,
+	}
+}
+
+// Record 计算哈希并追加一条审计记录
+// 调用方的请求被取消后仍然写入，写入失败时记录错误日志并返回错误
+func (a *Auditor) Record(ctx context.Context, record *storage.AuditRecord) error {
+	ctx, cancel := context.WithTimeout(detach(ctx), recordTimeout)
+	defer cancel()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// 首次写入时从存储中恢复链尾，保证重启后哈希链连续
+	if !a.loaded {
+		last, err := a.store.LastAudit(ctx)
+		if err != nil {
+			a.logger.Error("读取审计链尾失败，审计记录未写入: method=%s, err=%v", record.Method, err)
+			return fmt.Errorf("读取审计链尾失败: %w", err)
+		}
+		if last != nil {
+			a.lastHash = last.Hash
+		}
+		a.loaded = true
+	}
+
+	if record.RiskResult == "" {
+		record.RiskResult = RiskNotChecked
+	}
+	record.PrevHash = a.lastHash
+	record.Hash = Hash(record)
+
+	if err := a.store.AppendAudit(ctx, record); err != nil {
+		a.logger.Error("写入审计记录失败: method=%s, err=%v", record.Method, err)
+		return fmt.Errorf("写入审计记录失败: %w", err)
+	}
+	a.lastHash = record.Hash
+	return nil
+}
+
+// 保留上下文中的值但不继承取消和截止时间，Go 1.21的context.WithoutCancel之前的等价实现
+type detachedContext struct {
+	parent context.Context
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// 参与哈希计算的字段，顺序固定
+type hashedRecord struct {
+	PrevHash   string `json:"prevHash"`
+	Time       int64  `json:"time"`
+	Caller     string `json:"caller"`
+	Method     string `json:"method"`
+	Request    string `json:"request"`
+	RiskResult string `json:"riskResult"`
+	RetCode    int    `json:"retCode"`
+	RetMsg     string `json:"retMsg"`
+	Error      string `json:"error"`
+	LatencyMs  int64  `json:"latencyMs"`
+}
+
+// Hash 计算审计记录的SHA-256哈希
+// 记录ID由存储分配，不参与计算，连续性由Verify单独检查
+func Hash(record *storage.AuditRecord) string {
+	data, _ := json.Marshal(hashedRecord{
+		PrevHash:   record.PrevHash,
+		Time:       record.Time,
+		Caller:     record.Caller,
+		Method:     record.Method,
+		Request:    record.Request,
+		RiskResult: record.RiskResult,
+		RetCode:    record.RetCode,
+		RetMsg:     record.RetMsg,
+		Error:      record.Error,
+		LatencyMs:  record.LatencyMs,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Redact 将请求序列化为JSON，并把密钥、口令等敏感字段替换为占位符
+func Redact(request interface{}) string {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return ""
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(data)
+	}
+
+	data, err = json.Marshal(redactValue(value))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// 递归脱敏JSON值
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSecretKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// 判断字段名是否为敏感字段
+func isSecretKey(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyResult 是审计链验证结果
+type VerifyResult struct {
+	Records  int    `json:"records"`  // 已验证的记录数
+	LastID   int64  `json:"lastId"`   // 最后一条记录的ID
+	LastHash string `json:"lastHash"` // 链尾哈希，可记录在外部用于检测尾部记录被删除
+}
+
+// Verify 从头检查审计链，返回第一处断裂的位置
+func Verify(ctx context.Context, store storage.Store) (*VerifyResult, error) {
+	result := &VerifyResult{}
+	for {
+		records, err := store.ListAudit(ctx, storage.AuditFilter{AfterID: result.LastID, Limit: verifyPageSize})
+		if err != nil {
+			return result, err
+		}
+
+		for i := range records {
+			record := &records[i]
+			if record.ID != result.LastID+1 {
+				return result, fmt.Errorf("审计记录不连续: 记录%d之后是记录%d", result.LastID, record.ID)
+			}
+			if record.PrevHash != result.LastHash {
+				return result, fmt.Errorf("审计链断裂: 记录%d的上一条哈希不匹配", record.ID)
+			}
+			if Hash(record) != record.Hash {
+				return result, fmt.Errorf("审计记录%d已被修改: 哈希不匹配", record.ID)
+			}
+
+			result.Records++
+			result.LastID = record.ID
+			result.LastHash = record.Hash
+		}
+
+		if len(records) < verifyPageSize {
+			return result, nil
+		}
+	}
+}
+
+// 上下文中保存调用方身份的键
+type callerKey struct{}
+
+// WithCaller 在上下文中设置调用方身份
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext 获取上下文中的调用方身份
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// 上下文中保存风控检查结果的键
+type riskKey struct{}
+
+// WithRisk 在上下文中设置风控检查结果，由风控拦截器在放行请求前调用
+func WithRisk(ctx context.Context, risk string) context.Context {
+	return context.WithValue(ctx, riskKey{}, risk)
+}
+
+// RiskFromContext 获取上下文中的风控检查结果，未经过风控检查时返回RiskNotChecked
+func RiskFromContext(ctx context.Context) string {
+	if risk, ok := ctx.Value(riskKey{}).(string); ok && risk != "" {
+		return risk
+	}
+	return RiskNotChecked
+}
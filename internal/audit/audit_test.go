@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 读取时篡改指定记录的存储
+type tamperStore struct {
+	storage.Store
+	tamper func(record *storage.AuditRecord)
+}
+
+func (s *tamperStore) ListAudit(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditRecord, error) {
+	records, err := s.Store.ListAudit(ctx, filter)
+	for i := range records {
+		s.tamper(&records[i])
+	}
+	return records, err
+}
+
+// 写入若干条审计记录
+func recordN(t *testing.T, a *Auditor, methods ...string) {
+	t.Helper()
+	for _, method := range methods {
+		if err := a.Record(context.Background(), &storage.AuditRecord{Method: method, Caller: "bot"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecordChain(t *testing.T) {
+	store := storage.NewMemoryStore()
+	recordN(t, New(store, logger.New(logger.FatalLevel, "stderr")), "CreateOrder", "CancelOrder")
+
+	// 新的审计器从存储中恢复链尾
+	recordN(t, New(store, logger.New(logger.FatalLevel, "stderr")), "Withdraw")
+
+	records, err := store.ListAudit(context.Background(), storage.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3", len(records))
+	}
+	if records[0].PrevHash != "" || records[0].RiskResult != RiskNotChecked {
+		t.Errorf("first record = %+v", records[0])
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].PrevHash != records[i-1].Hash {
+			t.Errorf("record %d PrevHash = %s, want %s", records[i].ID, records[i].PrevHash, records[i-1].Hash)
+		}
+	}
+
+	result, err := Verify(context.Background(), store)
+	if err != nil {
+		t.Fatalf("Verify err = %v", err)
+	}
+	if result.Records != 3 || result.LastID != 3 || result.LastHash != records[2].Hash {
+		t.Errorf("Verify = %+v", result)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(record *storage.AuditRecord)
+		wantErr string
+	}{
+		{"modified request", func(r *storage.AuditRecord) {
+			if r.ID == 2 {
+				r.Request = `{"qty":"100"}`
+			}
+		}, "已被修改"},
+		{"modified risk result", func(r *storage.AuditRecord) {
+			if r.ID == 1 {
+				r.RiskResult = RiskPassed
+			}
+		}, "已被修改"},
+		{"broken chain", func(r *storage.AuditRecord) {
+			if r.ID == 3 {
+				r.PrevHash = strings.Repeat("0", 64)
+			}
+		}, "审计链断裂"},
+		{"deleted record", func(r *storage.AuditRecord) {
+			if r.ID >= 2 {
+				r.ID++
+			}
+		}, "不连续"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			recordN(t, New(store, logger.New(logger.FatalLevel, "stderr")), "CreateOrder", "AmendOrder", "CancelOrder")
+
+			_, err := Verify(context.Background(), &tamperStore{Store: store, tamper: tt.tamper})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	request := map[string]interface{}{
+		"symbol":      "BTCUSDT",
+		"api_key":     "key",
+		"apiSecret":   "secret",
+		"X-Signature": "sig",
+		"nested": map[string]interface{}{
+			"passphrase": "pass",
+			"qty":        "1",
+		},
+		"items": []interface{}{map[string]interface{}{"accessToken": "tok"}},
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(Redact(request)), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"plain field", got["symbol"], "BTCUSDT"},
+		{"snake case key", got["api_key"], redacted},
+		{"secret", got["apiSecret"], redacted},
+		{"signature header", got["X-Signature"], redacted},
+		{"nested secret", got["nested"].(map[string]interface{})["passphrase"], redacted},
+		{"nested plain field", got["nested"].(map[string]interface{})["qty"], "1"},
+		{"secret in array", got["items"].([]interface{})[0].(map[string]interface{})["accessToken"], redacted},
+	}
+	for _, tt := range tests {
+		if tt.value != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.value, tt.want)
+		}
+	}
+}
+
+func TestRiskFromContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"not set", context.Background(), RiskNotChecked},
+		{"empty", WithRisk(context.Background(), ""), RiskNotChecked},
+		{"passed", WithRisk(context.Background(), RiskPassed), RiskPassed},
+		{"rejected", WithRisk(context.Background(), RiskRejected), RiskRejected},
+	}
+	for _, tt := range tests {
+		if got := RiskFromContext(tt.ctx); got != tt.want {
+			t.Errorf("%s: RiskFromContext = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bybit-mcp/internal/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 权限
+const (
+	PermRead     = "read"     // 查询行情、订单、仓位、余额等
+	PermTrade    = "trade"    // 下单、撤单、改单和调整杠杆等交易操作
+	PermTreasury = "treasury" // 划转和提现等资金操作
+	PermAdmin    = "admin"    // 账户模式、子账户管理和审计日志
+)
+
+// 内置角色
+const (
+	RoleReadOnly  = "read-only"
+	RoleTrader    = "trader"
+	RoleTreasurer = "treasurer"
+	RoleAdmin     = "admin"
+)
+
+// 内置角色拥有的权限
+var builtinRoles = map[string][]string{
+	RoleReadOnly:  {PermRead},
+	RoleTrader:    {PermRead, PermTrade},
+	RoleTreasurer: {PermRead, PermTreasury},
+	RoleAdmin:     {PermRead, PermTrade, PermTreasury, PermAdmin},
+}
+
+// 需要特定权限的RPC方法
+// 未列出的方法中Get和List开头的视为查询，其余一律需要admin权限
+var methodPermissions = map[string]string{
+	// 查询
+	"Scan":           PermRead,
+	"StreamAlerts":   PermRead,
+	"SimulateMargin": PermRead,
+
+	// 交易
+	"CreateOrder":     PermTrade,
+	"CancelOrder":     PermTrade,
+	"AmendOrder":      PermTrade,
+	"CancelAllOrders": PermTrade,
+	"SetLeverage":     PermTrade,
+	"SetTpSlMode":     PermTrade,
+	"SetRiskLimit":    PermTrade,
+	"CreateAlert":     PermTrade,
+	"DeleteAlert":     PermTrade,
+
+	// 资金
+	"AssetTransfer":     PermTreasury,
+	"Withdraw":          PermTreasury,
+	"UniversalTransfer": PermTreasury,
+	"ApproveWithdrawal": PermTreasury,
+	"RejectWithdrawal":  PermTreasury,
+
+	// 提现申请包含目标地址和金额，只有资金权限可以查询
+	"ListWithdrawalRequests": PermTreasury,
+
+	// 管理
+	"SetAccountMode":  PermAdmin,
+	"CreateSubMember": PermAdmin,
+	"ListSubMembers":  PermAdmin,
+	"CreateSubAPIKey": PermAdmin,
+	"ListSubAPIKeys":  PermAdmin,
+	"DeleteSubAPIKey": PermAdmin,
+	"FreezeSubMember": PermAdmin,
+	"QueryAuditLog":   PermAdmin,
+}
+
+// MethodPermission 返回调用RPC方法所需的权限，fullMethod形如/bybit.BybitMCPService/Withdraw
+func MethodPermission(fullMethod string) string {
+	method := MethodName(fullMethod)
+	if perm, ok := methodPermissions[method]; ok {
+		return perm
+	}
+	if strings.HasPrefix(method, "Get") || strings.HasPrefix(method, "List") {
+		return PermRead
+	}
+	return PermAdmin
+}
+
+// MethodName 返回完整方法名中的方法部分
+func MethodName(fullMethod string) string {
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[i+1:]
+	}
+	return fullMethod
+}
+
+// Identity 是认证后的调用方身份
+type Identity struct {
+	Name        string          // 客户端名称
+	Role        string          // 角色
+	permissions map[string]bool // 角色拥有的权限
+	accounts    map[string]bool // 允许使用的账户，为空表示全部账户
+}
+
+// Allows 判断是否拥有指定权限
+func (i *Identity) Allows(perm string) bool {
+	return i.permissions[perm]
+}
+
+// CanUseAccount 判断是否允许使用指定账户
+func (i *Identity) CanUseAccount(name string) bool {
+	return len(i.accounts) == 0 || i.accounts[name]
+}
+
+// 上下文中保存调用方身份的键
+type identityKey struct{}
+
+// WithIdentity 在上下文中设置调用方身份
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext 获取上下文中的调用方身份，未启用认证时返回nil
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// Authenticator 根据Bearer令牌或mTLS客户端证书识别调用方
+type Authenticator struct {
+	mu      sync.RWMutex
+	clients []*client
+}
+
+// 已配置的客户端
+type client struct {
+	token       []byte
+	certSubject string
+	identity    *Identity
+}
+
+// New 根据配置创建认证器，角色不存在或客户端缺少凭据时返回错误
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	roles := map[string][]string{}
+	for name, perms := range builtinRoles {
+		roles[name] = perms
+	}
+	for name, perms := range cfg.Roles {
+		for _, perm := range perms {
+			switch perm {
+			case PermRead, PermTrade, PermTreasury, PermAdmin:
+			default:
+				return nil, fmt.Errorf("角色%s包含未知权限: %s", name, perm)
+			}
+		}
+		roles[name] = perms
+	}
+
+	a := &Authenticator{}
+	names := map[string]bool{}
+	for _, c := range cfg.Clients {
+		if c.Name == "" {
+			return nil, fmt.Errorf("客户端名称不能为空")
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("客户端名称重复: %s", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Token == "" && c.CertSubject == "" {
+			return nil, fmt.Errorf("客户端%s未配置令牌或证书", c.Name)
+		}
+		perms, ok := roles[c.Role]
+		if !ok {
+			return nil, fmt.Errorf("客户端%s的角色不存在: %s", c.Name, c.Role)
+		}
+
+		identity := &Identity{
+			Name:        c.Name,
+			Role:        c.Role,
+			permissions: map[string]bool{},
+			accounts:    map[string]bool{},
+		}
+		for _, perm := range perms {
+			identity.permissions[perm] = true
+		}
+		for _, account := range c.Accounts {
+			identity.accounts[account] = true
+		}
+
+		a.clients = append(a.clients, &client{
+			token:       []byte(c.Token),
+			certSubject: c.CertSubject,
+			identity:    identity,
+		})
+	}
+
+	return a, nil
+}
+
+// Replace 在运行中使用next的客户端和角色配置，已建立的连接在下一个请求时生效
+func (a *Authenticator) Replace(next *Authenticator) {
+	next.mu.RLock()
+	clients := next.clients
+	next.mu.RUnlock()
+
+	a.mu.Lock()
+	a.clients = clients
+	a.mu.Unlock()
+}
+
+// Authenticate 识别调用方，优先使用Bearer令牌，其次是已验证的客户端证书
+func (a *Authenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	a.mu.RLock()
+	clients := a.clients
+	a.mu.RUnlock()
+
+	if token := bearerToken(ctx); token != "" {
+		for _, c := range clients {
+			if len(c.token) > 0 && subtle.ConstantTimeCompare(c.token, []byte(token)) == 1 {
+				return c.identity, nil
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, "无效的访问令牌")
+	}
+
+	if subject := certSubject(ctx); subject != "" {
+		for _, c := range clients {
+			if c.certSubject != "" && c.certSubject == subject {
+				return c.identity, nil
+			}
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "未授权的客户端证书: %s", subject)
+	}
+
+	return nil, status.Error(codes.Unauthenticated, "缺少访问令牌或客户端证书")
+}
+
+// Authorize 检查调用方是否有权限调用RPC方法
+func Authorize(identity *Identity, fullMethod string) error {
+	perm := MethodPermission(fullMethod)
+	if !identity.Allows(perm) {
+		return status.Errorf(codes.PermissionDenied, "客户端%s（角色%s）没有调用%s所需的%s权限", identity.Name, identity.Role, MethodName(fullMethod), perm)
+	}
+	return nil
+}
+
+// 读取authorization元数据中的Bearer令牌
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, value := range md.Get("authorization") {
+		if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+			return strings.TrimSpace(value[7:])
+		}
+	}
+	return ""
+}
+
+// 读取已通过验证的客户端证书的CommonName
+func certSubject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName
+}
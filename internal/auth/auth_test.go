@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bybit-mcp/internal/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 测试用的客户端配置
+var testClients = config.AuthConfig{
+	Enabled: true,
+	Clients: []config.ClientConfig{
+		{Name: "reader", Token: "reader-token", Role: RoleReadOnly},
+		{Name: "bot", Token: "bot-token", Role: RoleTrader, Accounts: []string{"grid-bot"}},
+		{Name: "treasury", Token: "treasury-token", Role: RoleTreasurer},
+		{Name: "ops", Token: "ops-token", Role: RoleAdmin},
+		{Name: "desk", Token: "desk-token", Role: "desk"},
+	},
+	Roles: map[string][]string{"desk": {PermRead, PermTrade, PermTreasury}},
+}
+
+// 携带Bearer令牌的服务端上下文
+func withToken(authorization string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func TestMethodPermission(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"GetTickers", PermRead},
+		{"ListAlerts", PermRead},
+		{"Scan", PermRead},
+		{"SimulateMargin", PermRead},
+		{"StreamAlerts", PermRead},
+		{"CreateOrder", PermTrade},
+		{"CancelAllOrders", PermTrade},
+		{"CreateAlert", PermTrade},
+		{"DeleteAlert", PermTrade},
+		{"Withdraw", PermTreasury},
+		{"ApproveWithdrawal", PermTreasury},
+		{"ListWithdrawalRequests", PermTreasury},
+		{"ListSubMembers", PermAdmin},
+		{"QueryAuditLog", PermAdmin},
+		{"SetAccountMode", PermAdmin},
+		{"ReloadConfig", PermAdmin},
+		{"SomeFutureMethod", PermAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := MethodPermission("/bybit.BybitMCPService/" + tt.method); got != tt.want {
+				t.Errorf("MethodPermission(%s) = %s, want %s", tt.method, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	a, err := New(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		wantClient    string
+		wantErrorCode codes.Code
+	}{
+		{"bearer token", withToken("Bearer reader-token"), "reader", codes.OK},
+		{"lowercase scheme", withToken("bearer ops-token"), "ops", codes.OK},
+		{"custom role", withToken("Bearer desk-token"), "desk", codes.OK},
+		{"unknown token", withToken("Bearer forged"), "", codes.Unauthenticated},
+		{"token without scheme", withToken("reader-token"), "", codes.Unauthenticated},
+		{"no metadata", context.Background(), "", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := a.Authenticate(tt.ctx)
+			if code := status.Code(err); code != tt.wantErrorCode {
+				t.Fatalf("Authenticate err = %v, want code %v", err, tt.wantErrorCode)
+			}
+			if err == nil && identity.Name != tt.wantClient {
+				t.Errorf("Authenticate identity = %s, want %s", identity.Name, tt.wantClient)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a, err := New(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		token   string
+		method  string
+		allowed bool
+	}{
+		{"reader-token", "GetPositions", true},
+		{"reader-token", "CreateOrder", false},
+		{"reader-token", "ListWithdrawalRequests", false},
+		{"reader-token", "CreateAlert", false},
+		{"bot-token", "CreateOrder", true},
+		{"bot-token", "CreateAlert", true},
+		{"bot-token", "Withdraw", false},
+		{"treasury-token", "Withdraw", true},
+		{"treasury-token", "ListWithdrawalRequests", true},
+		{"treasury-token", "CreateOrder", false},
+		{"treasury-token", "QueryAuditLog", false},
+		{"desk-token", "CreateOrder", true},
+		{"desk-token", "ApproveWithdrawal", true},
+		{"desk-token", "SetAccountMode", false},
+		{"ops-token", "SetAccountMode", true},
+		{"ops-token", "ReloadConfig", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token+"/"+tt.method, func(t *testing.T) {
+			identity, err := a.Authenticate(withToken("Bearer " + tt.token))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Authorize(identity, "/bybit.BybitMCPService/"+tt.method)
+			if tt.allowed && err != nil {
+				t.Errorf("Authorize err = %v, want allowed", err)
+			}
+			if !tt.allowed && status.Code(err) != codes.PermissionDenied {
+				t.Errorf("Authorize err = %v, want PermissionDenied", err)
+			}
+		})
+	}
+}
+
+func TestCanUseAccount(t *testing.T) {
+	a, err := New(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		token   string
+		account string
+		want    bool
+	}{
+		{"bot-token", "grid-bot", true},
+		{"bot-token", "main", false},
+		{"reader-token", "main", true},
+		{"reader-token", "grid-bot", true},
+	}
+	for _, tt := range tests {
+		identity, err := a.Authenticate(withToken("Bearer " + tt.token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := identity.CanUseAccount(tt.account); got != tt.want {
+			t.Errorf("%s CanUseAccount(%s) = %v, want %v", identity.Name, tt.account, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AuthConfig
+	}{
+		{"empty name", config.AuthConfig{Clients: []config.ClientConfig{{Token: "t", Role: RoleAdmin}}}},
+		{"duplicate name", config.AuthConfig{Clients: []config.ClientConfig{
+			{Name: "a", Token: "t1", Role: RoleAdmin},
+			{Name: "a", Token: "t2", Role: RoleAdmin},
+		}}},
+		{"no credentials", config.AuthConfig{Clients: []config.ClientConfig{{Name: "a", Role: RoleAdmin}}}},
+		{"unknown role", config.AuthConfig{Clients: []config.ClientConfig{{Name: "a", Token: "t", Role: "root"}}}},
+		{"unknown permission", config.AuthConfig{Roles: map[string][]string{"desk": {"withdraw"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	a, err := New(testClients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := New(config.AuthConfig{Clients: []config.ClientConfig{{Name: "reader", Token: "rotated", Role: RoleReadOnly}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Replace(next)
+
+	if _, err := a.Authenticate(withToken("Bearer reader-token")); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("old token err = %v, want Unauthenticated", err)
+	}
+	if identity, err := a.Authenticate(withToken("Bearer rotated")); err != nil || identity.Name != "reader" {
+		t.Errorf("rotated token identity = %v, err = %v", identity, err)
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/bybit-mcp/internal/audit"
+	"google.golang.org/grpc"
+)
+
+// DeniedFunc 在请求被拒绝时调用，用于写入审计日志
+type DeniedFunc func(ctx context.Context, fullMethod string, req interface{}, err error)
+
+// 认证并鉴权，成功时返回带有调用方身份的上下文
+func (a *Authenticator) check(ctx context.Context, fullMethod string) (context.Context, error) {
+	identity, err := a.Authenticate(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	// 审计日志中记录认证后的客户端名称
+	ctx = WithIdentity(ctx, identity)
+	ctx = audit.WithCaller(ctx, identity.Name)
+
+	if err := Authorize(identity, fullMethod); err != nil {
+		return ctx, err
+	}
+	return ctx, nil
+}
+
+// UnaryInterceptor 返回认证和鉴权的一元拦截器
+func UnaryInterceptor(a *Authenticator, onDenied DeniedFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.check(ctx, info.FullMethod)
+		if err != nil {
+			if onDenied != nil {
+				onDenied(ctx, info.FullMethod, req, err)
+			}
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor 返回认证和鉴权的流式拦截器
+func StreamInterceptor(a *Authenticator, onDenied DeniedFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.check(ss.Context(), info.FullMethod)
+		if err != nil {
+			if onDenied != nil {
+				onDenied(ctx, info.FullMethod, nil, err)
+			}
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// 替换上下文的ServerStream
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回带有调用方身份的上下文
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
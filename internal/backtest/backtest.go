@@ -0,0 +1,299 @@
+// Package backtest 用历史K线和逐笔成交回测交易策略
+// 历史数据通过分页调用market/kline下载并缓存到本地；撮合复用paper模拟交易引擎，
+// 策略使用与实盘service.BybitService相同的下单接口，回测结果包括权益曲线和成交记录
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/paper"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// 时间格式
+const dateLayout = "2006-01-02"
+
+// Bar 是一根K线
+type Bar struct {
+	Category string  `json:"category"` // 产品类别
+	Symbol   string  `json:"symbol"`   // 交易对
+	Interval string  `json:"interval"` // K线周期
+	Start    int64   `json:"start"`    // 开始时间（毫秒）
+	End      int64   `json:"end"`      // 结束时间（毫秒），不含
+	Open     float64 `json:"open"`     // 开盘价
+	High     float64 `json:"high"`     // 最高价
+	Low      float64 `json:"low"`      // 最低价
+	Close    float64 `json:"close"`    // 收盘价
+	Volume   float64 `json:"volume"`   // 成交量
+	Turnover float64 `json:"turnover"` // 成交额
+}
+
+// Trade 是一笔历史成交
+type Trade struct {
+	Time  int64   `json:"time"`  // 成交时间（毫秒）
+	Price float64 `json:"price"` // 成交价
+	Size  float64 `json:"size"`  // 成交数量
+	Side  string  `json:"side"`  // 主动方向：Buy或Sell
+}
+
+// Config 是一次回测的配置
+type Config struct {
+	Category    string             `json:"category"`    // 产品类别：spot或linear
+	Symbol      string             `json:"symbol"`      // 交易对
+	Interval    string             `json:"interval"`    // K线周期：1、3、5、15、30、60、120、240、360、720、D或W
+	Start       string             `json:"start"`       // 开始时间，RFC3339或2006-01-02（UTC）
+	End         string             `json:"end"`         // 结束时间（不含），为空时到最近一根已收盘的K线
+	Strategy    string             `json:"strategy"`    // 策略名称
+	Params      map[string]float64 `json:"params"`      // 策略参数
+	UseTrades   bool               `json:"useTrades"`   // 是否用逐笔成交驱动撮合，否则按开高低收模拟K线内的价格路径
+	FundingRate float64            `json:"fundingRate"` // 线性合约每8小时结算的资金费率，0表示不收取
+	Paper       config.PaperConfig `json:"paper"`       // 初始余额、手续费、滑点、杠杆和维持保证金率，enabled和updateInterval不使用
+	Data        DataConfig         `json:"data"`        // 历史数据
+	Output      OutputConfig       `json:"output"`      // 结果文件
+}
+
+// DataConfig 表示历史数据的下载和缓存配置
+type DataConfig struct {
+	CacheDir    string `json:"cacheDir"`    // 缓存目录，默认data/backtest
+	HistoryPath string `json:"historyPath"` // 保存K线的历史行情库，默认为缓存目录下的history.db，可以与服务的history.path共用
+	Environment string `json:"environment"` // 下载K线使用的Bybit环境，默认mainnet
+	BaseURL     string `json:"baseUrl"`     // 自定义REST地址，环境为custom时使用
+	TradesURL   string `json:"tradesUrl"`   // 逐笔成交数据地址，默认https://public.bybit.com
+	Offline     bool   `json:"offline"`     // 只使用缓存，缺少数据时报错
+}
+
+// OutputConfig 表示回测结果的输出文件，为空时不输出
+type OutputConfig struct {
+	EquityFile string `json:"equityFile"` // 权益曲线（CSV）
+	TradesFile string `json:"tradesFile"` // 成交记录（CSV）
+	ReportFile string `json:"reportFile"` // 完整结果（JSON）
+}
+
+// 默认配置
+const (
+	DefaultCacheDir  = "data/backtest"
+	DefaultTradesURL = "https://public.bybit.com"
+)
+
+// LoadConfig 读取回测配置文件，支持JSON和YAML，未知字段视为错误
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取回测配置: %v", err)
+	}
+
+	// YAML先转换为JSON，两种格式使用相同的字段名
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var value interface{}
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("无法解析回测配置%s: %v", path, err)
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("无法解析回测配置%s: %v", path, err)
+		}
+	}
+
+	cfg := &Config{
+		Paper: config.DefaultConfig().Paper,
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("无法解析回测配置%s: %v", path, err)
+	}
+	if cfg.Data.CacheDir == "" {
+		cfg.Data.CacheDir = DefaultCacheDir
+	}
+	if cfg.Data.TradesURL == "" {
+		cfg.Data.TradesURL = DefaultTradesURL
+	}
+	if cfg.Data.HistoryPath == "" {
+		cfg.Data.HistoryPath = filepath.Join(cfg.Data.CacheDir, "history.db")
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate 检查回测配置
+func (c *Config) Validate() error {
+	if c.Category != bybitapi.CategorySpot && c.Category != bybitapi.CategoryLinear {
+		return fmt.Errorf("category只能是spot或linear")
+	}
+	if c.Symbol == "" {
+		return fmt.Errorf("symbol不能为空")
+	}
+	if err := (marketdata.Series{Kind: marketdata.KindKline, Category: c.Category, Symbol: c.Symbol, Interval: c.Interval}).Validate(); err != nil {
+		return err
+	}
+	start, end, err := c.TimeRange(time.Now())
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return fmt.Errorf("start必须早于end")
+	}
+	if c.Strategy == "" {
+		return fmt.Errorf("strategy不能为空，可选值: %s", strings.Join(Strategies(), "、"))
+	}
+	if c.FundingRate != 0 && c.Category != bybitapi.CategoryLinear {
+		return fmt.Errorf("只有linear可以设置fundingRate")
+	}
+	if c.Paper.TakerFeeRate < 0 || c.Paper.SlippageBps < 0 {
+		return fmt.Errorf("paper.takerFeeRate和paper.slippageBps不能为负数")
+	}
+	return nil
+}
+
+// TimeRange 返回回测的开始和结束时间（毫秒），end为空时到now
+func (c *Config) TimeRange(now time.Time) (int64, int64, error) {
+	start, err := parseTime(c.Start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("start格式错误: %v", err)
+	}
+	end := now.UnixMilli()
+	if c.End != "" {
+		if end, err = parseTime(c.End); err != nil {
+			return 0, 0, fmt.Errorf("end格式错误: %v", err)
+		}
+	}
+	return start, end, nil
+}
+
+// 解析RFC3339或日期格式的时间，日期按UTC零点计算
+func parseTime(s string) (int64, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UnixMilli(), nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("%q不是RFC3339或2006-01-02格式", s)
+	}
+	return t.UnixMilli(), nil
+}
+
+// Dataset 是回测使用的历史数据
+type Dataset struct {
+	Bars       []Bar           // 按时间排序的K线
+	Trades     []Trade         // 按时间排序的逐笔成交，未启用useTrades时为空
+	Instrument *model.Response // instruments-info的响应，提供下单数量和价格精度
+}
+
+// EquityPoint 是权益曲线上的一个点，在每根K线收盘时记录
+type EquityPoint struct {
+	Time     int64   `json:"time"`     // K线结束时间（毫秒）
+	Price    float64 `json:"price"`    // 收盘价
+	Equity   float64 `json:"equity"`   // 账户总权益（美元）
+	Drawdown float64 `json:"drawdown"` // 相对历史最高权益的回撤比例
+}
+
+// Result 是回测结果
+type Result struct {
+	Summary   Summary           `json:"summary"`   // 统计指标
+	Equity    []EquityPoint     `json:"equity"`    // 权益曲线
+	Trades    []model.Execution `json:"trades"`    // 成交记录（包括资金费用），按时间排序
+	ClosedPnl []model.ClosedPnl `json:"closedPnl"` // 平仓盈亏记录，按时间排序
+}
+
+// Run 按时间顺序回放历史数据并运行策略
+// 每根K线先按价格路径推进行情，撮合挂单、触发止盈止损、收取资金费用和检查强平，收盘时调用策略；
+// 策略下的市价单按收盘价加滑点成交，限价单在之后的行情触及限价时成交
+func Run(ctx context.Context, cfg *Config, strategy Strategy, data *Dataset, log *logger.Logger) (*Result, error) {
+	if len(data.Bars) == 0 {
+		return nil, fmt.Errorf("%s在回测区间内没有K线", cfg.Symbol)
+	}
+
+	market := newReplayMarket(cfg, data)
+	sim := paper.New(market, cfg.Paper, log)
+	sim.SetClock(market.now)
+
+	result := &Result{Equity: make([]EquityPoint, 0, len(data.Bars))}
+	for i, bar := range data.Bars {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, t := range market.path(i) {
+			market.advance(t.time, t.price)
+			if err := sim.Refresh(ctx); err != nil {
+				return nil, fmt.Errorf("%s刷新模拟行情失败: %v", formatTime(t.time), err)
+			}
+		}
+
+		market.complete(i)
+		if err := strategy.OnBar(ctx, sim, bar); err != nil {
+			return nil, fmt.Errorf("策略在%s出错: %v", formatTime(bar.End), err)
+		}
+
+		equity, err := walletEquity(ctx, sim)
+		if err != nil {
+			return nil, err
+		}
+		result.Equity = append(result.Equity, EquityPoint{Time: bar.End, Price: bar.Close, Equity: equity})
+	}
+
+	executions, err := sim.GetAllExecutions(ctx, &model.ExecutionQuery{Category: cfg.Category})
+	if err != nil {
+		return nil, err
+	}
+	closed, err := sim.GetAllClosedPnl(ctx, &model.ClosedPnlQuery{Category: cfg.Category})
+	if err != nil {
+		return nil, err
+	}
+	result.Trades = reverseExecutions(executions.List)
+	result.ClosedPnl = reverseClosedPnl(closed.List)
+	result.Summary = summarize(cfg, data, result)
+	return result, nil
+}
+
+// 查询模拟账户的总权益
+func walletEquity(ctx context.Context, sim *paper.Service) (float64, error) {
+	resp, err := sim.GetWalletBalance(ctx, "UNIFIED", "")
+	if err != nil {
+		return 0, err
+	}
+	var result struct {
+		List []struct {
+			TotalEquity string `json:"totalEquity"`
+		} `json:"list"`
+	}
+	if err := decodeResult(resp, &result); err != nil {
+		return 0, err
+	}
+	if len(result.List) == 0 {
+		return 0, fmt.Errorf("钱包余额为空")
+	}
+	return marketdata.ParseNum(result.List[0].TotalEquity), nil
+}
+
+// 模拟账户按时间倒序返回记录，回测结果按时间正序排列
+func reverseExecutions(list []model.Execution) []model.Execution {
+	reversed := make([]model.Execution, len(list))
+	for i, e := range list {
+		reversed[len(list)-1-i] = e
+	}
+	return reversed
+}
+
+func reverseClosedPnl(list []model.ClosedPnl) []model.ClosedPnl {
+	reversed := make([]model.ClosedPnl, len(list))
+	for i, r := range list {
+		reversed[len(list)-1-i] = r
+	}
+	return reversed
+}
+
+// 格式化毫秒时间戳，用于日志和错误信息
+func formatTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
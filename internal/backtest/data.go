@@ -0,0 +1,192 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/paper"
+	"github.com/bybit-mcp/pkg/errors"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// Loader 下载回测需要的历史数据并缓存到本地
+// K线通过历史行情库读取，再次回测时只下载库中缺少的部分；逐笔成交按天缓存Bybit公开数据的原始文件
+type Loader struct {
+	market     paper.MarketData
+	history    *marketdata.History
+	cfg        DataConfig
+	httpClient *http.Client
+	logger     *logger.Logger
+	now        func() time.Time
+}
+
+// NewLoader 创建历史数据加载器，market用于调用instruments-info，history用于读取和下载K线
+func NewLoader(market paper.MarketData, history *marketdata.History, cfg DataConfig, log *logger.Logger) *Loader {
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = DefaultCacheDir
+	}
+	if cfg.TradesURL == "" {
+		cfg.TradesURL = DefaultTradesURL
+	}
+	history.SetOffline(cfg.Offline)
+	return &Loader{
+		market:     market,
+		history:    history,
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		logger:     log,
+		now:        time.Now,
+	}
+}
+
+// Load 加载回测区间内的K线、交易对规则，以及启用useTrades时的逐笔成交
+func (l *Loader) Load(ctx context.Context, cfg *Config) (*Dataset, error) {
+	start, end, err := cfg.TimeRange(l.now())
+	if err != nil {
+		return nil, err
+	}
+
+	bars, err := l.Klines(ctx, cfg.Category, cfg.Symbol, cfg.Interval, start, end)
+	if err != nil {
+		return nil, err
+	}
+	instrument, err := l.Instrument(ctx, cfg.Category, cfg.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	data := &Dataset{Bars: bars, Instrument: instrument}
+	if cfg.UseTrades && len(bars) > 0 {
+		if data.Trades, err = l.Trades(ctx, cfg.Category, cfg.Symbol, bars[0].Start, bars[len(bars)-1].End); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Klines 返回开始时间在[start, end)内且已经收盘的K线，按时间排序
+func (l *Loader) Klines(ctx context.Context, category, symbol, interval string, start, end int64) ([]Bar, error) {
+	series := marketdata.Series{Kind: marketdata.KindKline, Category: category, Symbol: symbol, Interval: interval}
+	if err := series.Validate(); err != nil {
+		return nil, err
+	}
+	// 未收盘的K线不保存也不回测
+	start = series.Align(start)
+	if latest := series.Align(l.now().UnixMilli()); end > latest {
+		end = latest
+	}
+	if start >= end {
+		return nil, nil
+	}
+
+	if l.cfg.Offline {
+		missing, err := l.history.Missing(ctx, series, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("历史行情库中缺少%s %s的K线（%s至%s），离线模式不下载", symbol, interval, formatTime(missing[0].From), formatTime(missing[len(missing)-1].To))
+		}
+	}
+
+	candles, err := l.history.Candles(ctx, series, start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+	step := series.Step()
+	bars := make([]Bar, len(candles))
+	for i, c := range candles {
+		bars[i] = Bar{
+			Category: category,
+			Symbol:   symbol,
+			Interval: interval,
+			Start:    c.Start,
+			End:      c.Start + step,
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+			Volume:   c.Volume,
+			Turnover: c.Turnover,
+		}
+	}
+	return bars, nil
+}
+
+// Instrument 返回交易对的instruments-info响应，缓存后离线回测也可以使用
+func (l *Loader) Instrument(ctx context.Context, category, symbol string) (*model.Response, error) {
+	path := filepath.Join(l.cfg.CacheDir, "instruments", category, symbol+".json")
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var resp model.Response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("交易对缓存%s格式错误: %v", path, err)
+		}
+		return &resp, nil
+	}
+	if l.cfg.Offline {
+		return nil, fmt.Errorf("缓存中没有%s的交易对信息，离线模式不下载", symbol)
+	}
+
+	resp, err := l.market.GetInstruments(ctx, category, symbol, "")
+	if err != nil {
+		return nil, fmt.Errorf("获取%s交易对信息失败: %v", symbol, err)
+	}
+	var result struct {
+		List []json.RawMessage `json:"list"`
+	}
+	if err := decodeResult(resp, &result); err != nil {
+		return nil, fmt.Errorf("获取%s交易对信息失败: %v", symbol, err)
+	}
+	if len(result.List) == 0 {
+		return nil, fmt.Errorf("交易对不存在: %s %s", category, symbol)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, data); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// 写入缓存文件
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建缓存目录: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("无法写入缓存: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("无法写入缓存: %v", err)
+	}
+	return nil
+}
+
+// 解析响应中的result，Bybit返回错误码时返回错误
+func decodeResult(resp *model.Response, v interface{}) error {
+	if resp == nil {
+		return errors.New(errors.ErrAPIResponseInvalid, "响应为空")
+	}
+	if err := errors.FromBybitAPIError(resp.RetCode, resp.RetMsg); err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return errors.Wrap(errors.ErrAPIResponseInvalid, "序列化响应失败", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrap(errors.ErrAPIResponseInvalid, "解析响应失败", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package backtest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/paper"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 资金费用结算间隔
+const fundingInterval = int64(8 * time.Hour / time.Millisecond)
+
+// GetKline未指定limit时返回的数量，与Bybit一致
+const defaultKlineLimit = 200
+
+// 价格路径上的一个点
+type tick struct {
+	time  int64
+	price float64
+}
+
+// 回放历史数据的行情源，实现paper.MarketData
+// 行情只有回测的交易对，买一卖一价都等于最新价，订单簿为空，吃单按最新价加滑点成交；
+// GetKline只返回已经收盘的K线，策略无法看到未来的数据
+type replayMarket struct {
+	category    string
+	symbol      string
+	interval    string
+	bars        []Bar
+	trades      []Trade
+	instrument  *model.Response
+	fundingRate float64
+
+	mu        sync.Mutex
+	time      int64   // 当前时间（毫秒）
+	price     float64 // 最新价
+	completed int     // 已经收盘的K线数量
+	tradeAt   int     // 下一笔未回放的逐笔成交
+}
+
+var _ paper.MarketData = (*replayMarket)(nil)
+
+// 创建回放行情源
+func newReplayMarket(cfg *Config, data *Dataset) *replayMarket {
+	m := &replayMarket{
+		category:    cfg.Category,
+		symbol:      cfg.Symbol,
+		interval:    cfg.Interval,
+		bars:        data.Bars,
+		trades:      data.Trades,
+		instrument:  data.Instrument,
+		fundingRate: cfg.FundingRate,
+	}
+	if len(data.Bars) > 0 {
+		m.time = data.Bars[0].Start
+		m.price = data.Bars[0].Open
+	}
+	return m
+}
+
+// 当前时间，作为模拟账户的时钟
+func (m *replayMarket) now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return time.UnixMilli(m.time)
+}
+
+// 推进到新的时间和价格
+func (m *replayMarket) advance(t int64, price float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.time = t
+	m.price = price
+}
+
+// 第i根K线收盘，之后GetKline可以返回该K线
+func (m *replayMarket) complete(i int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completed = i + 1
+}
+
+// 第i根K线内的价格路径，最后一个点是收盘价
+// 有逐笔成交时按成交价变化的顺序回放，否则按开盘、先到的极值、后到的极值、收盘模拟：
+// 阳线假设先到最低价，阴线假设先到最高价
+func (m *replayMarket) path(i int) []tick {
+	bar := m.bars[i]
+	var ticks []tick
+
+	m.mu.Lock()
+	for m.tradeAt < len(m.trades) && m.trades[m.tradeAt].Time < bar.Start {
+		m.tradeAt++
+	}
+	last := 0.0
+	for m.tradeAt < len(m.trades) && m.trades[m.tradeAt].Time < bar.End {
+		t := m.trades[m.tradeAt]
+		if t.Price != last {
+			ticks = append(ticks, tick{time: t.Time, price: t.Price})
+			last = t.Price
+		}
+		m.tradeAt++
+	}
+	m.mu.Unlock()
+
+	if len(ticks) == 0 {
+		third := (bar.End - bar.Start) / 3
+		first, second := bar.Low, bar.High
+		if bar.Close < bar.Open {
+			first, second = bar.High, bar.Low
+		}
+		ticks = []tick{
+			{time: bar.Start, price: bar.Open},
+			{time: bar.Start + third, price: first},
+			{time: bar.Start + 2*third, price: second},
+		}
+	}
+	return append(ticks, tick{time: bar.End - 1, price: bar.Close})
+}
+
+// 是否为回测的交易对
+func (m *replayMarket) matches(category, symbol string) bool {
+	return category == m.category && (symbol == "" || symbol == m.symbol)
+}
+
+// 成功响应
+func (m *replayMarket) ok(result interface{}) *model.Response {
+	return &model.Response{
+		RetCode:    0,
+		RetMsg:     "OK",
+		Result:     result,
+		RetExtInfo: map[string]interface{}{},
+		Time:       m.now().UnixMilli(),
+	}
+}
+
+// GetKline 返回已经收盘的K线，按时间倒序
+func (m *replayMarket) GetKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	list := [][]string{}
+	if m.matches(category, symbol) && interval == m.interval {
+		if limit <= 0 {
+			limit = defaultKlineLimit
+		}
+		m.mu.Lock()
+		completed := m.completed
+		m.mu.Unlock()
+		for i := completed - 1; i >= 0 && len(list) < limit; i-- {
+			bar := m.bars[i]
+			if (start > 0 && bar.Start < start) || (end > 0 && bar.Start > end) {
+				continue
+			}
+			list = append(list, []string{
+				strconv.FormatInt(bar.Start, 10),
+				marketdata.FormatNum(bar.Open), marketdata.FormatNum(bar.High), marketdata.FormatNum(bar.Low), marketdata.FormatNum(bar.Close),
+				marketdata.FormatNum(bar.Volume), marketdata.FormatNum(bar.Turnover),
+			})
+		}
+	}
+	return m.ok(model.Kline{Category: category, Symbol: symbol, List: list}), nil
+}
+
+// GetOrderbook 返回空订单簿，模拟账户按最新价加滑点成交
+func (m *replayMarket) GetOrderbook(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	return m.ok(map[string]interface{}{
+		"s":  symbol,
+		"b":  [][]string{},
+		"a":  [][]string{},
+		"ts": m.now().UnixMilli(),
+	}), nil
+}
+
+// GetTickers 返回回测交易对的最新价
+func (m *replayMarket) GetTickers(ctx context.Context, category, symbol string) (*model.Response, error) {
+	list := []map[string]string{}
+	if m.matches(category, symbol) {
+		m.mu.Lock()
+		price := marketdata.FormatNum(m.price)
+		now := m.time
+		m.mu.Unlock()
+
+		ticker := map[string]string{
+			"symbol":    m.symbol,
+			"lastPrice": price,
+			"bid1Price": price,
+			"ask1Price": price,
+		}
+		if category == bybitapi.CategoryLinear {
+			ticker["markPrice"] = price
+			ticker["fundingRate"] = "0"
+			ticker["nextFundingTime"] = "0"
+			if m.fundingRate != 0 {
+				ticker["fundingRate"] = marketdata.FormatNum(m.fundingRate)
+				ticker["nextFundingTime"] = strconv.FormatInt((now/fundingInterval+1)*fundingInterval, 10)
+			}
+		}
+		list = append(list, ticker)
+	}
+	return m.ok(map[string]interface{}{"category": category, "list": list}), nil
+}
+
+// GetInstruments 返回下载时缓存的交易对信息
+func (m *replayMarket) GetInstruments(ctx context.Context, category, symbol, status string) (*model.Response, error) {
+	if m.matches(category, symbol) && m.instrument != nil {
+		return m.instrument, nil
+	}
+	return m.ok(map[string]interface{}{"category": category, "list": []interface{}{}}), nil
+}
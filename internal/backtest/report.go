@@ -0,0 +1,208 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bybit-mcp/internal/analytics"
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/paper"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// Summary 是回测的统计指标，比例均为小数，例如0.05表示5%
+type Summary struct {
+	Strategy      string  `json:"strategy"`      // 策略名称
+	Category      string  `json:"category"`      // 产品类别
+	Symbol        string  `json:"symbol"`        // 交易对
+	Interval      string  `json:"interval"`      // K线周期
+	Start         int64   `json:"start"`         // 第一根K线的开始时间（毫秒）
+	End           int64   `json:"end"`           // 最后一根K线的结束时间（毫秒）
+	Bars          int     `json:"bars"`          // K线数量
+	InitialEquity float64 `json:"initialEquity"` // 期初权益
+	FinalEquity   float64 `json:"finalEquity"`   // 期末权益，包括未平仓仓位的未实现盈亏
+	TotalReturn   float64 `json:"totalReturn"`   // 总收益率
+	BuyHoldReturn float64 `json:"buyHoldReturn"` // 同期持有标的的收益率，用于对比
+	MaxDrawdown   float64 `json:"maxDrawdown"`   // 最大回撤
+	Sharpe        float64 `json:"sharpe"`        // 按日收益率计算的年化夏普比率
+	Trades        int     `json:"trades"`        // 成交笔数（不包括资金费用）
+	ClosedTrades  int     `json:"closedTrades"`  // 平仓次数
+	WinRate       float64 `json:"winRate"`       // 盈利的平仓占比
+	Fees          float64 `json:"fees"`          // 手续费合计
+	Funding       float64 `json:"funding"`       // 资金费用合计，正数表示支出
+}
+
+// 汇总统计指标，同时计算权益曲线的回撤
+func summarize(cfg *Config, data *Dataset, result *Result) Summary {
+	bars := data.Bars
+	summary := Summary{
+		Strategy: cfg.Strategy,
+		Category: cfg.Category,
+		Symbol:   cfg.Symbol,
+		Interval: cfg.Interval,
+		Start:    bars[0].Start,
+		End:      bars[len(bars)-1].End,
+		Bars:     len(bars),
+	}
+
+	// 期初权益按初始余额计算，非稳定币按第一根K线的开盘价折算
+	for coin, amount := range initialBalance(cfg) {
+		if coin == "USDT" || coin == "USDC" {
+			summary.InitialEquity += amount
+		} else if coin+"USDT" == cfg.Symbol && cfg.Category == bybitapi.CategorySpot {
+			summary.InitialEquity += amount * bars[0].Open
+		}
+	}
+	if bars[0].Open > 0 {
+		summary.BuyHoldReturn = bars[len(bars)-1].Close/bars[0].Open - 1
+	}
+
+	peak := summary.InitialEquity
+	for i := range result.Equity {
+		point := &result.Equity[i]
+		peak = math.Max(peak, point.Equity)
+		if peak > 0 {
+			point.Drawdown = 1 - point.Equity/peak
+		}
+		summary.MaxDrawdown = math.Max(summary.MaxDrawdown, point.Drawdown)
+	}
+	if n := len(result.Equity); n > 0 {
+		summary.FinalEquity = result.Equity[n-1].Equity
+	}
+	if summary.InitialEquity > 0 {
+		summary.TotalReturn = summary.FinalEquity/summary.InitialEquity - 1
+	}
+	summary.Sharpe = analytics.Sharpe(dailyReturns(summary.InitialEquity, result.Equity))
+
+	for i := range result.Trades {
+		exec := &result.Trades[i]
+		switch exec.ExecType {
+		case model.ExecTypeFunding:
+			summary.Funding += exec.ExecFee
+		default:
+			summary.Trades++
+			summary.Fees += analytics.FeeValue(exec)
+		}
+	}
+	wins := 0
+	for _, record := range result.ClosedPnl {
+		summary.ClosedTrades++
+		if record.ClosedPnl > 0 {
+			wins++
+		}
+	}
+	if summary.ClosedTrades > 0 {
+		summary.WinRate = float64(wins) / float64(summary.ClosedTrades)
+	}
+	return summary
+}
+
+// 回测使用的初始余额，未配置时与模拟交易一致
+func initialBalance(cfg *Config) map[string]float64 {
+	if len(cfg.Paper.InitialBalance) == 0 {
+		return map[string]float64{"USDT": paper.DefaultInitialBalance}
+	}
+	return cfg.Paper.InitialBalance
+}
+
+// 按UTC日期取每天最后的权益，计算日收益率
+func dailyReturns(initial float64, equity []EquityPoint) []float64 {
+	var returns []float64
+	prev := initial
+	for i, point := range equity {
+		day := time.UnixMilli(point.Time - 1).UTC().Format(dateLayout)
+		if i+1 < len(equity) && time.UnixMilli(equity[i+1].Time-1).UTC().Format(dateLayout) == day {
+			continue
+		}
+		if prev > 0 {
+			returns = append(returns, point.Equity/prev-1)
+		}
+		prev = point.Equity
+	}
+	return returns
+}
+
+// String 返回便于阅读的统计摘要
+func (s Summary) String() string {
+	return fmt.Sprintf(`策略: %s  %s %s %s
+区间: %s 至 %s（%d根K线）
+权益: %.2f -> %.2f  收益率: %.2f%%  持有标的: %.2f%%
+最大回撤: %.2f%%  夏普比率: %.2f
+成交: %d笔  平仓: %d次  胜率: %.2f%%  手续费: %.4f  资金费用: %.4f`,
+		s.Strategy, s.Category, s.Symbol, s.Interval,
+		formatTime(s.Start), formatTime(s.End), s.Bars,
+		s.InitialEquity, s.FinalEquity, s.TotalReturn*100, s.BuyHoldReturn*100,
+		s.MaxDrawdown*100, s.Sharpe,
+		s.Trades, s.ClosedTrades, s.WinRate*100, s.Fees, s.Funding)
+}
+
+// WriteFiles 按输出配置写入权益曲线、成交记录和完整结果
+func (r *Result) WriteFiles(output OutputConfig) error {
+	if output.EquityFile != "" {
+		rows := [][]string{{"time", "price", "equity", "drawdown"}}
+		for _, p := range r.Equity {
+			rows = append(rows, []string{formatTime(p.Time), marketdata.FormatNum(p.Price), marketdata.FormatNum(p.Equity), marketdata.FormatNum(p.Drawdown)})
+		}
+		if err := writeCSV(output.EquityFile, rows); err != nil {
+			return err
+		}
+	}
+	if output.TradesFile != "" {
+		rows := [][]string{{"time", "symbol", "side", "orderType", "execType", "price", "qty", "value", "fee", "feeCurrency", "closedSize", "orderId"}}
+		for _, e := range r.Trades {
+			rows = append(rows, []string{
+				formatTime(e.ExecTime), e.Symbol, e.Side, e.OrderType, e.ExecType,
+				marketdata.FormatNum(e.ExecPrice), marketdata.FormatNum(e.ExecQty), marketdata.FormatNum(e.ExecValue), marketdata.FormatNum(e.ExecFee), e.FeeCurrency,
+				marketdata.FormatNum(e.ClosedSize), e.OrderId,
+			})
+		}
+		if err := writeCSV(output.TradesFile, rows); err != nil {
+			return err
+		}
+	}
+	if output.ReportFile != "" {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return fmt.Errorf("无法序列化回测结果: %v", err)
+		}
+		if err := writeOutput(output.ReportFile, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 写入CSV文件
+func writeCSV(path string, rows [][]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %v", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("无法写入%s: %v", path, err)
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("无法写入%s: %v", path, err)
+	}
+	return nil
+}
+
+// 写入输出文件
+func writeOutput(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("无法写入%s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// Strategy 是回测策略
+// OnBar在每根K线收盘时调用，trader与实盘的service.BybitService接口相同：
+// 可以下单、撤单、查询仓位和余额，也可以用GetKline查询已经收盘的历史K线。
+// 下单被拒绝（例如余额不足）时返回的响应包含错误码，只有返回error才会中止回测
+type Strategy interface {
+	OnBar(ctx context.Context, trader service.BybitService, bar Bar) error
+}
+
+// Factory 根据参数创建策略
+type Factory func(params map[string]float64) (Strategy, error)
+
+var (
+	strategiesMu sync.Mutex
+	strategies   = map[string]Factory{
+		"buy-and-hold": newBuyAndHold,
+		"sma-cross":    newSMACross,
+	}
+)
+
+// Register 注册策略，同名策略会被替换
+func Register(name string, factory Factory) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[name] = factory
+}
+
+// Strategies 返回全部已注册的策略名称，按名称排序
+func Strategies() []string {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStrategy 按名称创建策略
+func NewStrategy(name string, params map[string]float64) (Strategy, error) {
+	strategiesMu.Lock()
+	factory, ok := strategies[name]
+	strategiesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的策略%q，可选值: %s", name, strings.Join(Strategies(), "、"))
+	}
+	return factory(params)
+}
+
+// 读取参数，未配置时使用默认值
+func param(params map[string]float64, name string, fallback float64) float64 {
+	if v, ok := params[name]; ok {
+		return v
+	}
+	return fallback
+}
+
+// 按基础币种数量下市价单，返回是否被接受
+func marketOrder(ctx context.Context, trader service.BybitService, bar Bar, side string, qty float64) (bool, error) {
+	resp, err := trader.CreateOrder(ctx, bar.Category, bar.Symbol, side, "Market", qty, 0, map[string]string{"marketUnit": "baseCoin"})
+	if err != nil {
+		return false, err
+	}
+	return resp.RetCode == 0, nil
+}
+
+// 买入并持有：第一根K线收盘时买入qty，之后不再交易
+type buyAndHold struct {
+	qty    float64
+	bought bool
+}
+
+func newBuyAndHold(params map[string]float64) (Strategy, error) {
+	qty := param(params, "qty", 0)
+	if qty <= 0 {
+		return nil, fmt.Errorf("buy-and-hold需要参数qty（大于0）")
+	}
+	return &buyAndHold{qty: qty}, nil
+}
+
+func (s *buyAndHold) OnBar(ctx context.Context, trader service.BybitService, bar Bar) error {
+	if s.bought {
+		return nil
+	}
+	ok, err := marketOrder(ctx, trader, bar, "Buy", s.qty)
+	s.bought = ok
+	return err
+}
+
+// 均线交叉：快线上穿慢线时做多qty，下穿时平多；合约在short为1时同时反手做空
+// 参数：fast（默认10）、slow（默认30）、qty、short
+type smaCross struct {
+	fast, slow int
+	qty        float64
+	short      bool
+	closes     []float64
+	position   float64 // 策略持有的数量，空头为负
+}
+
+func newSMACross(params map[string]float64) (Strategy, error) {
+	s := &smaCross{
+		fast:  int(param(params, "fast", 10)),
+		slow:  int(param(params, "slow", 30)),
+		qty:   param(params, "qty", 0),
+		short: param(params, "short", 0) != 0,
+	}
+	if s.fast <= 0 || s.slow <= s.fast {
+		return nil, fmt.Errorf("sma-cross需要0 < fast < slow")
+	}
+	if s.qty <= 0 {
+		return nil, fmt.Errorf("sma-cross需要参数qty（大于0）")
+	}
+	return s, nil
+}
+
+// 最近n根K线收盘价的平均值，offset为向前偏移的K线数
+func (s *smaCross) sma(n, offset int) float64 {
+	end := len(s.closes) - offset
+	sum := 0.0
+	for _, c := range s.closes[end-n : end] {
+		sum += c
+	}
+	return sum / float64(n)
+}
+
+func (s *smaCross) OnBar(ctx context.Context, trader service.BybitService, bar Bar) error {
+	s.closes = append(s.closes, bar.Close)
+	if len(s.closes) > s.slow+1 {
+		s.closes = s.closes[1:]
+	}
+	if len(s.closes) <= s.slow {
+		return nil
+	}
+
+	prevDiff := s.sma(s.fast, 1) - s.sma(s.slow, 1)
+	diff := s.sma(s.fast, 0) - s.sma(s.slow, 0)
+
+	target := s.position
+	switch {
+	case prevDiff <= 0 && diff > 0:
+		target = s.qty
+	case prevDiff >= 0 && diff < 0:
+		target = 0
+		if s.short && bar.Category == bybitapi.CategoryLinear {
+			target = -s.qty
+		}
+	}
+	if target == s.position {
+		return nil
+	}
+
+	side, qty := "Buy", target-s.position
+	if qty < 0 {
+		side, qty = "Sell", -qty
+	}
+	ok, err := marketOrder(ctx, trader, bar, side, qty)
+	if err != nil {
+		return err
+	}
+	if ok {
+		s.position = target
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package backtest
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/marketdata"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 一天的毫秒数
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+// Trades 返回[start, end)内的逐笔成交，按时间排序
+// REST接口只能查询最近的成交，历史成交来自Bybit公开数据（tradesUrl）按天提供的文件，当天的数据要到次日才能下载
+func (l *Loader) Trades(ctx context.Context, category, symbol string, start, end int64) ([]Trade, error) {
+	var trades []Trade
+	for day := start - (start%dayMillis+dayMillis)%dayMillis; day < end; day += dayMillis {
+		date := time.UnixMilli(day).UTC().Format(dateLayout)
+		path, err := l.tradeFile(ctx, category, symbol, date)
+		if err != nil {
+			return nil, err
+		}
+		dayTrades, err := readTrades(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range dayTrades {
+			if t.Time >= start && t.Time < end {
+				trades = append(trades, t)
+			}
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].Time < trades[j].Time })
+	return trades, nil
+}
+
+// 返回某一天的逐笔成交文件，缓存中没有时下载
+func (l *Loader) tradeFile(ctx context.Context, category, symbol, date string) (string, error) {
+	path := filepath.Join(l.cfg.CacheDir, "trades", category, symbol, date+".csv.gz")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if l.cfg.Offline {
+		return "", fmt.Errorf("缓存中没有%s %s的逐笔成交，离线模式不下载", symbol, date)
+	}
+
+	url := tradeURL(l.cfg.TradesURL, category, symbol, date)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("下载%s %s的逐笔成交失败: %v", symbol, date, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("没有%s %s的逐笔成交数据（%s）", symbol, date, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载%s %s的逐笔成交失败: 状态码%d", symbol, date, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("下载%s %s的逐笔成交失败: %v", symbol, date, err)
+	}
+	if err := writeFile(path, data); err != nil {
+		return "", err
+	}
+	l.logger.Info("下载%s %s的逐笔成交%d字节", symbol, date, len(data))
+	return path, nil
+}
+
+// 公开数据的文件地址，合约和现货的目录与文件名格式不同
+func tradeURL(baseURL, category, symbol, date string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if category == bybitapi.CategorySpot {
+		return fmt.Sprintf("%s/spot/%s/%s_%s.csv.gz", baseURL, symbol, symbol, date)
+	}
+	return fmt.Sprintf("%s/trading/%s/%s%s.csv.gz", baseURL, symbol, symbol, date)
+}
+
+// 读取gzip压缩的逐笔成交CSV，按表头识别列
+// 合约文件的timestamp为秒（带小数）、数量列为size；现货文件的timestamp为毫秒、数量列为volume
+func readTrades(path string) ([]Trade, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取逐笔成交: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("逐笔成交文件%s格式错误: %v", path, err)
+	}
+	defer gz.Close()
+
+	reader := csv.NewReader(gz)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("逐笔成交文件%s格式错误: %v", path, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	timeCol, ok1 := columns["timestamp"]
+	priceCol, ok2 := columns["price"]
+	sizeCol, ok3 := columns["size"]
+	if !ok3 {
+		sizeCol, ok3 = columns["volume"]
+	}
+	sideCol, hasSide := columns["side"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("逐笔成交文件%s缺少timestamp、price或size列", path)
+	}
+
+	var trades []Trade
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("逐笔成交文件%s格式错误: %v", path, err)
+		}
+		ts, err := strconv.ParseFloat(record[timeCol], 64)
+		if err != nil {
+			continue
+		}
+		// 早于1973年的毫秒数不可能出现，小于1e11视为秒
+		if ts < 1e11 {
+			ts *= 1000
+		}
+		trade := Trade{Time: int64(ts), Price: marketdata.ParseNum(record[priceCol]), Size: marketdata.ParseNum(record[sizeCol])}
+		if hasSide {
+			trade.Side = "Sell"
+			if strings.EqualFold(record[sideCol], "buy") {
+				trade.Side = "Buy"
+			}
+		}
+		if trade.Price > 0 {
+			trades = append(trades, trade)
+		}
+	}
+	return trades, nil
+}
@@ -0,0 +1,439 @@
+package bybitmock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+)
+
+// 子账户用户名规则：6-16位字母和数字，必须包含字母
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9]{6,16}$`)
+
+// 划转记录
+type transferRecord struct {
+	TransferId      string `json:"transferId"`
+	Coin            string `json:"coin"`
+	Amount          string `json:"amount"`
+	FromMemberId    string `json:"fromMemberId,omitempty"`
+	ToMemberId      string `json:"toMemberId,omitempty"`
+	FromAccountType string `json:"fromAccountType"`
+	ToAccountType   string `json:"toAccountType"`
+	Timestamp       string `json:"timestamp"`
+	Status          string `json:"status"`
+}
+
+// 提现记录
+type withdrawRecord struct {
+	WithdrawId   string `json:"withdrawId"`
+	TxID         string `json:"txID"`
+	WithdrawType int    `json:"withdrawType"`
+	Coin         string `json:"coin"`
+	Chain        string `json:"chain"`
+	Amount       string `json:"amount"`
+	WithdrawFee  string `json:"withdrawFee"`
+	Status       string `json:"status"`
+	ToAddress    string `json:"toAddress"`
+	Tag          string `json:"tag"`
+	CreateTime   string `json:"createTime"`
+	UpdateTime   string `json:"updateTime"`
+}
+
+// 子账户
+type subMember struct {
+	UID         string `json:"uid"`
+	Username    string `json:"username"`
+	MemberType  int    `json:"memberType"`
+	Status      int    `json:"status"`
+	AccountMode int    `json:"accountMode"`
+	Remark      string `json:"remark"`
+}
+
+// 子账户API密钥
+type subAPIKey struct {
+	ID          string              `json:"id"`
+	Subuid      string              `json:"-"`
+	Note        string              `json:"note"`
+	APIKey      string              `json:"apiKey"`
+	ReadOnly    int                 `json:"readOnly"`
+	Secret      string              `json:"secret,omitempty"`
+	IPs         []string            `json:"ips"`
+	Permissions map[string][]string `json:"permissions"`
+}
+
+// 随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// 检查数量参数
+func requireAmount(req *request) *model.Response {
+	amount, ok := req.number("amount")
+	if !ok || amount <= 0 {
+		return reject(retParamsError, "params error: amount invalid")
+	}
+	return nil
+}
+
+// ==================== 资产接口 ====================
+
+// 查询资产信息，余额取自模拟统一账户
+func (s *Server) handleAssetInfo(ctx context.Context, req *request) *model.Response {
+	resp, err := s.exchange.GetWalletBalance(ctx, "UNIFIED", req.params["coin"])
+	if err != nil || resp.RetCode != retOK {
+		return reply(resp, err)
+	}
+	var wallet struct {
+		List []struct {
+			Coin []struct {
+				Coin          string `json:"coin"`
+				WalletBalance string `json:"walletBalance"`
+				Available     string `json:"availableToWithdraw"`
+			} `json:"coin"`
+		} `json:"list"`
+	}
+	data, _ := json.Marshal(resp.Result)
+	if err := json.Unmarshal(data, &wallet); err != nil {
+		return reject(retServerError, "%v", err)
+	}
+
+	assets := []map[string]string{}
+	for _, account := range wallet.List {
+		for _, c := range account.Coin {
+			assets = append(assets, map[string]string{
+				"coin":     c.Coin,
+				"frozen":   "0",
+				"free":     c.Available,
+				"withdraw": "0",
+			})
+		}
+	}
+	return success(map[string]interface{}{
+		"spot": map[string]interface{}{"status": "ACCOUNT_STATUS_NORMAL", "assets": assets},
+	})
+}
+
+// 账户内划转，只记录不改变余额
+func (s *Server) handleInterTransfer(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "transferId", "coin", "amount", "fromAccountType", "toAccountType"); resp != nil {
+		return resp
+	}
+	if resp := requireAmount(req); resp != nil {
+		return resp
+	}
+	p := req.params
+	if p["fromAccountType"] == p["toAccountType"] {
+		return reject(retParamsError, "params error: fromAccountType and toAccountType cannot be the same")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.transfers {
+		if t.TransferId == p["transferId"] {
+			return reject(retParamsError, "params error: transferId duplicated")
+		}
+	}
+	s.transfers = append(s.transfers, transferRecord{
+		TransferId:      p["transferId"],
+		Coin:            p["coin"],
+		Amount:          p["amount"],
+		FromAccountType: p["fromAccountType"],
+		ToAccountType:   p["toAccountType"],
+		Timestamp:       strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Status:          "SUCCESS",
+	})
+	return success(map[string]string{"transferId": p["transferId"], "status": "SUCCESS"})
+}
+
+// 母子账户划转，只记录不改变余额
+func (s *Server) handleUniversalTransfer(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "transferId", "coin", "amount", "fromMemberId", "toMemberId", "fromAccountType", "toAccountType"); resp != nil {
+		return resp
+	}
+	if resp := requireAmount(req); resp != nil {
+		return resp
+	}
+	p := req.params
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.universal {
+		if t.TransferId == p["transferId"] {
+			return reject(retParamsError, "params error: transferId duplicated")
+		}
+	}
+	s.universal = append(s.universal, transferRecord{
+		TransferId:      p["transferId"],
+		Coin:            p["coin"],
+		Amount:          p["amount"],
+		FromMemberId:    p["fromMemberId"],
+		ToMemberId:      p["toMemberId"],
+		FromAccountType: p["fromAccountType"],
+		ToAccountType:   p["toAccountType"],
+		Timestamp:       strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Status:          "SUCCESS",
+	})
+	return success(map[string]string{"transferId": p["transferId"], "status": "SUCCESS"})
+}
+
+// 处理asset/transfer/query-inter-transfer-list
+func (s *Server) handleInterTransferList(ctx context.Context, req *request) *model.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return success(map[string]interface{}{"list": filterTransfers(s.transfers, req), "nextPageCursor": ""})
+}
+
+// 处理asset/transfer/query-universal-transfer-list
+func (s *Server) handleUniversalTransferList(ctx context.Context, req *request) *model.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return success(map[string]interface{}{"list": filterTransfers(s.universal, req), "nextPageCursor": ""})
+}
+
+// 按查询条件筛选划转记录，按时间从新到旧排列
+func filterTransfers(records []transferRecord, req *request) []transferRecord {
+	p := req.params
+	start, end := req.integer("startTime"), req.integer("endTime")
+	limit := int(req.integer("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+
+	list := []transferRecord{}
+	for i := len(records) - 1; i >= 0 && len(list) < limit; i-- {
+		t := records[i]
+		ts, _ := strconv.ParseInt(t.Timestamp, 10, 64)
+		switch {
+		case p["transferId"] != "" && t.TransferId != p["transferId"],
+			p["coin"] != "" && t.Coin != p["coin"],
+			p["status"] != "" && t.Status != p["status"],
+			start > 0 && ts < start,
+			end > 0 && ts > end:
+			continue
+		}
+		list = append(list, t)
+	}
+	return list
+}
+
+// 提现，只记录不改变余额
+func (s *Server) handleWithdraw(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "coin", "chain", "address", "amount", "timestamp"); resp != nil {
+		return resp
+	}
+	if resp := requireAmount(req); resp != nil {
+		return resp
+	}
+	p := req.params
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	id := strconv.Itoa(10000000 + len(s.withdrawals))
+	s.withdrawals = append(s.withdrawals, withdrawRecord{
+		WithdrawId:  id,
+		Coin:        p["coin"],
+		Chain:       p["chain"],
+		Amount:      p["amount"],
+		WithdrawFee: "0",
+		Status:      "SecurityCheck",
+		ToAddress:   p["address"],
+		Tag:         p["tag"],
+		CreateTime:  now,
+		UpdateTime:  now,
+	})
+	return success(map[string]string{"id": id})
+}
+
+// 处理asset/withdraw/query-record
+func (s *Server) handleWithdrawList(ctx context.Context, req *request) *model.Response {
+	p := req.params
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []withdrawRecord{}
+	for i := len(s.withdrawals) - 1; i >= 0; i-- {
+		w := s.withdrawals[i]
+		if (p["withdrawID"] != "" && w.WithdrawId != p["withdrawID"]) || (p["coin"] != "" && w.Coin != p["coin"]) {
+			continue
+		}
+		list = append(list, w)
+	}
+	return success(map[string]interface{}{"rows": list, "nextPageCursor": ""})
+}
+
+// 模拟账户没有充值记录
+func (s *Server) handleDepositList(ctx context.Context, req *request) *model.Response {
+	return success(map[string]interface{}{"rows": []interface{}{}, "nextPageCursor": ""})
+}
+
+// ==================== 子账户接口 ====================
+
+// 处理user/create-sub-member
+func (s *Server) handleCreateSubMember(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "username", "memberType"); resp != nil {
+		return resp
+	}
+	p := req.params
+	if !usernamePattern.MatchString(p["username"]) || strings.Trim(p["username"], "0123456789") == "" {
+		return reject(retParamsError, "params error: username invalid")
+	}
+	memberType := int(req.integer("memberType"))
+	if memberType != 1 && memberType != 6 {
+		return reject(retParamsError, "params error: memberType invalid")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.subMembers {
+		if m.Username == p["username"] {
+			return reject(31005, "Username already exists")
+		}
+	}
+	s.nextUID++
+	member := &subMember{
+		UID:         strconv.FormatInt(s.nextUID, 10),
+		Username:    p["username"],
+		MemberType:  memberType,
+		Status:      1,
+		AccountMode: 3,
+		Remark:      p["note"],
+	}
+	s.subMembers = append(s.subMembers, member)
+	return success(member)
+}
+
+// 处理user/query-api，子账户API密钥返回子账户的UID，其余密钥属于母账户
+func (s *Server) handleQueryAPI(ctx context.Context, req *request) *model.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uid := int64(MasterUID)
+	for _, k := range s.subKeys {
+		if k.APIKey == req.apiKey {
+			uid, _ = strconv.ParseInt(k.Subuid, 10, 64)
+		}
+	}
+	return success(map[string]interface{}{
+		"apiKey":   req.apiKey,
+		"readOnly": 0,
+		"userID":   uid,
+	})
+}
+
+// 处理user/query-sub-members
+func (s *Server) handleListSubMembers(ctx context.Context, req *request) *model.Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return success(map[string]interface{}{"subMembers": append([]*subMember{}, s.subMembers...)})
+}
+
+// 创建子账户API密钥，新密钥可以直接访问私有接口
+func (s *Server) handleCreateSubAPIKey(ctx context.Context, req *request) *model.Response {
+	var body model.SubAPIKeyRequest
+	if err := json.Unmarshal(req.body, &body); err != nil {
+		return reject(retParamsError, "params error: %v", err)
+	}
+	if body.Subuid == 0 || len(body.Permissions) == 0 {
+		return reject(retParamsError, "params error: subuid and permissions are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findSubMember(strconv.FormatInt(body.Subuid, 10)) == nil {
+		return reject(retParamsError, "params error: subuid not found")
+	}
+	ips := []string{"*"}
+	if body.IPs != "" {
+		ips = strings.Split(body.IPs, ",")
+	}
+	key := &subAPIKey{
+		ID:          strconv.Itoa(len(s.subKeys) + 1),
+		Subuid:      strconv.FormatInt(body.Subuid, 10),
+		Note:        body.Note,
+		APIKey:      randomHex(9),
+		ReadOnly:    body.ReadOnly,
+		IPs:         ips,
+		Permissions: body.Permissions,
+	}
+	secret := randomHex(18)
+	s.subKeys = append(s.subKeys, key)
+	s.keys[key.APIKey] = secret
+
+	created := *key
+	created.Secret = secret
+	return success(created)
+}
+
+// 处理user/sub-apikeys
+func (s *Server) handleListSubAPIKeys(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "subMemberId"); resp != nil {
+		return resp
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []*subAPIKey{}
+	for _, k := range s.subKeys {
+		if k.Subuid == req.params["subMemberId"] {
+			list = append(list, k)
+		}
+	}
+	return success(map[string]interface{}{"result": list, "nextPageCursor": ""})
+}
+
+// 处理user/delete-sub-api
+func (s *Server) handleDeleteSubAPIKey(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "apikey"); resp != nil {
+		return resp
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, k := range s.subKeys {
+		if k.APIKey == req.params["apikey"] {
+			s.subKeys = append(s.subKeys[:i], s.subKeys[i+1:]...)
+			delete(s.keys, k.APIKey)
+			return success(map[string]interface{}{})
+		}
+	}
+	return reject(retParamsError, "params error: apikey not found")
+}
+
+// 处理user/frozen-sub-member
+func (s *Server) handleFreezeSubMember(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "subuid", "frozen"); resp != nil {
+		return resp
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	member := s.findSubMember(req.params["subuid"])
+	if member == nil {
+		return reject(retParamsError, "params error: subuid not found")
+	}
+	switch req.params["frozen"] {
+	case "0":
+		member.Status = 1
+	case "1":
+		member.Status = 2
+	default:
+		return reject(retParamsError, "params error: frozen must be 0 or 1")
+	}
+	return success(map[string]interface{}{})
+}
+
+// 按UID查找子账户，调用方持有s.mu
+func (s *Server) findSubMember(uid string) *subMember {
+	for _, m := range s.subMembers {
+		if m.UID == uid {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package bybitmock
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 校验私有接口的签名，通过时返回nil
+// 与Bybit相同，只接受V5规则的签名：timestamp + apiKey + recvWindow + 查询字符串（GET）或请求体（POST）
+func (s *Server) authenticate(r *http.Request, req *request) *model.Response {
+	if req.apiKey == "" {
+		return reject(retInvalidAPIKey, "API key is invalid.")
+	}
+	s.mu.Lock()
+	secret, ok := s.keys[req.apiKey]
+	s.mu.Unlock()
+	if !ok {
+		return reject(retInvalidAPIKey, "API key is invalid.")
+	}
+
+	timestamp := r.Header.Get("X-BAPI-TIMESTAMP")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return reject(retParamsError, "empty param of timestamp")
+	}
+	recvWindow := r.Header.Get("X-BAPI-RECV-WINDOW")
+	window, err := strconv.ParseInt(recvWindow, 10, 64)
+	if err != nil {
+		return reject(retParamsError, "invalid recv_window")
+	}
+	now := time.Now().UnixMilli()
+	if ts > now+1000 || now-ts > window {
+		return reject(retTimestampError, "invalid request, please check your server timestamp or recv_window param. req_timestamp[%d],server_timestamp[%d],recv_window[%d]", ts, now, window)
+	}
+
+	payload := timestamp + req.apiKey + recvWindow
+	if req.method == http.MethodGet {
+		payload += r.URL.RawQuery
+	} else {
+		payload += string(req.body)
+	}
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(payload))
+	expected := hex.EncodeToString(h.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(r.Header.Get("X-BAPI-SIGN")))) {
+		return reject(retSignError, "error sign! origin_string[%s]", payload)
+	}
+	return nil
+}
+
+// Sign 按V5规则计算签名，用于在测试中构造请求
+func Sign(apiSecret, timestamp, apiKey, payload string) string {
+	h := hmac.New(sha256.New, []byte(apiSecret))
+	h.Write([]byte(timestamp + apiKey + bybitapi.RecvWindow + payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
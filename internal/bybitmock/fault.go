@@ -0,0 +1,124 @@
+package bybitmock
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// AllEndpoints 表示对全部接口生效的故障
+const AllEndpoints = "*"
+
+// Fault 描述注入到接口的故障
+// Status不为0时返回该HTTP状态码；否则返回HTTP 200和RetCode/RetMsg组成的Bybit错误；
+// Drop为true时直接断开连接，模拟网络错误
+type Fault struct {
+	Status  int           // HTTP状态码
+	RetCode int           // Bybit返回码
+	RetMsg  string        // Bybit返回消息
+	Delay   time.Duration // 返回前额外等待的时间，可以用来触发客户端超时
+	Drop    bool          // 断开连接不返回响应，Go的HTTP客户端会自动重试一次复用连接上的幂等请求
+	Times   int           // 生效次数，为0时一直生效直到ClearFaults
+}
+
+// 常用的故障
+var (
+	// FaultRateLimit 是Bybit的接口限频错误
+	FaultRateLimit = Fault{RetCode: 10006, RetMsg: "Too many visits!"}
+	// FaultServerError 是Bybit的内部错误
+	FaultServerError = Fault{RetCode: 10016, RetMsg: "Server error."}
+	// FaultIPBanned 是IP超频后返回的HTTP 403
+	FaultIPBanned = Fault{Status: http.StatusForbidden}
+	// FaultBadGateway 是网关错误
+	FaultBadGateway = Fault{Status: http.StatusBadGateway}
+)
+
+// Fail 为接口注入故障，endpoint为去掉/v5/前缀的路径，AllEndpoints对全部接口生效
+// 同一接口再次注入时替换原故障，单个接口的故障优先于AllEndpoints
+func (s *Server) Fail(endpoint string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := fault
+	s.faults[endpoint] = &f
+}
+
+// ClearFaults 清除全部注入的故障和延迟
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = map[string]*Fault{}
+	s.latency = 0
+}
+
+// SetLatency 设置每个请求的固定延迟
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// 当前的固定延迟
+func (s *Server) currentLatency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latency
+}
+
+// 取出接口的故障并扣减剩余次数，没有故障时返回nil
+func (s *Server) takeFault(endpoint string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := endpoint
+	fault, ok := s.faults[key]
+	if !ok {
+		key = AllEndpoints
+		if fault, ok = s.faults[key]; !ok {
+			return nil
+		}
+	}
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(s.faults, key)
+		}
+	}
+	f := *fault
+	return &f
+}
+
+// 按故障返回响应
+func (s *Server) applyFault(w http.ResponseWriter, r *http.Request, fault *Fault) {
+	if !s.delay(r.Context(), fault.Delay) {
+		return
+	}
+	switch {
+	case fault.Drop:
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	case fault.Status != 0:
+		http.Error(w, http.StatusText(fault.Status), fault.Status)
+	default:
+		s.write(w, reject(fault.RetCode, "%s", fault.RetMsg))
+	}
+}
+
+// 等待d，客户端先断开时返回false
+func (s *Server) delay(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,549 @@
+package bybitmock
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 每次设置价格时生成的订单簿档数
+const bookLevels = 25
+
+// Instrument 是模拟的交易对
+type Instrument struct {
+	Category    string  // spot或linear
+	Symbol      string  // 交易对
+	BaseCoin    string  // 交易币种
+	QuoteCoin   string  // 计价币种
+	SettleCoin  string  // 结算币种，现货为空
+	TickSize    float64 // 最小价格变动
+	QtyStep     float64 // 数量步长
+	MinOrderQty float64 // 最小下单数量
+	MaxOrderQty float64 // 最大下单数量
+	MaxLeverage float64 // 最大杠杆，现货为0
+}
+
+// Kline 是一根K线
+type Kline struct {
+	Start    int64 // 开始时间（毫秒）
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+	Turnover float64
+}
+
+// 交易对的行情
+type ticker struct {
+	last            float64
+	mark            float64
+	index           float64
+	prev24h         float64
+	fundingRate     float64
+	nextFundingTime int64
+}
+
+// 交易对的订单簿，价格和数量
+type orderbook struct {
+	bids [][2]float64 // 价格从高到低
+	asks [][2]float64 // 价格从低到高
+}
+
+// 预置的交易对
+func defaultInstruments() []Instrument {
+	return []Instrument{
+		{Category: bybitapi.CategorySpot, Symbol: "BTCUSDT", BaseCoin: "BTC", QuoteCoin: "USDT", TickSize: 0.01, QtyStep: 0.000001, MinOrderQty: 0.000048, MaxOrderQty: 71.73956243},
+		{Category: bybitapi.CategorySpot, Symbol: "ETHUSDT", BaseCoin: "ETH", QuoteCoin: "USDT", TickSize: 0.01, QtyStep: 0.00001, MinOrderQty: 0.00062, MaxOrderQty: 1229.2336343},
+		{Category: bybitapi.CategoryLinear, Symbol: "BTCUSDT", BaseCoin: "BTC", QuoteCoin: "USDT", SettleCoin: "USDT", TickSize: 0.1, QtyStep: 0.001, MinOrderQty: 0.001, MaxOrderQty: 1190, MaxLeverage: 100},
+		{Category: bybitapi.CategoryLinear, Symbol: "ETHUSDT", BaseCoin: "ETH", QuoteCoin: "USDT", SettleCoin: "USDT", TickSize: 0.01, QtyStep: 0.01, MinOrderQty: 0.01, MaxOrderQty: 7240, MaxLeverage: 100},
+	}
+}
+
+// AddInstrument 添加或替换交易对
+func (s *Server) AddInstrument(inst Instrument) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := inst
+	s.instruments[inst.Category+"/"+inst.Symbol] = &i
+}
+
+// SetPrice 设置交易对的最新价，标记价格和指数价格同时设为该价格，
+// 并生成以该价格为中心、每档相差一个最小价格变动的订单簿，然后撮合已有挂单
+func (s *Server) SetPrice(category, symbol string, price float64) {
+	s.mu.Lock()
+	tick := 0.01
+	if inst, ok := s.instruments[category+"/"+symbol]; ok && inst.TickSize > 0 {
+		tick = inst.TickSize
+	}
+	book := &orderbook{}
+	for i := 1; i <= bookLevels; i++ {
+		size := float64(i) * 0.5
+		book.bids = append(book.bids, [2]float64{round(price-float64(i)*tick, tick), size})
+		book.asks = append(book.asks, [2]float64{round(price+float64(i)*tick, tick), size})
+	}
+	s.books[category+"/"+symbol] = book
+	s.setLast(category, symbol, price)
+	s.mu.Unlock()
+
+	s.refresh()
+}
+
+// SetOrderbook 设置交易对的订单簿，每档为价格和数量，最新价设为买一和卖一的中间价
+func (s *Server) SetOrderbook(category, symbol string, bids, asks [][2]float64) {
+	s.mu.Lock()
+	book := &orderbook{
+		bids: append([][2]float64(nil), bids...),
+		asks: append([][2]float64(nil), asks...),
+	}
+	sort.Slice(book.bids, func(i, j int) bool { return book.bids[i][0] > book.bids[j][0] })
+	sort.Slice(book.asks, func(i, j int) bool { return book.asks[i][0] < book.asks[j][0] })
+	s.books[category+"/"+symbol] = book
+	if len(book.bids) > 0 && len(book.asks) > 0 {
+		s.setLast(category, symbol, (book.bids[0][0]+book.asks[0][0])/2)
+	}
+	s.mu.Unlock()
+
+	s.refresh()
+}
+
+// SetFunding 设置永续合约的资金费率和下次结算时间（毫秒）
+func (s *Server) SetFunding(symbol string, rate float64, nextFundingTime int64) {
+	s.mu.Lock()
+	t := s.ticker(bybitapi.CategoryLinear, symbol)
+	t.fundingRate = rate
+	t.nextFundingTime = nextFundingTime
+	s.mu.Unlock()
+
+	s.refresh()
+}
+
+// SetKlines 设置交易对某个周期的K线，未设置的交易对按最新价生成K线
+func (s *Server) SetKlines(category, symbol, interval string, klines []Kline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := append([]Kline(nil), klines...)
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Start < rows[j].Start })
+	s.klines[category+"/"+symbol+"/"+interval] = rows
+}
+
+// 更新最新价，调用方持有s.mu
+func (s *Server) setLast(category, symbol string, price float64) {
+	t := s.ticker(category, symbol)
+	if t.prev24h == 0 {
+		t.prev24h = price
+	}
+	t.last, t.mark, t.index = price, price, price
+}
+
+// 获取交易对的行情，不存在时创建，调用方持有s.mu
+func (s *Server) ticker(category, symbol string) *ticker {
+	key := category + "/" + symbol
+	t, ok := s.tickers[key]
+	if !ok {
+		t = &ticker{}
+		if category == bybitapi.CategoryLinear {
+			t.fundingRate = 0.0001
+			t.nextFundingTime = nextFunding(time.Now())
+		}
+		s.tickers[key] = t
+	}
+	return t
+}
+
+// 撮合挂单、触发止盈止损和收取资金费用
+func (s *Server) refresh() {
+	if s.exchange == nil {
+		return
+	}
+	if err := s.exchange.Refresh(context.Background()); err != nil {
+		s.logger.Warn("模拟交易所刷新行情失败: %v", err)
+	}
+}
+
+// 下一个资金费结算时间，每8小时结算一次
+func nextFunding(now time.Time) int64 {
+	period := 8 * time.Hour
+	return now.Truncate(period).Add(period).UnixMilli()
+}
+
+// 按步长四舍五入，避免浮点误差
+func round(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	// 再按步长的小数位数格式化一次，去掉乘法带来的误差
+	decimals := 0
+	if i := strings.IndexByte(str(step), '.'); i >= 0 {
+		decimals = len(str(step)) - i - 1
+	}
+	v, _ = strconv.ParseFloat(strconv.FormatFloat(math.Round(v/step)*step, 'f', decimals, 64), 64)
+	return v
+}
+
+// 格式化数字
+func str(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// 检查产品类别，模拟服务器只支持现货和USDT永续
+func validCategory(category string) bool {
+	return category == bybitapi.CategorySpot || category == bybitapi.CategoryLinear
+}
+
+// ==================== 行情接口 ====================
+
+// 交易对信息，与market/instruments-info一致
+func (s *Server) instrumentsInfo(category, symbol, status string) *model.Response {
+	if !validCategory(category) {
+		return reject(retParamsError, "Illegal category")
+	}
+	if status != "" && status != "Trading" {
+		return success(map[string]interface{}{"category": category, "list": []interface{}{}, "nextPageCursor": ""})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []interface{}{}
+	for _, inst := range s.sortedInstruments(category) {
+		if symbol != "" && inst.Symbol != symbol {
+			continue
+		}
+		list = append(list, instrumentView(inst))
+	}
+	return success(map[string]interface{}{"category": category, "list": list, "nextPageCursor": ""})
+}
+
+// 按交易对排序的交易对列表，调用方持有s.mu
+func (s *Server) sortedInstruments(category string) []*Instrument {
+	var list []*Instrument
+	for _, inst := range s.instruments {
+		if inst.Category == category {
+			list = append(list, inst)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+	return list
+}
+
+// Bybit格式的交易对信息
+func instrumentView(inst *Instrument) map[string]interface{} {
+	view := map[string]interface{}{
+		"symbol":      inst.Symbol,
+		"baseCoin":    inst.BaseCoin,
+		"quoteCoin":   inst.QuoteCoin,
+		"status":      "Trading",
+		"priceFilter": map[string]string{"tickSize": str(inst.TickSize)},
+	}
+	if inst.Category == bybitapi.CategorySpot {
+		view["innovation"] = "0"
+		view["marginTrading"] = "both"
+		view["lotSizeFilter"] = map[string]string{
+			"basePrecision":  str(inst.QtyStep),
+			"quotePrecision": "0.00000001",
+			"minOrderQty":    str(inst.MinOrderQty),
+			"maxOrderQty":    str(inst.MaxOrderQty),
+			"minOrderAmt":    "1",
+			"maxOrderAmt":    "4000000",
+		}
+		return view
+	}
+	view["contractType"] = "LinearPerpetual"
+	view["settleCoin"] = inst.SettleCoin
+	view["launchTime"] = "1585526400000"
+	view["deliveryTime"] = "0"
+	view["fundingInterval"] = 480
+	view["unifiedMarginTrade"] = true
+	view["leverageFilter"] = map[string]string{
+		"minLeverage":  "1",
+		"maxLeverage":  str(inst.MaxLeverage),
+		"leverageStep": "0.01",
+	}
+	view["priceFilter"] = map[string]string{
+		"minPrice": str(inst.TickSize),
+		"maxPrice": "1999999.8",
+		"tickSize": str(inst.TickSize),
+	}
+	view["lotSizeFilter"] = map[string]string{
+		"qtyStep":             str(inst.QtyStep),
+		"minOrderQty":         str(inst.MinOrderQty),
+		"maxOrderQty":         str(inst.MaxOrderQty),
+		"maxMktOrderQty":      str(inst.MaxOrderQty / 10),
+		"postOnlyMaxOrderQty": str(inst.MaxOrderQty),
+		"minNotionalValue":    "5",
+	}
+	return view
+}
+
+// 行情，与market/tickers一致
+func (s *Server) tickersInfo(category, symbol string) *model.Response {
+	if !validCategory(category) {
+		return reject(retParamsError, "Illegal category")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []interface{}{}
+	for _, inst := range s.sortedInstruments(category) {
+		if symbol != "" && inst.Symbol != symbol {
+			continue
+		}
+		t, ok := s.tickers[category+"/"+inst.Symbol]
+		if !ok {
+			continue
+		}
+		list = append(list, s.tickerView(inst, t))
+	}
+	if symbol != "" && len(list) == 0 {
+		return reject(retParamsError, "Not supported symbols")
+	}
+	return success(map[string]interface{}{"category": category, "list": list})
+}
+
+// Bybit格式的行情，调用方持有s.mu
+func (s *Server) tickerView(inst *Instrument, t *ticker) map[string]string {
+	var bid, bidSize, ask, askSize float64
+	if book, ok := s.books[inst.Category+"/"+inst.Symbol]; ok {
+		if len(book.bids) > 0 {
+			bid, bidSize = book.bids[0][0], book.bids[0][1]
+		}
+		if len(book.asks) > 0 {
+			ask, askSize = book.asks[0][0], book.asks[0][1]
+		}
+	}
+	change := 0.0
+	if t.prev24h > 0 {
+		change = t.last/t.prev24h - 1
+	}
+	view := map[string]string{
+		"symbol":        inst.Symbol,
+		"lastPrice":     str(t.last),
+		"prevPrice24h":  str(t.prev24h),
+		"price24hPcnt":  strconv.FormatFloat(change, 'f', 6, 64),
+		"highPrice24h":  str(math.Max(t.last, t.prev24h)),
+		"lowPrice24h":   str(math.Min(t.last, t.prev24h)),
+		"turnover24h":   str(round(t.last*1000, 0.0001)),
+		"volume24h":     "1000",
+		"bid1Price":     str(bid),
+		"bid1Size":      str(bidSize),
+		"ask1Price":     str(ask),
+		"ask1Size":      str(askSize),
+		"usdIndexPrice": str(t.index),
+	}
+	if inst.Category == bybitapi.CategoryLinear {
+		view["markPrice"] = str(t.mark)
+		view["indexPrice"] = str(t.index)
+		view["prevPrice1h"] = str(t.prev24h)
+		view["openInterest"] = "50000"
+		view["openInterestValue"] = str(round(t.mark*50000, 0.01))
+		view["fundingRate"] = str(t.fundingRate)
+		view["nextFundingTime"] = strconv.FormatInt(t.nextFundingTime, 10)
+		view["predictedDeliveryPrice"] = ""
+		view["basisRate"] = ""
+		view["deliveryFeeRate"] = ""
+		view["deliveryTime"] = "0"
+	}
+	return view
+}
+
+// 订单簿，与market/orderbook一致
+func (s *Server) orderbookInfo(category, symbol string, limit int) *model.Response {
+	if !validCategory(category) {
+		return reject(retParamsError, "Illegal category")
+	}
+	maxLimit, defaultLimit := 500, 25
+	if category == bybitapi.CategorySpot {
+		maxLimit, defaultLimit = 200, 1
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		return reject(retParamsError, "params error: limit must be between 1 and %d", maxLimit)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book, ok := s.books[category+"/"+symbol]
+	if !ok {
+		return reject(retParamsError, "params error: Symbol Invalid")
+	}
+	now := time.Now().UnixMilli()
+	return success(map[string]interface{}{
+		"s":   symbol,
+		"b":   levelsView(book.bids, limit),
+		"a":   levelsView(book.asks, limit),
+		"ts":  now,
+		"u":   now,
+		"seq": now,
+		"cts": now,
+	})
+}
+
+// 订单簿前limit档
+func levelsView(levels [][2]float64, limit int) [][]string {
+	rows := [][]string{}
+	for i, l := range levels {
+		if i >= limit {
+			break
+		}
+		rows = append(rows, []string{str(l[0]), str(l[1])})
+	}
+	return rows
+}
+
+// K线周期
+var intervals = map[string]time.Duration{
+	"1":   time.Minute,
+	"3":   3 * time.Minute,
+	"5":   5 * time.Minute,
+	"15":  15 * time.Minute,
+	"30":  30 * time.Minute,
+	"60":  time.Hour,
+	"120": 2 * time.Hour,
+	"240": 4 * time.Hour,
+	"360": 6 * time.Hour,
+	"720": 12 * time.Hour,
+	"D":   24 * time.Hour,
+	"W":   7 * 24 * time.Hour,
+	"M":   30 * 24 * time.Hour,
+}
+
+// K线，与market/kline一致，按开始时间从新到旧排列
+func (s *Server) klineInfo(category, symbol, interval string, limit int, start, end int64) *model.Response {
+	if !validCategory(category) {
+		return reject(retParamsError, "Illegal category")
+	}
+	period, ok := intervals[interval]
+	if !ok {
+		return reject(retParamsError, "Invalid period!")
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	if limit > 1000 {
+		return reject(retParamsError, "params error: limit must be between 1 and 1000")
+	}
+	if end <= 0 {
+		end = time.Now().UnixMilli()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.instruments[category+"/"+symbol]; !ok {
+		return reject(retParamsError, "params error: Symbol Invalid")
+	}
+
+	rows, ok := s.klines[category+"/"+symbol+"/"+interval]
+	if !ok {
+		rows = generateKlines(s.ticker(category, symbol).last, period, end, limit)
+	}
+	list := [][]string{}
+	for i := len(rows) - 1; i >= 0 && len(list) < limit; i-- {
+		k := rows[i]
+		if k.Start > end || (start > 0 && k.Start < start) {
+			continue
+		}
+		list = append(list, []string{
+			strconv.FormatInt(k.Start, 10), str(k.Open), str(k.High), str(k.Low), str(k.Close), str(k.Volume), str(k.Turnover),
+		})
+	}
+	return success(map[string]interface{}{"category": category, "symbol": symbol, "list": list})
+}
+
+// 生成截止到end、收盘价围绕last小幅波动的K线，最后一根收盘价等于last
+func generateKlines(last float64, period time.Duration, end int64, count int) []Kline {
+	step := period.Milliseconds()
+	latest := end - end%step
+	rows := make([]Kline, count)
+	for i := 0; i < count; i++ {
+		// 距离最新一根的根数
+		age := float64(count - 1 - i)
+		closePrice := last * (1 + 0.005*math.Sin(age/5))
+		openPrice := last * (1 + 0.005*math.Sin((age+1)/5))
+		rows[i] = Kline{
+			Start:    latest - int64(count-1-i)*step,
+			Open:     round(openPrice, 0.01),
+			High:     round(math.Max(openPrice, closePrice)*1.001, 0.01),
+			Low:      round(math.Min(openPrice, closePrice)*0.999, 0.01),
+			Close:    round(closePrice, 0.01),
+			Volume:   100,
+			Turnover: round(closePrice*100, 0.01),
+		}
+	}
+	return rows
+}
+
+// 最近成交，与market/recent-trade一致
+func (s *Server) recentTrades(category, symbol string, limit int) *model.Response {
+	if !validCategory(category) {
+		return reject(retParamsError, "Illegal category")
+	}
+	maxLimit, defaultLimit := 1000, 500
+	if category == bybitapi.CategorySpot {
+		maxLimit, defaultLimit = 60, 60
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		return reject(retParamsError, "params error: limit must be between 1 and %d", maxLimit)
+	}
+
+	s.mu.Lock()
+	t, ok := s.tickers[category+"/"+symbol]
+	s.mu.Unlock()
+	if !ok {
+		return reject(retParamsError, "params error: Symbol Invalid")
+	}
+
+	now := time.Now().UnixMilli()
+	list := []map[string]interface{}{}
+	for i := 0; i < limit; i++ {
+		side := "Buy"
+		if i%2 == 1 {
+			side = "Sell"
+		}
+		list = append(list, map[string]interface{}{
+			"execId":       strconv.FormatInt(now*1000+int64(i), 10),
+			"symbol":       symbol,
+			"price":        str(t.last),
+			"size":         "0.01",
+			"side":         side,
+			"time":         strconv.FormatInt(now-int64(i)*1000, 10),
+			"isBlockTrade": false,
+		})
+	}
+	return success(map[string]interface{}{"category": category, "list": list})
+}
+
+// marketSource 把模拟行情提供给撮合引擎
+type marketSource struct {
+	s *Server
+}
+
+// GetKline 获取模拟K线
+func (m marketSource) GetKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	return m.s.klineInfo(category, symbol, interval, limit, start, end), nil
+}
+
+// GetOrderbook 获取模拟订单簿
+func (m marketSource) GetOrderbook(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	return m.s.orderbookInfo(category, symbol, limit), nil
+}
+
+// GetTickers 获取模拟行情
+func (m marketSource) GetTickers(ctx context.Context, category, symbol string) (*model.Response, error) {
+	return m.s.tickersInfo(category, symbol), nil
+}
+
+// GetInstruments 获取模拟交易对
+func (m marketSource) GetInstruments(ctx context.Context, category, symbol, status string) (*model.Response, error) {
+	return m.s.instrumentsInfo(category, symbol, status), nil
+}
@@ -0,0 +1,371 @@
+// Package bybitmock 提供模拟Bybit V5 REST接口的HTTP服务器，用于集成测试和本地调试
+// 服务器按Bybit的规则校验签名，行情由测试设置，订单、仓位和账户接口由paper包模拟撮合，
+// 资产和子账户接口只在内存中记录，并且可以为任意接口注入错误、延迟和断连
+package bybitmock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/paper"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/errors"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 默认的API密钥、母账户UID和初始资金
+const (
+	DefaultAPIKey    = "mock-api-key"
+	DefaultAPISecret = "mock-api-secret"
+	MasterUID        = 100000 // 母账户UID，子账户的UID从下一个数开始
+	DefaultBalance   = 100000 // 初始USDT余额
+)
+
+// 与Bybit一致的返回码
+const (
+	retOK               = 0
+	retParamsError      = 10001
+	retTimestampError   = 10002
+	retInvalidAPIKey    = 10003
+	retSignError        = 10004
+	retPermissionDenied = 10005
+	retServerError      = 10016
+)
+
+// Request 是服务器收到的一次请求，用于在测试中断言客户端发送的参数
+type Request struct {
+	Method   string            // HTTP方法
+	Endpoint string            // 去掉/v5/前缀的路径，例如order/create
+	Params   map[string]string // 查询参数或请求体中的标量字段
+	APIKey   string            // X-BAPI-API-KEY请求头
+	Time     time.Time         // 收到请求的时间
+}
+
+// 解析后的请求
+type request struct {
+	method   string
+	endpoint string
+	params   map[string]string
+	body     []byte
+	apiKey   string
+}
+
+// 接口处理函数
+type handler func(ctx context.Context, req *request) *model.Response
+
+// 接口路由
+type route struct {
+	method  string
+	private bool // 是否需要签名
+	handle  handler
+}
+
+// Server 是模拟的Bybit V5 REST服务器
+type Server struct {
+	logger   *logger.Logger
+	exchange *paper.Service
+	routes   map[string]route
+	srv      *httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]string // API密钥到密文
+	instruments map[string]*Instrument
+	tickers     map[string]*ticker
+	books       map[string]*orderbook
+	klines      map[string][]Kline
+	faults      map[string]*Fault
+	latency     time.Duration
+	requests    []Request
+	transfers   []transferRecord
+	universal   []transferRecord
+	withdrawals []withdrawRecord
+	subMembers  []*subMember
+	subKeys     []*subAPIKey
+	nextUID     int64
+}
+
+// New 创建模拟服务器，apiKey和apiSecret是接受的密钥，服务器需要调用Start启动或作为http.Handler使用
+// 服务器预置了BTCUSDT和ETHUSDT的现货和永续合约，以及DefaultBalance的USDT余额
+func New(apiKey, apiSecret string) *Server {
+	log := logger.New(logger.InfoLevel, "stdout")
+	s := &Server{
+		logger:      log,
+		keys:        map[string]string{apiKey: apiSecret},
+		instruments: map[string]*Instrument{},
+		tickers:     map[string]*ticker{},
+		books:       map[string]*orderbook{},
+		klines:      map[string][]Kline{},
+		faults:      map[string]*Fault{},
+		nextUID:     MasterUID,
+	}
+	s.exchange = paper.New(marketSource{s}, config.PaperConfig{
+		InitialBalance: map[string]float64{"USDT": DefaultBalance},
+		MakerFeeRate:   0.0002,
+		TakerFeeRate:   0.00055,
+	}, log)
+	s.routes = s.buildRoutes()
+
+	for _, inst := range defaultInstruments() {
+		s.AddInstrument(inst)
+	}
+	s.SetPrice(bybitapi.CategorySpot, "BTCUSDT", 65000)
+	s.SetPrice(bybitapi.CategoryLinear, "BTCUSDT", 65000)
+	s.SetPrice(bybitapi.CategorySpot, "ETHUSDT", 3000)
+	s.SetPrice(bybitapi.CategoryLinear, "ETHUSDT", 3000)
+	return s
+}
+
+// Start 在本地随机端口启动服务器，返回服务器地址
+func (s *Server) Start() string {
+	s.srv = httptest.NewServer(s)
+	return s.srv.URL
+}
+
+// Close 关闭服务器
+func (s *Server) Close() {
+	if s.srv != nil {
+		s.srv.Close()
+	}
+}
+
+// URL 返回服务器地址，服务器未启动时为空
+func (s *Server) URL() string {
+	if s.srv == nil {
+		return ""
+	}
+	return s.srv.URL
+}
+
+// Environment 返回指向服务器的接入环境，可以直接传给bybitapi.Client.SetEnvironment
+func (s *Server) Environment() bybitapi.Environment {
+	return bybitapi.Environment{Name: bybitapi.EnvCustom, RESTURL: s.URL()}
+}
+
+// AddKey 添加一组可以访问私有接口的API密钥
+func (s *Server) AddKey(apiKey, apiSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[apiKey] = apiSecret
+}
+
+// SetBalance 设置模拟账户的钱包余额
+func (s *Server) SetBalance(coin string, amount float64) {
+	s.exchange.SetBalance(coin, amount)
+}
+
+// Requests 返回收到的全部请求，按接收顺序
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP 处理/v5/下的请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/"+bybitapi.APIVersion+"/") {
+		http.NotFound(w, r)
+		return
+	}
+	req, err := parseRequest(r)
+	if err != nil {
+		s.write(w, reject(retParamsError, "%v", err))
+		return
+	}
+	s.record(req)
+
+	if !s.delay(r.Context(), s.currentLatency()) {
+		return
+	}
+	if fault := s.takeFault(req.endpoint); fault != nil {
+		s.applyFault(w, r, fault)
+		return
+	}
+
+	rt, ok := s.routes[req.endpoint]
+	if !ok || rt.method != req.method {
+		http.NotFound(w, r)
+		return
+	}
+	if rt.private {
+		if resp := s.authenticate(r, req); resp != nil {
+			s.write(w, resp)
+			return
+		}
+	}
+	s.write(w, rt.handle(r.Context(), req))
+}
+
+// 读取请求参数，GET请求取查询参数，POST请求取JSON请求体
+func parseRequest(r *http.Request) (*request, error) {
+	req := &request{
+		method:   r.Method,
+		endpoint: strings.TrimPrefix(r.URL.Path, "/"+bybitapi.APIVersion+"/"),
+		params:   map[string]string{},
+		apiKey:   r.Header.Get("X-BAPI-API-KEY"),
+	}
+
+	if r.Method == http.MethodGet {
+		for k, v := range r.URL.Query() {
+			req.params[k] = v[0]
+		}
+		return req, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %v", err)
+	}
+	req.body = body
+	if len(body) == 0 {
+		return req, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("request body is not valid json")
+	}
+	for k, v := range fields {
+		switch v := v.(type) {
+		case string:
+			req.params[k] = v
+		case float64, bool:
+			req.params[k] = fmt.Sprint(v)
+		}
+	}
+	return req, nil
+}
+
+// 记录请求
+func (s *Server) record(req *request) {
+	params := make(map[string]string, len(req.params))
+	for k, v := range req.params {
+		params[k] = v
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{
+		Method:   req.method,
+		Endpoint: req.endpoint,
+		Params:   params,
+		APIKey:   req.apiKey,
+		Time:     time.Now(),
+	})
+}
+
+// 写出JSON响应
+func (s *Server) write(w http.ResponseWriter, resp *model.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Warn("写出模拟响应失败: %v", err)
+	}
+}
+
+// 构造成功响应
+func success(result interface{}) *model.Response {
+	return &model.Response{
+		RetCode:    retOK,
+		RetMsg:     "OK",
+		Result:     result,
+		RetExtInfo: map[string]interface{}{},
+		Time:       time.Now().UnixMilli(),
+	}
+}
+
+// 构造错误响应
+func reject(code int, format string, args ...interface{}) *model.Response {
+	return &model.Response{
+		RetCode:    code,
+		RetMsg:     fmt.Sprintf(format, args...),
+		Result:     map[string]interface{}{},
+		RetExtInfo: map[string]interface{}{},
+		Time:       time.Now().UnixMilli(),
+	}
+}
+
+// 把撮合引擎的返回值转换为响应
+func reply(resp *model.Response, err error) *model.Response {
+	if err == nil {
+		return resp
+	}
+	if e, ok := err.(*errors.Error); ok && e.Code == errors.ErrPermissionDenied {
+		return reject(retPermissionDenied, "%s", e.Message)
+	}
+	return reject(retServerError, "%v", err)
+}
+
+// 检查必填参数，缺少时返回错误响应
+func require(req *request, names ...string) *model.Response {
+	for _, name := range names {
+		if req.params[name] == "" {
+			return reject(retParamsError, "params error: %s is required", name)
+		}
+	}
+	return nil
+}
+
+// 接口路由表
+func (s *Server) buildRoutes() map[string]route {
+	get := func(h handler) route { return route{method: http.MethodGet, private: true, handle: h} }
+	post := func(h handler) route { return route{method: http.MethodPost, private: true, handle: h} }
+	public := func(h handler) route { return route{method: http.MethodGet, handle: h} }
+
+	return map[string]route{
+		// 行情
+		"market/kline":            public(s.handleKline),
+		"market/orderbook":        public(s.handleOrderbook),
+		"market/tickers":          public(s.handleTickers),
+		"market/instruments-info": public(s.handleInstruments),
+		"market/recent-trade":     public(s.handleRecentTrades),
+
+		// 订单
+		"order/create":     post(s.handleCreateOrder),
+		"order/amend":      post(s.handleAmendOrder),
+		"order/cancel":     post(s.handleCancelOrder),
+		"order/cancel-all": post(s.handleCancelAll),
+		"order/realtime":   get(s.handleOpenOrders),
+		"order/history":    get(s.handleOrderHistory),
+
+		// 仓位
+		"position/list":           get(s.handlePositions),
+		"position/set-leverage":   post(s.handleSetLeverage),
+		"position/trading-stop":   post(s.handleTradingStop),
+		"position/switch-mode":    post(s.handleSwitchMode),
+		"position/set-risk-limit": post(s.handleSetRiskLimit),
+		"position/set-tpsl-mode":  post(s.handleSetTpSlMode),
+		"position/closed-pnl":     get(s.handleClosedPnl),
+		"execution/list":          get(s.handleExecutions),
+
+		// 账户
+		"account/wallet-balance":  get(s.handleWalletBalance),
+		"account/fee-rate":        get(s.handleFeeRate),
+		"account/info":            get(s.handleAccountInfo),
+		"account/set-margin-mode": post(s.handleSetMarginMode),
+
+		// 资产
+		"asset/transfer/query-asset-info":              get(s.handleAssetInfo),
+		"asset/transfer/inter-transfer":                post(s.handleInterTransfer),
+		"asset/transfer/query-inter-transfer-list":     get(s.handleInterTransferList),
+		"asset/transfer/universal-transfer":            post(s.handleUniversalTransfer),
+		"asset/transfer/query-universal-transfer-list": get(s.handleUniversalTransferList),
+		"asset/withdraw/create":                        post(s.handleWithdraw),
+		"asset/withdraw/query-record":                  get(s.handleWithdrawList),
+		"asset/deposit/query-record":                   get(s.handleDepositList),
+
+		// 子账户
+		"user/create-sub-member": post(s.handleCreateSubMember),
+		"user/query-sub-members": get(s.handleListSubMembers),
+		"user/create-sub-api":    post(s.handleCreateSubAPIKey),
+		"user/sub-apikeys":       get(s.handleListSubAPIKeys),
+		"user/delete-sub-api":    post(s.handleDeleteSubAPIKey),
+		"user/frozen-sub-member": post(s.handleFreezeSubMember),
+		"user/query-api":         get(s.handleQueryAPI),
+	}
+}
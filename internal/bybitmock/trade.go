@@ -0,0 +1,459 @@
+package bybitmock
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 解析数字参数，参数为空时返回0，格式错误时返回false
+func (r *request) number(name string) (float64, bool) {
+	v := r.params[name]
+	if v == "" {
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+// 解析整数参数，参数为空或格式错误时返回0
+func (r *request) integer(name string) int64 {
+	v, _ := strconv.ParseInt(r.params[name], 10, 64)
+	return v
+}
+
+// 除names以外的参数，作为撮合引擎的options
+func (r *request) options(names ...string) map[string]string {
+	skip := map[string]bool{}
+	for _, name := range names {
+		skip[name] = true
+	}
+	options := map[string]string{}
+	for k, v := range r.params {
+		if !skip[k] {
+			options[k] = v
+		}
+	}
+	return options
+}
+
+// 解析qty和price
+func (r *request) qtyPrice() (qty, price float64, resp *model.Response) {
+	qty, ok := r.number("qty")
+	if !ok {
+		return 0, 0, reject(retParamsError, "params error: qty invalid")
+	}
+	price, ok = r.number("price")
+	if !ok {
+		return 0, 0, reject(retParamsError, "params error: price invalid")
+	}
+	return qty, price, nil
+}
+
+// ==================== 行情接口 ====================
+
+// 处理market/kline
+func (s *Server) handleKline(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol", "interval"); resp != nil {
+		return resp
+	}
+	p := req.params
+	return s.klineInfo(p["category"], p["symbol"], p["interval"], int(req.integer("limit")), req.integer("start"), req.integer("end"))
+}
+
+// 处理market/orderbook
+func (s *Server) handleOrderbook(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol"); resp != nil {
+		return resp
+	}
+	return s.orderbookInfo(req.params["category"], req.params["symbol"], int(req.integer("limit")))
+}
+
+// 处理market/tickers
+func (s *Server) handleTickers(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	return s.tickersInfo(req.params["category"], req.params["symbol"])
+}
+
+// 处理market/instruments-info
+func (s *Server) handleInstruments(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	return s.instrumentsInfo(req.params["category"], req.params["symbol"], req.params["status"])
+}
+
+// 处理market/recent-trade
+func (s *Server) handleRecentTrades(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol"); resp != nil {
+		return resp
+	}
+	return s.recentTrades(req.params["category"], req.params["symbol"], int(req.integer("limit")))
+}
+
+// ==================== 订单接口 ====================
+
+// 处理order/create
+func (s *Server) handleCreateOrder(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol", "side", "orderType", "qty"); resp != nil {
+		return resp
+	}
+	qty, price, resp := req.qtyPrice()
+	if resp != nil {
+		return resp
+	}
+	p := req.params
+	options := req.options("category", "symbol", "side", "orderType", "qty", "price")
+	return reply(s.exchange.CreateOrder(ctx, p["category"], p["symbol"], p["side"], p["orderType"], qty, price, options))
+}
+
+// 处理order/amend
+func (s *Server) handleAmendOrder(ctx context.Context, req *request) *model.Response {
+	if resp := requireOrderId(req); resp != nil {
+		return resp
+	}
+	qty, price, resp := req.qtyPrice()
+	if resp != nil {
+		return resp
+	}
+	p := req.params
+	options := req.options("category", "symbol", "orderId", "orderLinkId", "qty", "price")
+	return reply(s.exchange.AmendOrder(ctx, p["category"], p["symbol"], p["orderId"], p["orderLinkId"], qty, price, options))
+}
+
+// 处理order/cancel
+func (s *Server) handleCancelOrder(ctx context.Context, req *request) *model.Response {
+	if resp := requireOrderId(req); resp != nil {
+		return resp
+	}
+	p := req.params
+	return reply(s.exchange.CancelOrder(ctx, p["category"], p["symbol"], p["orderId"], p["orderLinkId"]))
+}
+
+// 检查改单和撤单的必填参数
+func requireOrderId(req *request) *model.Response {
+	if resp := require(req, "category", "symbol"); resp != nil {
+		return resp
+	}
+	if req.params["orderId"] == "" && req.params["orderLinkId"] == "" {
+		return reject(retParamsError, "params error: orderId or orderLinkId is required")
+	}
+	return nil
+}
+
+// 处理order/cancel-all
+func (s *Server) handleCancelAll(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	p := req.params
+	if p["category"] == bybitapi.CategoryLinear && p["symbol"] == "" && p["baseCoin"] == "" && p["settleCoin"] == "" {
+		return reject(retParamsError, "params error: symbol, baseCoin or settleCoin is required")
+	}
+
+	orders, err := s.exchange.GetAllOpenOrders(ctx, orderQuery(req))
+	if err != nil {
+		return reply(nil, err)
+	}
+	list := []map[string]string{}
+	for _, o := range orders.List {
+		resp, err := s.exchange.CancelOrder(ctx, p["category"], o.Symbol, o.OrderId, "")
+		if err != nil || resp.RetCode != retOK {
+			continue
+		}
+		list = append(list, map[string]string{"orderId": o.OrderId, "orderLinkId": o.OrderLinkId})
+	}
+	return success(map[string]interface{}{"list": list, "success": "1"})
+}
+
+// 处理order/realtime
+func (s *Server) handleOpenOrders(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	return reply(s.exchange.GetOpenOrders(ctx, orderQuery(req)))
+}
+
+// 处理order/history
+func (s *Server) handleOrderHistory(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	return reply(s.exchange.GetOrderHistory(ctx, orderQuery(req)))
+}
+
+// 订单查询条件
+func orderQuery(req *request) *model.OrderQuery {
+	p := req.params
+	return &model.OrderQuery{
+		Category:    p["category"],
+		Symbol:      p["symbol"],
+		BaseCoin:    p["baseCoin"],
+		SettleCoin:  p["settleCoin"],
+		OrderId:     p["orderId"],
+		OrderLinkId: p["orderLinkId"],
+		OrderFilter: p["orderFilter"],
+		OrderStatus: p["orderStatus"],
+		StartTime:   req.integer("startTime"),
+		EndTime:     req.integer("endTime"),
+		Limit:       int(req.integer("limit")),
+		Cursor:      p["cursor"],
+	}
+}
+
+// ==================== 仓位接口 ====================
+
+// 处理position/list
+func (s *Server) handlePositions(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	p := req.params
+	if p["category"] == bybitapi.CategoryLinear && p["symbol"] == "" && p["settleCoin"] == "" {
+		return reject(retParamsError, "Missing some parameters that must be filled in, symbol or settleCoin")
+	}
+	return reply(s.exchange.GetPositions(ctx, p["category"], p["symbol"], p["settleCoin"], p["positionIdx"]))
+}
+
+// 处理position/set-leverage
+func (s *Server) handleSetLeverage(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol", "buyLeverage", "sellLeverage"); resp != nil {
+		return resp
+	}
+	buy, ok1 := req.number("buyLeverage")
+	sell, ok2 := req.number("sellLeverage")
+	if !ok1 || !ok2 {
+		return reject(retParamsError, "params error: leverage invalid")
+	}
+	return reply(s.exchange.SetLeverage(ctx, req.params["category"], req.params["symbol"], buy, sell))
+}
+
+// 处理position/trading-stop
+func (s *Server) handleTradingStop(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol"); resp != nil {
+		return resp
+	}
+	takeProfit, ok1 := req.number("takeProfit")
+	stopLoss, ok2 := req.number("stopLoss")
+	if !ok1 || !ok2 {
+		return reject(retParamsError, "params error: takeProfit or stopLoss invalid")
+	}
+	options := req.options("category", "symbol")
+	return reply(s.exchange.SetTradingStop(ctx, req.params["category"], req.params["symbol"], takeProfit, stopLoss, options))
+}
+
+// 处理position/switch-mode
+func (s *Server) handleSwitchMode(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "mode"); resp != nil {
+		return resp
+	}
+	return reply(s.exchange.SwitchPositionMode(ctx, req.params["category"], req.params["symbol"], req.params["mode"]))
+}
+
+// 模拟账户不区分风险限额，直接返回设置的值
+func (s *Server) handleSetRiskLimit(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol", "riskId"); resp != nil {
+		return resp
+	}
+	return success(map[string]interface{}{
+		"category":       req.params["category"],
+		"riskId":         req.integer("riskId"),
+		"riskLimitValue": "2000000",
+	})
+}
+
+// 处理position/set-tpsl-mode
+func (s *Server) handleSetTpSlMode(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category", "symbol", "tpSlMode"); resp != nil {
+		return resp
+	}
+	mode := req.params["tpSlMode"]
+	if mode != "Full" && mode != "Partial" {
+		return reject(retParamsError, "params error: tpSlMode invalid")
+	}
+	return success(map[string]string{"tpSlMode": mode})
+}
+
+// Bybit格式的成交记录
+type executionView struct {
+	Symbol          string `json:"symbol"`
+	OrderId         string `json:"orderId"`
+	OrderLinkId     string `json:"orderLinkId"`
+	Side            string `json:"side"`
+	OrderPrice      string `json:"orderPrice"`
+	OrderQty        string `json:"orderQty"`
+	LeavesQty       string `json:"leavesQty"`
+	OrderType       string `json:"orderType"`
+	StopOrderType   string `json:"stopOrderType"`
+	ExecFee         string `json:"execFee"`
+	ExecId          string `json:"execId"`
+	ExecPrice       string `json:"execPrice"`
+	ExecQty         string `json:"execQty"`
+	ExecType        string `json:"execType"`
+	ExecValue       string `json:"execValue"`
+	ExecTime        string `json:"execTime"`
+	FeeCurrency     string `json:"feeCurrency"`
+	IsMaker         bool   `json:"isMaker"`
+	FeeRate         string `json:"feeRate"`
+	TradeIv         string `json:"tradeIv"`
+	MarkIv          string `json:"markIv"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	UnderlyingPrice string `json:"underlyingPrice"`
+	BlockTradeId    string `json:"blockTradeId"`
+	ClosedSize      string `json:"closedSize"`
+	Seq             int64  `json:"seq"`
+}
+
+// 处理execution/list
+func (s *Server) handleExecutions(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	p := req.params
+	executions, err := s.exchange.GetExecutions(ctx, &model.ExecutionQuery{
+		Category:    p["category"],
+		Symbol:      p["symbol"],
+		BaseCoin:    p["baseCoin"],
+		OrderId:     p["orderId"],
+		OrderLinkId: p["orderLinkId"],
+		ExecType:    p["execType"],
+		StartTime:   req.integer("startTime"),
+		EndTime:     req.integer("endTime"),
+		Limit:       int(req.integer("limit")),
+		Cursor:      p["cursor"],
+	})
+	if err != nil {
+		return reply(nil, err)
+	}
+
+	list := make([]executionView, len(executions.List))
+	for i, e := range executions.List {
+		list[i] = executionView{
+			Symbol:          e.Symbol,
+			OrderId:         e.OrderId,
+			OrderLinkId:     e.OrderLinkId,
+			Side:            e.Side,
+			OrderPrice:      str(e.OrderPrice),
+			OrderQty:        str(e.OrderQty),
+			LeavesQty:       "0",
+			OrderType:       e.OrderType,
+			ExecFee:         str(e.ExecFee),
+			ExecId:          e.ExecId,
+			ExecPrice:       str(e.ExecPrice),
+			ExecQty:         str(e.ExecQty),
+			ExecType:        e.ExecType,
+			ExecValue:       str(e.ExecValue),
+			ExecTime:        strconv.FormatInt(e.ExecTime, 10),
+			FeeCurrency:     e.FeeCurrency,
+			IsMaker:         e.IsMaker,
+			FeeRate:         str(e.FeeRate),
+			MarkPrice:       str(e.MarkPrice),
+			IndexPrice:      str(e.IndexPrice),
+			UnderlyingPrice: str(e.UnderlyingPrice),
+			ClosedSize:      str(e.ClosedSize),
+			Seq:             e.ExecTime,
+		}
+		// 合约成交不返回feeCurrency
+		if p["category"] != bybitapi.CategorySpot {
+			list[i].FeeCurrency = ""
+		}
+	}
+	return success(map[string]interface{}{"category": p["category"], "list": list, "nextPageCursor": executions.NextPageCursor})
+}
+
+// Bybit格式的平仓盈亏
+type closedPnlView struct {
+	Symbol        string `json:"symbol"`
+	OrderType     string `json:"orderType"`
+	Leverage      string `json:"leverage"`
+	UpdatedTime   string `json:"updatedTime"`
+	Side          string `json:"side"`
+	OrderId       string `json:"orderId"`
+	ClosedPnl     string `json:"closedPnl"`
+	AvgEntryPrice string `json:"avgEntryPrice"`
+	Qty           string `json:"qty"`
+	CumEntryValue string `json:"cumEntryValue"`
+	CreatedTime   string `json:"createdTime"`
+	OrderPrice    string `json:"orderPrice"`
+	ClosedSize    string `json:"closedSize"`
+	AvgExitPrice  string `json:"avgExitPrice"`
+	ExecType      string `json:"execType"`
+	FillCount     string `json:"fillCount"`
+	CumExitValue  string `json:"cumExitValue"`
+}
+
+// 处理position/closed-pnl
+func (s *Server) handleClosedPnl(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "category"); resp != nil {
+		return resp
+	}
+	p := req.params
+	records, err := s.exchange.GetClosedPnl(ctx, &model.ClosedPnlQuery{
+		Category:  p["category"],
+		Symbol:    p["symbol"],
+		StartTime: req.integer("startTime"),
+		EndTime:   req.integer("endTime"),
+		Limit:     int(req.integer("limit")),
+		Cursor:    p["cursor"],
+	})
+	if err != nil {
+		return reply(nil, err)
+	}
+
+	list := make([]closedPnlView, len(records.List))
+	for i, r := range records.List {
+		list[i] = closedPnlView{
+			Symbol:        r.Symbol,
+			OrderType:     r.OrderType,
+			Leverage:      str(r.Leverage),
+			UpdatedTime:   strconv.FormatInt(r.UpdatedTime, 10),
+			Side:          r.Side,
+			OrderId:       r.OrderId,
+			ClosedPnl:     str(r.ClosedPnl),
+			AvgEntryPrice: str(r.AvgEntryPrice),
+			Qty:           str(r.Qty),
+			CumEntryValue: str(r.CumEntryValue),
+			CreatedTime:   strconv.FormatInt(r.CreatedTime, 10),
+			OrderPrice:    str(r.OrderPrice),
+			ClosedSize:    str(r.ClosedSize),
+			AvgExitPrice:  str(r.AvgExitPrice),
+			ExecType:      r.ExecType,
+			FillCount:     strconv.Itoa(r.FillCount),
+			CumExitValue:  str(r.CumExitValue),
+		}
+	}
+	return success(map[string]interface{}{"category": p["category"], "list": list, "nextPageCursor": records.NextPageCursor})
+}
+
+// ==================== 账户接口 ====================
+
+// 处理account/wallet-balance
+func (s *Server) handleWalletBalance(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "accountType"); resp != nil {
+		return resp
+	}
+	return reply(s.exchange.GetWalletBalance(ctx, req.params["accountType"], req.params["coin"]))
+}
+
+// 处理account/fee-rate
+func (s *Server) handleFeeRate(ctx context.Context, req *request) *model.Response {
+	return reply(s.exchange.GetFeeRate(ctx, req.params["category"], req.params["symbol"]))
+}
+
+// 处理account/info
+func (s *Server) handleAccountInfo(ctx context.Context, req *request) *model.Response {
+	return reply(s.exchange.GetAccountInfo(ctx))
+}
+
+// 处理account/set-margin-mode
+func (s *Server) handleSetMarginMode(ctx context.Context, req *request) *model.Response {
+	if resp := require(req, "setMarginMode"); resp != nil {
+		return resp
+	}
+	return reply(s.exchange.SetMarginMode(ctx, req.params["setMarginMode"]))
+}
@@ -0,0 +1,490 @@
+// Package carry 比较永续和交割合约与现货的价格，计算资金费率套利和期现套利的年化收益
+//
+// 永续合约按当前资金费率（下次结算的预测值）持续不变计算年化资金费率，
+// 资金费率为正时做多现货、做空合约收取资金费用，为负时反向操作；
+// 交割合约按当前基差在交割时收敛为0计算年化基差收益。
+// 排序使用扣除开仓和平仓手续费之后的年化收益，不包括现货借币利息、滑点和基差变化。
+package carry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 单次查询返回机会的默认和最大数量
+const (
+	defaultLimit = 20
+	maxLimit     = 200
+)
+
+// 默认的持有天数，用于把永续合约的往返手续费摊到年化收益中
+const defaultHoldDays = 30
+
+// 资金费率结算间隔未知时使用的小时数
+const defaultFundingHours = 8
+
+// 合约的资金费率结算间隔缓存时间
+const intervalTTL = time.Hour
+
+// 无法获取账户手续费率时使用的默认费率（非VIP）
+var defaultFees = map[string]FeeRate{
+	bybitapi.CategorySpot:    {Maker: 0.001, Taker: 0.001, Source: "default"},
+	bybitapi.CategoryLinear:  {Maker: 0.0002, Taker: 0.00055, Source: "default"},
+	bybitapi.CategoryInverse: {Maker: 0.0002, Taker: 0.00055, Source: "default"},
+}
+
+// 套利方向
+const (
+	LongSpotShortContract = "long_spot_short_contract" // 做多现货、做空合约
+	ShortSpotLongContract = "short_spot_long_contract" // 做空现货（需要借币）、做多合约
+)
+
+// 合约类型
+const (
+	KindPerpetual = "perpetual"
+	KindFuture    = "future"
+)
+
+// Query 是套利机会的查询条件
+type Query struct {
+	Categories  []string // linear和inverse，为空时两者都包括
+	BaseCoin    string   // 只包括该币种的合约
+	HoldDays    float64  // 永续合约的持有天数，默认30天
+	MinTurnover float64  // 合约24小时成交额（USD）下限
+	Maker       bool     // 按挂单手续费计算，默认按吃单手续费
+	Limit       int      // 默认20，最多200
+}
+
+// FeeRate 是一个产品类别的手续费率
+type FeeRate struct {
+	Maker  float64 `json:"maker"`
+	Taker  float64 `json:"taker"`
+	Source string  `json:"source"` // account表示账户的实际费率，default表示无法获取时使用的默认费率
+}
+
+// Opportunity 是一个合约和对应现货之间的套利机会，比率都是小数
+type Opportunity struct {
+	Symbol     string `json:"symbol"`
+	Category   string `json:"category"`
+	Kind       string `json:"kind"` // perpetual或future
+	BaseCoin   string `json:"baseCoin"`
+	SpotSymbol string `json:"spotSymbol"`
+	Direction  string `json:"direction"`
+
+	ContractPrice float64 `json:"contractPrice"` // 合约标记价格
+	SpotPrice     float64 `json:"spotPrice"`     // 现货最新价，已按合约的倍数前缀换算
+	Basis         float64 `json:"basis"`         // 合约价格减现货价格
+	BasisRate     float64 `json:"basisRate"`     // 基差相对现货价格的比例
+
+	FundingRate          float64 `json:"fundingRate,omitempty"`          // 下次结算的预测资金费率
+	FundingIntervalHours float64 `json:"fundingIntervalHours,omitempty"` // 资金费率结算间隔（小时）
+	NextFundingTime      int64   `json:"nextFundingTime,omitempty"`      // 下次结算时间（毫秒）
+	AnnualisedFunding    float64 `json:"annualisedFunding,omitempty"`    // 年化资金费率
+
+	DeliveryTime    int64   `json:"deliveryTime,omitempty"`    // 交割时间（毫秒）
+	DaysToDelivery  float64 `json:"daysToDelivery,omitempty"`  // 距交割的天数
+	AnnualisedBasis float64 `json:"annualisedBasis,omitempty"` // 年化基差收益
+
+	Fees          float64 `json:"fees"`          // 开仓和平仓（交割合约为交割）的手续费合计占名义价值的比例
+	NetAnnualised float64 `json:"netAnnualised"` // 扣除手续费后的年化收益，用于排序
+	Turnover24h   float64 `json:"turnover24h"`   // 合约24小时成交额（USD）
+}
+
+// PositionFunding 是一个合约仓位下次结算的预计资金费用
+// 正数表示收到，负数表示支付；USDT和USDC合约以结算币计，反向合约以币计
+type PositionFunding struct {
+	Symbol          string  `json:"symbol"`
+	Category        string  `json:"category"`
+	Side            string  `json:"side"`
+	Size            float64 `json:"size"`
+	PositionValue   float64 `json:"positionValue"` // 按标记价格计算的仓位价值（USD）
+	FundingRate     float64 `json:"fundingRate"`
+	NextFundingTime int64   `json:"nextFundingTime"`
+	NextFunding     float64 `json:"nextFunding"`  // 下次结算的预计资金费用
+	DailyFunding    float64 `json:"dailyFunding"` // 按当前资金费率计算的每日资金费用
+}
+
+// Result 是套利机会的查询结果
+type Result struct {
+	Time           int64              `json:"time"`
+	HoldDays       float64            `json:"holdDays"`
+	Fees           map[string]FeeRate `json:"fees"` // 按产品类别的手续费率
+	Scanned        int                `json:"scanned"`
+	Opportunities  []Opportunity      `json:"opportunities"`
+	Positions      []PositionFunding  `json:"positions"`
+	PositionsError string             `json:"positionsError,omitempty"` // 获取仓位失败的原因，例如没有配置API密钥
+}
+
+// 行情中用到的字段，缺失的数值为0
+type ticker struct {
+	symbol          string
+	lastPrice       float64
+	markPrice       float64
+	fundingRate     float64
+	fundingHours    float64
+	nextFundingTime int64
+	deliveryTime    int64
+	deliveryFeeRate float64
+	turnover24h     float64
+	volume24h       float64
+}
+
+// Analyzer 计算资金费率和基差套利机会
+type Analyzer struct {
+	svc service.BybitService
+	now func() time.Time
+
+	mu        sync.Mutex
+	intervals map[string]*fundingIntervals // 账户和产品类别 -> 合约的结算间隔
+}
+
+// 一个产品类别的合约资金费率结算间隔（小时）
+type fundingIntervals struct {
+	fetched time.Time
+	hours   map[string]float64
+}
+
+// NewAnalyzer 创建套利分析器
+func NewAnalyzer(svc service.BybitService) *Analyzer {
+	return &Analyzer{svc: svc, now: time.Now, intervals: map[string]*fundingIntervals{}}
+}
+
+// Validate 检查查询条件
+func Validate(query Query) error {
+	for _, category := range query.Categories {
+		if category != bybitapi.CategoryLinear && category != bybitapi.CategoryInverse {
+			return fmt.Errorf("产品类别只能是linear或inverse")
+		}
+	}
+	if query.HoldDays < 0 || query.MinTurnover < 0 || query.Limit < 0 {
+		return fmt.Errorf("持有天数、成交额下限和数量不能为负数")
+	}
+	return nil
+}
+
+// Analyze 获取现货和合约行情，按扣除手续费后的年化收益排序套利机会，并计算当前仓位的预计资金费用
+func (a *Analyzer) Analyze(ctx context.Context, query Query) (*Result, error) {
+	if err := Validate(query); err != nil {
+		return nil, err
+	}
+	categories := query.Categories
+	if len(categories) == 0 {
+		categories = []string{bybitapi.CategoryLinear, bybitapi.CategoryInverse}
+	}
+	if query.HoldDays == 0 {
+		query.HoldDays = defaultHoldDays
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	} else if query.Limit > maxLimit {
+		query.Limit = maxLimit
+	}
+	baseCoin := strings.ToUpper(query.BaseCoin)
+
+	spots, err := a.tickers(ctx, bybitapi.CategorySpot)
+	if err != nil {
+		return nil, err
+	}
+	now := a.now()
+	result := &Result{
+		Time:          now.UnixMilli(),
+		HoldDays:      query.HoldDays,
+		Fees:          map[string]FeeRate{bybitapi.CategorySpot: a.feeRate(ctx, bybitapi.CategorySpot)},
+		Opportunities: []Opportunity{},
+		Positions:     []PositionFunding{},
+	}
+	spotFee := result.Fees[bybitapi.CategorySpot].rate(query.Maker)
+
+	contracts := map[string]map[string]ticker{}
+	for _, category := range categories {
+		list, err := a.tickers(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+		contracts[category] = list
+		result.Fees[category] = a.feeRate(ctx, category)
+		contractFee := result.Fees[category].rate(query.Maker)
+
+		for _, t := range list {
+			match, ok := matchSpot(category, t.symbol)
+			if !ok || (baseCoin != "" && !strings.EqualFold(match.baseCoin, baseCoin)) {
+				continue
+			}
+			result.Scanned++
+			// 反向合约的成交量以USD计
+			turnover := t.turnover24h
+			if category == bybitapi.CategoryInverse {
+				turnover = t.volume24h
+			}
+			if turnover < query.MinTurnover || t.markPrice <= 0 {
+				continue
+			}
+			match, spot, ok := match.lookup(spots)
+			if !ok || spot.lastPrice <= 0 {
+				continue
+			}
+
+			o := Opportunity{
+				Symbol:        t.symbol,
+				Category:      category,
+				BaseCoin:      match.baseCoin,
+				SpotSymbol:    match.spot,
+				ContractPrice: t.markPrice,
+				SpotPrice:     spot.lastPrice * match.scale,
+				Turnover24h:   turnover,
+			}
+			o.Basis = o.ContractPrice - o.SpotPrice
+			o.BasisRate = o.Basis / o.SpotPrice
+
+			if t.deliveryTime == 0 {
+				hours := a.fundingHours(ctx, category, t)
+				o.Kind = KindPerpetual
+				o.FundingRate = t.fundingRate
+				o.FundingIntervalHours = hours
+				o.NextFundingTime = t.nextFundingTime
+				o.AnnualisedFunding = t.fundingRate * 24 / hours * 365
+				o.Direction = direction(t.fundingRate)
+				o.Fees = 2*spotFee + 2*contractFee
+				o.NetAnnualised = math.Abs(o.AnnualisedFunding) - o.Fees*365/query.HoldDays
+			} else {
+				days := float64(t.deliveryTime-now.UnixMilli()) / float64(24*time.Hour/time.Millisecond)
+				if days < 1 {
+					continue
+				}
+				o.Kind = KindFuture
+				o.DeliveryTime = t.deliveryTime
+				o.DaysToDelivery = days
+				o.AnnualisedBasis = o.BasisRate * 365 / days
+				o.Direction = direction(o.BasisRate)
+				o.Fees = 2*spotFee + contractFee + t.deliveryFeeRate
+				o.NetAnnualised = (math.Abs(o.BasisRate) - o.Fees) * 365 / days
+			}
+			result.Opportunities = append(result.Opportunities, o)
+		}
+	}
+
+	sort.SliceStable(result.Opportunities, func(i, j int) bool {
+		return result.Opportunities[i].NetAnnualised > result.Opportunities[j].NetAnnualised
+	})
+	if len(result.Opportunities) > query.Limit {
+		result.Opportunities = result.Opportunities[:query.Limit]
+	}
+
+	if err := a.positions(ctx, result, contracts, baseCoin); err != nil {
+		result.PositionsError = err.Error()
+	}
+	return result, nil
+}
+
+// 收取资金费用或基差为正时做多现货、做空合约
+func direction(rate float64) string {
+	if rate >= 0 {
+		return LongSpotShortContract
+	}
+	return ShortSpotLongContract
+}
+
+func (f FeeRate) rate(maker bool) float64 {
+	if maker {
+		return f.Maker
+	}
+	return f.Taker
+}
+
+// 计算合约仓位下次结算的预计资金费用，交割合约没有资金费用
+func (a *Analyzer) positions(ctx context.Context, result *Result, contracts map[string]map[string]ticker, baseCoin string) error {
+	for _, category := range []string{bybitapi.CategoryLinear, bybitapi.CategoryInverse} {
+		list, ok := contracts[category]
+		if !ok {
+			continue
+		}
+		settleCoins := []string{""}
+		if category == bybitapi.CategoryLinear {
+			settleCoins = []string{"USDT", "USDC"}
+		}
+		for _, settleCoin := range settleCoins {
+			resp, err := a.svc.GetPositions(ctx, category, "", settleCoin, "")
+			if err != nil {
+				return err
+			}
+			var positions []model.Position
+			if err := decodeList(resp, &positions); err != nil {
+				return err
+			}
+			for _, position := range positions {
+				size := num(position.Size)
+				t, ok := list[position.Symbol]
+				if size == 0 || !ok || t.deliveryTime != 0 || t.markPrice <= 0 {
+					continue
+				}
+				if match, ok := matchSpot(category, position.Symbol); baseCoin != "" && (!ok || !strings.EqualFold(match.baseCoin, baseCoin)) {
+					continue
+				}
+
+				// 多头在资金费率为正时支付资金费用
+				sign := -1.0
+				if position.Side == "Sell" {
+					sign = 1
+				}
+				p := PositionFunding{
+					Symbol:          position.Symbol,
+					Category:        category,
+					Side:            position.Side,
+					Size:            size,
+					FundingRate:     t.fundingRate,
+					NextFundingTime: t.nextFundingTime,
+				}
+				if category == bybitapi.CategoryInverse {
+					p.PositionValue = size
+					p.NextFunding = sign * size / t.markPrice * t.fundingRate
+				} else {
+					p.PositionValue = size * t.markPrice
+					p.NextFunding = sign * p.PositionValue * t.fundingRate
+				}
+				p.DailyFunding = p.NextFunding * 24 / a.fundingHours(ctx, category, t)
+				result.Positions = append(result.Positions, p)
+			}
+		}
+	}
+	return nil
+}
+
+// 获取一个产品类别的全部行情
+func (a *Analyzer) tickers(ctx context.Context, category string) (map[string]ticker, error) {
+	resp, err := a.svc.GetTickers(ctx, category, "")
+	if err != nil {
+		return nil, err
+	}
+	var list []map[string]interface{}
+	if err := decodeList(resp, &list); err != nil {
+		return nil, err
+	}
+	tickers := make(map[string]ticker, len(list))
+	for _, item := range list {
+		t := ticker{
+			symbol:          text(item["symbol"]),
+			lastPrice:       num(text(item["lastPrice"])),
+			markPrice:       num(text(item["markPrice"])),
+			fundingRate:     num(text(item["fundingRate"])),
+			fundingHours:    num(text(item["fundingIntervalHour"])),
+			nextFundingTime: int64(num(text(item["nextFundingTime"]))),
+			deliveryTime:    int64(num(text(item["deliveryTime"]))),
+			deliveryFeeRate: num(text(item["deliveryFeeRate"])),
+			turnover24h:     num(text(item["turnover24h"])),
+			volume24h:       num(text(item["volume24h"])),
+		}
+		if t.symbol != "" {
+			tickers[t.symbol] = t
+		}
+	}
+	return tickers, nil
+}
+
+// 获取账户在产品类别上的手续费率，失败时（例如没有配置API密钥）使用默认费率
+// 返回的列表中没有交易对的项作为整个产品类别的费率，否则取第一个交易对的费率
+func (a *Analyzer) feeRate(ctx context.Context, category string) FeeRate {
+	resp, err := a.svc.GetFeeRate(ctx, category, "")
+	if err != nil {
+		return defaultFees[category]
+	}
+	var rates []struct {
+		Symbol       string `json:"symbol"`
+		MakerFeeRate string `json:"makerFeeRate"`
+		TakerFeeRate string `json:"takerFeeRate"`
+	}
+	if err := decodeList(resp, &rates); err != nil || len(rates) == 0 {
+		return defaultFees[category]
+	}
+	rate := rates[0]
+	for _, r := range rates {
+		if r.Symbol == "" {
+			rate = r
+			break
+		}
+	}
+	return FeeRate{Maker: num(rate.MakerFeeRate), Taker: num(rate.TakerFeeRate), Source: "account"}
+}
+
+// 返回合约的资金费率结算间隔（小时）
+// 行情中没有结算间隔时从合约信息中获取，仍然没有时使用8小时
+func (a *Analyzer) fundingHours(ctx context.Context, category string, t ticker) float64 {
+	if t.fundingHours > 0 {
+		return t.fundingHours
+	}
+
+	key := service.AccountFromContext(ctx) + "/" + category
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	intervals, ok := a.intervals[key]
+	if !ok || a.now().Sub(intervals.fetched) > intervalTTL {
+		intervals = &fundingIntervals{fetched: a.now(), hours: a.fetchIntervals(ctx, category)}
+		a.intervals[key] = intervals
+	}
+	if hours := intervals.hours[t.symbol]; hours > 0 {
+		return hours
+	}
+	return defaultFundingHours
+}
+
+// 从合约信息中读取结算间隔，失败时返回空表
+func (a *Analyzer) fetchIntervals(ctx context.Context, category string) map[string]float64 {
+	hours := map[string]float64{}
+	resp, err := a.svc.GetInstruments(ctx, category, "", "")
+	if err != nil {
+		return hours
+	}
+	var instruments []map[string]interface{}
+	if err := decodeList(resp, &instruments); err != nil {
+		return hours
+	}
+	for _, instrument := range instruments {
+		// fundingInterval的单位为分钟
+		if minutes := num(text(instrument["fundingInterval"])); minutes > 0 {
+			hours[text(instrument["symbol"])] = minutes / 60
+		}
+	}
+	return hours
+}
+
+// 把响应中的list解析到v
+func decodeList(resp *model.Response, v interface{}) error {
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return err
+	}
+	if len(page.List) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(page.List, v); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// 把JSON中的字符串或数字转换为字符串
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func num(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
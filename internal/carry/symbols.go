@@ -0,0 +1,72 @@
+package carry
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 反向交割合约的月份代码，例如BTCUSDZ24
+var inverseFuture = regexp.MustCompile(`^([A-Z0-9]+)USD[FGHJKMNQUVXZ]\d{2}$`)
+
+// 合约价格相对现货价格的倍数前缀，例如1000PEPEUSDT的一张合约对应1000个PEPE
+var multiplierPrefix = regexp.MustCompile(`^(1000000|100000|10000|1000|100|10)([A-Z].*)$`)
+
+// 合约对应的现货交易对
+type spotMatch struct {
+	baseCoin string
+	spot     string  // 现货交易对
+	scale    float64 // 合约价格等于现货价格乘以scale
+}
+
+// 返回合约对应的现货交易对，无法识别时返回false
+// USDT合约对应同名的USDT现货，USDC合约对应USDC现货，反向合约没有USD现货，使用USDT现货近似
+func matchSpot(category, symbol string) (spotMatch, bool) {
+	var base, quote string
+	switch category {
+	case bybitapi.CategoryLinear:
+		if i := strings.Index(symbol, "-"); i > 0 {
+			// 交割合约：BTCUSDT-27DEC24为USDT交割，BTC-27DEC24为USDC交割
+			symbol = symbol[:i]
+			if !strings.HasSuffix(symbol, "USDT") {
+				symbol += "PERP"
+			}
+		}
+		switch {
+		case strings.HasSuffix(symbol, "USDT"):
+			base, quote = strings.TrimSuffix(symbol, "USDT"), "USDT"
+		case strings.HasSuffix(symbol, "PERP"):
+			base, quote = strings.TrimSuffix(symbol, "PERP"), "USDC"
+		case strings.HasSuffix(symbol, "USDC"):
+			base, quote = strings.TrimSuffix(symbol, "USDC"), "USDC"
+		}
+	case bybitapi.CategoryInverse:
+		if m := inverseFuture.FindStringSubmatch(symbol); m != nil {
+			base = m[1]
+		} else {
+			base = strings.TrimSuffix(symbol, "USD")
+		}
+		quote = "USDT"
+	}
+	if base == "" || base == symbol {
+		return spotMatch{}, false
+	}
+	return spotMatch{baseCoin: base, spot: base + quote, scale: 1}, true
+}
+
+// 在现货行情中查找合约对应的交易对，同名交易对不存在时去掉倍数前缀再查找
+func (m spotMatch) lookup(spots map[string]ticker) (spotMatch, ticker, bool) {
+	if t, ok := spots[m.spot]; ok {
+		return m, t, true
+	}
+	prefix := multiplierPrefix.FindStringSubmatch(m.baseCoin)
+	if prefix == nil {
+		return m, ticker{}, false
+	}
+	quote := strings.TrimPrefix(m.spot, m.baseCoin)
+	scaled := spotMatch{baseCoin: prefix[2], spot: prefix[2] + quote, scale: math.Pow10(len(prefix[1]) - 1)}
+	t, ok := spots[scaled.spot]
+	return scaled, t, ok
+}
@@ -0,0 +1,264 @@
+// Package cassette 录制和回放Bybit HTTP请求
+// 录制时把脱敏后的请求和响应逐条追加到磁带文件（每行一个JSON），回放时按录制顺序返回响应，不访问网络
+// 签名、API密钥和时间戳只出现在请求头中，磁带不保存请求头；响应中的API密钥和Secret替换为REDACTED
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Redacted 是脱敏后的字段值
+const Redacted = "REDACTED"
+
+// 需要脱敏的查询参数和JSON字段
+var sensitiveFields = map[string]bool{
+	"api_key":   true,
+	"apiKey":    true,
+	"apiSecret": true,
+	"secret":    true,
+	"sign":      true,
+	"signature": true,
+}
+
+// 每次请求都会变化、回放匹配时忽略的参数
+var volatileFields = map[string]bool{
+	"timestamp":   true,
+	"recvWindow":  true,
+	"recv_window": true,
+}
+
+// 客户端生成的ID，每次请求都不同，回放匹配时忽略请求体中的这些字段
+// 查询字符串中的同名参数是查询条件，仍然参与匹配
+var generatedFields = map[string]bool{
+	"orderLinkId": true,
+	"transferId":  true,
+}
+
+// 录制的响应头，其余响应头不保存
+var recordedHeaders = []string{
+	"Content-Type",
+	"X-Bapi-Limit",
+	"X-Bapi-Limit-Status",
+	"X-Bapi-Limit-Reset-Timestamp",
+	"Retry-After",
+}
+
+// Interaction 是一次录制的请求和响应
+type Interaction struct {
+	Request    Request   `json:"request"`            // 脱敏后的请求
+	Response   *Response `json:"response,omitempty"` // 响应，网络错误时为空
+	Error      string    `json:"error,omitempty"`    // 网络错误
+	DurationMs int64     `json:"durationMs"`         // 请求耗时（毫秒）
+	RecordedAt time.Time `json:"recordedAt"`         // 录制时间
+}
+
+// Request 是脱敏后的请求
+type Request struct {
+	Method string `json:"method"`          // HTTP方法
+	Path   string `json:"path"`            // 请求路径，例如/v5/order/create
+	Query  string `json:"query,omitempty"` // 查询字符串
+	Body   string `json:"body,omitempty"`  // 请求体
+}
+
+// Response 是录制的响应
+type Response struct {
+	Status int               `json:"status"`           // HTTP状态码
+	Header map[string]string `json:"header,omitempty"` // 响应头
+	Body   string            `json:"body"`             // 响应体
+}
+
+// ReadFile 读取磁带文件中的全部请求
+func ReadFile(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开磁带文件: %v", err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("磁带文件%s第%d行格式错误: %v", path, line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("无法读取磁带文件: %v", err)
+	}
+	return interactions, nil
+}
+
+// 生成脱敏后的请求记录
+func newRequest(req *http.Request, body []byte) Request {
+	return Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  sanitizeQuery(req.URL.RawQuery),
+		Body:   string(sanitizeBody(body)),
+	}
+}
+
+// 删除查询字符串中的敏感参数
+func sanitizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for key := range values {
+		if sensitiveFields[key] {
+			values.Del(key)
+		}
+	}
+	return values.Encode()
+}
+
+// 把JSON中的敏感字段替换为Redacted，不包含敏感字段或不是JSON时原样返回
+func sanitizeBody(body []byte) []byte {
+	value, ok := decodeJSON(body)
+	if !ok || !redact(value) {
+		return body
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// 解析JSON，保留数字的原始写法
+func decodeJSON(body []byte) (interface{}, bool) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// 递归替换敏感字段，返回是否有替换
+func redact(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[key] {
+				if s, ok := field.(string); !ok || s != "" {
+					v[key] = Redacted
+					changed = true
+				}
+				continue
+			}
+			if redact(field) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redact(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// 回放时用于精确匹配的键：方法、路径，以及去掉易变参数并按键排序的查询参数和请求体，请求体还去掉客户端生成的ID
+func (r Request) key() string {
+	return r.Method + " " + r.Path + "?" + canonicalQuery(r.Query) + "\n" + canonicalBody(r.Body)
+}
+
+// 去掉易变参数并按键排序的查询字符串
+func canonicalQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for key := range values {
+		if volatileFields[key] {
+			values.Del(key)
+		}
+	}
+	return values.Encode()
+}
+
+// 去掉易变字段和客户端生成的ID的请求体，JSON按键排序后重新序列化
+func canonicalBody(body string) string {
+	value, ok := decodeJSON([]byte(body))
+	if !ok {
+		return body
+	}
+	if fields, ok := value.(map[string]interface{}); ok {
+		for key := range fields {
+			if volatileFields[key] {
+				delete(fields, key)
+			}
+		}
+	}
+	removeGenerated(value)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+	return string(data)
+}
+
+// 递归删除客户端生成的ID，批量下单的每个订单都有自己的orderLinkId
+func removeGenerated(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if generatedFields[key] {
+				delete(v, key)
+				continue
+			}
+			removeGenerated(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			removeGenerated(item)
+		}
+	}
+}
+
+// 保存需要录制的响应头
+func recordHeader(header http.Header) map[string]string {
+	recorded := map[string]string{}
+	for _, name := range recordedHeaders {
+		if value := header.Get(name); value != "" {
+			recorded[name] = value
+		}
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+	return recorded
+}
+
+// 请求的简短描述，用于错误信息
+func (r Request) String() string {
+	if r.Query == "" {
+		return r.Method + " " + r.Path
+	}
+	return r.Method + " " + r.Path + "?" + r.Query
+}
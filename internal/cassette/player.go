@@ -0,0 +1,106 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// ErrNoInteraction 表示磁带中没有可以回放的请求
+var ErrNoInteraction = errors.New("磁带中没有匹配的请求")
+
+// Player 是回放磁带的http.RoundTripper，不访问网络
+// 每条记录只回放一次，返回第一条参数完全相同的未回放记录。匹配时只忽略时间戳等易变参数
+// 和客户端生成的ID（例如orderLinkId），其他参数不同时返回ErrNoInteraction
+type Player struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Load 读取磁带文件并创建Player
+func Load(path string) (*Player, error) {
+	interactions, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlayer(interactions), nil
+}
+
+// NewPlayer 回放给定的请求记录
+func NewPlayer(interactions []Interaction) *Player {
+	return &Player{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+}
+
+// RoundTrip 返回录制的响应，录制时的网络错误原样返回
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+	request := newRequest(req, body)
+
+	interaction, ok := p.next(request)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoInteraction, request)
+	}
+	if interaction.Response == nil {
+		return nil, errors.New(interaction.Error)
+	}
+
+	resp := interaction.Response
+	header := http.Header{}
+	for name, value := range resp.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(resp.Body))),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+// 取出下一条匹配的记录
+func (p *Player) next(request Request) (Interaction, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := request.key()
+	for i, interaction := range p.interactions {
+		if !p.used[i] && interaction.Request.key() == key {
+			p.used[i] = true
+			return interaction, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// Remaining 返回尚未回放的请求数
+func (p *Player) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	remaining := 0
+	for _, used := range p.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,125 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Recorder 是录制请求的http.RoundTripper
+// 请求转发给next，每个请求完成后立即把脱敏后的记录追加到磁带文件，服务异常退出也不会丢失已完成的请求
+type Recorder struct {
+	next http.RoundTripper
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+	err  error // 第一次写入失败的错误，由Close返回
+}
+
+// NewRecorder 创建录制到path的Recorder，文件已存在时追加，next为nil时使用http.DefaultTransport
+func NewRecorder(path string, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("无法创建磁带目录: %v", err)
+	}
+	// 磁带包含账户余额和订单，只允许当前用户读取
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开磁带文件: %v", err)
+	}
+	return &Recorder{
+		next: next,
+		path: path,
+		file: file,
+		enc:  json.NewEncoder(file),
+	}, nil
+}
+
+// Path 返回磁带文件路径
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// RoundTrip 发送请求并录制请求和响应
+// 写入磁带失败不影响请求本身，错误由Close返回
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+		// 转发的请求使用新的请求体，不修改调用方的请求
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	interaction := Interaction{
+		Request:    newRequest(req, body),
+		RecordedAt: time.Now(),
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		interaction.Error = err.Error()
+		interaction.DurationMs = time.Since(interaction.RecordedAt).Milliseconds()
+		r.write(&interaction)
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	interaction.DurationMs = time.Since(interaction.RecordedAt).Milliseconds()
+	if err != nil {
+		interaction.Error = err.Error()
+		r.write(&interaction)
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+
+	interaction.Response = &Response{
+		Status: resp.StatusCode,
+		Header: recordHeader(resp.Header),
+		Body:   string(sanitizeBody(data)),
+	}
+	r.write(&interaction)
+	return resp, nil
+}
+
+// 追加一条记录
+func (r *Recorder) write(interaction *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	if err := r.enc.Encode(interaction); err != nil && r.err == nil {
+		r.err = fmt.Errorf("写入磁带文件失败: %v", err)
+	}
+}
+
+// Close 关闭磁带文件，之后的请求仍然转发但不再录制
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return r.err
+	}
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = fmt.Errorf("关闭磁带文件失败: %v", err)
+	}
+	r.file = nil
+	return r.err
+}
@@ -15,22 +15,24 @@ type BybitClient struct {
 	// API客户端
 	client *bybitapi.Client
 	// 日志记录器
-	logger *logger.Logger
+	logger    *logger.Logger
+	logOutput string
 	// API模块
-	Market   *market.MarketAPI
-	Order    *order.OrderAPI
+	Market   *market.MarketService
+	Order    *order.OrderService
 	Position *position.PositionService
-	Account  *account.AccountAPI
-	Asset    *asset.AssetAPI
+	Account  *account.AccountService
+	Asset    *asset.AssetService
 }
 
 // ClientConfig 客户端配置
 type ClientConfig struct {
-	APIKey     string // API密钥
-	APISecret  string // API密钥对应的密文
-	Debug      bool   // 是否开启调试模式
-	LogLevel   string // 日志级别
-	LogOutput  string // 日志输出位置
+	APIKey      string               // API密钥
+	APISecret   string               // API密钥对应的密文
+	Debug       bool                 // 是否开启调试模式
+	LogLevel    string               // 日志级别
+	LogOutput   string               // 日志输出位置
+	Environment bybitapi.Environment // 运行环境，为空时使用主网
 }
 
 // NewBybitClient 创建一个新的Bybit客户端
@@ -38,6 +40,9 @@ func NewBybitClient(config ClientConfig) *BybitClient {
 	// 创建API客户端
 	client := bybitapi.NewClient(config.APIKey, config.APISecret)
 	client.SetDebug(config.Debug)
+	if config.Environment.RESTURL != "" {
+		client.SetEnvironment(config.Environment)
+	}
 
 	// 创建日志记录器
 	logLevel := config.LogLevel
@@ -51,26 +56,27 @@ func NewBybitClient(config ClientConfig) *BybitClient {
 	log := logger.New(logLevel, logOutput)
 
 	// 创建API模块
-	marketAPI := market.NewMarketAPI(client, log)
-	orderAPI := order.NewOrderAPI(client, log)
-	positionAPI := position.NewPositionService(client, logLevel, logOutput)
-	accountAPI := account.NewAccountAPI(client, log)
-	assetAPI := asset.NewAssetAPI(client, log)
+	marketAPI := market.NewMarketService(client, log)
+	orderAPI := order.NewOrderService(client, log)
+	positionAPI := position.NewPositionService(client, log)
+	accountAPI := account.NewAccountService(client, log)
+	assetAPI := asset.NewAssetService(client, log)
 
 	return &BybitClient{
-		client:   client,
-		logger:   log,
-		Market:   marketAPI,
-		Order:    orderAPI,
-		Position: positionAPI,
-		Account:  accountAPI,
-		Asset:    assetAPI,
+		client:    client,
+		logger:    log,
+		logOutput: logOutput,
+		Market:    marketAPI,
+		Order:     orderAPI,
+		Position:  positionAPI,
+		Account:   accountAPI,
+		Asset:     assetAPI,
 	}
 }
 
 // SetLogLevel 设置日志级别
 func (c *BybitClient) SetLogLevel(level string) {
-	c.logger = logger.New(level, c.logger.(*logger.Logger).Output())
+	c.logger.Configure(level, c.logOutput)
 }
 
 // SetDebug 设置调试模式
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bybit-mcp/pkg/bybitapi"
 )
 
 // Config 表示MCP服务的配置
@@ -17,20 +20,129 @@ type Config struct {
 
 	// 日志配置
 	Logger LoggerConfig `json:"logger"`
+
+	// 存储配置
+	Storage StorageConfig `json:"storage"`
+
+	// 认证配置
+	Auth AuthConfig `json:"auth"`
+
+	// 提现策略
+	Withdrawal WithdrawalConfig `json:"withdrawal"`
+
+	// 下单风控
+	Risk RiskConfig `json:"risk"`
+
+	// 模拟交易配置
+	Paper PaperConfig `json:"paper"`
+
+	// 录制和回放Bybit请求
+	Recording RecordingConfig `json:"recording"`
+
+	// 历史行情库
+	History HistoryConfig `json:"history"`
+
+	// 行情扫描
+	Scanner ScannerConfig `json:"scanner"`
+
+	// 告警
+	Alerts AlertsConfig `json:"alerts"`
 }
 
 // ServerConfig 表示服务器配置
 type ServerConfig struct {
-	Host string `json:"host"` // 服务主机
-	Port int    `json:"port"` // 服务端口
+	Host string    `json:"host"` // 服务主机
+	Port int       `json:"port"` // 服务端口
+	TLS  TLSConfig `json:"tls"`  // TLS配置，未配置证书时使用明文连接
+}
+
+// TLSConfig 表示gRPC监听端口的TLS配置
+type TLSConfig struct {
+	CertFile          string `json:"certFile"`          // 服务端证书文件（PEM）
+	KeyFile           string `json:"keyFile"`           // 服务端私钥文件（PEM）
+	ClientCAFile      string `json:"clientCAFile"`      // 用于验证客户端证书的CA文件（PEM）
+	MinVersion        string `json:"minVersion"`        // 最低TLS版本：1.2或1.3，默认1.2
+	RequireClientCert bool   `json:"requireClientCert"` // 是否强制要求客户端证书（mTLS）
+	ReloadInterval    int    `json:"reloadInterval"`    // 检查证书文件变化的间隔（秒），0表示使用默认值30秒
+}
+
+// Enabled 判断是否启用TLS
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != ""
 }
 
 // BybitConfig 表示Bybit API配置
 type BybitConfig struct {
-	BaseURL   string `json:"baseUrl"`   // API基础URL
-	APIKey    string `json:"apiKey"`    // API密钥
-	APISecret string `json:"apiSecret"` // API密钥
-	Debug     bool   `json:"debug"`     // 调试模式
+	Environment         string          `json:"environment"`             // 接入环境：mainnet、testnet、demo、bytick、nl、tr、kz、hk或custom，为空时按baseUrl判断
+	BaseURL             string          `json:"baseUrl"`                 // 自定义API基础URL，环境为custom时必填
+	WSURL               string          `json:"wsUrl"`                   // 自定义WebSocket基础URL，仅环境为custom时使用
+	AllowMainnetTrading bool            `json:"allowMainnetTrading"`     // 是否允许在主网上下单、划转、提现等变更操作
+	APIKey              string          `json:"apiKey" secret:"true"`    // API密钥
+	APISecret           string          `json:"apiSecret" secret:"true"` // API密钥
+	Debug               bool            `json:"debug"`                   // 调试模式
+	Master              bool            `json:"master"`                  // 单账户模式下该API密钥是否属于母账户
+	Accounts            []AccountConfig `json:"accounts"`                // 多账户配置，为空时使用上面的单个API密钥
+	DefaultAccount      string          `json:"defaultAccount"`          // 请求未指定账户时使用的账户，为空时使用第一个账户
+}
+
+// AccountConfig 表示一个主账户或子账户的API配置
+type AccountConfig struct {
+	Name        string  `json:"name"`                    // 账户名称，请求中通过该名称选择账户
+	APIKey      string  `json:"apiKey" secret:"true"`    // API密钥
+	APISecret   string  `json:"apiSecret" secret:"true"` // API密钥
+	RateLimit   float64 `json:"rateLimit"`               // 每秒最大请求数，0表示不限制
+	Burst       int     `json:"burst"`                   // 允许的突发请求数
+	Master      bool    `json:"master"`                  // 是否为母账户，只有母账户可以管理子账户和母子账户划转
+	Environment string  `json:"environment"`             // 该账户的接入环境，为空时使用bybit.environment
+}
+
+// 未配置多账户时单个账户的名称
+const DefaultAccountName = "default"
+
+// AccountList 返回全部账户配置
+// 未配置accounts时把apiKey/apiSecret作为名为default的单个账户
+func (c *BybitConfig) AccountList() []AccountConfig {
+	if len(c.Accounts) > 0 {
+		return c.Accounts
+	}
+	return []AccountConfig{{
+		Name:      DefaultAccountName,
+		APIKey:    c.APIKey,
+		APISecret: c.APISecret,
+		Master:    c.Master,
+	}}
+}
+
+// AccountEnvironment 返回账户使用的接入环境
+// 账户单独指定环境时只有custom环境使用bybit.baseUrl
+func (c *BybitConfig) AccountEnvironment(account AccountConfig) (bybitapi.Environment, error) {
+	if account.Environment == "" {
+		return bybitapi.ResolveEnvironment(c.Environment, c.BaseURL, c.WSURL)
+	}
+
+	baseURL := ""
+	if strings.EqualFold(account.Environment, bybitapi.EnvCustom) {
+		baseURL = c.BaseURL
+	}
+	return bybitapi.ResolveEnvironment(account.Environment, baseURL, c.WSURL)
+}
+
+// ValidateAccounts 检查账户名称是否为空或重复，以及默认账户是否存在
+func (c *BybitConfig) ValidateAccounts() error {
+	names := map[string]bool{}
+	for _, account := range c.AccountList() {
+		if account.Name == "" {
+			return fmt.Errorf("账户名称不能为空")
+		}
+		if names[account.Name] {
+			return fmt.Errorf("账户名称重复: %s", account.Name)
+		}
+		names[account.Name] = true
+	}
+	if c.DefaultAccount != "" && !names[c.DefaultAccount] {
+		return fmt.Errorf("默认账户不存在: %s", c.DefaultAccount)
+	}
+	return nil
 }
 
 // LoggerConfig 表示日志配置
@@ -39,26 +151,127 @@ type LoggerConfig struct {
 	Output string `json:"output"` // 日志输出
 }
 
-// LoadConfig 从文件加载配置
-func LoadConfig(filePath string) (*Config, error) {
-	// 检查文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("配置文件不存在: %s", filePath)
-	}
+// StorageConfig 表示持久化存储配置
+type StorageConfig struct {
+	Driver           string `json:"driver"`           // 存储驱动: sqlite, memory
+	Path             string `json:"path"`             // SQLite数据库文件路径
+	SnapshotInterval int    `json:"snapshotInterval"` // 仓位和钱包快照间隔（秒），0表示不做快照
+}
 
-	// 读取文件
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("无法读取配置文件: %v", err)
-	}
+// AuthConfig 表示调用方认证和权限配置
+type AuthConfig struct {
+	Enabled  bool                `json:"enabled"`  // 是否启用认证，未启用时任何能访问端口的客户端都可以调用全部接口
+	Insecure bool                `json:"insecure"` // 未启用认证时必须设置为true才能启动，明确接受不认证调用方的风险
+	Clients  []ClientConfig      `json:"clients"`  // 允许访问的客户端
+	Roles    map[string][]string `json:"roles"`    // 自定义角色，值为权限列表：read、trade、treasury、admin
+}
 
-	// 解析JSON
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("无法解析配置文件: %v", err)
-	}
+// ClientConfig 表示一个允许访问的客户端
+// 通过Bearer令牌或mTLS客户端证书的CommonName识别，至少配置一种
+type ClientConfig struct {
+	Name        string   `json:"name"`                // 客户端名称，记录在审计日志中
+	Token       string   `json:"token" secret:"true"` // Bearer令牌
+	CertSubject string   `json:"certSubject"`         // 客户端证书的CommonName
+	Role        string   `json:"role"`                // 角色：read-only、trader、treasurer、admin或自定义角色
+	Accounts    []string `json:"accounts"`            // 允许使用的账户，为空表示全部账户
+}
+
+// WithdrawalConfig 表示提现策略
+// 白名单为空时拒绝全部提现
+type WithdrawalConfig struct {
+	Whitelist         []WhitelistEntry   `json:"whitelist"`         // 允许提现的地址
+	MaxPerTransaction map[string]float64 `json:"maxPerTransaction"` // 每个币种单笔提现上限，未配置的币种不限制
+	MaxPerDay         map[string]float64 `json:"maxPerDay"`         // 每个币种24小时内提现总额上限，未配置的币种不限制
+	CooldownHours     int                `json:"cooldownHours"`     // 新加入白名单的地址需要等待的小时数，0表示无需等待
+	ApprovalTTL       int                `json:"approvalTtl"`       // 待审批提现的有效期（分钟），0表示使用默认值60分钟
+	DisableApproval   bool               `json:"disableApproval"`   // 是否跳过二次审批，通过检查的提现直接提交
+}
+
+// RiskConfig 表示下单和修改仓位设置前的风控限制，未配置的限制不检查
+type RiskConfig struct {
+	AllowedSymbols []string           `json:"allowedSymbols"` // 允许交易的交易对，为空表示不限制
+	MaxOrderQty    map[string]float64 `json:"maxOrderQty"`    // 每个交易对单笔订单的最大数量，未配置的交易对不限制
+	MaxOrderValue  float64            `json:"maxOrderValue"`  // 单笔限价订单的最大金额（数量乘以价格），0表示不限制
+	MaxLeverage    float64            `json:"maxLeverage"`    // 允许设置的最大杠杆，0表示不限制
+}
+
+// WhitelistEntry 表示一个白名单提现地址
+type WhitelistEntry struct {
+	Coin    string `json:"coin"`    // 币种
+	Chain   string `json:"chain"`   // 链
+	Address string `json:"address"` // 地址
+	Tag     string `json:"tag"`     // 地址标签，部分链需要
+	Label   string `json:"label"`   // 备注
+}
+
+// PaperConfig 表示模拟交易配置
+// 启用后全部账户都使用模拟交易：行情从Bybit获取，订单、仓位和余额只在本地模拟，服务重启后清空
+type PaperConfig struct {
+	Enabled               bool               `json:"enabled"`               // 是否启用模拟交易
+	InitialBalance        map[string]float64 `json:"initialBalance"`        // 每个账户的初始余额，为空时为10000 USDT
+	MakerFeeRate          float64            `json:"makerFeeRate"`          // 挂单手续费率
+	TakerFeeRate          float64            `json:"takerFeeRate"`          // 吃单手续费率
+	SlippageBps           float64            `json:"slippageBps"`           // 吃单成交价在订单簿价格之外的滑点（基点）
+	DefaultLeverage       float64            `json:"defaultLeverage"`       // 未设置杠杆的合约使用的杠杆，0表示10倍
+	MaintenanceMarginRate float64            `json:"maintenanceMarginRate"` // 合约维持保证金率，0表示0.5%
+	UpdateInterval        int                `json:"updateInterval"`        // 刷新行情、撮合挂单、收取资金费用和检查强平的间隔（秒），0表示5秒
+}
+
+// 录制模式
+const (
+	RecordingOff    = "off"    // 不录制
+	RecordingRecord = "record" // 录制发送到Bybit的请求和响应
+	RecordingReplay = "replay" // 回放录制的响应，不访问Bybit
+)
+
+// RecordingConfig 表示Bybit请求的录制和回放配置
+// 每个账户的请求录制到dir/session/账户名.jsonl，签名和API密钥不会写入文件
+type RecordingConfig struct {
+	Mode    string `json:"mode"`    // 录制模式：off、record或replay，为空时不录制
+	Dir     string `json:"dir"`     // 磁带目录
+	Session string `json:"session"` // 会话名称，录制时为空则使用启动时间，回放时必填
+}
 
-	return &config, nil
+// Enabled 判断是否录制或回放
+func (c *RecordingConfig) Enabled() bool {
+	return c.Mode == RecordingRecord || c.Mode == RecordingReplay
+}
+
+// CassettePath 返回账户的磁带文件路径
+func (c *RecordingConfig) CassettePath(account string) string {
+	return filepath.Join(c.Dir, c.Session, account+".jsonl")
+}
+
+// HistoryConfig 表示历史行情库配置
+// GetHistoricalKlines优先返回库中的数据，缺少的区间通过请求选择的账户从Bybit获取后保存
+type HistoryConfig struct {
+	Path    string `json:"path"`    // SQLite数据库文件路径，为空时不启用历史行情库
+	Offline bool   `json:"offline"` // 只返回库中已有的数据，不从Bybit获取
+}
+
+// ScannerConfig 表示行情扫描配置
+// 扫描器定时获取categories中全部交易对的行情，保存最近window分钟的历史，供Scan接口按表达式筛选
+type ScannerConfig struct {
+	Categories []string `json:"categories"` // 定时获取的产品类别：spot、linear或inverse
+	Interval   int      `json:"interval"`   // 获取间隔（秒），0表示不定时获取，只在扫描时获取
+	Window     int      `json:"window"`     // 保存的历史时长（分钟），也是表达式最长的回看时间
+}
+
+// AlertsConfig 表示告警配置
+// 告警规则通过CreateAlert接口创建并保存在存储中，每隔interval秒获取行情、仓位和钱包余额检查一次条件，
+// 触发时写入日志并推送给StreamAlerts的订阅方，配置了webhook.url时同时发送到该地址
+type AlertsConfig struct {
+	Interval int           `json:"interval"` // 检查间隔（秒），0表示不检查
+	Cooldown int           `json:"cooldown"` // 告警未指定冷却时间时两次触发的最短间隔（秒）
+	Webhook  WebhookConfig `json:"webhook"`  // 告警Webhook
+}
+
+// WebhookConfig 表示告警Webhook配置
+// 请求体为JSON格式的告警事件，X-Alert-Signature头为时间戳和请求体的HMAC-SHA256签名
+type WebhookConfig struct {
+	URL     string `json:"url"`                  // 接收告警的地址，为空时不发送
+	Secret  string `json:"secret" secret:"true"` // 签名密钥
+	Timeout int    `json:"timeout"`              // 单次请求的超时时间（秒），0表示10秒
 }
 
 // DefaultConfig 返回默认配置
@@ -69,7 +282,7 @@ func DefaultConfig() *Config {
 			Port: 50051,
 		},
 		Bybit: BybitConfig{
-			BaseURL:   "https://api.bybit.com",
+			BaseURL:   "",
 			APIKey:    "",
 			APISecret: "",
 			Debug:     false,
@@ -78,6 +291,31 @@ func DefaultConfig() *Config {
 			Level:  "info",
 			Output: "stdout",
 		},
+		Storage: StorageConfig{
+			Driver:           "memory",
+			Path:             "data/bybit-mcp.db",
+			SnapshotInterval: 0,
+		},
+		Paper: PaperConfig{
+			MakerFeeRate: 0.0002,
+			TakerFeeRate: 0.00055,
+		},
+		Recording: RecordingConfig{
+			Mode: RecordingOff,
+			Dir:  "cassettes",
+		},
+		History: HistoryConfig{
+			Path: "data/history.db",
+		},
+		Scanner: ScannerConfig{
+			Categories: []string{bybitapi.CategoryLinear},
+			Interval:   60,
+			Window:     60,
+		},
+		Alerts: AlertsConfig{
+			Interval: 15,
+			Cooldown: 300,
+		},
 	}
 }
 
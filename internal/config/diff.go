@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Diff 比较两份配置，返回发生变化的配置路径，按路径排序
+// 带name字段的列表元素按名称比较，例如bybit.accounts.main.apiKey；新增或删除的元素只返回到名称一级
+func Diff(old, new *Config) []string {
+	var changes []string
+	diffValue(toValue(old), toValue(new), "", &changes)
+	sort.Strings(changes)
+	return changes
+}
+
+// 把配置转换为JSON值，便于逐级比较
+func toValue(cfg *Config) interface{} {
+	data, _ := json.Marshal(cfg)
+	var value interface{}
+	_ = json.Unmarshal(data, &value)
+	return value
+}
+
+// 递归比较两个JSON值
+func diffValue(old, new interface{}, path string, changes *[]string) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		diffMap(oldMap, newMap, path, changes)
+		return
+	}
+
+	oldNamed, oldOK := namedElements(old)
+	newNamed, newOK := namedElements(new)
+	if oldOK && newOK {
+		diffMap(oldNamed, newNamed, path, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, path)
+	}
+}
+
+// 比较两个对象的每个键
+func diffMap(old, new map[string]interface{}, path string, changes *[]string) {
+	keys := map[string]bool{}
+	for key := range old {
+		keys[key] = true
+	}
+	for key := range new {
+		keys[key] = true
+	}
+	for key := range keys {
+		diffValue(old[key], new[key], joinPath(path, ".", key), changes)
+	}
+}
+
+// 把元素都带有name字段的列表转换为按名称索引的对象，空列表也视为命名列表
+func namedElements(value interface{}) (map[string]interface{}, bool) {
+	if value == nil {
+		return map[string]interface{}{}, true
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	named := map[string]interface{}{}
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := obj["name"].(string)
+		if !ok || name == "" {
+			return nil, false
+		}
+		named[name] = obj
+	}
+	return named, true
+}
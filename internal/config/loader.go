@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 脱敏后的占位符
+const maskedValue = "***"
+
+// 从文件读取环境变量值时使用的后缀，例如BYBIT_API_SECRET_FILE=/run/secrets/bybit_api_secret
+const fileEnvSuffix = "_FILE"
+
+// Options 是分层加载配置的参数
+// 优先级从低到高依次为：默认值、配置文件、环境变量、命令行覆盖
+type Options struct {
+	File      string   // 配置文件路径，支持JSON和YAML，为空时不读取文件
+	Env       []string // 环境变量，形如KEY=VALUE，通常为os.Environ()
+	Overrides []string // 命令行覆盖，形如server.port=8080，键为配置路径
+}
+
+// Load 按默认值、配置文件、环境变量和命令行覆盖的顺序加载配置，并校验最终结果
+func Load(opts Options) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if opts.File != "" {
+		if err := decodeFile(opts.File, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := Fields(cfg)
+	if err := applyEnv(fields, opts.Env); err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(fields, opts.Overrides); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// 读取配置文件并覆盖到cfg上，未知字段视为错误
+func decodeFile(filePath string, cfg *Config) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("无法读取配置文件: %v", err)
+	}
+
+	// YAML先转换为JSON，两种格式使用相同的字段名
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		var value interface{}
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("无法解析配置文件%s: %v", filePath, err)
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("无法解析配置文件%s: %v", filePath, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("无法解析配置文件%s: %v", filePath, err)
+	}
+	return nil
+}
+
+// Field 是一个可以通过环境变量或命令行覆盖的配置项
+type Field struct {
+	Path   string        // 配置路径，例如server.tls.certFile
+	Env    string        // 环境变量名，例如SERVER_TLS_CERT_FILE
+	Secret bool          // 是否为密钥，打印配置时会被脱敏
+	value  reflect.Value // 字段值
+}
+
+// Type 返回配置项的类型名称
+func (f *Field) Type() string {
+	return f.value.Kind().String()
+}
+
+// Fields 列出配置中全部可覆盖的标量字段，按配置路径排序
+// 列表类配置中带name字段的元素按名称展开，例如bybit.accounts.main.apiKey对应BYBIT_ACCOUNTS_MAIN_API_KEY
+func Fields(cfg *Config) []Field {
+	var fields []Field
+	collectFields(reflect.ValueOf(cfg).Elem(), "", "", &fields)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+	return fields
+}
+
+// 递归收集结构体中的标量字段
+func collectFields(v reflect.Value, path, env string, fields *[]Field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldPath := joinPath(path, ".", name)
+		fieldEnv := joinPath(env, "_", envName(name))
+		fv := v.Field(i)
+
+		switch fv.Kind() {
+		case reflect.Struct:
+			collectFields(fv, fieldPath, fieldEnv, fields)
+		case reflect.Slice:
+			if fv.Type().Elem().Kind() != reflect.Struct {
+				continue
+			}
+			for j := 0; j < fv.Len(); j++ {
+				elem := fv.Index(j)
+				named := elem.FieldByName("Name")
+				if !named.IsValid() || named.String() == "" {
+					continue
+				}
+				collectFields(elem, joinPath(fieldPath, ".", named.String()), joinPath(fieldEnv, "_", envName(named.String())), fields)
+			}
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+			*fields = append(*fields, Field{
+				Path:   fieldPath,
+				Env:    fieldEnv,
+				Secret: sf.Tag.Get("secret") == "true",
+				value:  fv,
+			})
+		}
+	}
+}
+
+// 连接路径，prefix为空时直接返回name
+func joinPath(prefix, sep, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + sep + name
+}
+
+// 把camelCase字段名或账户名称转换为环境变量形式，例如clientCAFile转换为CLIENT_CA_FILE
+func envName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune('_')
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// 应用环境变量，XXX_FILE形式的变量从文件读取值，用于Docker secrets
+func applyEnv(fields []Field, environ []string) error {
+	env := map[string]string{}
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	for i := range fields {
+		f := &fields[i]
+		value, ok := env[f.Env]
+		fileName, fromFile := env[f.Env+fileEnvSuffix]
+		if ok && fromFile {
+			return fmt.Errorf("环境变量%s和%s不能同时设置", f.Env, f.Env+fileEnvSuffix)
+		}
+		if fromFile {
+			data, err := ioutil.ReadFile(fileName)
+			if err != nil {
+				return fmt.Errorf("无法读取环境变量%s指定的文件: %v", f.Env+fileEnvSuffix, err)
+			}
+			value, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return fmt.Errorf("环境变量%s的值无效: %v", f.Env, err)
+		}
+	}
+	return nil
+}
+
+// 应用命令行覆盖，配置路径不区分大小写
+func applyOverrides(fields []Field, overrides []string) error {
+	for _, override := range overrides {
+		i := strings.Index(override, "=")
+		if i <= 0 {
+			return fmt.Errorf("无效的配置覆盖%q，格式应为路径=值", override)
+		}
+		path, value := override[:i], override[i+1:]
+
+		found := false
+		for j := range fields {
+			if strings.EqualFold(fields[j].Path, path) {
+				if err := fields[j].set(value); err != nil {
+					return fmt.Errorf("配置项%s的值无效: %v", path, err)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("未知的配置项: %s", path)
+		}
+	}
+	return nil
+}
+
+// 按字段类型解析并设置值
+func (f *Field) set(value string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q不是有效的布尔值", value)
+		}
+		f.value.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q不是有效的整数", value)
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q不是有效的数字", value)
+		}
+		f.value.SetFloat(n)
+	}
+	return nil
+}
+
+// Masked 返回配置的副本，其中的API密钥和访问令牌已被替换为占位符
+func (c *Config) Masked() *Config {
+	data, _ := json.Marshal(c)
+	masked := &Config{}
+	_ = json.Unmarshal(data, masked)
+
+	for _, f := range Fields(masked) {
+		if f.Secret && f.value.String() != "" {
+			f.value.SetString(maskedValue)
+		}
+	}
+	return masked
+}
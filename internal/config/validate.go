@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 有效的日志级别
+var validLogLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true, "fatal": true}
+
+// ValidationError 包含配置中的全部错误
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "配置错误:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate 检查配置是否完整有效，一次返回全部问题
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// 服务
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		add("server.port必须在1到65535之间，当前为%d", c.Server.Port)
+	}
+	tls := c.Server.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		add("server.tls.certFile和server.tls.keyFile必须同时配置")
+	}
+	if tls.RequireClientCert && tls.ClientCAFile == "" {
+		add("server.tls.requireClientCert需要同时配置server.tls.clientCAFile")
+	}
+	switch tls.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		add("server.tls.minVersion只能是1.2或1.3，当前为%q", tls.MinVersion)
+	}
+	if tls.ReloadInterval < 0 {
+		add("server.tls.reloadInterval不能为负数")
+	}
+
+	// Bybit账户
+	if err := c.Bybit.ValidateAccounts(); err != nil {
+		add("bybit.accounts: %v", err)
+	}
+	for _, account := range c.Bybit.AccountList() {
+		if (account.APIKey == "") != (account.APISecret == "") {
+			add("账户%s的apiKey和apiSecret必须同时配置", account.Name)
+		}
+		if account.RateLimit < 0 || account.Burst < 0 {
+			add("账户%s的rateLimit和burst不能为负数", account.Name)
+		}
+		if _, err := c.Bybit.AccountEnvironment(account); err != nil {
+			add("账户%s的环境配置错误: %v", account.Name, err)
+		}
+	}
+
+	// 日志
+	if !validLogLevels[strings.ToLower(c.Logger.Level)] {
+		add("logger.level只能是debug、info、warn、error或fatal，当前为%q", c.Logger.Level)
+	}
+
+	// 存储
+	switch strings.ToLower(c.Storage.Driver) {
+	case "", "memory":
+	case "sqlite":
+		if c.Storage.Path == "" {
+			add("storage.driver为sqlite时必须配置storage.path")
+		}
+	default:
+		add("storage.driver只能是sqlite或memory，当前为%q", c.Storage.Driver)
+	}
+	if c.Storage.SnapshotInterval < 0 {
+		add("storage.snapshotInterval不能为负数")
+	}
+
+	// 认证
+	if c.Auth.Enabled && len(c.Auth.Clients) == 0 {
+		add("auth.enabled为true时至少需要配置一个客户端")
+	}
+
+	// 提现
+	for i, entry := range c.Withdrawal.Whitelist {
+		if entry.Coin == "" || entry.Chain == "" || entry.Address == "" {
+			add("withdrawal.whitelist第%d项缺少coin、chain或address", i+1)
+		}
+	}
+	for coin, limit := range c.Withdrawal.MaxPerTransaction {
+		if limit <= 0 {
+			add("withdrawal.maxPerTransaction.%s必须大于0", coin)
+		}
+	}
+	for coin, limit := range c.Withdrawal.MaxPerDay {
+		if limit <= 0 {
+			add("withdrawal.maxPerDay.%s必须大于0", coin)
+		}
+	}
+	if c.Withdrawal.CooldownHours < 0 || c.Withdrawal.ApprovalTTL < 0 {
+		add("withdrawal.cooldownHours和withdrawal.approvalTtl不能为负数")
+	}
+
+	// 下单风控
+	for symbol, limit := range c.Risk.MaxOrderQty {
+		if limit <= 0 {
+			add("risk.maxOrderQty.%s必须大于0", symbol)
+		}
+	}
+	if c.Risk.MaxOrderValue < 0 || c.Risk.MaxLeverage < 0 {
+		add("risk.maxOrderValue和risk.maxLeverage不能为负数")
+	}
+
+	// 模拟交易
+	paper := c.Paper
+	for coin, amount := range paper.InitialBalance {
+		if amount < 0 {
+			add("paper.initialBalance.%s不能为负数", coin)
+		}
+	}
+	if paper.TakerFeeRate < 0 || paper.MakerFeeRate <= -1 {
+		add("paper.takerFeeRate不能为负数，paper.makerFeeRate必须大于-1")
+	}
+	if paper.SlippageBps < 0 {
+		add("paper.slippageBps不能为负数")
+	}
+	if paper.DefaultLeverage != 0 && paper.DefaultLeverage < 1 {
+		add("paper.defaultLeverage不能小于1")
+	}
+	if paper.MaintenanceMarginRate < 0 || paper.MaintenanceMarginRate >= 1 {
+		add("paper.maintenanceMarginRate必须在0到1之间")
+	}
+	if paper.UpdateInterval < 0 {
+		add("paper.updateInterval不能为负数")
+	}
+
+	// 录制和回放
+	recording := c.Recording
+	switch recording.Mode {
+	case "", RecordingOff:
+	case RecordingRecord, RecordingReplay:
+		if recording.Dir == "" {
+			add("recording.dir不能为空")
+		}
+		if recording.Mode == RecordingReplay && recording.Session == "" {
+			add("回放时必须指定recording.session")
+		}
+		if strings.ContainsAny(recording.Session, `/\`) || recording.Session == ".." {
+			add("recording.session不能包含路径分隔符")
+		}
+	default:
+		add("recording.mode必须是off、record或replay")
+	}
+
+	// 行情扫描
+	for _, category := range c.Scanner.Categories {
+		switch category {
+		case bybitapi.CategorySpot, bybitapi.CategoryLinear, bybitapi.CategoryInverse:
+		default:
+			add("scanner.categories只能包含spot、linear或inverse，当前为%q", category)
+		}
+	}
+	if c.Scanner.Interval < 0 {
+		add("scanner.interval不能为负数")
+	}
+	if c.Scanner.Window <= 0 {
+		add("scanner.window必须大于0")
+	}
+
+	// 告警
+	if c.Alerts.Interval < 0 {
+		add("alerts.interval不能为负数")
+	}
+	if c.Alerts.Cooldown < 0 {
+		add("alerts.cooldown不能为负数")
+	}
+	if webhook := c.Alerts.Webhook; webhook.URL != "" {
+		if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("alerts.webhook.url必须是http或https地址")
+		}
+		if webhook.Secret == "" {
+			add("配置alerts.webhook.url时必须同时配置alerts.webhook.secret")
+		}
+		if webhook.Timeout < 0 {
+			add("alerts.webhook.timeout不能为负数")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package indicators
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 每个序列保存的K线数量，也是market/kline单次返回的最大数量
+const maxBars = 1000
+
+// 最多缓存的序列数量，超过时移除最久未使用的序列
+const maxSeries = 200
+
+// K线周期对应的分钟数，日线、周线和月线单独处理
+var intervalMinutes = map[string]int{
+	"1": 1, "3": 3, "5": 5, "15": 15, "30": 30, "60": 60, "120": 120, "240": 240, "360": 360, "720": 720,
+}
+
+// Point 是指标在一根K线上的值，Time为K线的开始时间（毫秒）
+type Point struct {
+	Time   int64  `json:"time"`
+	Values Values `json:"values"`
+}
+
+// Output 是一个指标的计算结果，Points按时间正序
+// Stable为false表示参与计算的K线少于指标稳定所需的数量，数值可能受初始值影响
+type Output struct {
+	Name   string  `json:"name"`
+	Spec   Spec    `json:"spec"`
+	Stable bool    `json:"stable"`
+	Points []Point `json:"points"`
+}
+
+// Result 是一个交易对的指标查询结果
+type Result struct {
+	Category   string   `json:"category"`
+	Symbol     string   `json:"symbol"`
+	Interval   string   `json:"interval"`
+	Bars       int      `json:"bars"`    // 参与计算的K线数量
+	LastBar    *Bar     `json:"lastBar"` // 最新一根已经收盘的K线
+	Indicators []Output `json:"indicators"`
+}
+
+// 序列按账户区分，不同账户可能连接不同的Bybit环境
+type seriesKey struct {
+	account, category, symbol, interval string
+}
+
+// 一个序列的K线和指标状态
+type tracker struct {
+	mu         sync.Mutex
+	used       time.Time
+	bars       []Bar // 最近maxBars根已经收盘的K线
+	indicators map[string]*tracked
+}
+
+// 一个指标的状态和最近maxBars个值
+type tracked struct {
+	spec      Spec
+	indicator Indicator
+	fed       int
+	points    []Point
+}
+
+// Engine 用GetKline获取的已收盘K线增量计算指标
+// 每个序列只在第一次查询时获取最近1000根K线，之后只获取新收盘的K线；
+// 也可以用Push传入K线推送中已经收盘的K线
+type Engine struct {
+	market service.MarketDataService
+	now    func() time.Time
+
+	mu     sync.Mutex
+	series map[seriesKey]*tracker
+}
+
+// NewEngine 创建指标计算引擎
+func NewEngine(market service.MarketDataService) *Engine {
+	return &Engine{market: market, now: time.Now, series: map[seriesKey]*tracker{}}
+}
+
+// 检查产品类别和K线周期
+func validate(category, symbol, interval string) error {
+	if category != bybitapi.CategorySpot && category != bybitapi.CategoryLinear && category != bybitapi.CategoryInverse {
+		return fmt.Errorf("技术指标只支持spot、linear和inverse")
+	}
+	if symbol == "" {
+		return fmt.Errorf("交易对不能为空")
+	}
+	if _, ok := intervalMinutes[interval]; !ok && interval != "D" && interval != "W" && interval != "M" {
+		return fmt.Errorf("不支持的K线周期%q，可选值: 1、3、5、15、30、60、120、240、360、720、D、W、M", interval)
+	}
+	return nil
+}
+
+// 返回K线的结束时间
+func barEnd(start int64, interval string) int64 {
+	t := time.UnixMilli(start).UTC()
+	switch interval {
+	case "D":
+		return t.AddDate(0, 0, 1).UnixMilli()
+	case "W":
+		return t.AddDate(0, 0, 7).UnixMilli()
+	case "M":
+		return t.AddDate(0, 1, 0).UnixMilli()
+	}
+	return t.Add(time.Duration(intervalMinutes[interval]) * time.Minute).UnixMilli()
+}
+
+// 返回序列的状态，不存在时创建
+func (e *Engine) tracker(key seriesKey) *tracker {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t, ok := e.series[key]
+	if !ok {
+		if len(e.series) >= maxSeries {
+			e.evict()
+		}
+		t = &tracker{indicators: map[string]*tracked{}}
+		e.series[key] = t
+	}
+	t.used = e.now()
+	return t
+}
+
+// 移除最久未使用的序列，调用方持有e.mu
+func (e *Engine) evict() {
+	var oldest seriesKey
+	var oldestUsed time.Time
+	for key, t := range e.series {
+		if oldestUsed.IsZero() || t.used.Before(oldestUsed) {
+			oldest, oldestUsed = key, t.used
+		}
+	}
+	delete(e.series, oldest)
+}
+
+// Query 计算交易对在interval周期上的指标，每个指标返回最近limit个值（默认1个，最多1000个）
+// 只使用已经收盘的K线，正在形成的K线不参与计算
+func (e *Engine) Query(ctx context.Context, category, symbol, interval string, specs []Spec, limit int) (*Result, error) {
+	if err := validate(category, symbol, interval); err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("至少需要一个指标")
+	}
+	normalized := make([]Spec, len(specs))
+	for i, spec := range specs {
+		var err error
+		if normalized[i], err = spec.Normalize(); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = 1
+	} else if limit > maxBars {
+		limit = maxBars
+	}
+
+	t := e.tracker(seriesKey{service.AccountFromContext(ctx), category, symbol, interval})
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := e.refresh(ctx, t, category, symbol, interval); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Category: category, Symbol: symbol, Interval: interval, Bars: len(t.bars), Indicators: []Output{}}
+	if len(t.bars) > 0 {
+		last := t.bars[len(t.bars)-1]
+		result.LastBar = &last
+	}
+	for _, spec := range normalized {
+		tr, err := t.indicator(spec)
+		if err != nil {
+			return nil, err
+		}
+		points := tr.points
+		if len(points) > limit {
+			points = points[len(points)-limit:]
+		}
+		result.Indicators = append(result.Indicators, Output{
+			Name:   spec.String(),
+			Spec:   spec,
+			Stable: tr.fed >= spec.Warmup(),
+			Points: append([]Point{}, points...),
+		})
+	}
+	return result, nil
+}
+
+// Push 传入K线推送中已经收盘的K线，只有已经查询过的序列会更新，早于最新K线的会被忽略
+func (e *Engine) Push(ctx context.Context, category, symbol, interval string, bar Bar) {
+	key := seriesKey{service.AccountFromContext(ctx), category, symbol, interval}
+	e.mu.Lock()
+	t, ok := e.series[key]
+	e.mu.Unlock()
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.bars) > 0 {
+		t.append([]Bar{bar})
+	}
+}
+
+// 最新一根K线的开始时间，没有K线时返回-1
+func (t *tracker) lastStart() int64 {
+	if len(t.bars) == 0 {
+		return -1
+	}
+	return t.bars[len(t.bars)-1].Start
+}
+
+// 获取上次之后新收盘的K线并更新指标，下一根K线尚未收盘时不请求，调用方持有t.mu
+func (e *Engine) refresh(ctx context.Context, t *tracker, category, symbol, interval string) error {
+	now := e.now().UnixMilli()
+	last := t.lastStart()
+	if last >= 0 && barEnd(barEnd(last, interval), interval) > now {
+		return nil
+	}
+
+	start := int64(0)
+	if last >= 0 {
+		start = last + 1
+	}
+	resp, err := e.market.GetKline(ctx, category, symbol, interval, maxBars, start, now)
+	if err != nil {
+		return err
+	}
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	if len(page.List) > 0 {
+		if err := json.Unmarshal(page.List, &rows); err != nil {
+			return fmt.Errorf("解析K线失败: %v", err)
+		}
+	}
+
+	bars := make([]Bar, 0, len(rows))
+	for _, row := range rows {
+		bar, ok := parseBar(row)
+		if ok && barEnd(bar.Start, interval) <= now {
+			bars = append(bars, bar)
+		}
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Start < bars[j].Start })
+
+	// 返回了一整页时，和已有的K线之间可能有缺口，重新计算
+	if len(rows) >= maxBars {
+		t.bars = nil
+		t.indicators = map[string]*tracked{}
+	}
+	t.append(bars)
+	return nil
+}
+
+// 追加按时间排序的K线并更新全部指标
+func (t *tracker) append(bars []Bar) {
+	for _, bar := range bars {
+		if bar.Start <= t.lastStart() {
+			continue
+		}
+		t.bars = append(t.bars, bar)
+		for _, tr := range t.indicators {
+			tr.update(bar)
+		}
+	}
+	if len(t.bars) > maxBars {
+		t.bars = append([]Bar(nil), t.bars[len(t.bars)-maxBars:]...)
+	}
+}
+
+// 返回指标的状态，第一次使用时用已有的K线计算
+func (t *tracker) indicator(spec Spec) (*tracked, error) {
+	name := spec.String()
+	if tr, ok := t.indicators[name]; ok {
+		return tr, nil
+	}
+	indicator, err := New(spec)
+	if err != nil {
+		return nil, err
+	}
+	tr := &tracked{spec: spec, indicator: indicator, points: []Point{}}
+	for _, bar := range t.bars {
+		tr.update(bar)
+	}
+	t.indicators[name] = tr
+	return tr, nil
+}
+
+// 传入一根K线并记录指标的值
+func (tr *tracked) update(bar Bar) {
+	tr.indicator.Update(bar)
+	tr.fed++
+	if values, ok := tr.indicator.Value(); ok {
+		tr.points = append(tr.points, Point{Time: bar.Start, Values: values})
+		if len(tr.points) > maxBars {
+			tr.points = append([]Point(nil), tr.points[len(tr.points)-maxBars:]...)
+		}
+	}
+}
+
+// 解析一行K线：[开始时间, 开盘价, 最高价, 最低价, 收盘价, 成交量, 成交额]
+func parseBar(row []string) (Bar, bool) {
+	if len(row) < 6 {
+		return Bar{}, false
+	}
+	start, err := strconv.ParseInt(row[0], 10, 64)
+	if err != nil {
+		return Bar{}, false
+	}
+	values := make([]float64, 5)
+	for i := range values {
+		if values[i], err = strconv.ParseFloat(row[i+1], 64); err != nil {
+			return Bar{}, false
+		}
+	}
+	return Bar{Start: start, Open: values[0], High: values[1], Low: values[2], Close: values[3], Volume: values[4]}, true
+}
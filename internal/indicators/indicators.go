@@ -0,0 +1,207 @@
+// Package indicators 计算常用的技术指标
+//
+// 每个指标都是增量计算的：按时间顺序逐根传入已经收盘的K线，随时可以读取最新的值，
+// 因此既可以用GetKline获取的历史K线初始化，也可以继续接收K线推送更新。
+package indicators
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 指标类型
+const (
+	TypeSMA        = "sma"        // 简单移动平均，参数：周期（默认20）
+	TypeEMA        = "ema"        // 指数移动平均，参数：周期（默认20）
+	TypeRSI        = "rsi"        // 相对强弱指数，参数：周期（默认14）
+	TypeMACD       = "macd"       // 参数：快线、慢线、信号线周期（默认12、26、9）
+	TypeBollinger  = "bollinger"  // 布林带，参数：周期、标准差倍数（默认20、2）
+	TypeATR        = "atr"        // 平均真实波幅，参数：周期（默认14）
+	TypeVWAP       = "vwap"       // 成交量加权平均价，参数：周期，为0时按UTC自然日累计（默认0）
+	TypeStochastic = "stochastic" // 随机指标，参数：%K周期、%K平滑周期、%D周期（默认14、3、3）
+	TypeOBV        = "obv"        // 能量潮，没有参数
+	TypeADX        = "adx"        // 平均趋向指数，参数：周期（默认14）
+)
+
+// Types 返回全部指标类型
+func Types() []string {
+	return []string{TypeSMA, TypeEMA, TypeRSI, TypeMACD, TypeBollinger, TypeATR, TypeVWAP, TypeStochastic, TypeOBV, TypeADX}
+}
+
+// 各指标的默认参数，参数个数也是最多可以指定的个数
+var defaultParams = map[string][]float64{
+	TypeSMA:        {20},
+	TypeEMA:        {20},
+	TypeRSI:        {14},
+	TypeMACD:       {12, 26, 9},
+	TypeBollinger:  {20, 2},
+	TypeATR:        {14},
+	TypeVWAP:       {0},
+	TypeStochastic: {14, 3, 3},
+	TypeOBV:        {},
+	TypeADX:        {14},
+}
+
+// Bar 是一根已经收盘的K线
+type Bar struct {
+	Start  int64   `json:"start"` // 开始时间（毫秒）
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// Values 是指标在一根K线上的值，按字段名索引
+// 单值指标的字段为value；MACD为macd、signal、histogram；布林带为upper、middle、lower；
+// 随机指标为k、d；ADX为adx、plusDi、minusDi
+type Values map[string]float64
+
+// Indicator 是增量计算的技术指标
+type Indicator interface {
+	// Update 传入下一根已经收盘的K线
+	Update(bar Bar)
+	// Value 返回最新的值，数据不足以计算时ok为false
+	Value() (values Values, ok bool)
+}
+
+// Spec 描述一个指标，Params按顺序对应各指标的参数，省略的参数使用默认值
+type Spec struct {
+	Type   string    `json:"type"`
+	Params []float64 `json:"params,omitempty"`
+}
+
+// Normalize 检查指标类型和参数，返回补全默认参数后的Spec
+func (s Spec) Normalize() (Spec, error) {
+	kind := strings.ToLower(strings.TrimSpace(s.Type))
+	defaults, ok := defaultParams[kind]
+	if !ok {
+		return Spec{}, fmt.Errorf("未知的指标类型%q，可选值: %s", s.Type, strings.Join(Types(), "、"))
+	}
+	if len(s.Params) > len(defaults) {
+		return Spec{}, fmt.Errorf("%s最多%d个参数", kind, len(defaults))
+	}
+	params := append([]float64(nil), defaults...)
+	copy(params, s.Params)
+
+	for i, p := range params {
+		// 除布林带的标准差倍数外，参数都是周期，必须是整数
+		if kind == TypeBollinger && i == 1 {
+			if p <= 0 {
+				return Spec{}, fmt.Errorf("布林带的标准差倍数必须大于0")
+			}
+			continue
+		}
+		if p != float64(int(p)) || p < 0 || (p == 0 && kind != TypeVWAP) {
+			return Spec{}, fmt.Errorf("%s的周期必须是正整数", kind)
+		}
+	}
+	if kind == TypeMACD && params[0] >= params[1] {
+		return Spec{}, fmt.Errorf("MACD的快线周期必须小于慢线周期")
+	}
+	return Spec{Type: kind, Params: params}, nil
+}
+
+// String 返回指标名称，例如macd(12,26,9)
+func (s Spec) String() string {
+	if len(s.Params) == 0 {
+		return s.Type
+	}
+	params := make([]string, len(s.Params))
+	for i, p := range s.Params {
+		params[i] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	return s.Type + "(" + strings.Join(params, ",") + ")"
+}
+
+// Warmup 返回指标稳定所需的大致K线数量，用于决定初始化时获取多少历史K线
+// 指数平滑类指标在这个数量之后受初始值的影响已经可以忽略
+func (s Spec) Warmup() int {
+	p := make([]int, len(s.Params))
+	for i, v := range s.Params {
+		p[i] = int(v)
+	}
+	switch s.Type {
+	case TypeEMA, TypeRSI, TypeATR:
+		return 10 * p[0]
+	case TypeMACD:
+		return 10*p[1] + p[2]
+	case TypeADX:
+		return 20 * p[0]
+	case TypeStochastic:
+		return p[0] + p[1] + p[2]
+	case TypeOBV:
+		return 1
+	case TypeVWAP:
+		if p[0] == 0 {
+			return 1
+		}
+	}
+	return p[0]
+}
+
+// New 按Spec创建指标，Spec会先经过Normalize
+func New(spec Spec) (Indicator, error) {
+	spec, err := spec.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	p := spec.Params
+	switch spec.Type {
+	case TypeSMA:
+		return newSMAIndicator(int(p[0])), nil
+	case TypeEMA:
+		return newEMAIndicator(int(p[0])), nil
+	case TypeRSI:
+		return newRSI(int(p[0])), nil
+	case TypeMACD:
+		return newMACD(int(p[0]), int(p[1]), int(p[2])), nil
+	case TypeBollinger:
+		return newBollinger(int(p[0]), p[1]), nil
+	case TypeATR:
+		return newATR(int(p[0])), nil
+	case TypeVWAP:
+		return newVWAP(int(p[0])), nil
+	case TypeStochastic:
+		return newStochastic(int(p[0]), int(p[1]), int(p[2])), nil
+	case TypeOBV:
+		return &obv{}, nil
+	case TypeADX:
+		return newADX(int(p[0])), nil
+	}
+	return nil, fmt.Errorf("未知的指标类型%q", spec.Type)
+}
+
+// 固定长度的滑动窗口，保存最近的n个值
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(n int) *window {
+	return &window{values: make([]float64, n)}
+}
+
+// 加入一个值，窗口已满时返回被移出的值
+func (w *window) push(v float64) (removed float64, evicted bool) {
+	if w.full {
+		removed, evicted = w.values[w.next], true
+	}
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+	return removed, evicted
+}
+
+// 窗口中的值，顺序不限
+func (w *window) all() []float64 {
+	if w.full {
+		return w.values
+	}
+	return w.values[:w.next]
+}
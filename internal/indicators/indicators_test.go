@@ -0,0 +1,235 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// 只有收盘价的K线
+func closes(values ...float64) []Bar {
+	bars := make([]Bar, len(values))
+	for i, v := range values {
+		bars[i] = Bar{Start: int64(i) * 60000, Open: v, High: v, Low: v, Close: v, Volume: 1}
+	}
+	return bars
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		spec    Spec
+		want    string
+		wantErr bool
+	}{
+		{spec: Spec{Type: "MACD"}, want: "macd(12,26,9)"},
+		{spec: Spec{Type: " sma ", Params: []float64{50}}, want: "sma(50)"},
+		{spec: Spec{Type: "bollinger", Params: []float64{20, 2.5}}, want: "bollinger(20,2.5)"},
+		{spec: Spec{Type: "stochastic", Params: []float64{9}}, want: "stochastic(9,3,3)"},
+		{spec: Spec{Type: "vwap"}, want: "vwap(0)"},
+		{spec: Spec{Type: "obv"}, want: "obv"},
+		{spec: Spec{Type: "kdj"}, wantErr: true},
+		{spec: Spec{Type: "sma", Params: []float64{1.5}}, wantErr: true},
+		{spec: Spec{Type: "ema", Params: []float64{0}}, wantErr: true},
+		{spec: Spec{Type: "rsi", Params: []float64{-14}}, wantErr: true},
+		{spec: Spec{Type: "bollinger", Params: []float64{20, 0}}, wantErr: true},
+		{spec: Spec{Type: "macd", Params: []float64{26, 12}}, wantErr: true},
+		{spec: Spec{Type: "atr", Params: []float64{14, 3}}, wantErr: true},
+		{spec: Spec{Type: "obv", Params: []float64{1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.spec.Normalize()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Normalize(%+v) = %s, want error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Normalize(%+v) = %s, %v, want %s", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	tests := []struct {
+		spec Spec
+		want int
+	}{
+		{Spec{Type: TypeSMA}, 20},
+		{Spec{Type: TypeEMA}, 200},
+		{Spec{Type: TypeMACD}, 269},
+		{Spec{Type: TypeADX}, 280},
+		{Spec{Type: TypeStochastic}, 20},
+		{Spec{Type: TypeVWAP}, 1},
+		{Spec{Type: TypeVWAP, Params: []float64{30}}, 30},
+		{Spec{Type: TypeOBV}, 1},
+	}
+	for _, tt := range tests {
+		spec, err := tt.spec.Normalize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := spec.Warmup(); got != tt.want {
+			t.Errorf("%s Warmup = %d, want %d", spec, got, tt.want)
+		}
+	}
+}
+
+func TestIndicators(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  Spec
+		bars  []Bar
+		ready int // 第几根K线开始有值
+		want  Values
+	}{
+		{
+			name:  "sma",
+			spec:  Spec{Type: TypeSMA, Params: []float64{3}},
+			bars:  closes(1, 2, 3, 4, 5),
+			ready: 3,
+			want:  Values{"value": 4},
+		},
+		{
+			// alpha=0.5，初始值为前3根的平均值2
+			name:  "ema",
+			spec:  Spec{Type: TypeEMA, Params: []float64{3}},
+			bars:  closes(1, 2, 3, 4, 5),
+			ready: 3,
+			want:  Values{"value": 4},
+		},
+		{
+			name:  "rsi only gains",
+			spec:  Spec{Type: TypeRSI, Params: []float64{3}},
+			bars:  closes(1, 2, 3, 4),
+			ready: 4,
+			want:  Values{"value": 100},
+		},
+		{
+			// 平均涨幅2/3，平均跌幅1/3，RS=2
+			name:  "rsi mixed",
+			spec:  Spec{Type: TypeRSI, Params: []float64{3}},
+			bars:  closes(10, 11, 10, 11),
+			ready: 4,
+			want:  Values{"value": 100 - 100.0/3},
+		},
+		{
+			name:  "rsi flat",
+			spec:  Spec{Type: TypeRSI, Params: []float64{2}},
+			bars:  closes(5, 5, 5),
+			ready: 3,
+			want:  Values{"value": 50},
+		},
+		{
+			// 线性上涨时快慢线之差恒定
+			name:  "macd",
+			spec:  Spec{Type: TypeMACD, Params: []float64{2, 3, 2}},
+			bars:  closes(1, 2, 3, 4, 5),
+			ready: 4,
+			want:  Values{"macd": 0.5, "signal": 0.5, "histogram": 0},
+		},
+		{
+			name:  "bollinger",
+			spec:  Spec{Type: TypeBollinger, Params: []float64{4, 2}},
+			bars:  closes(1, 2, 3, 4),
+			ready: 4,
+			want:  Values{"upper": 2.5 + 2*math.Sqrt(1.25), "middle": 2.5, "lower": 2.5 - 2*math.Sqrt(1.25)},
+		},
+		{
+			// 真实波幅依次为2、3、1，初始值2.5，再经Wilder平滑为1.75
+			name: "atr",
+			spec: Spec{Type: TypeATR, Params: []float64{2}},
+			bars: []Bar{
+				{High: 10, Low: 8, Close: 9},
+				{High: 12, Low: 9, Close: 11},
+				{High: 11, Low: 10, Close: 10.5},
+			},
+			ready: 2,
+			want:  Values{"value": 1.75},
+		},
+		{
+			// 第三根K线属于新的一天，重新开始累计
+			name: "vwap daily",
+			spec: Spec{Type: TypeVWAP},
+			bars: []Bar{
+				{Start: 0, High: 3, Low: 1, Close: 2, Volume: 1},
+				{Start: 60000, High: 6, Low: 3, Close: 3, Volume: 3},
+				{Start: dayMillis, High: 6, Low: 4, Close: 5, Volume: 2},
+			},
+			ready: 1,
+			want:  Values{"value": 5},
+		},
+		{
+			name: "vwap rolling",
+			spec: Spec{Type: TypeVWAP, Params: []float64{2}},
+			bars: []Bar{
+				{Start: 0, High: 3, Low: 1, Close: 2, Volume: 1},
+				{Start: 60000, High: 6, Low: 3, Close: 3, Volume: 3},
+				{Start: dayMillis, High: 7, Low: 5, Close: 6, Volume: 1},
+			},
+			ready: 2,
+			want:  Values{"value": 4.5},
+		},
+		{
+			name: "stochastic",
+			spec: Spec{Type: TypeStochastic, Params: []float64{3, 2, 1}},
+			bars: []Bar{
+				{High: 3, Low: 1, Close: 2},
+				{High: 4, Low: 2, Close: 3},
+				{High: 5, Low: 3, Close: 4},
+				{High: 6, Low: 4, Close: 6},
+			},
+			ready: 4,
+			want:  Values{"k": 87.5, "d": 87.5},
+		},
+		{
+			name: "obv",
+			spec: Spec{Type: TypeOBV},
+			bars: []Bar{
+				{Close: 1, Volume: 10},
+				{Close: 2, Volume: 5},
+				{Close: 1, Volume: 3},
+				{Close: 1, Volume: 7},
+			},
+			ready: 1,
+			want:  Values{"value": 2},
+		},
+		{
+			// 单边上涨时-DI为0，ADX为100
+			name: "adx",
+			spec: Spec{Type: TypeADX, Params: []float64{2}},
+			bars: []Bar{
+				{High: 2, Low: 1, Close: 1.5},
+				{High: 3, Low: 2, Close: 2.5},
+				{High: 4, Low: 3, Close: 3.5},
+				{High: 5, Low: 4, Close: 4.5},
+			},
+			ready: 4,
+			want:  Values{"adx": 100, "plusDi": 200.0 / 3, "minusDi": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indicator, err := New(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Values
+			var ok bool
+			for i, bar := range tt.bars {
+				indicator.Update(bar)
+				got, ok = indicator.Value()
+				if ok != (i+1 >= tt.ready) {
+					t.Fatalf("bar %d: ready = %v, want ready from bar %d", i+1, ok, tt.ready)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Value = %v, want %v", got, tt.want)
+			}
+			for field, want := range tt.want {
+				if math.Abs(got[field]-want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", field, got[field], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package indicators
+
+import "math"
+
+// 相对强弱指数，涨跌幅使用Wilder平滑
+type rsi struct {
+	gain, loss *ema
+	prev       float64
+	started    bool
+	value      float64
+	ready      bool
+}
+
+func newRSI(period int) *rsi {
+	return &rsi{gain: newWilder(period), loss: newWilder(period)}
+}
+
+func (r *rsi) Update(bar Bar) {
+	if !r.started {
+		r.prev, r.started = bar.Close, true
+		return
+	}
+	change := bar.Close - r.prev
+	r.prev = bar.Close
+	gain, _ := r.gain.add(math.Max(change, 0))
+	loss, ok := r.loss.add(math.Max(-change, 0))
+	if !ok {
+		return
+	}
+	switch {
+	case loss == 0 && gain == 0:
+		r.value = 50
+	case loss == 0:
+		r.value = 100
+	default:
+		r.value = 100 - 100/(1+gain/loss)
+	}
+	r.ready = true
+}
+
+func (r *rsi) Value() (Values, bool) {
+	if !r.ready {
+		return nil, false
+	}
+	return Values{"value": r.value}, true
+}
+
+// 真实波幅，第一根K线没有前收盘价，取最高价减最低价
+type trueRange struct {
+	prevClose float64
+	started   bool
+}
+
+func (t *trueRange) next(bar Bar) float64 {
+	tr := bar.High - bar.Low
+	if t.started {
+		tr = math.Max(tr, math.Max(math.Abs(bar.High-t.prevClose), math.Abs(bar.Low-t.prevClose)))
+	}
+	t.prevClose, t.started = bar.Close, true
+	return tr
+}
+
+// 平均真实波幅，使用Wilder平滑
+type atr struct {
+	tr    trueRange
+	avg   *ema
+	value float64
+	ready bool
+}
+
+func newATR(period int) *atr {
+	return &atr{avg: newWilder(period)}
+}
+
+func (a *atr) Update(bar Bar) {
+	a.value, a.ready = a.avg.add(a.tr.next(bar))
+}
+
+func (a *atr) Value() (Values, bool) {
+	if !a.ready {
+		return nil, false
+	}
+	return Values{"value": a.value}, true
+}
+
+// 随机指标：%K为收盘价在最近kPeriod根K线最高最低价区间中的位置，
+// 经过smooth周期的简单平均后作为%K，%D为%K的dPeriod周期简单平均
+type stochastic struct {
+	highs, lows *window
+	smooth, d   *sma
+	k, dValue   float64
+	ready       bool
+}
+
+func newStochastic(kPeriod, smooth, dPeriod int) *stochastic {
+	return &stochastic{highs: newWindow(kPeriod), lows: newWindow(kPeriod), smooth: newSMA(smooth), d: newSMA(dPeriod)}
+}
+
+func (s *stochastic) Update(bar Bar) {
+	s.highs.push(bar.High)
+	s.lows.push(bar.Low)
+	if !s.highs.full {
+		return
+	}
+	highest, lowest := math.Inf(-1), math.Inf(1)
+	for _, v := range s.highs.all() {
+		highest = math.Max(highest, v)
+	}
+	for _, v := range s.lows.all() {
+		lowest = math.Min(lowest, v)
+	}
+	raw := 50.0
+	if highest > lowest {
+		raw = 100 * (bar.Close - lowest) / (highest - lowest)
+	}
+	k, ok := s.smooth.add(raw)
+	if !ok {
+		return
+	}
+	s.k = k
+	s.dValue, s.ready = s.d.add(k)
+}
+
+func (s *stochastic) Value() (Values, bool) {
+	if !s.ready {
+		return nil, false
+	}
+	return Values{"k": s.k, "d": s.dValue}, true
+}
+
+// 平均趋向指数：趋向变动和真实波幅经Wilder平滑得到+DI和-DI，DX再经Wilder平滑得到ADX
+type adx struct {
+	tr                trueRange
+	prevHigh, prevLow float64
+	started           bool
+	plusDM, minusDM   *ema
+	atr               *ema
+	dx                *ema
+	plusDI, minusDI   float64
+	value             float64
+	ready             bool
+}
+
+func newADX(period int) *adx {
+	return &adx{plusDM: newWilder(period), minusDM: newWilder(period), atr: newWilder(period), dx: newWilder(period)}
+}
+
+func (a *adx) Update(bar Bar) {
+	tr := a.tr.next(bar)
+	if !a.started {
+		a.prevHigh, a.prevLow, a.started = bar.High, bar.Low, true
+		return
+	}
+	up, down := bar.High-a.prevHigh, a.prevLow-bar.Low
+	a.prevHigh, a.prevLow = bar.High, bar.Low
+	plus, minus := 0.0, 0.0
+	if up > down && up > 0 {
+		plus = up
+	}
+	if down > up && down > 0 {
+		minus = down
+	}
+
+	plusDM, _ := a.plusDM.add(plus)
+	minusDM, _ := a.minusDM.add(minus)
+	atr, ok := a.atr.add(tr)
+	if !ok || atr == 0 {
+		return
+	}
+	a.plusDI, a.minusDI = 100*plusDM/atr, 100*minusDM/atr
+	dx := 0.0
+	if sum := a.plusDI + a.minusDI; sum > 0 {
+		dx = 100 * math.Abs(a.plusDI-a.minusDI) / sum
+	}
+	a.value, a.ready = a.dx.add(dx)
+}
+
+func (a *adx) Value() (Values, bool) {
+	if !a.ready {
+		return nil, false
+	}
+	return Values{"adx": a.value, "plusDi": a.plusDI, "minusDi": a.minusDI}, true
+}
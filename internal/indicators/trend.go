@@ -0,0 +1,146 @@
+package indicators
+
+import "math"
+
+// 简单移动平均
+type sma struct {
+	window *window
+	sum    float64
+}
+
+func newSMA(period int) *sma {
+	return &sma{window: newWindow(period)}
+}
+
+// 加入一个值，返回当前的平均值，数据不足时ok为false
+func (s *sma) add(v float64) (avg float64, ok bool) {
+	removed, _ := s.window.push(v)
+	s.sum += v - removed
+	return s.value()
+}
+
+func (s *sma) value() (float64, bool) {
+	if !s.window.full {
+		return 0, false
+	}
+	return s.sum / float64(len(s.window.values)), true
+}
+
+// 指数移动平均，用前period个值的简单平均作为初始值
+// alpha为平滑系数：EMA为2/(period+1)，Wilder平滑为1/period
+type ema struct {
+	alpha float64
+	seed  *sma
+	avg   float64
+	ready bool
+}
+
+func newEMA(period int) *ema {
+	return &ema{alpha: 2 / float64(period+1), seed: newSMA(period)}
+}
+
+func newWilder(period int) *ema {
+	return &ema{alpha: 1 / float64(period), seed: newSMA(period)}
+}
+
+func (e *ema) add(v float64) (avg float64, ok bool) {
+	if e.ready {
+		e.avg += e.alpha * (v - e.avg)
+		return e.avg, true
+	}
+	if e.avg, e.ready = e.seed.add(v); e.ready {
+		e.seed = nil
+	}
+	return e.avg, e.ready
+}
+
+func (e *ema) value() (float64, bool) {
+	return e.avg, e.ready
+}
+
+// 基于收盘价的单值均线指标
+type movingAverage struct {
+	add   func(v float64) (float64, bool)
+	value float64
+	ready bool
+}
+
+func newSMAIndicator(period int) *movingAverage {
+	return &movingAverage{add: newSMA(period).add}
+}
+
+func newEMAIndicator(period int) *movingAverage {
+	return &movingAverage{add: newEMA(period).add}
+}
+
+func (m *movingAverage) Update(bar Bar) {
+	m.value, m.ready = m.add(bar.Close)
+}
+
+func (m *movingAverage) Value() (Values, bool) {
+	if !m.ready {
+		return nil, false
+	}
+	return Values{"value": m.value}, true
+}
+
+// MACD：快线EMA减慢线EMA，信号线为MACD的EMA，柱状图为两者之差
+type macd struct {
+	fast, slow, signal *ema
+	line, sig          float64
+	ready              bool
+}
+
+func newMACD(fast, slow, signal int) *macd {
+	return &macd{fast: newEMA(fast), slow: newEMA(slow), signal: newEMA(signal)}
+}
+
+func (m *macd) Update(bar Bar) {
+	fast, _ := m.fast.add(bar.Close)
+	slow, ok := m.slow.add(bar.Close)
+	if !ok {
+		return
+	}
+	m.line = fast - slow
+	m.sig, m.ready = m.signal.add(m.line)
+}
+
+func (m *macd) Value() (Values, bool) {
+	if !m.ready {
+		return nil, false
+	}
+	return Values{"macd": m.line, "signal": m.sig, "histogram": m.line - m.sig}, true
+}
+
+// 布林带：中轨为简单移动平均，上下轨为中轨加减mult倍的总体标准差
+type bollinger struct {
+	mult   float64
+	window *window
+}
+
+func newBollinger(period int, mult float64) *bollinger {
+	return &bollinger{mult: mult, window: newWindow(period)}
+}
+
+func (b *bollinger) Update(bar Bar) {
+	b.window.push(bar.Close)
+}
+
+func (b *bollinger) Value() (Values, bool) {
+	if !b.window.full {
+		return nil, false
+	}
+	// 每次按窗口重新计算，避免累计平方和带来的精度损失
+	values := b.window.all()
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(values)))
+	return Values{"upper": mean + b.mult*std, "middle": mean, "lower": mean - b.mult*std}, true
+}
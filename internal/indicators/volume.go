@@ -0,0 +1,80 @@
+package indicators
+
+import "time"
+
+// 一天的毫秒数
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+// 成交量加权平均价，价格取(最高价+最低价+收盘价)/3
+// period为0时从每个UTC自然日的第一根K线开始累计，否则为最近period根K线的滚动值
+type vwap struct {
+	period        int
+	day           int64
+	pv, volume    float64
+	pvWin, volWin *window
+	value         float64
+	ready         bool
+}
+
+func newVWAP(period int) *vwap {
+	v := &vwap{period: period, day: -1}
+	if period > 0 {
+		v.pvWin, v.volWin = newWindow(period), newWindow(period)
+	}
+	return v
+}
+
+func (v *vwap) Update(bar Bar) {
+	pv := (bar.High + bar.Low + bar.Close) / 3 * bar.Volume
+	if v.period > 0 {
+		removedPV, _ := v.pvWin.push(pv)
+		removedVol, _ := v.volWin.push(bar.Volume)
+		v.pv += pv - removedPV
+		v.volume += bar.Volume - removedVol
+		if !v.pvWin.full {
+			return
+		}
+	} else {
+		if day := bar.Start / dayMillis; day != v.day {
+			v.day, v.pv, v.volume = day, 0, 0
+		}
+		v.pv += pv
+		v.volume += bar.Volume
+	}
+	if v.volume > 0 {
+		v.value, v.ready = v.pv/v.volume, true
+	}
+}
+
+func (v *vwap) Value() (Values, bool) {
+	if !v.ready {
+		return nil, false
+	}
+	return Values{"value": v.value}, true
+}
+
+// 能量潮：收盘价上涨时累加成交量，下跌时减去成交量，从第一根K线的0开始
+type obv struct {
+	prev    float64
+	value   float64
+	started bool
+}
+
+func (o *obv) Update(bar Bar) {
+	if o.started {
+		switch {
+		case bar.Close > o.prev:
+			o.value += bar.Volume
+		case bar.Close < o.prev:
+			o.value -= bar.Volume
+		}
+	}
+	o.prev, o.started = bar.Close, true
+}
+
+func (o *obv) Value() (Values, bool) {
+	if !o.started {
+		return nil, false
+	}
+	return Values{"value": o.value}, true
+}
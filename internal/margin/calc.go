@@ -0,0 +1,289 @@
+package margin
+
+import (
+	"fmt"
+	"math"
+)
+
+// 一个模拟中的仓位
+type holding struct {
+	symbol      string
+	side        string
+	size        float64
+	entry       float64
+	mark        float64
+	leverage    float64
+	isolated    bool
+	margin      float64 // 逐仓仓位的保证金，包括追加的保证金
+	exchangeLiq float64
+}
+
+func (h *holding) direction() float64 {
+	if h.side == "Sell" {
+		return -1
+	}
+	return 1
+}
+
+// 账户的仓位和余额
+type book struct {
+	wallet   float64 // 钱包余额，不含未实现盈亏
+	otherIM  float64 // 仓位以外的初始保证金，模拟中保持不变
+	otherMM  float64 // 仓位以外的维持保证金，模拟中保持不变
+	holdings []*holding
+	tiers    map[string][]tier
+	fee      float64 // 吃单手续费率
+}
+
+// 按标记价格计算的仓位数值
+type valuation struct {
+	tier  tier
+	value float64
+	pnl   float64
+	im    float64 // 逐仓为仓位保证金
+	mm    float64
+}
+
+func (b *book) value(h *holding) valuation {
+	v := valuation{value: h.size * h.mark, pnl: h.direction() * h.size * (h.mark - h.entry)}
+	v.tier = pickTier(b.tiers[h.symbol], v.value)
+	closeFee := v.value * b.fee
+	v.im = v.value/h.leverage + closeFee
+	if h.isolated {
+		v.im = h.margin
+	}
+	v.mm = math.Max(0, v.value*v.tier.rate-v.tier.deduction) + closeFee
+	return v
+}
+
+// 返回仓位价值所在的档位，超过最高档位时返回最高档位
+func pickTier(tiers []tier, value float64) tier {
+	for _, t := range tiers {
+		if value <= t.limit {
+			return t
+		}
+	}
+	if len(tiers) == 0 {
+		return tier{}
+	}
+	return tiers[len(tiers)-1]
+}
+
+// 计算账户和每个仓位的保证金，current为true时带上交易所返回的强平价格
+func (b *book) state(current bool) (*State, []string) {
+	var warnings []string
+	values := make([]valuation, len(b.holdings))
+	s := &State{WalletBalance: b.wallet, InitialMargin: b.otherIM, MaintenanceMargin: b.otherMM, Positions: []PositionMargin{}}
+
+	// 全仓部分的保证金余额不包括逐仓仓位的保证金和未实现盈亏
+	crossMM := b.otherMM
+	for i, h := range b.holdings {
+		v := b.value(h)
+		values[i] = v
+		s.UnrealisedPnl += v.pnl
+		s.InitialMargin += v.im
+		s.MaintenanceMargin += v.mm
+		if !h.isolated {
+			crossMM += v.mm
+		}
+		if v.tier.maxLeverage > 0 && h.leverage > v.tier.maxLeverage {
+			warnings = append(warnings, fmt.Sprintf("%s的杠杆%g超过仓位价值所在风险限额档位的最大杠杆%g", h.symbol, h.leverage, v.tier.maxLeverage))
+		}
+	}
+	s.MarginBalance = s.WalletBalance + s.UnrealisedPnl
+	s.Available = s.MarginBalance - s.InitialMargin
+	crossBalance := s.MarginBalance
+	for i, h := range b.holdings {
+		if h.isolated {
+			crossBalance -= h.margin + values[i].pnl
+		}
+	}
+	s.MarginRatio, s.Liquidated = ratio(crossMM, crossBalance)
+
+	for i, h := range b.holdings {
+		v := values[i]
+		p := PositionMargin{
+			Symbol:            h.symbol,
+			Side:              h.side,
+			Size:              h.size,
+			EntryPrice:        h.entry,
+			MarkPrice:         h.mark,
+			Leverage:          h.leverage,
+			PositionValue:     v.value,
+			UnrealisedPnl:     v.pnl,
+			Isolated:          h.isolated,
+			InitialMargin:     v.im,
+			MaintenanceMargin: v.mm,
+			MaintenanceRate:   v.tier.rate,
+			RiskLimitValue:    v.tier.limit,
+			MaxLeverage:       v.tier.maxLeverage,
+		}
+		if h.isolated {
+			p.PositionMargin = h.margin
+			p.MarginRatio, p.Liquidated = ratio(v.mm, h.margin+v.pnl)
+			p.LiquidationPrice = b.liquidationPrice(h, v.tier, h.margin, h.entry, 0)
+		} else {
+			p.MarginRatio, p.Liquidated = s.MarginRatio, s.Liquidated
+			p.LiquidationPrice = b.liquidationPrice(h, v.tier, crossBalance, h.mark, crossMM-v.mm)
+		}
+		if p.LiquidationPrice > 0 {
+			p.LiquidationDistance = (p.LiquidationPrice - h.mark) / h.mark
+		}
+		if current {
+			p.ExchangeLiqPrice = h.exchangeLiq
+		}
+		s.Positions = append(s.Positions, p)
+	}
+	return s, warnings
+}
+
+// 维持保证金除以保证金余额，余额不大于0且有维持保证金时为1
+func ratio(mm, balance float64) (float64, bool) {
+	if balance <= 0 {
+		if mm > 0 {
+			return 1, true
+		}
+		return 0, false
+	}
+	r := mm / balance
+	return r, r >= 1
+}
+
+// 求解价格P使保证金余额等于维持保证金：
+//
+//	balance + 方向*数量*(P-base) = rest + 数量*P*(维持保证金率+手续费率) - 速算扣除数
+//
+// 逐仓时balance为仓位保证金、base为开仓价格、rest为0；全仓时balance为全仓保证金余额、
+// base为标记价格、rest为其他仓位的维持保证金。多头的解不大于0时返回0
+func (b *book) liquidationPrice(h *holding, t tier, balance, base, rest float64) float64 {
+	k := t.rate + b.fee
+	var price float64
+	if h.side == "Sell" {
+		price = (balance + h.size*base - rest + t.deduction) / (h.size * (1 + k))
+	} else if k < 1 {
+		price = (rest - t.deduction - balance + h.size*base) / (h.size * (1 - k))
+	}
+	return math.Max(0, price)
+}
+
+func (b *book) clone() *book {
+	c := *b
+	c.holdings = make([]*holding, len(b.holdings))
+	for i, h := range b.holdings {
+		copied := *h
+		copied.exchangeLiq = 0
+		c.holdings[i] = &copied
+	}
+	return &c
+}
+
+// 返回仓位中出现的交易对
+func (b *book) symbols() []string {
+	var symbols []string
+	for _, h := range b.holdings {
+		symbols = append(symbols, h.symbol)
+	}
+	return symbols
+}
+
+// 返回交易对的第一个仓位
+func (b *book) find(symbol string) *holding {
+	for _, h := range b.holdings {
+		if h.symbol == symbol {
+			return h
+		}
+	}
+	return nil
+}
+
+// 返回交易对指定方向的仓位
+func (b *book) findSide(symbol, side string) *holding {
+	for _, h := range b.holdings {
+		if h.symbol == symbol && h.side == side {
+			return h
+		}
+	}
+	return nil
+}
+
+// 调整交易对的杠杆，逐仓仓位的保证金按新杠杆重新计算
+func (b *book) setLeverage(symbol string, leverage float64) {
+	for _, h := range b.holdings {
+		if h.symbol != symbol {
+			continue
+		}
+		h.leverage = leverage
+		if h.isolated {
+			h.margin = h.size * h.entry * (1/leverage + b.fee)
+		}
+	}
+}
+
+// 按价格成交，存在相同方向的仓位时加仓，否则减少反方向的仓位，超出部分开反向仓位
+// 开仓和平仓都按吃单手续费率扣除手续费
+func (b *book) fill(order Order, mark, leverage float64, isolated bool) {
+	price := order.Price
+	if price == 0 {
+		price = mark
+	}
+	qty := order.Qty
+	b.wallet -= qty * price * b.fee
+
+	if h := b.findSide(order.Symbol, order.Side); h != nil {
+		h.entry = (h.entry*h.size + price*qty) / (h.size + qty)
+		h.size += qty
+		if h.isolated {
+			h.margin += qty * price * (1/h.leverage + b.fee)
+		}
+		return
+	}
+
+	if h := b.find(order.Symbol); h != nil {
+		closed := math.Min(qty, h.size)
+		b.wallet += h.direction() * closed * (price - h.entry)
+		if h.isolated {
+			h.margin -= h.margin * closed / h.size
+		}
+		h.size -= closed
+		qty -= closed
+		isolated, leverage = h.isolated, h.leverage
+		if h.size == 0 {
+			b.remove(h)
+		}
+		if qty == 0 {
+			return
+		}
+	}
+
+	h := &holding{
+		symbol:   order.Symbol,
+		side:     order.Side,
+		size:     qty,
+		entry:    price,
+		mark:     mark,
+		leverage: leverage,
+		isolated: isolated,
+	}
+	if isolated {
+		h.margin = qty * price * (1/leverage + b.fee)
+	}
+	b.holdings = append(b.holdings, h)
+}
+
+func (b *book) remove(h *holding) {
+	for i, existing := range b.holdings {
+		if existing == h {
+			b.holdings = append(b.holdings[:i], b.holdings[i+1:]...)
+			return
+		}
+	}
+}
+
+// 按比例变动交易对的标记价格，交易对为空时变动全部仓位
+func (b *book) move(symbol string, change float64) {
+	for _, h := range b.holdings {
+		if symbol == "" || h.symbol == symbol {
+			h.mark *= 1 + change
+		}
+	}
+}
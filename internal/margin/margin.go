@@ -0,0 +1,548 @@
+// Package margin 根据当前仓位、钱包余额和风险限额档位估算初始保证金、维持保证金、保证金率和强平价格，
+// 并模拟下单、调整杠杆和价格变动之后的结果
+//
+// 只支持USDT和USDC合约（linear）。仓位价值按标记价格计算，维持保证金率取仓位价值所在的风险限额档位，
+// 初始保证金和维持保证金都包括按吃单手续费率估算的平仓手续费。
+// 逐仓仓位在仓位保证金加未实现盈亏不足维持保证金时强平；全仓和统一账户在全仓保证金余额不足全部维持保证金时强平，
+// 计算一个仓位的强平价格时其他仓位的价格保持不变，并且不考虑价格变化后风险限额档位的变化。
+// 组合保证金按压力测试计算保证金，无法用风险限额档位估算，不支持组合保证金账户。
+package margin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 保证金模式
+const (
+	ModeIsolated = "isolated" // 逐仓
+	ModeCross    = "cross"    // 经典账户全仓，逐仓仓位按仓位的设置
+	ModeUnified  = "unified"  // 统一账户全仓
+)
+
+// ErrPortfolioMargin 表示账户使用组合保证金模式，无法模拟
+var ErrPortfolioMargin = errors.New("不支持组合保证金账户，组合保证金按压力测试计算")
+
+// 每类模拟场景的最大数量
+const maxScenarios = 20
+
+// 新仓位没有指定杠杆时使用的杠杆
+const defaultLeverage = 10
+
+// 无法获取账户手续费率时使用的默认吃单费率（非VIP）
+const defaultTakerFee = 0.00055
+
+// Order 是模拟的市价或限价成交
+// 存在相同方向的仓位时加仓，否则减少反方向的仓位，超出部分开反向仓位
+type Order struct {
+	Symbol string
+	Side   string  // Buy或Sell
+	Qty    float64 // 合约数量
+	Price  float64 // 成交价格，0表示按标记价格
+}
+
+// Leverage 是模拟的杠杆调整，同时作为该交易对新仓位的杠杆
+type Leverage struct {
+	Symbol   string
+	Leverage float64
+}
+
+// PriceMove 是模拟的标记价格变动
+type PriceMove struct {
+	Symbol string  // 为空时应用于全部仓位
+	Change float64 // 变动比例（小数），例如-0.1表示下跌10%
+}
+
+// Query 是模拟条件，场景按调整杠杆、成交、价格变动的顺序应用
+type Query struct {
+	SettleCoin string // USDT或USDC，默认USDT
+	Mode       string // 为空时使用账户当前的保证金模式
+	Orders     []Order
+	Leverages  []Leverage
+	PriceMoves []PriceMove
+}
+
+// PositionMargin 是一个仓位的保证金和强平价格，金额以结算币计，比率都是小数
+type PositionMargin struct {
+	Symbol        string  `json:"symbol"`
+	Side          string  `json:"side"`
+	Size          float64 `json:"size"`
+	EntryPrice    float64 `json:"entryPrice"`
+	MarkPrice     float64 `json:"markPrice"`
+	Leverage      float64 `json:"leverage"`
+	PositionValue float64 `json:"positionValue"` // 按标记价格计算的仓位价值
+	UnrealisedPnl float64 `json:"unrealisedPnl"`
+	Isolated      bool    `json:"isolated"`
+
+	PositionMargin    float64 `json:"positionMargin,omitempty"` // 逐仓仓位的保证金
+	InitialMargin     float64 `json:"initialMargin"`
+	MaintenanceMargin float64 `json:"maintenanceMargin"`
+	MaintenanceRate   float64 `json:"maintenanceRate"` // 风险限额档位的维持保证金率
+	RiskLimitValue    float64 `json:"riskLimitValue"`  // 风险限额档位的仓位价值上限
+	MaxLeverage       float64 `json:"maxLeverage"`     // 风险限额档位的最大杠杆
+	MarginRatio       float64 `json:"marginRatio"`     // 逐仓为仓位的维持保证金率，全仓与账户相同
+
+	LiquidationPrice    float64 `json:"liquidationPrice"`           // 0表示价格下跌到0也不会强平
+	LiquidationDistance float64 `json:"liquidationDistance"`        // 强平价格相对标记价格的变动比例
+	ExchangeLiqPrice    float64 `json:"exchangeLiqPrice,omitempty"` // 交易所返回的强平价格，只有当前状态有
+	Liquidated          bool    `json:"liquidated"`                 // 当前价格已达到强平条件
+}
+
+// State 是账户的保证金状态
+type State struct {
+	WalletBalance     float64          `json:"walletBalance"` // 不含未实现盈亏
+	UnrealisedPnl     float64          `json:"unrealisedPnl"`
+	MarginBalance     float64          `json:"marginBalance"` // 钱包余额加未实现盈亏
+	InitialMargin     float64          `json:"initialMargin"`
+	MaintenanceMargin float64          `json:"maintenanceMargin"`
+	Available         float64          `json:"available"`   // 保证金余额减初始保证金
+	MarginRatio       float64          `json:"marginRatio"` // 全仓维持保证金除以全仓保证金余额，达到1时强平
+	Liquidated        bool             `json:"liquidated"`  // 全仓部分已达到强平条件
+	Positions         []PositionMargin `json:"positions"`
+}
+
+// Result 是保证金模拟的结果
+// 统一账户的余额和保证金以USD计，经典账户以结算币计
+type Result struct {
+	Time         int64    `json:"time"`
+	Mode         string   `json:"mode"`
+	SettleCoin   string   `json:"settleCoin"`
+	TakerFeeRate float64  `json:"takerFeeRate"`
+	Current      *State   `json:"current"`
+	Simulated    *State   `json:"simulated,omitempty"` // 没有模拟场景时为空
+	Warnings     []string `json:"warnings"`
+}
+
+// 风险限额档位，按仓位价值上限升序排列
+type tier struct {
+	limit       float64
+	rate        float64 // 维持保证金率
+	deduction   float64 // 维持保证金速算扣除数
+	maxLeverage float64
+}
+
+// Simulator 获取账户数据并计算保证金
+type Simulator struct {
+	svc service.BybitService
+	now func() time.Time
+}
+
+// NewSimulator 创建保证金模拟器
+func NewSimulator(svc service.BybitService) *Simulator {
+	return &Simulator{svc: svc, now: time.Now}
+}
+
+// Validate 检查模拟条件，同时统一交易对和方向的大小写
+func Validate(query *Query) error {
+	switch query.Mode {
+	case "", ModeIsolated, ModeCross, ModeUnified:
+	case "portfolio":
+		return fmt.Errorf("不支持模拟组合保证金，组合保证金按压力测试计算")
+	default:
+		return fmt.Errorf("不支持的保证金模式%q，可选值: isolated、cross、unified", query.Mode)
+	}
+	query.SettleCoin = strings.ToUpper(query.SettleCoin)
+	if query.SettleCoin != "" && query.SettleCoin != "USDT" && query.SettleCoin != "USDC" {
+		return fmt.Errorf("结算币只能是USDT或USDC")
+	}
+	if len(query.Orders) > maxScenarios || len(query.Leverages) > maxScenarios || len(query.PriceMoves) > maxScenarios {
+		return fmt.Errorf("每类模拟场景最多%d个", maxScenarios)
+	}
+
+	for i := range query.Orders {
+		order := &query.Orders[i]
+		order.Symbol = strings.ToUpper(order.Symbol)
+		switch strings.ToLower(order.Side) {
+		case "buy":
+			order.Side = "Buy"
+		case "sell":
+			order.Side = "Sell"
+		default:
+			return fmt.Errorf("成交的方向只能是Buy或Sell")
+		}
+		if order.Symbol == "" || order.Qty <= 0 || order.Price < 0 {
+			return fmt.Errorf("成交必须指定交易对和大于0的数量，价格不能为负数")
+		}
+	}
+	for i := range query.Leverages {
+		leverage := &query.Leverages[i]
+		leverage.Symbol = strings.ToUpper(leverage.Symbol)
+		if leverage.Symbol == "" || leverage.Leverage < 1 {
+			return fmt.Errorf("调整杠杆必须指定交易对，杠杆不能小于1")
+		}
+	}
+	for i := range query.PriceMoves {
+		move := &query.PriceMoves[i]
+		move.Symbol = strings.ToUpper(move.Symbol)
+		if move.Change <= -1 {
+			return fmt.Errorf("价格变动比例必须大于-1")
+		}
+	}
+	return nil
+}
+
+// Simulate 计算账户当前的保证金状态，有模拟场景时同时计算应用场景之后的状态
+func (s *Simulator) Simulate(ctx context.Context, query Query) (*Result, error) {
+	if err := Validate(&query); err != nil {
+		return nil, err
+	}
+	if query.SettleCoin == "" {
+		query.SettleCoin = "USDT"
+	}
+
+	mode, unified, err := s.accountMode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if query.Mode != "" {
+		mode = query.Mode
+	}
+	result := &Result{Time: s.now().UnixMilli(), Mode: mode, SettleCoin: query.SettleCoin, Warnings: []string{}}
+
+	b, err := s.positions(ctx, query.SettleCoin, mode)
+	if err != nil {
+		return nil, err
+	}
+	balance, im, mm, err := s.wallet(ctx, unified, query.SettleCoin)
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有仓位的交易对需要行情中的标记价格
+	marks := map[string]float64{}
+	for _, h := range b.holdings {
+		marks[h.symbol] = h.mark
+	}
+	tiers := map[string][]tier{}
+	symbols := b.symbols()
+	for _, order := range query.Orders {
+		symbols = append(symbols, order.Symbol)
+	}
+	for _, leverage := range query.Leverages {
+		symbols = append(symbols, leverage.Symbol)
+	}
+	for _, symbol := range symbols {
+		if _, ok := tiers[symbol]; ok {
+			continue
+		}
+		if tiers[symbol], err = s.riskLimits(ctx, symbol); err != nil {
+			return nil, err
+		}
+		if _, ok := marks[symbol]; !ok {
+			if marks[symbol], err = s.markPrice(ctx, symbol); err != nil {
+				return nil, err
+			}
+		}
+	}
+	result.TakerFeeRate = s.takerFee(ctx, query.SettleCoin)
+
+	// 钱包余额按交易所返回的保证金余额减去按标记价格计算的未实现盈亏，
+	// 交易所的保证金超过仓位保证金的部分（例如挂单、现货借币和期权）在模拟中保持不变
+	b.fee = result.TakerFeeRate
+	b.tiers = tiers
+	var positionIM, positionMM, pnl float64
+	for _, h := range b.holdings {
+		v := b.value(h)
+		pnl += v.pnl
+		positionIM += v.im
+		positionMM += v.mm
+	}
+	b.wallet = balance - pnl
+	b.otherIM = math.Max(0, im-positionIM)
+	b.otherMM = math.Max(0, mm-positionMM)
+
+	var warnings []string
+	result.Current, warnings = b.state(true)
+	result.Warnings = appendUnique(result.Warnings, warnings...)
+	if len(query.Orders) == 0 && len(query.Leverages) == 0 && len(query.PriceMoves) == 0 {
+		return result, nil
+	}
+
+	sim := b.clone()
+	leverages := map[string]float64{}
+	for _, leverage := range query.Leverages {
+		sim.setLeverage(leverage.Symbol, leverage.Leverage)
+		leverages[leverage.Symbol] = leverage.Leverage
+	}
+	for _, order := range query.Orders {
+		leverage := leverages[order.Symbol]
+		if h := sim.find(order.Symbol); h != nil && leverage == 0 {
+			leverage = h.leverage
+		}
+		if leverage == 0 {
+			leverage = defaultLeverage
+			result.Warnings = appendUnique(result.Warnings, fmt.Sprintf("%s没有指定杠杆，新仓位按%d倍计算", order.Symbol, defaultLeverage))
+		}
+		sim.fill(order, marks[order.Symbol], leverage, mode == ModeIsolated)
+	}
+	for _, move := range query.PriceMoves {
+		sim.move(move.Symbol, move.Change)
+	}
+	result.Simulated, warnings = sim.state(false)
+	result.Warnings = appendUnique(result.Warnings, warnings...)
+	return result, nil
+}
+
+// 返回账户当前的保证金模式和是否为统一账户，经典账户为cross，组合保证金账户返回ErrPortfolioMargin
+func (s *Simulator) accountMode(ctx context.Context) (string, bool, error) {
+	resp, err := s.svc.GetAccountInfo(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	var info struct {
+		UnifiedMarginStatus int    `json:"unifiedMarginStatus"`
+		MarginMode          string `json:"marginMode"`
+	}
+	if err := decodeResult(resp, &info); err != nil {
+		return "", false, err
+	}
+	// unifiedMarginStatus为1表示经典账户，3到6为不同版本的统一账户
+	if info.UnifiedMarginStatus <= 1 {
+		return ModeCross, false, nil
+	}
+	switch info.MarginMode {
+	case "ISOLATED_MARGIN":
+		return ModeIsolated, true, nil
+	case "PORTFOLIO_MARGIN":
+		return "", true, ErrPortfolioMargin
+	}
+	return ModeUnified, true, nil
+}
+
+// 获取结算币的全部仓位，逐仓模式下全部仓位按逐仓计算，经典账户按仓位的设置
+func (s *Simulator) positions(ctx context.Context, settleCoin, mode string) (*book, error) {
+	resp, err := s.svc.GetPositions(ctx, bybitapi.CategoryLinear, "", settleCoin, "")
+	if err != nil {
+		return nil, err
+	}
+	var positions []struct {
+		model.Position
+		AvgPrice  string `json:"avgPrice"`
+		LiqPrice  string `json:"liqPrice"`
+		TradeMode int    `json:"tradeMode"` // 0为全仓，1为逐仓
+	}
+	if err := decodeList(resp, &positions); err != nil {
+		return nil, err
+	}
+
+	b := &book{}
+	for _, position := range positions {
+		size := num(position.Size)
+		if size == 0 || (position.Side != "Buy" && position.Side != "Sell") {
+			continue
+		}
+		entry := num(position.AvgPrice)
+		if entry == 0 {
+			entry = num(position.EntryPrice)
+		}
+		h := &holding{
+			symbol:      position.Symbol,
+			side:        position.Side,
+			size:        size,
+			entry:       entry,
+			mark:        num(position.MarkPrice),
+			leverage:    num(position.Leverage),
+			isolated:    mode == ModeIsolated || (mode == ModeCross && position.TradeMode == 1),
+			exchangeLiq: num(position.LiqPrice),
+		}
+		if h.mark <= 0 {
+			h.mark = entry
+		}
+		if h.leverage <= 0 {
+			h.leverage = 1
+		}
+		if h.isolated {
+			h.margin = num(position.PositionBalance)
+			if h.margin <= 0 {
+				h.margin = num(position.PositionIM)
+			}
+			if h.margin <= 0 {
+				h.margin = size * entry / h.leverage
+			}
+		}
+		b.holdings = append(b.holdings, h)
+	}
+	return b, nil
+}
+
+// 返回保证金余额、初始保证金和维持保证金
+// 统一账户使用账户的合计值（USD），经典账户使用结算币的合约账户余额
+func (s *Simulator) wallet(ctx context.Context, unified bool, settleCoin string) (float64, float64, float64, error) {
+	accountType, coin := "UNIFIED", ""
+	if !unified {
+		accountType, coin = "CONTRACT", settleCoin
+	}
+	resp, err := s.svc.GetWalletBalance(ctx, accountType, coin)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var balances []struct {
+		model.WalletBalance
+		TotalInitialMargin     string `json:"totalInitialMargin"`
+		TotalMaintenanceMargin string `json:"totalMaintenanceMargin"`
+	}
+	if err := decodeList(resp, &balances); err != nil {
+		return 0, 0, 0, err
+	}
+	if len(balances) == 0 {
+		return 0, 0, 0, fmt.Errorf("没有%s账户的余额", accountType)
+	}
+
+	balance := balances[0]
+	if unified {
+		return num(balance.TotalMarginBalance), num(balance.TotalInitialMargin), num(balance.TotalMaintenanceMargin), nil
+	}
+	for _, c := range balance.Coin {
+		if c.Coin == settleCoin {
+			return num(c.Equity), num(c.TotalPositionIM) + num(c.TotalOrderIM), num(c.TotalPositionMM), nil
+		}
+	}
+	return 0, 0, 0, nil
+}
+
+// 获取交易对的风险限额档位
+func (s *Simulator) riskLimits(ctx context.Context, symbol string) ([]tier, error) {
+	resp, err := s.svc.GetRiskLimit(ctx, bybitapi.CategoryLinear, symbol, "")
+	if err != nil {
+		return nil, err
+	}
+	var list []struct {
+		Symbol            string `json:"symbol"`
+		RiskLimitValue    string `json:"riskLimitValue"`
+		MaintenanceMargin string `json:"maintenanceMargin"`
+		InitialMargin     string `json:"initialMargin"`
+		MaxLeverage       string `json:"maxLeverage"`
+		MMDeduction       string `json:"mmDeduction"`
+	}
+	if err := decodeList(resp, &list); err != nil {
+		return nil, err
+	}
+
+	var tiers []tier
+	for _, item := range list {
+		if item.Symbol != "" && item.Symbol != symbol {
+			continue
+		}
+		t := tier{
+			limit:       num(item.RiskLimitValue),
+			rate:        num(item.MaintenanceMargin),
+			deduction:   num(item.MMDeduction),
+			maxLeverage: num(item.MaxLeverage),
+		}
+		// 保证金率可能以百分比返回（例如"0.5"表示0.5%），此时初始保证金率乘以最大杠杆约为100
+		if im := num(item.InitialMargin); im*t.maxLeverage > 2 {
+			t.rate /= 100
+		}
+		tiers = append(tiers, t)
+	}
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("没有%s的风险限额档位", symbol)
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].limit < tiers[j].limit })
+	return tiers, nil
+}
+
+// 获取交易对的标记价格
+func (s *Simulator) markPrice(ctx context.Context, symbol string) (float64, error) {
+	resp, err := s.svc.GetTickers(ctx, bybitapi.CategoryLinear, symbol)
+	if err != nil {
+		return 0, err
+	}
+	var tickers []struct {
+		Symbol    string `json:"symbol"`
+		MarkPrice string `json:"markPrice"`
+	}
+	if err := decodeList(resp, &tickers); err != nil {
+		return 0, err
+	}
+	for _, t := range tickers {
+		if t.Symbol == symbol && num(t.MarkPrice) > 0 {
+			return num(t.MarkPrice), nil
+		}
+	}
+	return 0, fmt.Errorf("无法获取%s的标记价格", symbol)
+}
+
+// 获取账户合约的吃单手续费率，失败时（例如没有配置API密钥）使用默认费率
+func (s *Simulator) takerFee(ctx context.Context, settleCoin string) float64 {
+	resp, err := s.svc.GetFeeRate(ctx, bybitapi.CategoryLinear, "")
+	if err != nil {
+		return defaultTakerFee
+	}
+	var rates []struct {
+		Symbol       string `json:"symbol"`
+		TakerFeeRate string `json:"takerFeeRate"`
+	}
+	if err := decodeList(resp, &rates); err != nil || len(rates) == 0 {
+		return defaultTakerFee
+	}
+	for _, r := range rates {
+		if r.Symbol == "" || strings.HasSuffix(r.Symbol, settleCoin) {
+			return num(r.TakerFeeRate)
+		}
+	}
+	return num(rates[0].TakerFeeRate)
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// 把响应中的list解析到v
+func decodeList(resp *model.Response, v interface{}) error {
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return err
+	}
+	if len(page.List) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(page.List, v); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// 把响应的result解析到v，Bybit返回错误码时返回错误
+func decodeResult(resp *model.Response, v interface{}) error {
+	if _, err := pagination.DecodeResult(resp); err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+func num(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
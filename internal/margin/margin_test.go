@@ -0,0 +1,224 @@
+package margin
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+)
+
+// 只实现GetAccountInfo的服务
+type accountInfoService struct {
+	service.BybitService
+	info map[string]interface{}
+}
+
+func (s *accountInfoService) GetAccountInfo(ctx context.Context) (*model.Response, error) {
+	return &model.Response{RetCode: 0, RetMsg: "OK", Result: s.info}, nil
+}
+
+// 测试用的单档风险限额
+var testTiers = []tier{{limit: 1000000, rate: 0.005, maxLeverage: 100}}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   Query
+		wantErr bool
+	}{
+		{name: "empty", query: Query{}},
+		{name: "all modes", query: Query{Mode: ModeIsolated, SettleCoin: "usdc"}},
+		{name: "portfolio", query: Query{Mode: "portfolio"}, wantErr: true},
+		{name: "unknown mode", query: Query{Mode: "spot"}, wantErr: true},
+		{name: "unsupported settle coin", query: Query{SettleCoin: "BTC"}, wantErr: true},
+		{name: "order", query: Query{Orders: []Order{{Symbol: "btcusdt", Side: "buy", Qty: 1}}}},
+		{name: "order side", query: Query{Orders: []Order{{Symbol: "BTCUSDT", Side: "long", Qty: 1}}}, wantErr: true},
+		{name: "order qty", query: Query{Orders: []Order{{Symbol: "BTCUSDT", Side: "Buy"}}}, wantErr: true},
+		{name: "negative price", query: Query{Orders: []Order{{Symbol: "BTCUSDT", Side: "Buy", Qty: 1, Price: -1}}}, wantErr: true},
+		{name: "leverage below 1", query: Query{Leverages: []Leverage{{Symbol: "BTCUSDT", Leverage: 0.5}}}, wantErr: true},
+		{name: "price move", query: Query{PriceMoves: []PriceMove{{Change: -0.5}}}},
+		{name: "price move to zero", query: Query{PriceMoves: []PriceMove{{Change: -1}}}, wantErr: true},
+		{name: "too many scenarios", query: Query{PriceMoves: make([]PriceMove, maxScenarios+1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 统一交易对、方向和结算币的大小写
+	query := Query{SettleCoin: "usdt", Orders: []Order{{Symbol: "btcusdt", Side: "sell", Qty: 1}}}
+	if err := Validate(&query); err != nil {
+		t.Fatal(err)
+	}
+	if query.SettleCoin != "USDT" || query.Orders[0].Symbol != "BTCUSDT" || query.Orders[0].Side != "Sell" {
+		t.Errorf("Validate query = %+v", query)
+	}
+}
+
+func TestAccountMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        map[string]interface{}
+		wantMode    string
+		wantUnified bool
+		wantErr     error
+	}{
+		{"classic", map[string]interface{}{"unifiedMarginStatus": 1, "marginMode": "REGULAR_MARGIN"}, ModeCross, false, nil},
+		{"unified", map[string]interface{}{"unifiedMarginStatus": 4, "marginMode": "REGULAR_MARGIN"}, ModeUnified, true, nil},
+		{"unified isolated", map[string]interface{}{"unifiedMarginStatus": 5, "marginMode": "ISOLATED_MARGIN"}, ModeIsolated, true, nil},
+		{"portfolio", map[string]interface{}{"unifiedMarginStatus": 6, "marginMode": "PORTFOLIO_MARGIN"}, "", true, ErrPortfolioMargin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSimulator(&accountInfoService{info: tt.info})
+			mode, unified, err := s.accountMode(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("accountMode err = %v, want %v", err, tt.wantErr)
+			}
+			if mode != tt.wantMode || unified != tt.wantUnified {
+				t.Errorf("accountMode = %s, %v, want %s, %v", mode, unified, tt.wantMode, tt.wantUnified)
+			}
+		})
+	}
+}
+
+func TestPickTier(t *testing.T) {
+	tiers := []tier{{limit: 100, rate: 0.01}, {limit: 1000, rate: 0.02}, {limit: 10000, rate: 0.05}}
+	tests := []struct {
+		value float64
+		want  float64
+	}{
+		{0, 0.01},
+		{100, 0.01},
+		{100.01, 0.02},
+		{10000, 0.05},
+		{20000, 0.05},
+	}
+	for _, tt := range tests {
+		if got := pickTier(tiers, tt.value); got.rate != tt.want {
+			t.Errorf("pickTier(%v) rate = %v, want %v", tt.value, got.rate, tt.want)
+		}
+	}
+	if got := pickTier(nil, 1); got != (tier{}) {
+		t.Errorf("pickTier(nil) = %+v", got)
+	}
+}
+
+func TestState(t *testing.T) {
+	tests := []struct {
+		name        string
+		wallet      float64
+		holding     holding
+		wantIM      float64
+		wantMM      float64
+		wantRatio   float64
+		wantLiq     float64
+		wantLiqDone bool
+	}{
+		{
+			// 逐仓多头：保证金10，强平价格满足 10 + (P-100) = 0.005P
+			name:      "isolated long",
+			holding:   holding{symbol: "BTCUSDT", side: "Buy", size: 1, entry: 100, mark: 100, leverage: 10, isolated: true, margin: 10},
+			wallet:    1000,
+			wantIM:    10,
+			wantMM:    0.5,
+			wantRatio: 0.05,
+			wantLiq:   90 / 0.995,
+		},
+		{
+			// 全仓空头：强平价格满足 1000 - (P-100) = 0.005P
+			name:      "cross short",
+			holding:   holding{symbol: "BTCUSDT", side: "Sell", size: 1, entry: 100, mark: 100, leverage: 10},
+			wallet:    1000,
+			wantIM:    10,
+			wantMM:    0.5,
+			wantRatio: 0.0005,
+			wantLiq:   1100 / 1.005,
+		},
+		{
+			// 多头亏损超过余额，已达到强平条件
+			name:        "cross long liquidated",
+			holding:     holding{symbol: "BTCUSDT", side: "Buy", size: 1, entry: 200, mark: 100, leverage: 10},
+			wallet:      50,
+			wantIM:      10,
+			wantMM:      0.5,
+			wantRatio:   1,
+			wantLiq:     150 / 0.995,
+			wantLiqDone: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.holding
+			b := &book{wallet: tt.wallet, holdings: []*holding{&h}, tiers: map[string][]tier{"BTCUSDT": testTiers}}
+			s, _ := b.state(false)
+			p := s.Positions[0]
+			if !approx(p.InitialMargin, tt.wantIM) || !approx(p.MaintenanceMargin, tt.wantMM) {
+				t.Errorf("margin = %v/%v, want %v/%v", p.InitialMargin, p.MaintenanceMargin, tt.wantIM, tt.wantMM)
+			}
+			if !approx(p.MarginRatio, tt.wantRatio) || p.Liquidated != tt.wantLiqDone {
+				t.Errorf("ratio = %v, liquidated = %v, want %v, %v", p.MarginRatio, p.Liquidated, tt.wantRatio, tt.wantLiqDone)
+			}
+			if !approx(p.LiquidationPrice, tt.wantLiq) {
+				t.Errorf("liquidation price = %v, want %v", p.LiquidationPrice, tt.wantLiq)
+			}
+		})
+	}
+}
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name       string
+		order      Order
+		wantWallet float64
+		wantSide   string
+		wantSize   float64
+		wantEntry  float64
+	}{
+		{"add", Order{Symbol: "BTCUSDT", Side: "Buy", Qty: 2, Price: 130}, 1000, "Buy", 4, 115},
+		{"reduce", Order{Symbol: "BTCUSDT", Side: "Sell", Qty: 1, Price: 110}, 1010, "Buy", 1, 100},
+		{"flip", Order{Symbol: "BTCUSDT", Side: "Sell", Qty: 3, Price: 110}, 1020, "Sell", 1, 110},
+		{"close at mark", Order{Symbol: "BTCUSDT", Side: "Sell", Qty: 2}, 1040, "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &book{wallet: 1000, holdings: []*holding{{symbol: "BTCUSDT", side: "Buy", size: 2, entry: 100, mark: 120, leverage: 10}}}
+			b.fill(tt.order, 120, defaultLeverage, false)
+
+			if !approx(b.wallet, tt.wantWallet) {
+				t.Errorf("wallet = %v, want %v", b.wallet, tt.wantWallet)
+			}
+			h := b.find("BTCUSDT")
+			if tt.wantSize == 0 {
+				if h != nil {
+					t.Errorf("holding = %+v, want closed", h)
+				}
+				return
+			}
+			if h == nil || h.side != tt.wantSide || !approx(h.size, tt.wantSize) || !approx(h.entry, tt.wantEntry) {
+				t.Errorf("holding = %+v, want %s %v@%v", h, tt.wantSide, tt.wantSize, tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestFillChargesTakerFee(t *testing.T) {
+	b := &book{wallet: 1000, fee: 0.001}
+	b.fill(Order{Symbol: "BTCUSDT", Side: "Buy", Qty: 1, Price: 100}, 100, 5, true)
+
+	h := b.find("BTCUSDT")
+	if !approx(b.wallet, 999.9) || h == nil || !approx(h.margin, 20.1) {
+		t.Errorf("wallet = %v, holding = %+v", b.wallet, h)
+	}
+}
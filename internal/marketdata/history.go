@@ -0,0 +1,248 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// History 从本地库查询历史行情，缺少的区间从Bybit获取后保存
+type History struct {
+	store   *Store
+	source  Source
+	offline bool
+	logger  *logger.Logger
+	now     func() time.Time
+
+	mu    sync.Mutex
+	locks map[Series]*sync.Mutex // 同一序列同时只有一个请求在补齐数据
+}
+
+// NewHistory 创建历史行情查询，source为nil时只使用本地数据
+func NewHistory(store *Store, source Source, log *logger.Logger) *History {
+	return &History{
+		store:   store,
+		source:  source,
+		offline: source == nil,
+		logger:  log,
+		now:     time.Now,
+		locks:   map[Series]*sync.Mutex{},
+	}
+}
+
+// SetOffline 设置是否只使用本地数据，不从Bybit获取缺少的区间
+func (h *History) SetOffline(offline bool) {
+	h.offline = offline || h.source == nil
+}
+
+// 返回序列的补齐锁
+func (h *History) lock(series Series) *sync.Mutex {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	lock, ok := h.locks[series]
+	if !ok {
+		lock = &sync.Mutex{}
+		h.locks[series] = lock
+	}
+	return lock
+}
+
+// 可以保存的区间上限：K线和持仓量只保存已经结束的周期，资金费率保存到当前时间
+func (h *History) settled(series Series) int64 {
+	return series.Align(h.now().UnixMilli())
+}
+
+// Fill 从Bybit获取[from, to)中本地库缺少的区间，返回获取的区间数量
+// 尚未结束的周期不保存，下次查询时重新获取；离线模式不获取，查询只返回本地已有的数据
+func (h *History) Fill(ctx context.Context, series Series, from, to int64) (int, error) {
+	if err := series.Validate(); err != nil {
+		return 0, err
+	}
+	from = series.Align(from)
+	if settled := h.settled(series); to > settled {
+		to = settled
+	}
+	if from >= to {
+		return 0, nil
+	}
+
+	lock := h.lock(series)
+	lock.Lock()
+	defer lock.Unlock()
+
+	gaps, err := h.store.Gaps(ctx, series, from, to)
+	if err != nil || len(gaps) == 0 || h.offline {
+		return 0, err
+	}
+
+	for _, gap := range gaps {
+		count := 0
+		if series.IsCandle() {
+			candles, err := h.source.FetchCandles(ctx, series, gap)
+			if err != nil {
+				return 0, fmt.Errorf("获取%s失败: %v", series, err)
+			}
+			if err := h.store.SaveCandles(ctx, series, candles, gap); err != nil {
+				return 0, err
+			}
+			count = len(candles)
+		} else {
+			points, err := h.source.FetchPoints(ctx, series, gap)
+			if err != nil {
+				return 0, fmt.Errorf("获取%s失败: %v", series, err)
+			}
+			if err := h.store.SavePoints(ctx, series, points, gap); err != nil {
+				return 0, err
+			}
+			count = len(points)
+		}
+		h.logger.Info("获取%s %d条（%s至%s）", series, count, formatTime(gap.From), formatTime(gap.To))
+	}
+	return len(gaps), nil
+}
+
+// Missing 返回[from, to)中已经结束、但本地库中还没有的区间，离线模式下用来检查数据是否完整
+func (h *History) Missing(ctx context.Context, series Series, from, to int64) ([]Range, error) {
+	if err := series.Validate(); err != nil {
+		return nil, err
+	}
+	from = series.Align(from)
+	if settled := h.settled(series); to > settled {
+		to = settled
+	}
+	if from >= to {
+		return nil, nil
+	}
+	return h.store.Gaps(ctx, series, from, to)
+}
+
+// Candles 返回开始时间在[from, to)内的K线，按时间排序，最多limit根
+// 只补齐limit根K线覆盖的区间，limit为0时补齐整个区间
+func (h *History) Candles(ctx context.Context, series Series, from, to int64, limit int) ([]Candle, error) {
+	if !series.IsCandle() {
+		return nil, fmt.Errorf("%s不是K线", series.Kind)
+	}
+	if _, err := h.Fill(ctx, series, from, h.fillEnd(series, from, to, limit)); err != nil {
+		return nil, err
+	}
+	return h.store.Candles(ctx, series, from, to, limit)
+}
+
+// Points 返回时间在[from, to)内的资金费率或持仓量，按时间排序，最多limit条
+func (h *History) Points(ctx context.Context, series Series, from, to int64, limit int) ([]Point, error) {
+	if series.IsCandle() {
+		return nil, fmt.Errorf("%s不是数据点序列", series.Kind)
+	}
+	if _, err := h.Fill(ctx, series, from, h.fillEnd(series, from, to, limit)); err != nil {
+		return nil, err
+	}
+	return h.store.Points(ctx, series, from, to, limit)
+}
+
+// 需要补齐的区间终点，固定周期的序列只补齐limit个周期
+func (h *History) fillEnd(series Series, from, to int64, limit int) int64 {
+	if step := series.Step(); step > 0 && limit > 0 {
+		if end := series.Align(from) + int64(limit)*step; end < to {
+			return end
+		}
+	}
+	return to
+}
+
+// Backfill 补齐多个交易对、多种数据类型在[from, to)内的历史行情
+// intervals用于K线类数据，oiIntervals用于持仓量；不支持的类别和数据类型组合会跳过
+func (h *History) Backfill(ctx context.Context, category string, symbols, kinds, intervals, oiIntervals []string, from, to int64) error {
+	for _, symbol := range symbols {
+		for _, kind := range kinds {
+			if _, ok := kindCategories[kind]; !ok {
+				return fmt.Errorf("未知的数据类型%q，可选值: %s", kind, strings.Join(Kinds(), "、"))
+			}
+			var list []Series
+			switch kind {
+			case KindFundingRate:
+				list = append(list, Series{Kind: kind, Category: category, Symbol: symbol})
+			case KindOpenInterest:
+				for _, interval := range oiIntervals {
+					list = append(list, Series{Kind: kind, Category: category, Symbol: symbol, Interval: interval})
+				}
+			default:
+				for _, interval := range intervals {
+					list = append(list, Series{Kind: kind, Category: category, Symbol: symbol, Interval: interval})
+				}
+			}
+			for _, series := range list {
+				if !supports(series.Kind, series.Category) {
+					h.logger.Info("%s不支持%s，跳过", series.Category, series.Kind)
+					continue
+				}
+				if _, err := h.Fill(ctx, series, from, to); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// 判断数据类型是否支持产品类别
+func supports(kind, category string) bool {
+	for _, c := range kindCategories[kind] {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Result 是历史行情的查询结果，list按时间正序，每行的格式与Bybit相应接口一致：
+// K线为[开始时间, 开盘价, 最高价, 最低价, 收盘价, 成交量, 成交额]，标记价格、指数价格和溢价指数K线只有前5列，
+// 资金费率和持仓量为[时间, 数值]
+type Result struct {
+	Kind     string     `json:"kind"`
+	Category string     `json:"category"`
+	Symbol   string     `json:"symbol"`
+	Interval string     `json:"interval"`
+	List     [][]string `json:"list"`
+}
+
+// Query 查询[from, to)内最多limit条数据，后面还有数据时next为下一页的开始时间，否则为0
+func (h *History) Query(ctx context.Context, series Series, from, to int64, limit int) (*Result, int64, error) {
+	result := &Result{Kind: series.Kind, Category: series.Category, Symbol: series.Symbol, Interval: series.Interval, List: [][]string{}}
+	last := int64(-1)
+	if series.IsCandle() {
+		candles, err := h.Candles(ctx, series, from, to, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, c := range candles {
+			row := []string{strconv.FormatInt(c.Start, 10), FormatNum(c.Open), FormatNum(c.High), FormatNum(c.Low), FormatNum(c.Close)}
+			if series.Kind == KindKline {
+				row = append(row, FormatNum(c.Volume), FormatNum(c.Turnover))
+			}
+			result.List = append(result.List, row)
+			last = c.Start
+		}
+	} else {
+		points, err := h.Points(ctx, series, from, to, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, p := range points {
+			result.List = append(result.List, []string{strconv.FormatInt(p.Time, 10), FormatNum(p.Value)})
+			last = p.Time
+		}
+	}
+	if limit > 0 && len(result.List) == limit {
+		return result, last + 1, nil
+	}
+	// 本页补齐的区间内有缺失的周期（例如交易所停机）时，数量不足limit但后面仍有数据
+	if end := h.fillEnd(series, from, to, limit); end < to && end < h.settled(series) {
+		return result, end, nil
+	}
+	return result, 0, nil
+}
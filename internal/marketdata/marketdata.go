@@ -0,0 +1,180 @@
+// Package marketdata 下载Bybit的历史行情并保存在本地的SQLite时间序列库中
+//
+// 支持K线、标记价格K线、指数价格K线、溢价指数K线、资金费率历史和持仓量。
+// 每个序列记录已经从Bybit获取过的时间区间，查询时只下载缺少的部分，
+// 上市之前等没有数据的区间同样会被记录，不会重复请求。
+package marketdata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 数据类型
+const (
+	KindKline        = "kline"         // K线
+	KindMarkPrice    = "mark"          // 标记价格K线
+	KindIndexPrice   = "index"         // 指数价格K线
+	KindPremiumIndex = "premium"       // 溢价指数K线
+	KindFundingRate  = "funding"       // 资金费率历史
+	KindOpenInterest = "open-interest" // 持仓量
+)
+
+// Kinds 返回全部数据类型
+func Kinds() []string {
+	return []string{KindKline, KindMarkPrice, KindIndexPrice, KindPremiumIndex, KindFundingRate, KindOpenInterest}
+}
+
+// 每种数据类型支持的产品类别
+var kindCategories = map[string][]string{
+	KindKline:        {bybitapi.CategorySpot, bybitapi.CategoryLinear, bybitapi.CategoryInverse},
+	KindMarkPrice:    {bybitapi.CategoryLinear, bybitapi.CategoryInverse},
+	KindIndexPrice:   {bybitapi.CategoryLinear, bybitapi.CategoryInverse},
+	KindPremiumIndex: {bybitapi.CategoryLinear},
+	KindFundingRate:  {bybitapi.CategoryLinear, bybitapi.CategoryInverse},
+	KindOpenInterest: {bybitapi.CategoryLinear, bybitapi.CategoryInverse},
+}
+
+// K线周期对应的毫秒数，月线长度不固定，不支持保存
+var klineIntervals = map[string]int64{
+	"1":   int64(time.Minute / time.Millisecond),
+	"3":   int64(3 * time.Minute / time.Millisecond),
+	"5":   int64(5 * time.Minute / time.Millisecond),
+	"15":  int64(15 * time.Minute / time.Millisecond),
+	"30":  int64(30 * time.Minute / time.Millisecond),
+	"60":  int64(time.Hour / time.Millisecond),
+	"120": int64(2 * time.Hour / time.Millisecond),
+	"240": int64(4 * time.Hour / time.Millisecond),
+	"360": int64(6 * time.Hour / time.Millisecond),
+	"720": int64(12 * time.Hour / time.Millisecond),
+	"D":   int64(24 * time.Hour / time.Millisecond),
+	"W":   int64(7 * 24 * time.Hour / time.Millisecond),
+}
+
+// 持仓量统计周期对应的毫秒数
+var openInterestIntervals = map[string]int64{
+	"5min":  int64(5 * time.Minute / time.Millisecond),
+	"15min": int64(15 * time.Minute / time.Millisecond),
+	"30min": int64(30 * time.Minute / time.Millisecond),
+	"1h":    int64(time.Hour / time.Millisecond),
+	"4h":    int64(4 * time.Hour / time.Millisecond),
+	"1d":    int64(24 * time.Hour / time.Millisecond),
+}
+
+// 周线从周一零点开始，1970-01-01是周四
+const weekOffset = int64(4 * 24 * time.Hour / time.Millisecond)
+
+// Series 标识一个时间序列
+// 资金费率没有周期，Interval为空；持仓量的Interval为5min、15min、30min、1h、4h或1d
+type Series struct {
+	Kind     string `json:"kind"`
+	Category string `json:"category"`
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+}
+
+// Candle 是一根K线，标记价格、指数价格和溢价指数K线没有成交量和成交额
+type Candle struct {
+	Start    int64   `json:"start"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Volume   float64 `json:"volume"`
+	Turnover float64 `json:"turnover"`
+}
+
+// Point 是资金费率或持仓量的一个数据点
+type Point struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// String 返回便于日志阅读的序列名称
+func (s Series) String() string {
+	if s.Interval == "" {
+		return fmt.Sprintf("%s %s %s", s.Category, s.Symbol, s.Kind)
+	}
+	return fmt.Sprintf("%s %s %s %s", s.Category, s.Symbol, s.Kind, s.Interval)
+}
+
+// IsCandle 判断序列的数据是K线还是数据点
+func (s Series) IsCandle() bool {
+	return s.Kind != KindFundingRate && s.Kind != KindOpenInterest
+}
+
+// Validate 检查数据类型、产品类别和周期是否有效
+func (s Series) Validate() error {
+	if _, ok := kindCategories[s.Kind]; !ok {
+		return fmt.Errorf("未知的数据类型%q，可选值: %s", s.Kind, strings.Join(Kinds(), "、"))
+	}
+	if s.Symbol == "" {
+		return fmt.Errorf("交易对不能为空")
+	}
+	if !supports(s.Kind, s.Category) {
+		return fmt.Errorf("%s不支持产品类别%q", s.Kind, s.Category)
+	}
+	switch s.Kind {
+	case KindFundingRate:
+		if s.Interval != "" {
+			return fmt.Errorf("资金费率历史不需要周期")
+		}
+	case KindOpenInterest:
+		if _, ok := openInterestIntervals[s.Interval]; !ok {
+			return fmt.Errorf("不支持的持仓量周期%q，可选值: 5min、15min、30min、1h、4h、1d", s.Interval)
+		}
+	default:
+		if _, ok := klineIntervals[s.Interval]; !ok {
+			return fmt.Errorf("不支持的K线周期%q，可选值: 1、3、5、15、30、60、120、240、360、720、D、W", s.Interval)
+		}
+	}
+	return nil
+}
+
+// Step 返回序列相邻数据点的间隔（毫秒），资金费率的结算间隔不固定，返回0
+func (s Series) Step() int64 {
+	switch s.Kind {
+	case KindFundingRate:
+		return 0
+	case KindOpenInterest:
+		return openInterestIntervals[s.Interval]
+	default:
+		return klineIntervals[s.Interval]
+	}
+}
+
+// Align 对齐到所在周期的开始时间，资金费率不对齐
+func (s Series) Align(t int64) int64 {
+	step := s.Step()
+	if step == 0 {
+		return t
+	}
+	offset := int64(0)
+	if s.Interval == "W" {
+		offset = weekOffset
+	}
+	return t - ((t-offset)%step+step)%step
+}
+
+// FormatNum 格式化数字，不带多余的0
+func FormatNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ParseNum 解析数字字符串，无效时返回0
+func ParseNum(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// 格式化毫秒时间，用于日志和错误信息
+func formatTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,211 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/errors"
+)
+
+// 单页最多返回的数量
+const (
+	klinePageLimit = 1000 // market/kline及标记价格、指数价格、溢价指数K线
+	pointPageLimit = 200  // market/funding/history和market/open-interest
+)
+
+// Source 从Bybit获取历史行情
+type Source interface {
+	// FetchCandles 返回开始时间在[r.From, r.To)内的K线，顺序不限
+	FetchCandles(ctx context.Context, series Series, r Range) ([]Candle, error)
+	// FetchPoints 返回时间在[r.From, r.To)内的资金费率或持仓量，顺序不限
+	FetchPoints(ctx context.Context, series Series, r Range) ([]Point, error)
+}
+
+// ServiceSource 通过公共行情接口获取历史行情，不需要API密钥
+type ServiceSource struct {
+	market service.MarketDataService
+}
+
+// NewServiceSource 创建使用market获取数据的行情源
+func NewServiceSource(market service.MarketDataService) *ServiceSource {
+	return &ServiceSource{market: market}
+}
+
+// 解析响应中的result，Bybit返回错误码时返回错误
+func decodeResult(resp *model.Response, err error, v interface{}) error {
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return errors.New(errors.ErrAPIResponseInvalid, "响应为空")
+	}
+	if err := errors.FromBybitAPIError(resp.RetCode, resp.RetMsg); err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return errors.Wrap(errors.ErrAPIResponseInvalid, "序列化响应失败", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrap(errors.ErrAPIResponseInvalid, "解析响应失败", err)
+	}
+	return nil
+}
+
+// 各类K线对应的接口
+func (s *ServiceSource) klineFunc(kind string) func(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	switch kind {
+	case KindKline:
+		return s.market.GetKline
+	case KindMarkPrice:
+		return s.market.GetMarkPriceKline
+	case KindIndexPrice:
+		return s.market.GetIndexPriceKline
+	case KindPremiumIndex:
+		return s.market.GetPremiumIndexPriceKline
+	}
+	return nil
+}
+
+// FetchCandles 分页获取K线，接口按时间倒序返回，从区间末尾向前翻页
+func (s *ServiceSource) FetchCandles(ctx context.Context, series Series, r Range) ([]Candle, error) {
+	fetch := s.klineFunc(series.Kind)
+	if fetch == nil {
+		return nil, fmt.Errorf("%s不是K线", series.Kind)
+	}
+
+	var candles []Candle
+	cursor := r.To - 1
+	for cursor >= r.From {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var page model.Kline
+		resp, err := fetch(ctx, series.Category, series.Symbol, series.Interval, klinePageLimit, r.From, cursor)
+		if err := decodeResult(resp, err, &page); err != nil {
+			return nil, err
+		}
+
+		oldest := cursor + 1
+		for _, row := range page.List {
+			candle, ok := parseCandle(row)
+			if !ok || candle.Start < r.From || candle.Start >= r.To {
+				continue
+			}
+			candles = append(candles, candle)
+			if candle.Start < oldest {
+				oldest = candle.Start
+			}
+		}
+		if len(page.List) < klinePageLimit || oldest > cursor {
+			break
+		}
+		cursor = oldest - 1
+	}
+	return candles, nil
+}
+
+// 解析一行K线：[开始时间, 开盘价, 最高价, 最低价, 收盘价, 成交量, 成交额]，标记价格等K线只有前5列
+func parseCandle(row []string) (Candle, bool) {
+	if len(row) < 5 {
+		return Candle{}, false
+	}
+	start, err := strconv.ParseInt(row[0], 10, 64)
+	if err != nil {
+		return Candle{}, false
+	}
+	candle := Candle{Start: start, Open: ParseNum(row[1]), High: ParseNum(row[2]), Low: ParseNum(row[3]), Close: ParseNum(row[4])}
+	if len(row) >= 7 {
+		candle.Volume = ParseNum(row[5])
+		candle.Turnover = ParseNum(row[6])
+	}
+	return candle, true
+}
+
+// FetchPoints 分页获取资金费率历史或持仓量
+func (s *ServiceSource) FetchPoints(ctx context.Context, series Series, r Range) ([]Point, error) {
+	switch series.Kind {
+	case KindFundingRate:
+		return s.fetchFunding(ctx, series, r)
+	case KindOpenInterest:
+		return s.fetchOpenInterest(ctx, series, r)
+	}
+	return nil, fmt.Errorf("%s不是数据点序列", series.Kind)
+}
+
+// 资金费率历史按时间倒序返回，没有游标，以最早的结算时间向前翻页
+func (s *ServiceSource) fetchFunding(ctx context.Context, series Series, r Range) ([]Point, error) {
+	var points []Point
+	cursor := r.To - 1
+	for cursor >= r.From {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var page struct {
+			List []struct {
+				FundingRate          string `json:"fundingRate"`
+				FundingRateTimestamp string `json:"fundingRateTimestamp"`
+			} `json:"list"`
+		}
+		resp, err := s.market.GetFundingRateHistory(ctx, series.Category, series.Symbol, r.From, cursor, pointPageLimit)
+		if err := decodeResult(resp, err, &page); err != nil {
+			return nil, err
+		}
+
+		oldest := cursor + 1
+		for _, item := range page.List {
+			t, err := strconv.ParseInt(item.FundingRateTimestamp, 10, 64)
+			if err != nil || t < r.From || t >= r.To {
+				continue
+			}
+			points = append(points, Point{Time: t, Value: ParseNum(item.FundingRate)})
+			if t < oldest {
+				oldest = t
+			}
+		}
+		if len(page.List) < pointPageLimit || oldest > cursor {
+			break
+		}
+		cursor = oldest - 1
+	}
+	return points, nil
+}
+
+// 持仓量使用nextPageCursor翻页
+func (s *ServiceSource) fetchOpenInterest(ctx context.Context, series Series, r Range) ([]Point, error) {
+	var points []Point
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var page struct {
+			List []struct {
+				OpenInterest string `json:"openInterest"`
+				Timestamp    string `json:"timestamp"`
+			} `json:"list"`
+			NextPageCursor string `json:"nextPageCursor"`
+		}
+		resp, err := s.market.GetOpenInterest(ctx, series.Category, series.Symbol, series.Interval, r.From, r.To-1, pointPageLimit, cursor)
+		if err := decodeResult(resp, err, &page); err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.List {
+			t, err := strconv.ParseInt(item.Timestamp, 10, 64)
+			if err != nil || t < r.From || t >= r.To {
+				continue
+			}
+			points = append(points, Point{Time: t, Value: ParseNum(item.OpenInterest)})
+		}
+		if len(page.List) < pointPageLimit || page.NextPageCursor == "" || page.NextPageCursor == cursor {
+			break
+		}
+		cursor = page.NextPageCursor
+	}
+	return points, nil
+}
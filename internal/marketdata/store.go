@@ -0,0 +1,369 @@
+package marketdata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	// 纯Go实现的SQLite驱动，无需CGO
+	_ "modernc.org/sqlite"
+)
+
+// 数据库迁移脚本，按版本顺序执行，已发布的脚本不可修改
+// 数据表使用WITHOUT ROWID按(序列, 时间)聚簇存储，交易对等文本只在series表中保存一次
+var migrations = []string{
+	// 版本1：初始表结构
+	`CREATE TABLE series (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind     TEXT NOT NULL,
+		category TEXT NOT NULL,
+		symbol   TEXT NOT NULL,
+		interval TEXT NOT NULL,
+		UNIQUE (kind, category, symbol, interval)
+	);
+
+	CREATE TABLE candles (
+		series     INTEGER NOT NULL,
+		start_time INTEGER NOT NULL,
+		open       REAL NOT NULL,
+		high       REAL NOT NULL,
+		low        REAL NOT NULL,
+		close      REAL NOT NULL,
+		volume     REAL NOT NULL,
+		turnover   REAL NOT NULL,
+		PRIMARY KEY (series, start_time)
+	) WITHOUT ROWID;
+
+	CREATE TABLE points (
+		series INTEGER NOT NULL,
+		time   INTEGER NOT NULL,
+		value  REAL NOT NULL,
+		PRIMARY KEY (series, time)
+	) WITHOUT ROWID;
+
+	CREATE TABLE coverage (
+		series    INTEGER NOT NULL,
+		from_time INTEGER NOT NULL,
+		to_time   INTEGER NOT NULL,
+		PRIMARY KEY (series, from_time)
+	) WITHOUT ROWID;`,
+}
+
+// Range 是半开时间区间[From, To)，单位为毫秒
+type Range struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// Store 是基于SQLite的历史行情库
+type Store struct {
+	db *sql.DB
+}
+
+// Open 打开历史行情库并执行迁移
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未配置历史行情库路径")
+	}
+
+	// 确保目录存在
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("无法创建数据目录: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("无法打开历史行情库: %v", err)
+	}
+	// SQLite同一时间只允许一个写连接
+	db.SetMaxOpenConns(1)
+
+	store := &Store{db: db}
+	if err := store.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close 关闭历史行情库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// 执行尚未应用的迁移脚本
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("无法创建迁移表: %v", err)
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("无法读取迁移版本: %v", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("历史行情库版本%d高于程序支持的版本%d", current, len(migrations))
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("无法开始迁移事务: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行迁移%d失败: %v", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UnixMilli()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("记录迁移%d失败: %v", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交迁移%d失败: %v", version, err)
+		}
+	}
+	return nil
+}
+
+// 返回序列的ID，create为true时不存在则创建，否则不存在时返回0
+func (s *Store) seriesID(ctx context.Context, series Series, create bool) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM series WHERE kind = ? AND category = ? AND symbol = ? AND interval = ?`,
+		series.Kind, series.Category, series.Symbol, series.Interval).Scan(&id)
+	if err == sql.ErrNoRows && create {
+		result, err := s.db.ExecContext(ctx, `INSERT INTO series (kind, category, symbol, interval) VALUES (?, ?, ?, ?)`,
+			series.Kind, series.Category, series.Symbol, series.Interval)
+		if err != nil {
+			return 0, fmt.Errorf("无法创建序列%s: %v", series, err)
+		}
+		return result.LastInsertId()
+	}
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("无法读取序列%s: %v", series, err)
+	}
+	return id, nil
+}
+
+// SaveCandles 保存K线并记录[covered.From, covered.To)已经获取，已有的K线会被覆盖
+func (s *Store) SaveCandles(ctx context.Context, series Series, candles []Candle, covered Range) error {
+	id, err := s.seriesID(ctx, series, true)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("无法开始事务: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO candles (series, start_time, open, high, low, close, volume, turnover) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("无法保存K线: %v", err)
+	}
+	defer stmt.Close()
+	for _, c := range candles {
+		if _, err := stmt.ExecContext(ctx, id, c.Start, c.Open, c.High, c.Low, c.Close, c.Volume, c.Turnover); err != nil {
+			return fmt.Errorf("无法保存K线: %v", err)
+		}
+	}
+	if err := addCoverage(ctx, tx, id, covered); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("无法保存K线: %v", err)
+	}
+	return nil
+}
+
+// SavePoints 保存数据点并记录[covered.From, covered.To)已经获取，已有的数据点会被覆盖
+func (s *Store) SavePoints(ctx context.Context, series Series, points []Point, covered Range) error {
+	id, err := s.seriesID(ctx, series, true)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("无法开始事务: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO points (series, time, value) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("无法保存数据点: %v", err)
+	}
+	defer stmt.Close()
+	for _, p := range points {
+		if _, err := stmt.ExecContext(ctx, id, p.Time, p.Value); err != nil {
+			return fmt.Errorf("无法保存数据点: %v", err)
+		}
+	}
+	if err := addCoverage(ctx, tx, id, covered); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("无法保存数据点: %v", err)
+	}
+	return nil
+}
+
+// 记录已经获取的区间，与重叠或相邻的区间合并为一段
+func addCoverage(ctx context.Context, tx *sql.Tx, id int64, r Range) error {
+	if r.From >= r.To {
+		return nil
+	}
+	var from, to sql.NullInt64
+	if err := tx.QueryRowContext(ctx, `SELECT MIN(from_time), MAX(to_time) FROM coverage WHERE series = ? AND from_time <= ? AND to_time >= ?`,
+		id, r.To, r.From).Scan(&from, &to); err != nil {
+		return fmt.Errorf("无法读取已获取的区间: %v", err)
+	}
+	if from.Valid && from.Int64 < r.From {
+		r.From = from.Int64
+	}
+	if to.Valid && to.Int64 > r.To {
+		r.To = to.Int64
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM coverage WHERE series = ? AND from_time <= ? AND to_time >= ?`, id, r.To, r.From); err != nil {
+		return fmt.Errorf("无法更新已获取的区间: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO coverage (series, from_time, to_time) VALUES (?, ?, ?)`, id, r.From, r.To); err != nil {
+		return fmt.Errorf("无法更新已获取的区间: %v", err)
+	}
+	return nil
+}
+
+// Coverage 返回序列已经获取的区间，按时间排序且互不重叠
+func (s *Store) Coverage(ctx context.Context, series Series) ([]Range, error) {
+	id, err := s.seriesID(ctx, series, false)
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT from_time, to_time FROM coverage WHERE series = ? ORDER BY from_time`, id)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取已获取的区间: %v", err)
+	}
+	defer rows.Close()
+
+	var ranges []Range
+	for rows.Next() {
+		var r Range
+		if err := rows.Scan(&r.From, &r.To); err != nil {
+			return nil, fmt.Errorf("无法读取已获取的区间: %v", err)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, rows.Err()
+}
+
+// Gaps 返回[from, to)中尚未获取的区间
+func (s *Store) Gaps(ctx context.Context, series Series, from, to int64) ([]Range, error) {
+	covered, err := s.Coverage(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+	var gaps []Range
+	cursor := from
+	for _, r := range covered {
+		if r.To <= cursor {
+			continue
+		}
+		if r.From >= to {
+			break
+		}
+		if r.From > cursor {
+			gaps = append(gaps, Range{From: cursor, To: r.From})
+		}
+		cursor = r.To
+	}
+	if cursor < to {
+		gaps = append(gaps, Range{From: cursor, To: to})
+	}
+	return gaps, nil
+}
+
+// Candles 返回开始时间在[from, to)内的K线，按时间排序，limit为0时不限制数量
+func (s *Store) Candles(ctx context.Context, series Series, from, to int64, limit int) ([]Candle, error) {
+	id, err := s.seriesID(ctx, series, false)
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	query := `SELECT start_time, open, high, low, close, volume, turnover FROM candles WHERE series = ? AND start_time >= ? AND start_time < ? ORDER BY start_time`
+	args := []interface{}{id, from, to}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取K线: %v", err)
+	}
+	defer rows.Close()
+
+	var candles []Candle
+	for rows.Next() {
+		var c Candle
+		if err := rows.Scan(&c.Start, &c.Open, &c.High, &c.Low, &c.Close, &c.Volume, &c.Turnover); err != nil {
+			return nil, fmt.Errorf("无法读取K线: %v", err)
+		}
+		candles = append(candles, c)
+	}
+	return candles, rows.Err()
+}
+
+// Points 返回时间在[from, to)内的数据点，按时间排序，limit为0时不限制数量
+func (s *Store) Points(ctx context.Context, series Series, from, to int64, limit int) ([]Point, error) {
+	id, err := s.seriesID(ctx, series, false)
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	query := `SELECT time, value FROM points WHERE series = ? AND time >= ? AND time < ? ORDER BY time`
+	args := []interface{}{id, from, to}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取数据点: %v", err)
+	}
+	defer rows.Close()
+
+	var points []Point
+	for rows.Next() {
+		var p Point
+		if err := rows.Scan(&p.Time, &p.Value); err != nil {
+			return nil, fmt.Errorf("无法读取数据点: %v", err)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// ListSeries 返回库中全部序列
+func (s *Store) ListSeries(ctx context.Context) ([]Series, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT kind, category, symbol, interval FROM series ORDER BY category, symbol, kind, interval`)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取序列: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Series
+	for rows.Next() {
+		var series Series
+		if err := rows.Scan(&series.Kind, &series.Category, &series.Symbol, &series.Interval); err != nil {
+			return nil, fmt.Errorf("无法读取序列: %v", err)
+		}
+		list = append(list, series)
+	}
+	return list, rows.Err()
+}
@@ -1,5 +1,7 @@
 package model
 
+import "encoding/json"
+
 // 通用响应结构
 type Response struct {
 	RetCode    int         `json:"retCode"`    // 返回码
@@ -9,6 +11,13 @@ type Response struct {
 	Time       int64       `json:"time"`       // 时间戳
 }
 
+// 分页列表结果，用于按nextPageCursor翻页的接口
+type CursorResult struct {
+	Category       string          `json:"category,omitempty"` // 产品类型
+	List           json.RawMessage `json:"list"`               // 原始列表数据
+	NextPageCursor string          `json:"nextPageCursor"`     // 下一页游标
+}
+
 // 市场数据模型
 
 // K线数据
@@ -82,6 +91,29 @@ type Order struct {
 	UpdatedTime  string `json:"updatedTime"`  // 更新时间
 }
 
+// 订单查询条件，用于order/realtime和order/history
+type OrderQuery struct {
+	Category    string `json:"category"`              // 产品类型
+	Symbol      string `json:"symbol,omitempty"`      // 交易对
+	BaseCoin    string `json:"baseCoin,omitempty"`    // 交易币种
+	SettleCoin  string `json:"settleCoin,omitempty"`  // 结算币种
+	OrderId     string `json:"orderId,omitempty"`     // 订单ID
+	OrderLinkId string `json:"orderLinkId,omitempty"` // 自定义订单ID
+	OrderFilter string `json:"orderFilter,omitempty"` // 订单过滤: Order, StopOrder, tpslOrder, OcoOrder, BidirectionalTpslOrder
+	OrderStatus string `json:"orderStatus,omitempty"` // 订单状态（仅历史订单）
+	StartTime   int64  `json:"startTime,omitempty"`   // 开始时间（毫秒，仅历史订单）
+	EndTime     int64  `json:"endTime,omitempty"`     // 结束时间（毫秒，仅历史订单）
+	Limit       int    `json:"limit,omitempty"`       // 每页数量
+	Cursor      string `json:"cursor,omitempty"`      // 分页游标
+}
+
+// 订单列表
+type OrderList struct {
+	Category       string  `json:"category"`       // 产品类型
+	List           []Order `json:"list"`           // 订单列表
+	NextPageCursor string  `json:"nextPageCursor"` // 下一页游标
+}
+
 // 仓位模型
 
 // 仓位信息
@@ -106,6 +138,107 @@ type Position struct {
 	UpdatedTime    string `json:"updatedTime"`    // 更新时间
 }
 
+// 成交类型
+const (
+	ExecTypeTrade        = "Trade"        // 普通成交
+	ExecTypeAdlTrade     = "AdlTrade"     // 自动减仓成交
+	ExecTypeFunding      = "Funding"      // 资金费用
+	ExecTypeBustTrade    = "BustTrade"    // 强平成交
+	ExecTypeDelivery     = "Delivery"     // 交割
+	ExecTypeSettle       = "Settle"       // 结算
+	ExecTypeBlockTrade   = "BlockTrade"   // 大宗交易
+	ExecTypeMovePosition = "MovePosition" // 仓位转移
+)
+
+// 成交记录查询条件，用于execution/list
+type ExecutionQuery struct {
+	Category    string `json:"category"`              // 产品类型
+	Symbol      string `json:"symbol,omitempty"`      // 交易对
+	BaseCoin    string `json:"baseCoin,omitempty"`    // 交易币种
+	OrderId     string `json:"orderId,omitempty"`     // 订单ID
+	OrderLinkId string `json:"orderLinkId,omitempty"` // 自定义订单ID
+	ExecType    string `json:"execType,omitempty"`    // 成交类型
+	StartTime   int64  `json:"startTime,omitempty"`   // 开始时间（毫秒）
+	EndTime     int64  `json:"endTime,omitempty"`     // 结束时间（毫秒）
+	Limit       int    `json:"limit,omitempty"`       // 每页数量
+	Cursor      string `json:"cursor,omitempty"`      // 分页游标
+}
+
+// 成交记录
+type Execution struct {
+	Category        string  `json:"category"`        // 产品类型
+	Symbol          string  `json:"symbol"`          // 交易对
+	OrderId         string  `json:"orderId"`         // 订单ID
+	OrderLinkId     string  `json:"orderLinkId"`     // 自定义订单ID
+	Side            string  `json:"side"`            // 方向
+	OrderType       string  `json:"orderType"`       // 订单类型
+	OrderPrice      float64 `json:"orderPrice"`      // 订单价格
+	OrderQty        float64 `json:"orderQty"`        // 订单数量
+	ExecId          string  `json:"execId"`          // 成交ID
+	ExecType        string  `json:"execType"`        // 成交类型: Trade, Funding, BustTrade, Settle等
+	ExecPrice       float64 `json:"execPrice"`       // 成交价格
+	ExecQty         float64 `json:"execQty"`         // 成交数量
+	ExecValue       float64 `json:"execValue"`       // 成交价值
+	ExecFee         float64 `json:"execFee"`         // 手续费（负数表示返佣）
+	FeeRate         float64 `json:"feeRate"`         // 手续费率
+	FeeCurrency     string  `json:"feeCurrency"`     // 手续费币种
+	IsMaker         bool    `json:"isMaker"`         // 是否为挂单成交
+	ClosedSize      float64 `json:"closedSize"`      // 平仓数量
+	MarkPrice       float64 `json:"markPrice"`       // 成交时标记价格
+	IndexPrice      float64 `json:"indexPrice"`      // 成交时指数价格
+	UnderlyingPrice float64 `json:"underlyingPrice"` // 成交时标的价格（期权）
+	TradeIv         float64 `json:"tradeIv"`         // 成交隐含波动率（期权）
+	MarkIv          float64 `json:"markIv"`          // 标记隐含波动率（期权）
+	ExecTime        int64   `json:"execTime"`        // 成交时间（毫秒）
+}
+
+// 成交记录列表
+type ExecutionList struct {
+	Category       string      `json:"category"`       // 产品类型
+	List           []Execution `json:"list"`           // 成交记录
+	NextPageCursor string      `json:"nextPageCursor"` // 下一页游标
+}
+
+// 平仓盈亏查询条件，用于position/closed-pnl
+type ClosedPnlQuery struct {
+	Category  string `json:"category"`            // 产品类型
+	Symbol    string `json:"symbol,omitempty"`    // 交易对
+	StartTime int64  `json:"startTime,omitempty"` // 开始时间（毫秒）
+	EndTime   int64  `json:"endTime,omitempty"`   // 结束时间（毫秒）
+	Limit     int    `json:"limit,omitempty"`     // 每页数量
+	Cursor    string `json:"cursor,omitempty"`    // 分页游标
+}
+
+// 平仓盈亏记录
+type ClosedPnl struct {
+	Category      string  `json:"category"`      // 产品类型
+	Symbol        string  `json:"symbol"`        // 交易对
+	OrderId       string  `json:"orderId"`       // 订单ID
+	Side          string  `json:"side"`          // 平仓订单方向
+	OrderType     string  `json:"orderType"`     // 订单类型
+	ExecType      string  `json:"execType"`      // 成交类型
+	Qty           float64 `json:"qty"`           // 订单数量
+	OrderPrice    float64 `json:"orderPrice"`    // 订单价格
+	ClosedSize    float64 `json:"closedSize"`    // 平仓数量
+	CumEntryValue float64 `json:"cumEntryValue"` // 累计开仓价值
+	AvgEntryPrice float64 `json:"avgEntryPrice"` // 平均开仓价格
+	CumExitValue  float64 `json:"cumExitValue"`  // 累计平仓价值
+	AvgExitPrice  float64 `json:"avgExitPrice"`  // 平均平仓价格
+	ClosedPnl     float64 `json:"closedPnl"`     // 平仓盈亏（已扣除手续费）
+	FillCount     int     `json:"fillCount"`     // 成交笔数
+	Leverage      float64 `json:"leverage"`      // 杠杆
+	SettleCoin    string  `json:"settleCoin"`    // 结算币种
+	CreatedTime   int64   `json:"createdTime"`   // 创建时间（毫秒）
+	UpdatedTime   int64   `json:"updatedTime"`   // 更新时间（毫秒）
+}
+
+// 平仓盈亏列表
+type ClosedPnlList struct {
+	Category       string      `json:"category"`       // 产品类型
+	List           []ClosedPnl `json:"list"`           // 平仓盈亏记录
+	NextPageCursor string      `json:"nextPageCursor"` // 下一页游标
+}
+
 // 账户模型
 
 // 钱包余额
@@ -154,6 +287,249 @@ type AssetBalance struct {
 	TotalBalance   string `json:"totalBalance"`   // 总余额
 }
 
+// 划转类型
+const (
+	TransferTypeInternal  = "internal"  // 同一账户内不同账户类型之间的划转
+	TransferTypeUniversal = "universal" // 母子账户之间的划转
+)
+
+// 划转记录
+type Transfer struct {
+	TransferId      string  `json:"transferId"`             // 划转ID
+	Type            string  `json:"type"`                   // 划转类型
+	Coin            string  `json:"coin"`                   // 币种
+	Amount          float64 `json:"amount"`                 // 数量
+	FromMemberId    string  `json:"fromMemberId,omitempty"` // 转出账户UID（母子账户划转）
+	ToMemberId      string  `json:"toMemberId,omitempty"`   // 转入账户UID（母子账户划转）
+	FromAccountType string  `json:"fromAccountType"`        // 转出账户类型
+	ToAccountType   string  `json:"toAccountType"`          // 转入账户类型
+	Status          string  `json:"status"`                 // 状态
+	Timestamp       int64   `json:"timestamp"`              // 时间（毫秒）
+}
+
+// 母子账户间划转请求，用于asset/transfer/universal-transfer
+type UniversalTransferRequest struct {
+	TransferId      string `json:"transferId"`      // 划转ID（UUID），为空时自动生成
+	Coin            string `json:"coin"`            // 币种
+	Amount          string `json:"amount"`          // 数量
+	FromMemberId    string `json:"fromMemberId"`    // 转出账户UID
+	ToMemberId      string `json:"toMemberId"`      // 转入账户UID
+	FromAccountType string `json:"fromAccountType"` // 转出账户类型
+	ToAccountType   string `json:"toAccountType"`   // 转入账户类型
+}
+
+// 提现申请状态
+const (
+	WithdrawalPending    = "pending"    // 等待审批
+	WithdrawalRejected   = "rejected"   // 已拒绝
+	WithdrawalExpired    = "expired"    // 超时未审批
+	WithdrawalSubmitting = "submitting" // 正在提交到Bybit，提交后未能保存结果时保持此状态，需要人工核对
+	WithdrawalSubmitted  = "submitted"  // 已提交到Bybit
+	WithdrawalFailed     = "failed"     // 提交失败
+)
+
+// 提现申请
+type WithdrawalRequest struct {
+	ID          string `json:"id"`                    // 申请ID
+	Account     string `json:"account"`               // 使用的账户名称
+	Coin        string `json:"coin"`                  // 币种
+	Chain       string `json:"chain"`                 // 链
+	Address     string `json:"address"`               // 提现地址
+	Tag         string `json:"tag,omitempty"`         // 地址标签
+	Amount      string `json:"amount"`                // 数量
+	AccountType string `json:"accountType,omitempty"` // 出金账户类型
+	Status      string `json:"status"`                // 状态
+	RequestedBy string `json:"requestedBy"`           // 申请人
+	RequestedAt int64  `json:"requestedAt"`           // 申请时间（毫秒）
+	ExpiresAt   int64  `json:"expiresAt"`             // 审批截止时间（毫秒）
+	ReviewedBy  string `json:"reviewedBy,omitempty"`  // 审批人
+	ReviewedAt  int64  `json:"reviewedAt,omitempty"`  // 审批时间（毫秒）
+	WithdrawId  string `json:"withdrawId,omitempty"`  // Bybit返回的提现ID
+	Error       string `json:"error,omitempty"`       // 拒绝原因或提交失败的错误信息
+}
+
+// 子账户模型
+
+// 创建子账户请求，用于user/create-sub-member
+type SubMemberRequest struct {
+	Username   string `json:"username"`           // 用户名，6-16位字母和数字
+	Password   string `json:"password,omitempty"` // 密码，为空时不设置登录密码
+	MemberType int    `json:"memberType"`         // 1：普通子账户，6：托管子账户
+	Switch     int    `json:"switch,omitempty"`   // 0：关闭快速登录，1：开启
+	Note       string `json:"note,omitempty"`     // 备注
+}
+
+// 创建子账户API密钥请求，用于user/create-sub-api
+// Permissions的键为权限分组（如ContractTrade、Spot、Wallet），值为该分组下的权限列表
+type SubAPIKeyRequest struct {
+	Subuid      int64               `json:"subuid"`         // 子账户UID
+	Note        string              `json:"note,omitempty"` // 备注
+	ReadOnly    int                 `json:"readOnly"`       // 0：读写，1：只读
+	IPs         string              `json:"ips,omitempty"`  // 绑定的IP，多个用逗号分隔
+	Permissions map[string][]string `json:"permissions"`    // 权限
+}
+
+// 绩效分析模型
+
+// 绩效报告查询条件
+type PerformanceQuery struct {
+	Category      string  `json:"category"`                // 产品类型
+	Symbol        string  `json:"symbol,omitempty"`        // 交易对，为空表示全部
+	SettleCoin    string  `json:"settleCoin,omitempty"`    // 结算币种，用于查询全部仓位
+	StartTime     int64   `json:"startTime"`               // 开始时间（毫秒）
+	EndTime       int64   `json:"endTime"`                 // 结束时间（毫秒）
+	InitialEquity float64 `json:"initialEquity,omitempty"` // 期初权益，用于计算收益率、回撤比例和夏普比率
+}
+
+// 单个交易对的盈亏
+type SymbolPnl struct {
+	Symbol        string  `json:"symbol"`        // 交易对
+	RealizedPnl   float64 `json:"realizedPnl"`   // 已实现盈亏（平仓盈亏，已扣除开平仓手续费和资金费用）
+	UnrealizedPnl float64 `json:"unrealizedPnl"` // 未实现盈亏
+	TradingFee    float64 `json:"tradingFee"`    // 交易手续费
+	Funding       float64 `json:"funding"`       // 资金费用净额（正数为支付）
+	Turnover      float64 `json:"turnover"`      // 成交额
+	Trades        int     `json:"trades"`        // 平仓次数
+	Wins          int     `json:"wins"`          // 盈利次数
+}
+
+// 单日盈亏
+type DailyPnl struct {
+	Date          string  `json:"date"`          // 日期（UTC，YYYY-MM-DD）
+	RealizedPnl   float64 `json:"realizedPnl"`   // 已实现盈亏
+	UnrealizedPnl float64 `json:"unrealizedPnl"` // 日终持仓按当日收盘价计算的未实现盈亏
+	TradingFee    float64 `json:"tradingFee"`    // 交易手续费
+	Funding       float64 `json:"funding"`       // 资金费用净额（正数为支付）
+	NetPnl        float64 `json:"netPnl"`        // 净盈亏，平仓盈亏已扣除手续费和资金费用，等于已实现盈亏
+	Turnover      float64 `json:"turnover"`      // 成交额
+	Transfers     float64 `json:"transfers"`     // 划转净额
+	CumPnl        float64 `json:"cumPnl"`        // 累计净盈亏
+	Drawdown      float64 `json:"drawdown"`      // 盯市权益相对历史高点的回撤
+}
+
+// 手续费明细
+type FeeBreakdown struct {
+	Total      float64            `json:"total"`      // 手续费合计（扣除返佣）
+	Maker      float64            `json:"maker"`      // 挂单手续费
+	Taker      float64            `json:"taker"`      // 吃单手续费
+	Rebates    float64            `json:"rebates"`    // 返佣（负手续费）
+	ByCurrency map[string]float64 `json:"byCurrency"` // 按手续费币种统计的原始数量
+}
+
+// 资金费用明细
+type FundingSummary struct {
+	Paid     float64 `json:"paid"`     // 支付的资金费用
+	Received float64 `json:"received"` // 收到的资金费用
+	Net      float64 `json:"net"`      // 净额（正数为支付）
+}
+
+// 绩效报告
+type PerformanceReport struct {
+	Category       string             `json:"category"`       // 产品类型
+	StartTime      int64              `json:"startTime"`      // 开始时间（毫秒）
+	EndTime        int64              `json:"endTime"`        // 结束时间（毫秒）
+	RealizedPnl    float64            `json:"realizedPnl"`    // 已实现盈亏
+	UnrealizedPnl  float64            `json:"unrealizedPnl"`  // 未实现盈亏
+	NetPnl         float64            `json:"netPnl"`         // 净盈亏，平仓盈亏已扣除手续费和资金费用，等于已实现盈亏
+	Fees           FeeBreakdown       `json:"fees"`           // 手续费明细
+	Funding        FundingSummary     `json:"funding"`        // 资金费用明细
+	Turnover       float64            `json:"turnover"`       // 成交额
+	Trades         int                `json:"trades"`         // 平仓次数
+	WinRate        float64            `json:"winRate"`        // 胜率
+	AvgWin         float64            `json:"avgWin"`         // 平均盈利
+	AvgLoss        float64            `json:"avgLoss"`        // 平均亏损
+	ProfitFactor   float64            `json:"profitFactor"`   // 盈亏比（总盈利/总亏损）
+	MaxDrawdown    float64            `json:"maxDrawdown"`    // 最大回撤金额（按盯市权益计算）
+	MaxDrawdownPct float64            `json:"maxDrawdownPct"` // 最大回撤比例（各日回撤比例的最大值，与最大回撤金额可能不在同一天，需提供期初权益）
+	Sharpe         float64            `json:"sharpe"`         // 年化夏普比率（按日收益、365天计算）
+	NetTransfers   map[string]float64 `json:"netTransfers"`   // 按币种统计的划转净额
+	BySymbol       []SymbolPnl        `json:"bySymbol"`       // 按交易对统计
+	Daily          []DailyPnl         `json:"daily"`          // 按日统计
+}
+
+// 多账户汇总模型
+
+// 单个账户的仓位
+type AccountPositions struct {
+	Account   string     `json:"account"`         // 账户名称
+	Positions []Position `json:"positions"`       // 仓位列表
+	Error     string     `json:"error,omitempty"` // 获取失败时的错误信息
+}
+
+// 单个交易对在全部账户中的敞口
+type SymbolExposure struct {
+	Symbol        string  `json:"symbol"`        // 交易对
+	LongSize      float64 `json:"longSize"`      // 多头数量
+	ShortSize     float64 `json:"shortSize"`     // 空头数量
+	NetSize       float64 `json:"netSize"`       // 净数量（多头为正）
+	PositionValue float64 `json:"positionValue"` // 仓位价值
+	UnrealisedPnl float64 `json:"unrealisedPnl"` // 未实现盈亏
+	Accounts      int     `json:"accounts"`      // 持有仓位的账户数
+}
+
+// 跨账户仓位汇总
+type AggregatedPositions struct {
+	Category string             `json:"category"` // 产品类型
+	Accounts []AccountPositions `json:"accounts"` // 按账户列出的仓位
+	BySymbol []SymbolExposure   `json:"bySymbol"` // 按交易对汇总的敞口
+}
+
+// 单个账户的钱包余额
+type AccountBalance struct {
+	Account  string          `json:"account"`         // 账户名称
+	Balances []WalletBalance `json:"balances"`        // 钱包余额
+	Error    string          `json:"error,omitempty"` // 获取失败时的错误信息
+}
+
+// 单个币种在全部账户中的合计
+type CoinTotal struct {
+	Coin                string  `json:"coin"`                // 币种
+	Equity              float64 `json:"equity"`              // 权益
+	WalletBalance       float64 `json:"walletBalance"`       // 钱包余额
+	AvailableToWithdraw float64 `json:"availableToWithdraw"` // 可提现余额
+}
+
+// 跨账户余额汇总
+type AggregatedBalances struct {
+	AccountType string           `json:"accountType"` // 账户类型
+	TotalEquity float64          `json:"totalEquity"` // 全部账户总权益（USD）
+	Accounts    []AccountBalance `json:"accounts"`    // 按账户列出的余额
+	ByCoin      []CoinTotal      `json:"byCoin"`      // 按币种汇总
+}
+
+// 告警类型
+const (
+	AlertPriceCross  = "price_cross"  // 最新价穿越指定价格
+	AlertPriceChange = "price_change" // 最新价在一段时间内的涨跌幅
+	AlertFundingRate = "funding_rate" // 资金费率
+	AlertPositionPnl = "position_pnl" // 仓位未实现盈亏
+	AlertMMRate      = "mm_rate"      // 统一账户维持保证金率
+)
+
+// 告警方向
+const (
+	AlertAbove = "above" // 高于阈值，价格穿越时表示向上穿越
+	AlertBelow = "below" // 低于阈值，价格穿越时表示向下穿越
+)
+
+// 告警规则
+type Alert struct {
+	ID            string  `json:"id"`                      // 告警ID
+	Name          string  `json:"name,omitempty"`          // 名称
+	Account       string  `json:"account"`                 // 使用的账户名称
+	Type          string  `json:"type"`                    // 告警类型
+	Category      string  `json:"category,omitempty"`      // 产品类型
+	Symbol        string  `json:"symbol,omitempty"`        // 交易对
+	Direction     string  `json:"direction,omitempty"`     // 方向：above或below
+	Threshold     float64 `json:"threshold"`               // 阈值：价格、涨跌幅、资金费率、盈亏金额或保证金率
+	Window        int     `json:"window,omitempty"`        // 涨跌幅的时间窗口（分钟）
+	Cooldown      int     `json:"cooldown"`                // 两次触发的最短间隔（秒）
+	CreatedBy     string  `json:"createdBy"`               // 创建人
+	CreatedAt     int64   `json:"createdAt"`               // 创建时间（毫秒）
+	LastTriggered int64   `json:"lastTriggered,omitempty"` // 最近一次触发时间（毫秒）
+	TriggerCount  int     `json:"triggerCount"`            // 累计触发次数
+}
+
 // MCP服务请求/响应模型
 
 // MCP请求
@@ -0,0 +1,37 @@
+package options
+
+import "math"
+
+// Greeks 是一份期权的希腊值，与Bybit行情的单位一致：
+// vega为波动率变化1个百分点时的价格变化，theta为每天的价格变化
+type Greeks struct {
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Vega  float64 `json:"vega"`
+	Theta float64 `json:"theta"`
+}
+
+// Black76 按Black-76模型计算期权的希腊值，无风险利率取0
+// forward为标的（远期）价格，years为距离交割的年数，vol为隐含波动率（小数）；参数无效时ok为false
+func Black76(optionType string, forward, strike, years, vol float64) (greeks Greeks, ok bool) {
+	if forward <= 0 || strike <= 0 || years <= 0 || vol <= 0 {
+		return Greeks{}, false
+	}
+	sqrtT := math.Sqrt(years)
+	d1 := (math.Log(forward/strike) + vol*vol*years/2) / (vol * sqrtT)
+	pdf := math.Exp(-d1*d1/2) / math.Sqrt(2*math.Pi)
+
+	greeks.Delta = normCDF(d1)
+	if optionType == TypePut {
+		greeks.Delta--
+	}
+	greeks.Gamma = pdf / (forward * vol * sqrtT)
+	greeks.Vega = forward * pdf * sqrtT / 100
+	greeks.Theta = -forward * pdf * vol / (2 * sqrtT) / daysPerYear
+	return greeks, true
+}
+
+// 标准正态分布的累积分布函数
+func normCDF(x float64) float64 {
+	return math.Erfc(-x/math.Sqrt2) / 2
+}
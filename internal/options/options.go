@@ -0,0 +1,416 @@
+// Package options 汇总Bybit期权行情，生成期权链、隐含波动率曲面和持仓的希腊值
+//
+// 行情数据来自market/tickers（category=option），希腊值优先使用Bybit返回的值，
+// 缺少时按Black-76模型用标记隐含波动率计算，无风险利率取0，远期价格取标的价格。
+package options
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+)
+
+// 期权类型
+const (
+	TypeCall = "Call"
+	TypePut  = "Put"
+)
+
+// 到期日格式，例如29DEC23
+const expiryLayout = "2Jan06"
+
+// 期权在到期日的08:00（UTC）交割
+const expiryHour = 8
+
+// 一年的天数，用于换算到期时间
+const daysPerYear = 365
+
+// Contract 是从期权合约名称解析出的合约信息
+type Contract struct {
+	Symbol     string  `json:"symbol"`
+	BaseCoin   string  `json:"baseCoin"`
+	SettleCoin string  `json:"settleCoin"`
+	Expiry     string  `json:"expiry"`     // 到期日，例如29DEC23
+	ExpiryTime int64   `json:"expiryTime"` // 交割时间（毫秒）
+	Strike     float64 `json:"strike"`
+	Type       string  `json:"type"` // Call或Put
+}
+
+// ParseSymbol 解析期权合约名称，格式为BTC-29DEC23-40000-C，USDT结算的期权带-USDT后缀
+func ParseSymbol(symbol string) (Contract, error) {
+	parts := strings.Split(symbol, "-")
+	if len(parts) != 4 && len(parts) != 5 {
+		return Contract{}, fmt.Errorf("无效的期权合约%q", symbol)
+	}
+	date, err := time.Parse(expiryLayout, parts[1])
+	if err != nil {
+		return Contract{}, fmt.Errorf("无效的期权到期日%q", parts[1])
+	}
+	strike, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || strike <= 0 {
+		return Contract{}, fmt.Errorf("无效的期权行权价%q", parts[2])
+	}
+	contract := Contract{
+		Symbol:     symbol,
+		BaseCoin:   parts[0],
+		SettleCoin: "USDC",
+		Expiry:     strings.ToUpper(parts[1]),
+		ExpiryTime: date.Add(expiryHour * time.Hour).UnixMilli(),
+		Strike:     strike,
+	}
+	switch parts[3] {
+	case "C":
+		contract.Type = TypeCall
+	case "P":
+		contract.Type = TypePut
+	default:
+		return Contract{}, fmt.Errorf("无效的期权类型%q", parts[3])
+	}
+	if len(parts) == 5 {
+		contract.SettleCoin = parts[4]
+	}
+	return contract, nil
+}
+
+// Quote 是一个期权合约的行情，隐含波动率为小数，例如0.5表示50%
+// vega为波动率变化1个百分点时的价格变化，theta为每天的价格变化
+type Quote struct {
+	Symbol       string  `json:"symbol"`
+	Bid          float64 `json:"bid"`
+	BidSize      float64 `json:"bidSize"`
+	BidIv        float64 `json:"bidIv"`
+	Ask          float64 `json:"ask"`
+	AskSize      float64 `json:"askSize"`
+	AskIv        float64 `json:"askIv"`
+	Mark         float64 `json:"mark"`
+	MarkIv       float64 `json:"markIv"`
+	Delta        float64 `json:"delta"`
+	Gamma        float64 `json:"gamma"`
+	Vega         float64 `json:"vega"`
+	Theta        float64 `json:"theta"`
+	OpenInterest float64 `json:"openInterest"`
+	Volume24h    float64 `json:"volume24h"`
+	Computed     bool    `json:"computed,omitempty"` // 希腊值由Black-76模型计算
+}
+
+// Strike 是同一行权价的看涨和看跌期权
+type Strike struct {
+	Strike float64 `json:"strike"`
+	Call   *Quote  `json:"call,omitempty"`
+	Put    *Quote  `json:"put,omitempty"`
+}
+
+// Expiry 是同一到期日、同一结算币种的期权，按行权价排序
+type Expiry struct {
+	Expiry          string    `json:"expiry"`
+	ExpiryTime      int64     `json:"expiryTime"`
+	SettleCoin      string    `json:"settleCoin"`
+	Days            float64   `json:"days"`            // 距离交割的天数
+	UnderlyingPrice float64   `json:"underlyingPrice"` // 该到期日的标的价格
+	Strikes         []*Strike `json:"strikes"`
+}
+
+// Chain 是一个币种的期权链，按到期时间排序
+type Chain struct {
+	BaseCoin string    `json:"baseCoin"`
+	Time     int64     `json:"time"`
+	Expiries []*Expiry `json:"expiries"`
+}
+
+// market/tickers返回的期权行情
+type optionTicker struct {
+	Symbol          string `json:"symbol"`
+	Bid1Price       string `json:"bid1Price"`
+	Bid1Size        string `json:"bid1Size"`
+	Bid1Iv          string `json:"bid1Iv"`
+	Ask1Price       string `json:"ask1Price"`
+	Ask1Size        string `json:"ask1Size"`
+	Ask1Iv          string `json:"ask1Iv"`
+	MarkPrice       string `json:"markPrice"`
+	MarkIv          string `json:"markIv"`
+	UnderlyingPrice string `json:"underlyingPrice"`
+	OpenInterest    string `json:"openInterest"`
+	Volume24h       string `json:"volume24h"`
+	Delta           string `json:"delta"`
+	Gamma           string `json:"gamma"`
+	Vega            string `json:"vega"`
+	Theta           string `json:"theta"`
+}
+
+// Analyzer 使用Bybit行情和仓位生成期权链、波动率曲面和持仓希腊值
+type Analyzer struct {
+	svc service.BybitService
+	now func() time.Time
+}
+
+// NewAnalyzer 创建期权分析器
+func NewAnalyzer(svc service.BybitService) *Analyzer {
+	return &Analyzer{svc: svc, now: time.Now}
+}
+
+// 获取币种的期权行情，expDate为空时返回全部到期日
+func (a *Analyzer) tickers(ctx context.Context, baseCoin, expDate string) ([]optionTicker, error) {
+	resp, err := a.svc.GetOptionTickers(ctx, baseCoin, expDate)
+	if err != nil {
+		return nil, err
+	}
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return nil, err
+	}
+	var list []optionTicker
+	if len(page.List) > 0 {
+		if err := json.Unmarshal(page.List, &list); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Chain 返回按到期日和行权价分组的期权链，settleCoin为空时包括全部结算币种
+func (a *Analyzer) Chain(ctx context.Context, baseCoin, expDate, settleCoin string) (*Chain, error) {
+	if baseCoin == "" {
+		return nil, fmt.Errorf("必须指定币种")
+	}
+	list, err := a.tickers(ctx, strings.ToUpper(baseCoin), strings.ToUpper(expDate))
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now().UnixMilli()
+	chain := &Chain{BaseCoin: strings.ToUpper(baseCoin), Time: now, Expiries: []*Expiry{}}
+	expiries := map[string]*Expiry{}
+	strikes := map[string]*Strike{}
+	for _, ticker := range list {
+		contract, err := ParseSymbol(ticker.Symbol)
+		if err != nil || (settleCoin != "" && !strings.EqualFold(contract.SettleCoin, settleCoin)) {
+			continue
+		}
+		key := contract.Expiry + "/" + contract.SettleCoin
+		expiry, ok := expiries[key]
+		if !ok {
+			expiry = &Expiry{
+				Expiry:     contract.Expiry,
+				ExpiryTime: contract.ExpiryTime,
+				SettleCoin: contract.SettleCoin,
+				Days:       float64(contract.ExpiryTime-now) / float64(24*time.Hour/time.Millisecond),
+				Strikes:    []*Strike{},
+			}
+			expiries[key] = expiry
+			chain.Expiries = append(chain.Expiries, expiry)
+		}
+		underlying := num(ticker.UnderlyingPrice)
+		if expiry.UnderlyingPrice == 0 {
+			expiry.UnderlyingPrice = underlying
+		}
+
+		strikeKey := key + "/" + strconv.FormatFloat(contract.Strike, 'f', -1, 64)
+		strike, ok := strikes[strikeKey]
+		if !ok {
+			strike = &Strike{Strike: contract.Strike}
+			strikes[strikeKey] = strike
+			expiry.Strikes = append(expiry.Strikes, strike)
+		}
+		quote := newQuote(ticker, contract, underlying, expiry.Days/daysPerYear)
+		if contract.Type == TypeCall {
+			strike.Call = quote
+		} else {
+			strike.Put = quote
+		}
+	}
+
+	sort.Slice(chain.Expiries, func(i, j int) bool {
+		if chain.Expiries[i].ExpiryTime != chain.Expiries[j].ExpiryTime {
+			return chain.Expiries[i].ExpiryTime < chain.Expiries[j].ExpiryTime
+		}
+		return chain.Expiries[i].SettleCoin < chain.Expiries[j].SettleCoin
+	})
+	for _, expiry := range chain.Expiries {
+		sort.Slice(expiry.Strikes, func(i, j int) bool {
+			return expiry.Strikes[i].Strike < expiry.Strikes[j].Strike
+		})
+	}
+	return chain, nil
+}
+
+// 把行情转换为Quote，Bybit没有返回希腊值时用Black-76模型计算
+func newQuote(ticker optionTicker, contract Contract, underlying, years float64) *Quote {
+	quote := &Quote{
+		Symbol:       ticker.Symbol,
+		Bid:          num(ticker.Bid1Price),
+		BidSize:      num(ticker.Bid1Size),
+		BidIv:        num(ticker.Bid1Iv),
+		Ask:          num(ticker.Ask1Price),
+		AskSize:      num(ticker.Ask1Size),
+		AskIv:        num(ticker.Ask1Iv),
+		Mark:         num(ticker.MarkPrice),
+		MarkIv:       num(ticker.MarkIv),
+		Delta:        num(ticker.Delta),
+		Gamma:        num(ticker.Gamma),
+		Vega:         num(ticker.Vega),
+		Theta:        num(ticker.Theta),
+		OpenInterest: num(ticker.OpenInterest),
+		Volume24h:    num(ticker.Volume24h),
+	}
+	if quote.Delta == 0 && quote.Gamma == 0 && quote.Vega == 0 && quote.Theta == 0 && quote.MarkIv > 0 {
+		if greeks, ok := Black76(contract.Type, underlying, contract.Strike, years, quote.MarkIv); ok {
+			quote.Delta, quote.Gamma, quote.Vega, quote.Theta = greeks.Delta, greeks.Gamma, greeks.Vega, greeks.Theta
+			quote.Computed = true
+		}
+	}
+	return quote
+}
+
+// PositionGreeks 是一个期权仓位的希腊值，卖出仓位的数量为负
+type PositionGreeks struct {
+	Symbol          string  `json:"symbol"`
+	BaseCoin        string  `json:"baseCoin"`
+	Size            float64 `json:"size"`
+	UnderlyingPrice float64 `json:"underlyingPrice"`
+	Delta           float64 `json:"delta"`
+	Gamma           float64 `json:"gamma"`
+	Vega            float64 `json:"vega"`
+	Theta           float64 `json:"theta"`
+	DollarDelta     float64 `json:"dollarDelta"` // delta乘以标的价格
+}
+
+// GreeksTotal 是一个币种全部期权仓位的希腊值合计
+type GreeksTotal struct {
+	BaseCoin    string  `json:"baseCoin"`
+	Delta       float64 `json:"delta"`
+	Gamma       float64 `json:"gamma"`
+	Vega        float64 `json:"vega"`
+	Theta       float64 `json:"theta"`
+	DollarDelta float64 `json:"dollarDelta"`
+}
+
+// PortfolioGreeks 是期权持仓的希腊值，不同币种的delta单位不同，按币种分别合计
+type PortfolioGreeks struct {
+	Time      int64            `json:"time"`
+	Positions []PositionGreeks `json:"positions"`
+	Totals    []GreeksTotal    `json:"totals"`
+}
+
+// PortfolioGreeks 按期权仓位和当前行情计算持仓希腊值，baseCoin为空时包括全部币种
+// 只统计期权仓位，永续和现货的delta不计入
+func (a *Analyzer) PortfolioGreeks(ctx context.Context, baseCoin string) (*PortfolioGreeks, error) {
+	resp, err := a.svc.GetPositions(ctx, bybitapi.CategoryOption, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return nil, err
+	}
+	var positions []model.Position
+	if len(page.List) > 0 {
+		if err := json.Unmarshal(page.List, &positions); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &PortfolioGreeks{Time: a.now().UnixMilli(), Positions: []PositionGreeks{}, Totals: []GreeksTotal{}}
+	quotes := map[string]map[string]pricedQuote{} // 币种 -> 合约 -> 行情
+	totals := map[string]*GreeksTotal{}
+	for _, position := range positions {
+		size := num(position.Size)
+		if size == 0 {
+			continue
+		}
+		contract, err := ParseSymbol(position.Symbol)
+		if err != nil || (baseCoin != "" && !strings.EqualFold(contract.BaseCoin, baseCoin)) {
+			continue
+		}
+		if position.Side == "Sell" {
+			size = -size
+		}
+
+		coinQuotes, ok := quotes[contract.BaseCoin]
+		if !ok {
+			if coinQuotes, err = a.quotes(ctx, contract.BaseCoin); err != nil {
+				return nil, err
+			}
+			quotes[contract.BaseCoin] = coinQuotes
+		}
+		priced, ok := coinQuotes[position.Symbol]
+		if !ok {
+			return nil, fmt.Errorf("没有找到%s的行情", position.Symbol)
+		}
+		quote, underlying := priced.quote, priced.underlying
+
+		greeks := PositionGreeks{
+			Symbol:          position.Symbol,
+			BaseCoin:        contract.BaseCoin,
+			Size:            size,
+			UnderlyingPrice: underlying,
+			Delta:           size * quote.Delta,
+			Gamma:           size * quote.Gamma,
+			Vega:            size * quote.Vega,
+			Theta:           size * quote.Theta,
+		}
+		greeks.DollarDelta = greeks.Delta * underlying
+		result.Positions = append(result.Positions, greeks)
+
+		total, ok := totals[contract.BaseCoin]
+		if !ok {
+			total = &GreeksTotal{BaseCoin: contract.BaseCoin}
+			totals[contract.BaseCoin] = total
+		}
+		total.Delta += greeks.Delta
+		total.Gamma += greeks.Gamma
+		total.Vega += greeks.Vega
+		total.Theta += greeks.Theta
+		total.DollarDelta += greeks.DollarDelta
+	}
+
+	for _, total := range totals {
+		result.Totals = append(result.Totals, *total)
+	}
+	sort.Slice(result.Totals, func(i, j int) bool {
+		return result.Totals[i].BaseCoin < result.Totals[j].BaseCoin
+	})
+	return result, nil
+}
+
+// 带标的价格的期权行情
+type pricedQuote struct {
+	quote      *Quote
+	underlying float64
+}
+
+// 获取币种全部期权的行情，按合约名称索引
+func (a *Analyzer) quotes(ctx context.Context, baseCoin string) (map[string]pricedQuote, error) {
+	chain, err := a.Chain(ctx, baseCoin, "", "")
+	if err != nil {
+		return nil, err
+	}
+	quotes := map[string]pricedQuote{}
+	for _, expiry := range chain.Expiries {
+		for _, strike := range expiry.Strikes {
+			for _, quote := range []*Quote{strike.Call, strike.Put} {
+				if quote != nil {
+					quotes[quote.Symbol] = pricedQuote{quote: quote, underlying: expiry.UnderlyingPrice}
+				}
+			}
+		}
+	}
+	return quotes, nil
+}
+
+// 解析数字字符串，无效时返回0
+func num(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
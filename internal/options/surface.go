@@ -0,0 +1,178 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 默认的曲面网格
+var (
+	defaultMoneyness = []float64{0.7, 0.8, 0.9, 0.95, 1, 1.05, 1.1, 1.2, 1.3}
+	defaultTenors    = []float64{7, 14, 30, 60, 90, 180}
+)
+
+// 曲面默认使用的结算币种
+const defaultSettleCoin = "USDC"
+
+// SurfacePoint 是某个行权价的标记隐含波动率，moneyness为行权价除以标的价格
+type SurfacePoint struct {
+	Strike    float64 `json:"strike"`
+	Moneyness float64 `json:"moneyness"`
+	Iv        float64 `json:"iv"`
+}
+
+// Smile 是同一到期日的波动率微笑，按行权价排序
+type Smile struct {
+	Expiry          string         `json:"expiry"`
+	ExpiryTime      int64          `json:"expiryTime"`
+	Days            float64        `json:"days"`
+	UnderlyingPrice float64        `json:"underlyingPrice"`
+	Points          []SurfacePoint `json:"points"`
+}
+
+// GridRow 是某个期限在各moneyness上插值得到的隐含波动率，与Surface.Moneyness一一对应
+type GridRow struct {
+	Days float64   `json:"days"`
+	Ivs  []float64 `json:"ivs"`
+}
+
+// Surface 是一个币种的隐含波动率曲面
+// Smiles为各到期日的原始数据，Grid为按moneyness和期限（天）插值的网格
+type Surface struct {
+	BaseCoin   string    `json:"baseCoin"`
+	SettleCoin string    `json:"settleCoin"`
+	Time       int64     `json:"time"`
+	Smiles     []Smile   `json:"smiles"`
+	Moneyness  []float64 `json:"moneyness"`
+	Grid       []GridRow `json:"grid"`
+}
+
+// Surface 生成隐含波动率曲面，每个行权价使用虚值一侧期权的标记隐含波动率
+// 同一到期日内按moneyness线性插值，不同到期日之间按总方差（iv²×年数）线性插值；
+// 超出已有行权价或到期日范围时取最近的值。moneyness和tenors（天）为空时使用默认网格
+func (a *Analyzer) Surface(ctx context.Context, baseCoin, settleCoin string, moneyness, tenors []float64) (*Surface, error) {
+	if settleCoin == "" {
+		settleCoin = defaultSettleCoin
+	}
+	if len(moneyness) == 0 {
+		moneyness = defaultMoneyness
+	}
+	if len(tenors) == 0 {
+		tenors = defaultTenors
+	}
+	for _, m := range moneyness {
+		if m <= 0 {
+			return nil, fmt.Errorf("moneyness必须大于0")
+		}
+	}
+	for _, days := range tenors {
+		if days <= 0 {
+			return nil, fmt.Errorf("期限必须大于0")
+		}
+	}
+
+	chain, err := a.Chain(ctx, baseCoin, "", settleCoin)
+	if err != nil {
+		return nil, err
+	}
+	surface := &Surface{
+		BaseCoin:   chain.BaseCoin,
+		SettleCoin: settleCoin,
+		Time:       chain.Time,
+		Smiles:     []Smile{},
+		Moneyness:  moneyness,
+		Grid:       []GridRow{},
+	}
+	for _, expiry := range chain.Expiries {
+		if smile, ok := newSmile(expiry); ok {
+			surface.Smiles = append(surface.Smiles, smile)
+		}
+	}
+	if len(surface.Smiles) == 0 {
+		return surface, nil
+	}
+
+	sorted := append([]float64(nil), tenors...)
+	sort.Float64s(sorted)
+	for _, days := range sorted {
+		row := GridRow{Days: days, Ivs: make([]float64, len(moneyness))}
+		for i, m := range moneyness {
+			row.Ivs[i] = surface.iv(m, days)
+		}
+		surface.Grid = append(surface.Grid, row)
+	}
+	return surface, nil
+}
+
+// 从到期日的期权生成波动率微笑，已经交割或没有有效隐含波动率时ok为false
+func newSmile(expiry *Expiry) (Smile, bool) {
+	if expiry.Days <= 0 || expiry.UnderlyingPrice <= 0 {
+		return Smile{}, false
+	}
+	smile := Smile{
+		Expiry:          expiry.Expiry,
+		ExpiryTime:      expiry.ExpiryTime,
+		Days:            expiry.Days,
+		UnderlyingPrice: expiry.UnderlyingPrice,
+		Points:          []SurfacePoint{},
+	}
+	for _, strike := range expiry.Strikes {
+		// 行权价高于标的价格时看涨期权是虚值，否则看跌期权是虚值；虚值一侧没有报价时使用另一侧
+		otm, itm := strike.Put, strike.Call
+		if strike.Strike >= expiry.UnderlyingPrice {
+			otm, itm = strike.Call, strike.Put
+		}
+		iv := 0.0
+		if otm != nil {
+			iv = otm.MarkIv
+		}
+		if iv <= 0 && itm != nil {
+			iv = itm.MarkIv
+		}
+		if iv <= 0 {
+			continue
+		}
+		smile.Points = append(smile.Points, SurfacePoint{
+			Strike:    strike.Strike,
+			Moneyness: strike.Strike / expiry.UnderlyingPrice,
+			Iv:        iv,
+		})
+	}
+	return smile, len(smile.Points) > 0
+}
+
+// 在微笑上按moneyness线性插值，超出范围时取两端的值
+func (s Smile) iv(moneyness float64) float64 {
+	points := s.Points
+	if moneyness <= points[0].Moneyness {
+		return points[0].Iv
+	}
+	last := points[len(points)-1]
+	if moneyness >= last.Moneyness {
+		return last.Iv
+	}
+	i := sort.Search(len(points), func(i int) bool { return points[i].Moneyness >= moneyness })
+	lo, hi := points[i-1], points[i]
+	return lo.Iv + (hi.Iv-lo.Iv)*(moneyness-lo.Moneyness)/(hi.Moneyness-lo.Moneyness)
+}
+
+// 插值得到moneyness和期限（天）处的隐含波动率，Smiles按到期时间排序
+func (s *Surface) iv(moneyness, days float64) float64 {
+	smiles := s.Smiles
+	if days <= smiles[0].Days {
+		return smiles[0].iv(moneyness)
+	}
+	last := smiles[len(smiles)-1]
+	if days >= last.Days {
+		return last.iv(moneyness)
+	}
+	i := sort.Search(len(smiles), func(i int) bool { return smiles[i].Days >= days })
+	lo, hi := smiles[i-1], smiles[i]
+	loIv, hiIv := lo.iv(moneyness), hi.iv(moneyness)
+	// 总方差随期限线性变化
+	loVar, hiVar := loIv*loIv*lo.Days, hiIv*hiIv*hi.Days
+	variance := loVar + (hiVar-loVar)*(days-lo.Days)/(hi.Days-lo.Days)
+	return math.Sqrt(math.Max(variance, 0) / days)
+}
@@ -0,0 +1,457 @@
+package paper
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/analytics"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/errors"
+)
+
+// 绩效报告默认统计的天数
+const defaultReportDays = 30
+
+// 模拟交易不支持的操作
+func unsupported(action string) error {
+	return errors.New(errors.ErrPermissionDenied, "模拟交易模式不支持"+action)
+}
+
+// ==================== 市场数据 ====================
+
+// GetKline 获取K线数据
+func (s *Service) GetKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	return s.market.GetKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetOrderbook 获取订单簿数据
+func (s *Service) GetOrderbook(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	return s.market.GetOrderbook(ctx, category, symbol, limit)
+}
+
+// GetTickers 获取行情数据
+func (s *Service) GetTickers(ctx context.Context, category, symbol string) (*model.Response, error) {
+	return s.market.GetTickers(ctx, category, symbol)
+}
+
+// GetInstruments 获取交易对信息
+func (s *Service) GetInstruments(ctx context.Context, category, symbol, status string) (*model.Response, error) {
+	return s.market.GetInstruments(ctx, category, symbol, status)
+}
+
+// 撮合用不到的公共行情接口直接转发到行情源，行情源只提供撮合所需的数据时（例如回测）返回错误
+func (s *Service) publicMarket() (service.MarketDataService, error) {
+	if market, ok := s.market.(service.MarketDataService); ok {
+		return market, nil
+	}
+	return nil, unsupported("该行情接口")
+}
+
+// GetRecentTrades 获取最近成交
+func (s *Service) GetRecentTrades(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetRecentTrades(ctx, category, symbol, limit)
+}
+
+// GetMarkPriceKline 获取标记价格K线
+func (s *Service) GetMarkPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetMarkPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetIndexPriceKline 获取指数价格K线
+func (s *Service) GetIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetIndexPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetPremiumIndexPriceKline 获取溢价指数K线
+func (s *Service) GetPremiumIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetPremiumIndexPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetFundingRateHistory 获取资金费率历史
+func (s *Service) GetFundingRateHistory(ctx context.Context, category, symbol string, startTime, endTime int64, limit int) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetFundingRateHistory(ctx, category, symbol, startTime, endTime, limit)
+}
+
+// GetOpenInterest 获取持仓量
+func (s *Service) GetOpenInterest(ctx context.Context, category, symbol, intervalTime string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetOpenInterest(ctx, category, symbol, intervalTime, startTime, endTime, limit, cursor)
+}
+
+// GetLongShortRatio 获取多空持仓人数比
+func (s *Service) GetLongShortRatio(ctx context.Context, category, symbol, period string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetLongShortRatio(ctx, category, symbol, period, startTime, endTime, limit, cursor)
+}
+
+// GetHistoricalVolatility 获取期权历史波动率
+func (s *Service) GetHistoricalVolatility(ctx context.Context, category, baseCoin string, period int, startTime, endTime int64) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetHistoricalVolatility(ctx, category, baseCoin, period, startTime, endTime)
+}
+
+// GetInsurance 获取保险基金余额
+func (s *Service) GetInsurance(ctx context.Context, coin string) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetInsurance(ctx, coin)
+}
+
+// GetRiskLimit 获取风险限额档位
+func (s *Service) GetRiskLimit(ctx context.Context, category, symbol, cursor string) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetRiskLimit(ctx, category, symbol, cursor)
+}
+
+// GetDeliveryPrice 获取交割价格
+func (s *Service) GetDeliveryPrice(ctx context.Context, category, symbol, baseCoin string, limit int, cursor string) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetDeliveryPrice(ctx, category, symbol, baseCoin, limit, cursor)
+}
+
+// GetOptionTickers 获取期权行情
+func (s *Service) GetOptionTickers(ctx context.Context, baseCoin, expDate string) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetOptionTickers(ctx, baseCoin, expDate)
+}
+
+// GetServerTime 获取服务器时间
+func (s *Service) GetServerTime(ctx context.Context) (*model.Response, error) {
+	market, err := s.publicMarket()
+	if err != nil {
+		return nil, err
+	}
+	return market.GetServerTime(ctx)
+}
+
+// ==================== 账户 ====================
+
+// Bybit格式的统一账户钱包
+type walletView struct {
+	AccountType            string     `json:"accountType"`
+	AccountIMRate          string     `json:"accountIMRate"`
+	AccountMMRate          string     `json:"accountMMRate"`
+	TotalEquity            string     `json:"totalEquity"`
+	TotalWalletBalance     string     `json:"totalWalletBalance"`
+	TotalMarginBalance     string     `json:"totalMarginBalance"`
+	TotalAvailableBalance  string     `json:"totalAvailableBalance"`
+	TotalPerpUPL           string     `json:"totalPerpUPL"`
+	TotalInitialMargin     string     `json:"totalInitialMargin"`
+	TotalMaintenanceMargin string     `json:"totalMaintenanceMargin"`
+	Coin                   []coinView `json:"coin"`
+}
+
+// Bybit格式的币种余额
+type coinView struct {
+	Coin                string `json:"coin"`
+	Equity              string `json:"equity"`
+	UsdValue            string `json:"usdValue"`
+	WalletBalance       string `json:"walletBalance"`
+	Locked              string `json:"locked"`
+	AvailableToWithdraw string `json:"availableToWithdraw"`
+	TotalOrderIM        string `json:"totalOrderIM"`
+	TotalPositionIM     string `json:"totalPositionIM"`
+	TotalPositionMM     string `json:"totalPositionMM"`
+	UnrealisedPnl       string `json:"unrealisedPnl"`
+	CumRealisedPnl      string `json:"cumRealisedPnl"`
+}
+
+// 按名称排序的币种列表
+func sortedCoins(balances map[string]float64) []string {
+	coins := make([]string, 0, len(balances))
+	for coin := range balances {
+		coins = append(coins, coin)
+	}
+	sort.Strings(coins)
+	return coins
+}
+
+// 币种的美元价格，稳定币按1计算，没有行情的币种为0
+func (s *Service) usdPrice(coin string) float64 {
+	if stableCoins[coin] {
+		return 1
+	}
+	return s.prices[coin]
+}
+
+// GetWalletBalance 查询模拟统一账户的余额、保证金和未实现盈亏
+func (s *Service) GetWalletBalance(ctx context.Context, accountType, coin string) (*model.Response, error) {
+	if accountType != "" && accountType != "UNIFIED" {
+		return s.reject(retParamsError, "模拟交易只支持UNIFIED账户"), nil
+	}
+
+	wanted := map[string]bool{}
+	for _, c := range strings.Split(coin, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			wanted[c] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet := walletView{AccountType: "UNIFIED", Coin: []coinView{}}
+	var totalWallet, totalUpl, totalIM, totalMM float64
+	for _, c := range sortedCoins(s.balances) {
+		balance := s.balances[c]
+		upl := s.unrealised(c)
+		im, mm := s.margins(c)
+		orderIM, locked := s.reserved(c)
+		cumRealised := 0.0
+		for _, pos := range s.positions {
+			if pos.settleCoin == c {
+				cumRealised += pos.cumRealised
+			}
+		}
+
+		price := s.usdPrice(c)
+		totalWallet += balance * price
+		totalUpl += upl * price
+		totalIM += (im + orderIM) * price
+		totalMM += mm * price
+
+		if len(wanted) > 0 && !wanted[c] {
+			continue
+		}
+		wallet.Coin = append(wallet.Coin, coinView{
+			Coin:                c,
+			Equity:              format(balance + upl),
+			UsdValue:            format((balance + upl) * price),
+			WalletBalance:       format(balance),
+			Locked:              format(locked),
+			AvailableToWithdraw: format(math.Max(0, math.Min(balance-locked, s.available(c)))),
+			TotalOrderIM:        format(orderIM),
+			TotalPositionIM:     format(im),
+			TotalPositionMM:     format(mm),
+			UnrealisedPnl:       format(upl),
+			CumRealisedPnl:      format(cumRealised),
+		})
+	}
+
+	margin := totalWallet + totalUpl
+	wallet.TotalEquity = format(margin)
+	wallet.TotalWalletBalance = format(totalWallet)
+	wallet.TotalMarginBalance = format(margin)
+	wallet.TotalAvailableBalance = format(margin - totalIM)
+	wallet.TotalPerpUPL = format(totalUpl)
+	wallet.TotalInitialMargin = format(totalIM)
+	wallet.TotalMaintenanceMargin = format(totalMM)
+	wallet.AccountIMRate, wallet.AccountMMRate = "0", "0"
+	if margin > 0 {
+		wallet.AccountIMRate = format(totalIM / margin)
+		wallet.AccountMMRate = format(totalMM / margin)
+	}
+	return s.ok(map[string]interface{}{"list": []walletView{wallet}}), nil
+}
+
+// GetFeeRate 返回配置的模拟手续费率
+func (s *Service) GetFeeRate(ctx context.Context, category, symbol string) (*model.Response, error) {
+	rate := map[string]string{
+		"symbol":       symbol,
+		"makerFeeRate": format(s.cfg.MakerFeeRate),
+		"takerFeeRate": format(s.cfg.TakerFeeRate),
+	}
+	return s.ok(map[string]interface{}{"category": category, "list": []map[string]string{rate}}), nil
+}
+
+// GetAccountInfo 返回模拟账户信息，模拟账户为统一账户、全仓保证金模式
+func (s *Service) GetAccountInfo(ctx context.Context) (*model.Response, error) {
+	return s.ok(map[string]interface{}{
+		"unifiedMarginStatus": 4,
+		"marginMode":          "REGULAR_MARGIN",
+		"isMasterTrader":      false,
+		"spotHedgingStatus":   "OFF",
+		"updatedTime":         "0",
+	}), nil
+}
+
+// SetMarginMode 设置保证金模式，模拟交易只支持全仓保证金
+func (s *Service) SetMarginMode(ctx context.Context, marginMode string) (*model.Response, error) {
+	if marginMode != "REGULAR_MARGIN" {
+		return s.reject(retParamsError, "模拟交易只支持REGULAR_MARGIN"), nil
+	}
+	return s.ok(map[string]interface{}{"reasons": []interface{}{}}), nil
+}
+
+// ==================== 资产 ====================
+
+// GetCoinBalance 查询单个币种的模拟余额
+func (s *Service) GetCoinBalance(ctx context.Context, coin, accountType string) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balance := s.balances[coin]
+	_, locked := s.reserved(coin)
+	return s.ok(map[string]interface{}{
+		"accountType": "UNIFIED",
+		"balance": map[string]string{
+			"coin":            coin,
+			"walletBalance":   format(balance),
+			"transferBalance": format(math.Max(0, math.Min(balance-locked, s.available(coin)))),
+			"bonus":           "0",
+		},
+	}), nil
+}
+
+// TransferAsset 模拟账户只有一个统一账户，不支持划转
+func (s *Service) TransferAsset(ctx context.Context, transferId, coin, amount, fromAccountType, toAccountType string) (*model.Response, error) {
+	return nil, unsupported("划转")
+}
+
+// GetTransferHistory 模拟账户没有划转记录
+func (s *Service) GetTransferHistory(ctx context.Context, transferId, coin, status string, startTime, endTime int64, limit int) (*model.Response, error) {
+	return s.ok(map[string]interface{}{"list": []interface{}{}, "nextPageCursor": ""}), nil
+}
+
+// GetDepositHistory 模拟账户没有充值记录
+func (s *Service) GetDepositHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	return s.ok(map[string]interface{}{"rows": []interface{}{}, "nextPageCursor": ""}), nil
+}
+
+// GetWithdrawalHistory 模拟账户没有提现记录
+func (s *Service) GetWithdrawalHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	return s.ok(map[string]interface{}{"rows": []interface{}{}, "nextPageCursor": ""}), nil
+}
+
+// Withdraw 模拟账户不支持提现
+func (s *Service) Withdraw(ctx context.Context, coin, chain, address, tag, amount string, options map[string]string) (*model.Response, error) {
+	return nil, unsupported("提现")
+}
+
+// UniversalTransfer 模拟账户不支持母子账户划转
+func (s *Service) UniversalTransfer(ctx context.Context, req *model.UniversalTransferRequest) (*model.Response, error) {
+	return nil, unsupported("母子账户划转")
+}
+
+// ListAllTransfers 模拟账户没有划转记录
+func (s *Service) ListAllTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	return []model.Transfer{}, nil
+}
+
+// ==================== 子账户 ====================
+
+// CreateSubMember 模拟账户不支持子账户管理
+func (s *Service) CreateSubMember(ctx context.Context, req *model.SubMemberRequest) (*model.Response, error) {
+	return nil, unsupported("子账户管理")
+}
+
+// ListSubMembers 模拟账户不支持子账户管理
+func (s *Service) ListSubMembers(ctx context.Context) (*model.Response, error) {
+	return nil, unsupported("子账户管理")
+}
+
+// CreateSubAPIKey 模拟账户不支持子账户管理
+func (s *Service) CreateSubAPIKey(ctx context.Context, req *model.SubAPIKeyRequest) (*model.Response, error) {
+	return nil, unsupported("子账户管理")
+}
+
+// ListSubAPIKeys 模拟账户不支持子账户管理
+func (s *Service) ListSubAPIKeys(ctx context.Context, subMemberId string) (*model.Response, error) {
+	return nil, unsupported("子账户管理")
+}
+
+// DeleteSubAPIKey 模拟账户不支持子账户管理
+func (s *Service) DeleteSubAPIKey(ctx context.Context, apiKey string) (*model.Response, error) {
+	return nil, unsupported("子账户管理")
+}
+
+// FreezeSubMember 模拟账户不支持子账户管理
+func (s *Service) FreezeSubMember(ctx context.Context, subuid string, frozen bool) (*model.Response, error) {
+	return nil, unsupported("子账户管理")
+}
+
+// ==================== 绩效分析 ====================
+
+// GetPerformanceReport 根据模拟成交、平仓盈亏和当前仓位生成绩效报告
+func (s *Service) GetPerformanceReport(ctx context.Context, query *model.PerformanceQuery) (*model.PerformanceReport, error) {
+	// 复制查询条件，补充默认时间区间时不修改调用方的参数
+	copied := *query
+	query = &copied
+	if query.EndTime <= 0 {
+		query.EndTime = s.nowMs()
+	}
+	if query.StartTime <= 0 {
+		query.StartTime = query.EndTime - int64(defaultReportDays*24*time.Hour/time.Millisecond)
+	}
+
+	input := s.performanceInput(query)
+	// 日收盘价从行情源查询，查询期间不持有锁
+	if err := analytics.LoadDailyCloses(ctx, s.market.GetKline, query.Category, input, query.StartTime, query.EndTime); err != nil {
+		return nil, err
+	}
+	return analytics.BuildReport(query, input), nil
+}
+
+// 收集生成绩效报告所需的模拟成交、平仓盈亏和当前仓位
+func (s *Service) performanceInput(query *model.PerformanceQuery) *analytics.Input {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	input := &analytics.Input{
+		Executions: s.filterExecutions(&model.ExecutionQuery{
+			Category:  query.Category,
+			Symbol:    query.Symbol,
+			StartTime: query.StartTime,
+			EndTime:   query.EndTime,
+		}, 0),
+		ClosedPnl: s.filterClosedPnl(&model.ClosedPnlQuery{
+			Category:  query.Category,
+			Symbol:    query.Symbol,
+			StartTime: query.StartTime,
+			EndTime:   query.EndTime,
+		}, 0),
+	}
+	if query.Category == bybitapi.CategoryLinear {
+		for _, pos := range s.filterPositions(query.Symbol, query.SettleCoin) {
+			if pos.size != 0 {
+				input.Positions = append(input.Positions, s.positionModel(pos))
+			}
+		}
+	}
+	return input
+}
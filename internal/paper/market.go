@@ -0,0 +1,231 @@
+package paper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/errors"
+)
+
+// 撮合时读取的订单簿深度
+const bookDepth = 50
+
+// MarketData 提供撮合所需的行情，可以是实时行情，也可以是录制的行情
+// service.BybitService的实现都满足该接口
+type MarketData interface {
+	GetKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error)
+	GetOrderbook(ctx context.Context, category, symbol string, limit int) (*model.Response, error)
+	GetTickers(ctx context.Context, category, symbol string) (*model.Response, error)
+	GetInstruments(ctx context.Context, category, symbol, status string) (*model.Response, error)
+}
+
+// 交易对规则
+type instrument struct {
+	category    string
+	symbol      string
+	baseCoin    string
+	quoteCoin   string
+	settleCoin  string
+	qtyStep     float64
+	minOrderQty float64
+	tickSize    float64
+	maxLeverage float64
+}
+
+// 最新行情
+type quote struct {
+	bid             float64
+	ask             float64
+	last            float64
+	mark            float64
+	fundingRate     float64
+	nextFundingTime int64
+}
+
+// 订单簿中的一档
+type level struct {
+	price float64
+	qty   float64
+}
+
+// 订单簿快照
+type book struct {
+	bids []level // 价格从高到低
+	asks []level // 价格从低到高
+}
+
+// instruments-info返回的交易对信息
+type instrumentInfo struct {
+	Symbol        string `json:"symbol"`
+	BaseCoin      string `json:"baseCoin"`
+	QuoteCoin     string `json:"quoteCoin"`
+	SettleCoin    string `json:"settleCoin"`
+	LotSizeFilter struct {
+		QtyStep       string `json:"qtyStep"`
+		BasePrecision string `json:"basePrecision"`
+		MinOrderQty   string `json:"minOrderQty"`
+	} `json:"lotSizeFilter"`
+	PriceFilter struct {
+		TickSize string `json:"tickSize"`
+	} `json:"priceFilter"`
+	LeverageFilter struct {
+		MaxLeverage string `json:"maxLeverage"`
+	} `json:"leverageFilter"`
+}
+
+// tickers返回的行情
+type tickerInfo struct {
+	Symbol          string `json:"symbol"`
+	LastPrice       string `json:"lastPrice"`
+	MarkPrice       string `json:"markPrice"`
+	Bid1Price       string `json:"bid1Price"`
+	Ask1Price       string `json:"ask1Price"`
+	FundingRate     string `json:"fundingRate"`
+	NextFundingTime string `json:"nextFundingTime"`
+}
+
+// orderbook返回的订单簿
+type orderbookInfo struct {
+	Bids [][]string `json:"b"`
+	Asks [][]string `json:"a"`
+}
+
+// 获取交易对规则，结果会被缓存
+func (s *Service) instrument(ctx context.Context, category, symbol string) (*instrument, error) {
+	key := category + "/" + symbol
+	s.cacheMu.Lock()
+	inst, ok := s.instruments[key]
+	s.cacheMu.Unlock()
+	if ok {
+		return inst, nil
+	}
+
+	resp, err := s.market.GetInstruments(ctx, category, symbol, "")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		List []instrumentInfo `json:"list"`
+	}
+	if err := decodeResult(resp, &result); err != nil {
+		return nil, err
+	}
+	if len(result.List) == 0 {
+		return nil, nil
+	}
+
+	info := result.List[0]
+	inst = &instrument{
+		category:    category,
+		symbol:      info.Symbol,
+		baseCoin:    info.BaseCoin,
+		quoteCoin:   info.QuoteCoin,
+		settleCoin:  info.SettleCoin,
+		qtyStep:     num(info.LotSizeFilter.QtyStep),
+		minOrderQty: num(info.LotSizeFilter.MinOrderQty),
+		tickSize:    num(info.PriceFilter.TickSize),
+		maxLeverage: num(info.LeverageFilter.MaxLeverage),
+	}
+	if inst.qtyStep == 0 {
+		inst.qtyStep = num(info.LotSizeFilter.BasePrecision)
+	}
+	if inst.settleCoin == "" {
+		inst.settleCoin = inst.quoteCoin
+	}
+
+	s.cacheMu.Lock()
+	s.instruments[key] = inst
+	s.cacheMu.Unlock()
+	return inst, nil
+}
+
+// 获取交易对的最新行情
+func (s *Service) fetchQuote(ctx context.Context, category, symbol string) (*quote, error) {
+	resp, err := s.market.GetTickers(ctx, category, symbol)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		List []tickerInfo `json:"list"`
+	}
+	if err := decodeResult(resp, &result); err != nil {
+		return nil, err
+	}
+	for _, ticker := range result.List {
+		if ticker.Symbol != symbol {
+			continue
+		}
+		q := &quote{
+			bid:             num(ticker.Bid1Price),
+			ask:             num(ticker.Ask1Price),
+			last:            num(ticker.LastPrice),
+			mark:            num(ticker.MarkPrice),
+			fundingRate:     num(ticker.FundingRate),
+			nextFundingTime: int64(num(ticker.NextFundingTime)),
+		}
+		// 现货没有标记价格，使用最新成交价
+		if q.mark == 0 {
+			q.mark = q.last
+		}
+		return q, nil
+	}
+	return nil, fmt.Errorf("没有%s的行情", symbol)
+}
+
+// 获取订单簿快照
+func (s *Service) fetchBook(ctx context.Context, category, symbol string) (*book, error) {
+	resp, err := s.market.GetOrderbook(ctx, category, symbol, bookDepth)
+	if err != nil {
+		return nil, err
+	}
+	var result orderbookInfo
+	if err := decodeResult(resp, &result); err != nil {
+		return nil, err
+	}
+	return &book{bids: parseLevels(result.Bids), asks: parseLevels(result.Asks)}, nil
+}
+
+// 解析订单簿档位
+func parseLevels(rows [][]string) []level {
+	levels := make([]level, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		price, qty := num(row[0]), num(row[1])
+		if price > 0 && qty > 0 {
+			levels = append(levels, level{price: price, qty: qty})
+		}
+	}
+	return levels
+}
+
+// 解析响应中的result，Bybit返回错误码时返回错误
+func decodeResult(resp *model.Response, v interface{}) error {
+	if resp == nil {
+		return errors.New(errors.ErrAPIResponseInvalid, "响应为空")
+	}
+	if err := errors.FromBybitAPIError(resp.RetCode, resp.RetMsg); err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return errors.Wrap(errors.ErrAPIResponseInvalid, "序列化行情失败", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrap(errors.ErrAPIResponseInvalid, "解析行情失败", err)
+	}
+	return nil
+}
+
+// 解析数字字符串，无效时返回0
+func num(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
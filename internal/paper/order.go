@@ -0,0 +1,805 @@
+package paper
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/google/uuid"
+)
+
+// 订单状态
+const (
+	statusNew                     = "New"
+	statusPartiallyFilled         = "PartiallyFilled"
+	statusFilled                  = "Filled"
+	statusCancelled               = "Cancelled"
+	statusPartiallyFilledCanceled = "PartiallyFilledCanceled"
+)
+
+// 数量比较的误差
+const epsilon = 1e-9
+
+// 每页默认返回的订单和成交数量
+const (
+	defaultOrderLimit     = 20
+	defaultExecutionLimit = 50
+)
+
+// 模拟订单
+type order struct {
+	category      string
+	symbol        string
+	baseCoin      string
+	quoteCoin     string
+	settleCoin    string
+	orderId       string
+	orderLinkId   string
+	side          string
+	orderType     string
+	timeInForce   string
+	stopOrderType string
+	status        string
+	rejectReason  string
+	price         float64
+	qty           float64
+	quoteQty      bool // 现货市价买单的数量以报价币种计
+	reduceOnly    bool
+	takeProfit    float64
+	stopLoss      float64
+	cumExecQty    float64
+	cumExecValue  float64
+	cumExecFee    float64
+	createdTime   int64
+	updatedTime   int64
+
+	closed closeSummary // 本次撮合中平仓部分的汇总，用于生成平仓盈亏记录
+}
+
+// 平仓汇总
+type closeSummary struct {
+	size       float64
+	entryValue float64
+	exitValue  float64
+	pnl        float64
+	fills      int
+	execType   string
+}
+
+// 订单是否仍在挂单中
+func (o *order) open() bool {
+	return o.status == statusNew || o.status == statusPartiallyFilled
+}
+
+// 买单为1，卖单为-1
+func (o *order) dir() float64 {
+	if o.side == "Buy" {
+		return 1
+	}
+	return -1
+}
+
+// 未成交数量，报价币种计数的市价买单返回未成交金额
+func (o *order) remaining() float64 {
+	if o.quoteQty {
+		return o.qty - o.cumExecValue
+	}
+	return o.qty - o.cumExecQty
+}
+
+// 价格是否满足限价
+func (o *order) acceptable(price float64) bool {
+	if o.orderType != "Limit" {
+		return true
+	}
+	if o.side == "Buy" {
+		return price <= o.price+epsilon
+	}
+	return price >= o.price-epsilon
+}
+
+// 根据成交情况确定订单状态，rest表示未成交部分是否继续挂单
+func (o *order) finish(rest bool) {
+	switch {
+	case o.remaining() <= epsilon:
+		o.status = statusFilled
+	case rest && o.cumExecQty > 0:
+		o.status = statusPartiallyFilled
+	case rest:
+		o.status = statusNew
+	case o.cumExecQty > 0:
+		o.status = statusPartiallyFilledCanceled
+	default:
+		o.status = statusCancelled
+	}
+}
+
+// Bybit格式的订单
+type orderView struct {
+	OrderId       string `json:"orderId"`
+	OrderLinkId   string `json:"orderLinkId"`
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	OrderType     string `json:"orderType"`
+	Price         string `json:"price"`
+	Qty           string `json:"qty"`
+	AvgPrice      string `json:"avgPrice"`
+	LeavesQty     string `json:"leavesQty"`
+	CumExecQty    string `json:"cumExecQty"`
+	CumExecValue  string `json:"cumExecValue"`
+	CumExecFee    string `json:"cumExecFee"`
+	TimeInForce   string `json:"timeInForce"`
+	OrderStatus   string `json:"orderStatus"`
+	StopOrderType string `json:"stopOrderType"`
+	RejectReason  string `json:"rejectReason"`
+	ReduceOnly    bool   `json:"reduceOnly"`
+	PositionIdx   int    `json:"positionIdx"`
+	TakeProfit    string `json:"takeProfit"`
+	StopLoss      string `json:"stopLoss"`
+	CreatedTime   string `json:"createdTime"`
+	UpdatedTime   string `json:"updatedTime"`
+}
+
+// 转换为Bybit格式
+func (o *order) view() orderView {
+	avgPrice := 0.0
+	if o.cumExecQty > 0 {
+		avgPrice = o.cumExecValue / o.cumExecQty
+	}
+	leaves := 0.0
+	if o.open() {
+		leaves = o.remaining()
+	}
+	return orderView{
+		OrderId:       o.orderId,
+		OrderLinkId:   o.orderLinkId,
+		Symbol:        o.symbol,
+		Side:          o.side,
+		OrderType:     o.orderType,
+		Price:         format(o.price),
+		Qty:           format(o.qty),
+		AvgPrice:      format(avgPrice),
+		LeavesQty:     format(leaves),
+		CumExecQty:    format(o.cumExecQty),
+		CumExecValue:  format(o.cumExecValue),
+		CumExecFee:    format(o.cumExecFee),
+		TimeInForce:   o.timeInForce,
+		OrderStatus:   o.status,
+		StopOrderType: o.stopOrderType,
+		RejectReason:  o.rejectReason,
+		ReduceOnly:    o.reduceOnly,
+		TakeProfit:    format(o.takeProfit),
+		StopLoss:      format(o.stopLoss),
+		CreatedTime:   strconv.FormatInt(o.createdTime, 10),
+		UpdatedTime:   strconv.FormatInt(o.updatedTime, 10),
+	}
+}
+
+// 转换为订单模型
+func (o *order) model() model.Order {
+	return model.Order{
+		OrderId:      o.orderId,
+		OrderLinkId:  o.orderLinkId,
+		Symbol:       o.symbol,
+		Side:         o.side,
+		OrderType:    o.orderType,
+		Price:        format(o.price),
+		Qty:          format(o.qty),
+		TimeInForce:  o.timeInForce,
+		OrderStatus:  o.status,
+		CumExecQty:   format(o.cumExecQty),
+		CumExecValue: format(o.cumExecValue),
+		CumExecFee:   format(o.cumExecFee),
+		CreatedTime:  strconv.FormatInt(o.createdTime, 10),
+		UpdatedTime:  strconv.FormatInt(o.updatedTime, 10),
+	}
+}
+
+// CreateOrder 创建模拟订单，吃单部分按订单簿逐档成交，限价单未成交部分挂单等待行情触及
+func (s *Service) CreateOrder(ctx context.Context, category, symbol, side, orderType string, qty float64, price float64, options map[string]string) (*model.Response, error) {
+	s.logger.Debug("模拟下单: category=%s, symbol=%s, side=%s, orderType=%s, qty=%f, price=%f", category, symbol, side, orderType, qty, price)
+
+	if category != bybitapi.CategorySpot && category != bybitapi.CategoryLinear {
+		return s.reject(retParamsError, "模拟交易只支持spot和linear"), nil
+	}
+	if side != "Buy" && side != "Sell" {
+		return s.reject(retParamsError, "side只能是Buy或Sell"), nil
+	}
+	if orderType != "Market" && orderType != "Limit" {
+		return s.reject(retParamsError, "orderType只能是Market或Limit"), nil
+	}
+	if qty <= 0 {
+		return s.reject(retParamsError, "qty必须大于0"), nil
+	}
+	if orderType == "Limit" && price <= 0 {
+		return s.reject(retParamsError, "限价单必须指定price"), nil
+	}
+
+	inst, err := s.instrument(ctx, category, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil {
+		return s.reject(retParamsError, "交易对不存在: %s", symbol), nil
+	}
+	q, err := s.fetchQuote(ctx, category, symbol)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.fetchBook(ctx, category, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.nowMs()
+	o := &order{
+		category:    category,
+		symbol:      symbol,
+		baseCoin:    inst.baseCoin,
+		quoteCoin:   inst.quoteCoin,
+		settleCoin:  inst.settleCoin,
+		orderId:     uuid.NewString(),
+		orderLinkId: options["orderLinkId"],
+		side:        side,
+		orderType:   orderType,
+		timeInForce: options["timeInForce"],
+		reduceOnly:  options["reduceOnly"] == "true",
+		takeProfit:  num(options["takeProfit"]),
+		stopLoss:    num(options["stopLoss"]),
+		createdTime: now,
+		updatedTime: now,
+	}
+	if o.timeInForce == "" {
+		o.timeInForce = "GTC"
+		if orderType == "Market" {
+			o.timeInForce = "IOC"
+		}
+	}
+
+	// 现货市价买单默认按报价币种金额下单
+	if category == bybitapi.CategorySpot && orderType == "Market" && side == "Buy" && options["marketUnit"] != "baseCoin" {
+		o.quoteQty = true
+		o.qty = qty
+	} else {
+		o.qty = floorStep(qty, inst.qtyStep)
+		if o.qty < inst.minOrderQty || o.qty <= 0 {
+			return s.reject(retParamsError, "下单数量小于最小数量%s", format(inst.minOrderQty)), nil
+		}
+	}
+	if orderType == "Limit" {
+		o.price = roundStep(price, inst.tickSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applyQuote(category+"/"+symbol, q)
+
+	if o.orderLinkId != "" {
+		for _, existing := range s.orders {
+			if existing.orderLinkId == o.orderLinkId {
+				return s.reject(retDuplicateLinkId, "orderLinkId重复: %s", o.orderLinkId), nil
+			}
+		}
+	}
+	if resp := s.checkOrder(o, q); resp != nil {
+		return resp, nil
+	}
+
+	s.orders = append(s.orders, o)
+	s.ordersById[o.orderId] = o
+	s.execute(o, inst, b, q)
+
+	return s.ok(map[string]string{"orderId": o.orderId, "orderLinkId": o.orderLinkId}), nil
+}
+
+// 检查只减仓限制和可用余额，不满足时返回错误响应
+func (s *Service) checkOrder(o *order, q *quote) *model.Response {
+	ref := o.price
+	if o.orderType == "Market" {
+		ref = q.ask
+		if o.side == "Sell" {
+			ref = q.bid
+		}
+		if ref == 0 {
+			ref = q.last
+		}
+	}
+
+	if o.category == bybitapi.CategorySpot {
+		if o.side == "Sell" {
+			if s.available(o.baseCoin) < o.qty-epsilon {
+				return s.reject(retSpotInsufficient, "%s余额不足", o.baseCoin)
+			}
+			return nil
+		}
+		cost := o.qty * ref
+		if o.quoteQty {
+			cost = o.qty
+		}
+		if s.available(o.quoteCoin) < cost*(1+s.cfg.TakerFeeRate)-epsilon {
+			return s.reject(retSpotInsufficient, "%s余额不足", o.quoteCoin)
+		}
+		return nil
+	}
+
+	// 单向持仓模式下，反向订单先平仓，超出仓位的部分才需要保证金
+	closable := 0.0
+	if pos := s.positions[o.symbol]; pos != nil && pos.size*o.dir() < 0 {
+		closable = math.Abs(pos.size)
+	}
+	if o.reduceOnly {
+		if closable == 0 {
+			return s.reject(retReduceOnly, "当前没有可以减少的仓位，只减仓订单无法下单")
+		}
+		o.qty = math.Min(o.qty, closable)
+		return nil
+	}
+	opening := math.Max(0, o.qty-closable)
+	need := opening*ref/s.lev(o.symbol) + o.qty*ref*s.cfg.TakerFeeRate
+	if s.available(o.settleCoin) < need-epsilon {
+		return s.reject(retInsufficientBalance, "可用余额不足，需要%s %s", format(need), o.settleCoin)
+	}
+	return nil
+}
+
+// 按订单簿撮合新订单，并根据有效期决定未成交部分是否挂单
+func (s *Service) execute(o *order, inst *instrument, b *book, q *quote) {
+	levels := b.asks
+	if o.side == "Sell" {
+		levels = b.bids
+	}
+	// 订单簿为空时按最优报价成交
+	if len(levels) == 0 {
+		best := q.ask
+		if o.side == "Sell" {
+			best = q.bid
+		}
+		if best == 0 {
+			best = q.last
+		}
+		if best > 0 {
+			levels = []level{{price: best, qty: math.Inf(1)}}
+		}
+	}
+
+	rest := o.orderType == "Limit" && (o.timeInForce == "GTC" || o.timeInForce == "PostOnly")
+	crosses := len(levels) > 0 && o.acceptable(levels[0].price)
+
+	switch {
+	case o.timeInForce == "PostOnly" && crosses:
+		o.rejectReason = "EC_PostOnlyWillTakeLiquidity"
+		rest = false
+	case o.timeInForce == "FOK" && !s.fillable(o, levels):
+		o.rejectReason = "EC_FOKOrderCannotBeFullyFilled"
+	case o.timeInForce != "PostOnly":
+		s.take(o, inst, levels)
+	}
+
+	o.finish(rest)
+	o.updatedTime = s.nowMs()
+	s.flushClosed(o)
+}
+
+// 深度是否足以让订单全部成交
+func (s *Service) fillable(o *order, levels []level) bool {
+	total := 0.0
+	for _, lv := range levels {
+		if !o.acceptable(lv.price) {
+			break
+		}
+		total += lv.qty
+	}
+	return total >= o.qty-epsilon
+}
+
+// 吃单成交，按档位价格加上滑点
+func (s *Service) take(o *order, inst *instrument, levels []level) {
+	slippage := s.cfg.SlippageBps / 10000
+	for _, lv := range levels {
+		if o.remaining() <= epsilon || !o.acceptable(lv.price) {
+			break
+		}
+
+		price := lv.price * (1 + o.dir()*slippage)
+		if o.orderType == "Limit" {
+			// 滑点不会让成交价差于限价
+			if o.side == "Buy" {
+				price = math.Min(price, o.price)
+			} else {
+				price = math.Max(price, o.price)
+			}
+		}
+
+		qty := math.Min(lv.qty, o.remaining())
+		if o.quoteQty {
+			qty = floorStep(math.Min(lv.qty, o.remaining()/price), inst.qtyStep)
+		}
+		if qty <= epsilon {
+			break
+		}
+		s.fill(o, price, qty, false, model.ExecTypeTrade)
+	}
+}
+
+// 挂单在行情触及限价时按限价全部成交
+func (s *Service) matchResting() {
+	for _, o := range s.orders {
+		if !o.open() || o.orderType != "Limit" {
+			continue
+		}
+		q := s.quotes[o.category+"/"+o.symbol]
+		if q == nil {
+			continue
+		}
+		touched := (o.side == "Buy" && q.ask > 0 && q.ask <= o.price) || (o.side == "Sell" && q.bid > 0 && q.bid >= o.price)
+		if !touched {
+			continue
+		}
+
+		qty := o.remaining()
+		if o.reduceOnly {
+			pos := s.positions[o.symbol]
+			if pos == nil || pos.size*o.dir() >= 0 {
+				o.status = statusCancelled
+				o.updatedTime = s.nowMs()
+				continue
+			}
+			qty = math.Min(qty, math.Abs(pos.size))
+		}
+		s.fill(o, o.price, qty, true, model.ExecTypeTrade)
+		o.finish(true)
+		// 仓位小于订单数量时，只减仓订单的剩余部分撤销
+		if o.reduceOnly && o.open() {
+			o.status = statusCancelled
+		}
+		o.updatedTime = s.nowMs()
+		s.flushClosed(o)
+	}
+}
+
+// 记录一笔成交并更新余额和仓位
+func (s *Service) fill(o *order, price, qty float64, maker bool, execType string) {
+	rate := s.cfg.TakerFeeRate
+	if maker {
+		rate = s.cfg.MakerFeeRate
+	}
+	value := price * qty
+	fee := value * rate
+
+	o.cumExecQty += qty
+	o.cumExecValue += value
+	o.cumExecFee += fee
+
+	closedSize := 0.0
+	feeCurrency := o.settleCoin
+	if o.category == bybitapi.CategorySpot {
+		feeCurrency = o.quoteCoin
+		s.applySpotFill(o, price, qty, fee)
+	} else {
+		closedSize = s.applyLinearFill(o, price, qty, fee, execType)
+	}
+
+	mark := price
+	if q := s.quotes[o.category+"/"+o.symbol]; q != nil && q.mark > 0 {
+		mark = q.mark
+	}
+	s.executions = append(s.executions, model.Execution{
+		Category:    o.category,
+		Symbol:      o.symbol,
+		OrderId:     o.orderId,
+		OrderLinkId: o.orderLinkId,
+		Side:        o.side,
+		OrderType:   o.orderType,
+		OrderPrice:  o.price,
+		OrderQty:    o.qty,
+		ExecId:      uuid.NewString(),
+		ExecType:    execType,
+		ExecPrice:   price,
+		ExecQty:     qty,
+		ExecValue:   value,
+		ExecFee:     fee,
+		FeeRate:     rate,
+		FeeCurrency: feeCurrency,
+		IsMaker:     maker,
+		ClosedSize:  closedSize,
+		MarkPrice:   mark,
+		ExecTime:    s.nowMs(),
+	})
+}
+
+// 现货成交，手续费以报价币种收取
+func (s *Service) applySpotFill(o *order, price, qty, fee float64) {
+	if o.side == "Buy" {
+		s.balances[o.quoteCoin] -= price*qty + fee
+		s.balances[o.baseCoin] += qty
+		return
+	}
+	s.balances[o.baseCoin] -= qty
+	s.balances[o.quoteCoin] += price*qty - fee
+}
+
+// 生成本次撮合的平仓盈亏记录
+func (s *Service) flushClosed(o *order) {
+	c := o.closed
+	if c.size <= 0 {
+		return
+	}
+	s.closedPnl = append(s.closedPnl, model.ClosedPnl{
+		Category:      o.category,
+		Symbol:        o.symbol,
+		OrderId:       o.orderId,
+		Side:          o.side,
+		OrderType:     o.orderType,
+		ExecType:      c.execType,
+		Qty:           o.qty,
+		OrderPrice:    o.price,
+		ClosedSize:    c.size,
+		CumEntryValue: c.entryValue,
+		AvgEntryPrice: c.entryValue / c.size,
+		CumExitValue:  c.exitValue,
+		AvgExitPrice:  c.exitValue / c.size,
+		ClosedPnl:     c.pnl,
+		FillCount:     c.fills,
+		Leverage:      s.lev(o.symbol),
+		SettleCoin:    o.settleCoin,
+		CreatedTime:   o.createdTime,
+		UpdatedTime:   s.nowMs(),
+	})
+	o.closed = closeSummary{}
+}
+
+// 按订单ID或自定义订单ID查找订单
+func (s *Service) findOrder(category, symbol, orderId, orderLinkId string) *order {
+	if orderId != "" {
+		if o := s.ordersById[orderId]; o != nil && o.category == category && (symbol == "" || o.symbol == symbol) {
+			return o
+		}
+		return nil
+	}
+	if orderLinkId == "" {
+		return nil
+	}
+	for _, o := range s.orders {
+		if o.orderLinkId == orderLinkId && o.category == category && (symbol == "" || o.symbol == symbol) {
+			return o
+		}
+	}
+	return nil
+}
+
+// CancelOrder 撤销挂单
+func (s *Service) CancelOrder(ctx context.Context, category, symbol, orderId, orderLinkId string) (*model.Response, error) {
+	s.logger.Debug("模拟撤单: category=%s, symbol=%s, orderId=%s", category, symbol, orderId)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findOrder(category, symbol, orderId, orderLinkId)
+	if o == nil || !o.open() {
+		return s.reject(retOrderNotExists, "订单不存在或已经完成"), nil
+	}
+	o.status = statusCancelled
+	o.updatedTime = s.nowMs()
+	return s.ok(map[string]string{"orderId": o.orderId, "orderLinkId": o.orderLinkId}), nil
+}
+
+// CancelAllOrders 撤销全部挂单，symbol和settleCoin都为空时撤销该类别下全部挂单
+func (s *Service) CancelAllOrders(ctx context.Context, category, symbol, settleCoin string) (*model.Response, error) {
+	s.logger.Debug("模拟撤销全部订单: category=%s, symbol=%s, settleCoin=%s", category, symbol, settleCoin)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []map[string]string{}
+	for _, o := range s.orders {
+		if !o.open() || o.category != category || (symbol != "" && o.symbol != symbol) || (settleCoin != "" && o.settleCoin != settleCoin) {
+			continue
+		}
+		o.status = statusCancelled
+		o.updatedTime = s.nowMs()
+		list = append(list, map[string]string{"orderId": o.orderId, "orderLinkId": o.orderLinkId})
+	}
+	return s.ok(map[string]interface{}{"list": list, "success": "1"}), nil
+}
+
+// AmendOrder 修改挂单的数量或价格，修改后的订单在下次刷新行情时重新检查是否成交
+func (s *Service) AmendOrder(ctx context.Context, category, symbol, orderId, orderLinkId string, qty float64, price float64, options map[string]string) (*model.Response, error) {
+	s.logger.Debug("模拟改单: category=%s, symbol=%s, orderId=%s", category, symbol, orderId)
+
+	inst, err := s.instrument(ctx, category, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil {
+		return s.reject(retParamsError, "交易对不存在: %s", symbol), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findOrder(category, symbol, orderId, orderLinkId)
+	if o == nil || !o.open() {
+		return s.reject(retOrderNotExists, "订单不存在或已经完成"), nil
+	}
+	if qty > 0 {
+		qty = floorStep(qty, inst.qtyStep)
+		if qty <= o.cumExecQty+epsilon || qty < inst.minOrderQty {
+			return s.reject(retParamsError, "修改后的数量必须大于已成交数量且不小于最小数量"), nil
+		}
+		o.qty = qty
+	}
+	if price > 0 {
+		o.price = roundStep(price, inst.tickSize)
+	}
+	if v, ok := options["takeProfit"]; ok {
+		o.takeProfit = num(v)
+	}
+	if v, ok := options["stopLoss"]; ok {
+		o.stopLoss = num(v)
+	}
+	o.updatedTime = s.nowMs()
+	return s.ok(map[string]string{"orderId": o.orderId, "orderLinkId": o.orderLinkId}), nil
+}
+
+// 订单筛选条件
+type orderFilter struct {
+	category    string
+	symbol      string
+	baseCoin    string
+	settleCoin  string
+	orderId     string
+	orderLinkId string
+	status      string
+	startTime   int64
+	endTime     int64
+	open        *bool // 只返回挂单或只返回已完成的订单，nil表示全部
+}
+
+// 按创建时间倒序筛选订单，limit为0表示不限制
+func (s *Service) filterOrders(f orderFilter, limit int) []*order {
+	result := []*order{}
+	for i := len(s.orders) - 1; i >= 0; i-- {
+		o := s.orders[i]
+		switch {
+		case f.category != "" && o.category != f.category,
+			f.symbol != "" && o.symbol != f.symbol,
+			f.baseCoin != "" && o.baseCoin != f.baseCoin,
+			f.settleCoin != "" && o.settleCoin != f.settleCoin,
+			f.orderId != "" && o.orderId != f.orderId,
+			f.orderLinkId != "" && o.orderLinkId != f.orderLinkId,
+			f.status != "" && o.status != f.status,
+			f.startTime > 0 && o.createdTime < f.startTime,
+			f.endTime > 0 && o.createdTime > f.endTime,
+			f.open != nil && o.open() != *f.open:
+			continue
+		}
+		result = append(result, o)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// 订单列表响应
+func (s *Service) orderList(category string, orders []*order) *model.Response {
+	views := make([]orderView, len(orders))
+	for i, o := range orders {
+		views[i] = o.view()
+	}
+	return s.ok(map[string]interface{}{"category": category, "list": views, "nextPageCursor": ""})
+}
+
+// 订单模型列表
+func orderModels(category string, orders []*order) *model.OrderList {
+	list := &model.OrderList{Category: category, List: make([]model.Order, len(orders))}
+	for i, o := range orders {
+		list.List[i] = o.model()
+	}
+	return list
+}
+
+// 查询条件转换为筛选条件
+func queryFilter(query *model.OrderQuery, open bool) orderFilter {
+	return orderFilter{
+		category:    query.Category,
+		symbol:      query.Symbol,
+		baseCoin:    query.BaseCoin,
+		settleCoin:  query.SettleCoin,
+		orderId:     query.OrderId,
+		orderLinkId: query.OrderLinkId,
+		status:      query.OrderStatus,
+		startTime:   query.StartTime,
+		endTime:     query.EndTime,
+		open:        &open,
+	}
+}
+
+// 单页数量，未指定时使用默认值
+func pageLimit(limit, fallback int) int {
+	if limit <= 0 {
+		return fallback
+	}
+	return limit
+}
+
+// GetOrders 查询订单，包括挂单和已完成的订单
+func (s *Service) GetOrders(ctx context.Context, category, symbol, orderId, orderLinkId, orderStatus string, limit int) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := orderFilter{category: category, symbol: symbol, orderId: orderId, orderLinkId: orderLinkId, status: orderStatus}
+	return s.orderList(category, s.filterOrders(f, pageLimit(limit, defaultOrderLimit))), nil
+}
+
+// GetOpenOrders 查询挂单（单页）
+func (s *Service) GetOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orderList(query.Category, s.filterOrders(queryFilter(query, true), pageLimit(query.Limit, defaultOrderLimit))), nil
+}
+
+// GetOrderHistory 查询已完成的订单（单页）
+func (s *Service) GetOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.orderList(query.Category, s.filterOrders(queryFilter(query, false), pageLimit(query.Limit, defaultOrderLimit))), nil
+}
+
+// GetAllOpenOrders 查询全部挂单
+func (s *Service) GetAllOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return orderModels(query.Category, s.filterOrders(queryFilter(query, true), 0)), nil
+}
+
+// GetAllOrderHistory 查询全部已完成的订单
+func (s *Service) GetAllOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return orderModels(query.Category, s.filterOrders(queryFilter(query, false), 0)), nil
+}
+
+// 按时间倒序筛选成交记录
+func (s *Service) filterExecutions(query *model.ExecutionQuery, limit int) []model.Execution {
+	result := []model.Execution{}
+	for i := len(s.executions) - 1; i >= 0; i-- {
+		e := s.executions[i]
+		switch {
+		case query.Category != "" && e.Category != query.Category,
+			query.Symbol != "" && e.Symbol != query.Symbol,
+			query.OrderId != "" && e.OrderId != query.OrderId,
+			query.OrderLinkId != "" && e.OrderLinkId != query.OrderLinkId,
+			query.ExecType != "" && e.ExecType != query.ExecType,
+			query.StartTime > 0 && e.ExecTime < query.StartTime,
+			query.EndTime > 0 && e.ExecTime > query.EndTime:
+			continue
+		}
+		result = append(result, e)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// 按时间倒序筛选平仓盈亏记录
+func (s *Service) filterClosedPnl(query *model.ClosedPnlQuery, limit int) []model.ClosedPnl {
+	result := []model.ClosedPnl{}
+	for i := len(s.closedPnl) - 1; i >= 0; i-- {
+		r := s.closedPnl[i]
+		switch {
+		case query.Category != "" && r.Category != query.Category,
+			query.Symbol != "" && r.Symbol != query.Symbol,
+			query.StartTime > 0 && r.UpdatedTime < query.StartTime,
+			query.EndTime > 0 && r.UpdatedTime > query.EndTime:
+			continue
+		}
+		result = append(result, r)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+// Package paper 提供模拟交易实现
+// 行情来自实时或录制的数据，订单、仓位和余额都只在本地模拟，不会发送到交易所
+package paper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 默认的模拟交易参数
+const (
+	DefaultInitialBalance        = 10000           // 初始USDT余额
+	DefaultLeverage              = 10              // 未设置杠杆的交易对使用的杠杆
+	DefaultMaintenanceMarginRate = 0.005           // 维持保证金率
+	DefaultUpdateInterval        = 5 * time.Second // 行情刷新间隔
+)
+
+// 与Bybit一致的错误码
+const (
+	retParamsError         = 10001
+	retOrderNotExists      = 110001
+	retInsufficientBalance = 110007
+	retReduceOnly          = 110017
+	retDuplicateLinkId     = 110072
+	retLeverageNotModified = 110043
+	retSpotInsufficient    = 170131
+)
+
+// 按美元计价的稳定币
+var stableCoins = map[string]bool{"USDT": true, "USDC": true}
+
+// Service 是模拟交易的BybitService实现
+// 行情查询直接转发给market，交易、仓位、账户接口在本地模拟
+type Service struct {
+	market MarketData
+	cfg    config.PaperConfig
+	logger *logger.Logger
+	now    func() time.Time
+
+	cacheMu     sync.Mutex
+	instruments map[string]*instrument
+
+	mu         sync.Mutex
+	balances   map[string]float64   // 钱包余额
+	quotes     map[string]*quote    // 最新行情，键为category/symbol
+	prices     map[string]float64   // 币种的美元价格
+	orders     []*order             // 全部订单，按创建顺序
+	ordersById map[string]*order    // 按订单ID索引
+	positions  map[string]*position // 线性合约仓位，键为交易对
+	leverage   map[string]float64   // 交易对的杠杆
+	executions []model.Execution
+	closedPnl  []model.ClosedPnl
+}
+
+var _ service.BybitService = (*Service)(nil)
+
+// New 创建模拟交易服务，market提供行情
+func New(market MarketData, cfg config.PaperConfig, log *logger.Logger) *Service {
+	if cfg.DefaultLeverage <= 0 {
+		cfg.DefaultLeverage = DefaultLeverage
+	}
+	if cfg.MaintenanceMarginRate <= 0 {
+		cfg.MaintenanceMarginRate = DefaultMaintenanceMarginRate
+	}
+
+	balances := map[string]float64{}
+	for coin, amount := range cfg.InitialBalance {
+		balances[coin] = amount
+	}
+	if len(balances) == 0 {
+		balances["USDT"] = DefaultInitialBalance
+	}
+
+	return &Service{
+		market:      market,
+		cfg:         cfg,
+		logger:      log,
+		now:         time.Now,
+		instruments: map[string]*instrument{},
+		balances:    balances,
+		quotes:      map[string]*quote{},
+		prices:      map[string]float64{},
+		ordersById:  map[string]*order{},
+		positions:   map[string]*position{},
+		leverage:    map[string]float64{},
+	}
+}
+
+// UpdateInterval 返回配置的行情刷新间隔
+func (s *Service) UpdateInterval() time.Duration {
+	if s.cfg.UpdateInterval > 0 {
+		return time.Duration(s.cfg.UpdateInterval) * time.Second
+	}
+	return DefaultUpdateInterval
+}
+
+// SetBalance 设置币种的钱包余额，用于准备测试和回测的初始资金
+func (s *Service) SetBalance(coin string, amount float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[coin] = amount
+}
+
+// SetClock 替换模拟账户使用的时钟，回测时按历史行情的时间推进
+func (s *Service) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Run 按间隔刷新行情，撮合挂单、触发止盈止损、收取资金费用和检查强平，直到ctx结束
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				s.logger.Warn("刷新模拟交易行情失败: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh 获取挂单、仓位和持有币种的最新行情并更新模拟账户
+func (s *Service) Refresh(ctx context.Context) error {
+	// 收集需要行情的交易对，不持有锁请求行情
+	s.mu.Lock()
+	keys := map[string]bool{}
+	for _, o := range s.orders {
+		if o.open() {
+			keys[o.category+"/"+o.symbol] = true
+		}
+	}
+	for symbol, pos := range s.positions {
+		if pos.size != 0 {
+			keys[bybitapi.CategoryLinear+"/"+symbol] = true
+		}
+	}
+	for coin, amount := range s.balances {
+		if amount != 0 && !stableCoins[coin] {
+			keys[bybitapi.CategorySpot+"/"+coin+"USDT"] = true
+		}
+	}
+	s.mu.Unlock()
+
+	quotes := map[string]*quote{}
+	var firstErr error
+	for key := range keys {
+		category, symbol := splitKey(key)
+		q, err := s.fetchQuote(ctx, category, symbol)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %v", symbol, err)
+			}
+			continue
+		}
+		quotes[key] = q
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, q := range quotes {
+		s.applyQuote(key, q)
+	}
+	s.matchResting()
+	// 价格跳空越过强平价时先强平，止损不会在强平后再成交
+	s.checkLiquidation()
+	s.triggerStops()
+	s.settleFunding()
+	return firstErr
+}
+
+// 记录最新行情，现货USDT交易对的价格同时作为币种的美元价格
+func (s *Service) applyQuote(key string, q *quote) {
+	category, symbol := splitKey(key)
+	if category == bybitapi.CategorySpot && strings.HasSuffix(symbol, "USDT") {
+		s.prices[strings.TrimSuffix(symbol, "USDT")] = q.last
+	}
+	if category == bybitapi.CategoryLinear {
+		if pos, ok := s.positions[symbol]; ok && s.nowMs() < pos.nextFunding {
+			// 资金费用按结算前最后一次看到的费率收取
+			pos.fundingRate = q.fundingRate
+		}
+	}
+	s.quotes[key] = q
+}
+
+// 当前时间（毫秒）
+func (s *Service) nowMs() int64 {
+	return s.now().UnixMilli()
+}
+
+// 构造成功响应
+func (s *Service) ok(result interface{}) *model.Response {
+	return &model.Response{
+		RetCode:    0,
+		RetMsg:     "OK",
+		Result:     result,
+		RetExtInfo: map[string]interface{}{},
+		Time:       s.nowMs(),
+	}
+}
+
+// 构造与Bybit一致的错误响应
+func (s *Service) reject(code int, format string, args ...interface{}) *model.Response {
+	return &model.Response{
+		RetCode:    code,
+		RetMsg:     fmt.Sprintf(format, args...),
+		Result:     map[string]interface{}{},
+		RetExtInfo: map[string]interface{}{},
+		Time:       s.nowMs(),
+	}
+}
+
+// 拆分category/symbol键
+func splitKey(key string) (string, string) {
+	category, symbol, _ := strings.Cut(key, "/")
+	return category, symbol
+}
+
+// 格式化数量和价格，保留8位小数
+func format(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e8)/1e8, 'f', -1, 64)
+}
+
+// 按步长向下取整
+func floorStep(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	return math.Floor(v/step+1e-9) * step
+}
+
+// 按步长四舍五入
+func roundStep(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	return math.Round(v/step) * step
+}
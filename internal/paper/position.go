@@ -0,0 +1,566 @@
+package paper
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/google/uuid"
+)
+
+// 线性合约仓位，单向持仓模式，全仓保证金
+type position struct {
+	symbol      string
+	settleCoin  string
+	size        float64 // 正数为多仓，负数为空仓
+	entry       float64 // 开仓均价
+	openFee     float64 // 尚未计入平仓盈亏的开仓手续费
+	cumRealised float64 // 累计已实现盈亏，包括手续费和资金费用
+	takeProfit  float64
+	stopLoss    float64
+	fundingRate float64 // 下次结算使用的资金费率
+	nextFunding int64   // 下次资金费用结算时间（毫秒），0表示尚未获取
+	createdTime int64
+	updatedTime int64
+}
+
+// 交易对的杠杆，未设置时使用默认杠杆
+func (s *Service) lev(symbol string) float64 {
+	if lev, ok := s.leverage[symbol]; ok {
+		return lev
+	}
+	return s.cfg.DefaultLeverage
+}
+
+// 仓位的标记价格，没有行情时使用开仓均价
+func (s *Service) mark(pos *position) float64 {
+	if q := s.quotes[bybitapi.CategoryLinear+"/"+pos.symbol]; q != nil && q.mark > 0 {
+		return q.mark
+	}
+	return pos.entry
+}
+
+// 线性合约成交：先平反向仓位，剩余部分开仓，返回平仓数量
+func (s *Service) applyLinearFill(o *order, price, qty, fee float64, execType string) float64 {
+	now := s.nowMs()
+	pos := s.positions[o.symbol]
+	if pos == nil {
+		pos = &position{symbol: o.symbol, settleCoin: o.settleCoin, createdTime: now}
+		s.positions[o.symbol] = pos
+	}
+	s.balances[o.settleCoin] -= fee
+	pos.cumRealised -= fee
+	pos.updatedTime = now
+
+	closing := 0.0
+	if pos.size*o.dir() < 0 {
+		closing = math.Min(qty, math.Abs(pos.size))
+	}
+	if closing > 0 {
+		side := math.Copysign(1, pos.size)
+		pnl := closing * (price - pos.entry) * side
+		openFee := pos.openFee * closing / math.Abs(pos.size)
+		closeFee := fee * closing / qty
+
+		pos.openFee -= openFee
+		pos.size -= side * closing
+		pos.cumRealised += pnl
+		s.balances[o.settleCoin] += pnl
+
+		o.closed.size += closing
+		o.closed.entryValue += closing * pos.entry
+		o.closed.exitValue += closing * price
+		o.closed.pnl += pnl - openFee - closeFee
+		o.closed.fills++
+		o.closed.execType = execType
+
+		if math.Abs(pos.size) <= epsilon {
+			pos.size, pos.entry, pos.openFee = 0, 0, 0
+			pos.takeProfit, pos.stopLoss, pos.nextFunding = 0, 0, 0
+		}
+	}
+
+	opening := qty - closing
+	if opening > epsilon {
+		size := math.Abs(pos.size)
+		pos.entry = (size*pos.entry + opening*price) / (size + opening)
+		pos.size += o.dir() * opening
+		pos.openFee += fee * opening / qty
+		if o.takeProfit > 0 {
+			pos.takeProfit = o.takeProfit
+		}
+		if o.stopLoss > 0 {
+			pos.stopLoss = o.stopLoss
+		}
+	}
+	return closing
+}
+
+// 结算币种下全部仓位的未实现盈亏
+func (s *Service) unrealised(coin string) float64 {
+	total := 0.0
+	for _, pos := range s.positions {
+		if pos.settleCoin == coin && pos.size != 0 {
+			total += pos.size * (s.mark(pos) - pos.entry)
+		}
+	}
+	return total
+}
+
+// 仓位初始保证金
+func (s *Service) positionIM(pos *position) float64 {
+	return math.Abs(pos.size) * pos.entry / s.lev(pos.symbol)
+}
+
+// 仓位维持保证金
+func (s *Service) positionMM(pos *position) float64 {
+	return math.Abs(pos.size) * s.mark(pos) * s.cfg.MaintenanceMarginRate
+}
+
+// 结算币种下全部仓位的初始保证金和维持保证金
+func (s *Service) margins(coin string) (im, mm float64) {
+	for _, pos := range s.positions {
+		if pos.settleCoin == coin && pos.size != 0 {
+			im += s.positionIM(pos)
+			mm += s.positionMM(pos)
+		}
+	}
+	return im, mm
+}
+
+// 挂单占用：合约挂单的初始保证金，现货挂单冻结的余额
+func (s *Service) reserved(coin string) (margin, locked float64) {
+	for _, o := range s.orders {
+		if !o.open() {
+			continue
+		}
+		leaves := o.remaining()
+		switch {
+		case o.category == bybitapi.CategoryLinear && o.settleCoin == coin && !o.reduceOnly:
+			margin += leaves * o.price / s.lev(o.symbol)
+		case o.category == bybitapi.CategorySpot && o.side == "Buy" && o.quoteCoin == coin:
+			locked += leaves * o.price
+		case o.category == bybitapi.CategorySpot && o.side == "Sell" && o.baseCoin == coin:
+			locked += leaves
+		}
+	}
+	return margin, locked
+}
+
+// 币种的可用余额 = 钱包余额 + 未实现盈亏 - 仓位保证金 - 挂单占用
+func (s *Service) available(coin string) float64 {
+	im, _ := s.margins(coin)
+	margin, locked := s.reserved(coin)
+	return s.balances[coin] + s.unrealised(coin) - im - margin - locked
+}
+
+// 全仓模式下的预估强平价：其他仓位按当前标记价格不变，该仓位权益降到维持保证金时的价格
+func (s *Service) liqPrice(pos *position) float64 {
+	if pos.size == 0 {
+		return 0
+	}
+	others, othersMM := 0.0, 0.0
+	for _, other := range s.positions {
+		if other != pos && other.settleCoin == pos.settleCoin && other.size != 0 {
+			others += other.size * (s.mark(other) - other.entry)
+			othersMM += s.positionMM(other)
+		}
+	}
+	wallet := s.balances[pos.settleCoin]
+	size := math.Abs(pos.size)
+	rate := s.cfg.MaintenanceMarginRate
+
+	var price float64
+	if pos.size > 0 {
+		price = (othersMM - wallet - others + size*pos.entry) / (size * (1 - rate))
+	} else {
+		price = (wallet + others + size*pos.entry - othersMM) / (size * (1 + rate))
+	}
+	return math.Max(price, 0)
+}
+
+// 以市价平掉整个仓位，用于止盈止损和强平
+func (s *Service) closePosition(pos *position, price float64, stopOrderType, execType string) {
+	side := "Sell"
+	if pos.size < 0 {
+		side = "Buy"
+	}
+	now := s.nowMs()
+	o := &order{
+		category:      bybitapi.CategoryLinear,
+		symbol:        pos.symbol,
+		settleCoin:    pos.settleCoin,
+		orderId:       uuid.NewString(),
+		side:          side,
+		orderType:     "Market",
+		timeInForce:   "IOC",
+		stopOrderType: stopOrderType,
+		qty:           math.Abs(pos.size),
+		reduceOnly:    true,
+		createdTime:   now,
+		updatedTime:   now,
+	}
+	s.orders = append(s.orders, o)
+	s.ordersById[o.orderId] = o
+	s.fill(o, price, o.qty, false, execType)
+	o.finish(false)
+	s.flushClosed(o)
+}
+
+// 最新价触及止盈或止损价时平仓
+func (s *Service) triggerStops() {
+	for _, pos := range s.positions {
+		if pos.size == 0 || (pos.takeProfit == 0 && pos.stopLoss == 0) {
+			continue
+		}
+		q := s.quotes[bybitapi.CategoryLinear+"/"+pos.symbol]
+		if q == nil || q.last == 0 {
+			continue
+		}
+
+		long := pos.size > 0
+		stopOrderType := ""
+		switch {
+		case pos.takeProfit > 0 && ((long && q.last >= pos.takeProfit) || (!long && q.last <= pos.takeProfit)):
+			stopOrderType = "TakeProfit"
+		case pos.stopLoss > 0 && ((long && q.last <= pos.stopLoss) || (!long && q.last >= pos.stopLoss)):
+			stopOrderType = "StopLoss"
+		default:
+			continue
+		}
+
+		// 平多按买一价成交，平空按卖一价成交，并计入滑点
+		slippage := s.cfg.SlippageBps / 10000
+		price := q.bid * (1 - slippage)
+		if !long {
+			price = q.ask * (1 + slippage)
+		}
+		if price <= 0 {
+			price = q.last
+		}
+		s.logger.Info("模拟仓位触发%s: symbol=%s, lastPrice=%s", stopOrderType, pos.symbol, format(q.last))
+		s.closePosition(pos, price, stopOrderType, model.ExecTypeTrade)
+	}
+}
+
+// 到达资金费用结算时间时按标记价格收取或支付资金费用，多仓在费率为正时支付
+func (s *Service) settleFunding() {
+	now := s.nowMs()
+	for _, pos := range s.positions {
+		if pos.size == 0 {
+			continue
+		}
+		q := s.quotes[bybitapi.CategoryLinear+"/"+pos.symbol]
+		if pos.nextFunding == 0 {
+			if q != nil && q.nextFundingTime > now {
+				pos.nextFunding = q.nextFundingTime
+				pos.fundingRate = q.fundingRate
+			}
+			continue
+		}
+		if now < pos.nextFunding {
+			continue
+		}
+
+		mark := s.mark(pos)
+		fee := pos.size * mark * pos.fundingRate
+		s.balances[pos.settleCoin] -= fee
+		pos.cumRealised -= fee
+
+		side := "Buy"
+		if pos.size < 0 {
+			side = "Sell"
+		}
+		s.executions = append(s.executions, model.Execution{
+			Category:    bybitapi.CategoryLinear,
+			Symbol:      pos.symbol,
+			Side:        side,
+			ExecId:      uuid.NewString(),
+			ExecType:    model.ExecTypeFunding,
+			ExecPrice:   mark,
+			ExecQty:     math.Abs(pos.size),
+			ExecValue:   math.Abs(pos.size) * mark,
+			ExecFee:     fee,
+			FeeRate:     pos.fundingRate,
+			FeeCurrency: pos.settleCoin,
+			MarkPrice:   mark,
+			ExecTime:    pos.nextFunding,
+		})
+
+		pos.nextFunding = 0
+		if q != nil && q.nextFundingTime > now {
+			pos.nextFunding = q.nextFundingTime
+			pos.fundingRate = q.fundingRate
+		}
+	}
+}
+
+// 结算币种的权益低于维持保证金时按标记价格强平全部仓位并撤销挂单
+func (s *Service) checkLiquidation() {
+	coins := map[string]bool{}
+	for _, pos := range s.positions {
+		if pos.size != 0 {
+			coins[pos.settleCoin] = true
+		}
+	}
+
+	for coin := range coins {
+		equity := s.balances[coin] + s.unrealised(coin)
+		_, mm := s.margins(coin)
+		if mm == 0 || equity > mm {
+			continue
+		}
+
+		s.logger.Warn("模拟账户%s权益%s低于维持保证金%s，强平全部仓位", coin, format(equity), format(mm))
+		for _, o := range s.orders {
+			if o.open() && o.category == bybitapi.CategoryLinear && o.settleCoin == coin {
+				o.status = statusCancelled
+				o.updatedTime = s.nowMs()
+			}
+		}
+		for _, pos := range s.positions {
+			if pos.settleCoin == coin && pos.size != 0 {
+				s.closePosition(pos, s.mark(pos), "", model.ExecTypeBustTrade)
+			}
+		}
+		// 穿仓损失由保险基金承担
+		if s.balances[coin] < 0 {
+			s.balances[coin] = 0
+		}
+	}
+}
+
+// Bybit格式的仓位
+type positionView struct {
+	PositionIdx     int    `json:"positionIdx"`
+	RiskId          int    `json:"riskId"`
+	Symbol          string `json:"symbol"`
+	Side            string `json:"side"`
+	Size            string `json:"size"`
+	AvgPrice        string `json:"avgPrice"`
+	PositionValue   string `json:"positionValue"`
+	TradeMode       int    `json:"tradeMode"`
+	Leverage        string `json:"leverage"`
+	PositionBalance string `json:"positionBalance"`
+	MarkPrice       string `json:"markPrice"`
+	LiqPrice        string `json:"liqPrice"`
+	PositionIM      string `json:"positionIM"`
+	PositionMM      string `json:"positionMM"`
+	TakeProfit      string `json:"takeProfit"`
+	StopLoss        string `json:"stopLoss"`
+	UnrealisedPnl   string `json:"unrealisedPnl"`
+	CumRealisedPnl  string `json:"cumRealisedPnl"`
+	PositionStatus  string `json:"positionStatus"`
+	CreatedTime     string `json:"createdTime"`
+	UpdatedTime     string `json:"updatedTime"`
+}
+
+// 仓位方向
+func (pos *position) side() string {
+	switch {
+	case pos.size > 0:
+		return "Buy"
+	case pos.size < 0:
+		return "Sell"
+	}
+	return ""
+}
+
+// 转换为Bybit格式
+func (s *Service) positionView(pos *position) positionView {
+	mark := s.mark(pos)
+	im := s.positionIM(pos)
+	return positionView{
+		RiskId:          1,
+		Symbol:          pos.symbol,
+		Side:            pos.side(),
+		Size:            format(math.Abs(pos.size)),
+		AvgPrice:        format(pos.entry),
+		PositionValue:   format(math.Abs(pos.size) * pos.entry),
+		Leverage:        format(s.lev(pos.symbol)),
+		PositionBalance: format(im),
+		MarkPrice:       format(mark),
+		LiqPrice:        format(s.liqPrice(pos)),
+		PositionIM:      format(im),
+		PositionMM:      format(s.positionMM(pos)),
+		TakeProfit:      format(pos.takeProfit),
+		StopLoss:        format(pos.stopLoss),
+		UnrealisedPnl:   format(pos.size * (mark - pos.entry)),
+		CumRealisedPnl:  format(pos.cumRealised),
+		PositionStatus:  "Normal",
+		CreatedTime:     strconv.FormatInt(pos.createdTime, 10),
+		UpdatedTime:     strconv.FormatInt(pos.updatedTime, 10),
+	}
+}
+
+// 转换为仓位模型，用于绩效报告
+func (s *Service) positionModel(pos *position) model.Position {
+	view := s.positionView(pos)
+	return model.Position{
+		RiskId:          view.RiskId,
+		Symbol:          view.Symbol,
+		Side:            view.Side,
+		Size:            view.Size,
+		EntryPrice:      view.AvgPrice,
+		Leverage:        view.Leverage,
+		PositionValue:   view.PositionValue,
+		PositionBalance: view.PositionBalance,
+		MarkPrice:       view.MarkPrice,
+		PositionIM:      view.PositionIM,
+		PositionMM:      view.PositionMM,
+		TakeProfit:      view.TakeProfit,
+		StopLoss:        view.StopLoss,
+		UnrealisedPnl:   view.UnrealisedPnl,
+		CumRealisedPnl:  view.CumRealisedPnl,
+		CreatedTime:     view.CreatedTime,
+		UpdatedTime:     view.UpdatedTime,
+	}
+}
+
+// 按交易对排序筛选持仓，symbol为空时只返回有仓位的交易对
+func (s *Service) filterPositions(symbol, settleCoin string) []*position {
+	result := []*position{}
+	for _, pos := range s.positions {
+		if (symbol != "" && pos.symbol != symbol) || (settleCoin != "" && pos.settleCoin != settleCoin) {
+			continue
+		}
+		if symbol == "" && pos.size == 0 {
+			continue
+		}
+		result = append(result, pos)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].symbol < result[j].symbol })
+	return result
+}
+
+// GetPositions 查询模拟仓位，只有线性合约有仓位
+func (s *Service) GetPositions(ctx context.Context, category, symbol, settleCoin, positionIdx string) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	views := []positionView{}
+	if category == bybitapi.CategoryLinear {
+		for _, pos := range s.filterPositions(symbol, settleCoin) {
+			views = append(views, s.positionView(pos))
+		}
+	}
+	return s.ok(map[string]interface{}{"category": category, "list": views, "nextPageCursor": ""}), nil
+}
+
+// SetLeverage 设置交易对的杠杆，单向持仓模式下买卖杠杆必须相同
+func (s *Service) SetLeverage(ctx context.Context, category, symbol string, buyLeverage, sellLeverage float64) (*model.Response, error) {
+	if category != bybitapi.CategoryLinear {
+		return s.reject(retParamsError, "模拟交易只支持设置linear的杠杆"), nil
+	}
+	if buyLeverage != sellLeverage {
+		return s.reject(retParamsError, "单向持仓模式下buyLeverage和sellLeverage必须相同"), nil
+	}
+	inst, err := s.instrument(ctx, category, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil {
+		return s.reject(retParamsError, "交易对不存在: %s", symbol), nil
+	}
+	if buyLeverage < 1 || (inst.maxLeverage > 0 && buyLeverage > inst.maxLeverage) {
+		return s.reject(retParamsError, "杠杆必须在1到%s之间", format(inst.maxLeverage)), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lev(symbol) == buyLeverage {
+		return s.reject(retLeverageNotModified, "杠杆没有变化"), nil
+	}
+	old := s.lev(symbol)
+	s.leverage[symbol] = buyLeverage
+	// 降低杠杆后保证金不足时恢复原杠杆
+	if inst.settleCoin != "" && s.available(inst.settleCoin) < 0 {
+		s.leverage[symbol] = old
+		return s.reject(retInsufficientBalance, "可用余额不足以降低杠杆"), nil
+	}
+	return s.ok(map[string]interface{}{}), nil
+}
+
+// SetTradingStop 设置仓位的止盈止损，最新价触及时以市价平仓，options中的takeProfit或stopLoss为0时取消
+func (s *Service) SetTradingStop(ctx context.Context, category, symbol string, takeProfit, stopLoss float64, options map[string]string) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos := s.positions[symbol]
+	if category != bybitapi.CategoryLinear || pos == nil || pos.size == 0 {
+		return s.reject(retParamsError, "没有仓位，无法设置止盈止损"), nil
+	}
+
+	last := s.mark(pos)
+	if q := s.quotes[category+"/"+symbol]; q != nil && q.last > 0 {
+		last = q.last
+	}
+	long := pos.size > 0
+	if takeProfit > 0 && ((long && takeProfit <= last) || (!long && takeProfit >= last)) {
+		return s.reject(retParamsError, "止盈价%s无效，最新价为%s", format(takeProfit), format(last)), nil
+	}
+	if stopLoss > 0 && ((long && stopLoss >= last) || (!long && stopLoss <= last)) {
+		return s.reject(retParamsError, "止损价%s无效，最新价为%s", format(stopLoss), format(last)), nil
+	}
+
+	if takeProfit > 0 || options["takeProfit"] == "0" {
+		pos.takeProfit = takeProfit
+	}
+	if stopLoss > 0 || options["stopLoss"] == "0" {
+		pos.stopLoss = stopLoss
+	}
+	pos.updatedTime = s.nowMs()
+	return s.ok(map[string]interface{}{}), nil
+}
+
+// SwitchPositionMode 切换持仓模式，模拟交易只支持单向持仓
+func (s *Service) SwitchPositionMode(ctx context.Context, category, symbol, mode string) (*model.Response, error) {
+	if mode != "0" {
+		return s.reject(retParamsError, "模拟交易只支持单向持仓模式"), nil
+	}
+	return s.ok(map[string]interface{}{}), nil
+}
+
+// SetTpSlMode 模拟交易只支持全部仓位止盈止损
+func (s *Service) SetTpSlMode(ctx context.Context, category, symbol, tpSlMode string) (*model.Response, error) {
+	if tpSlMode != "Full" {
+		return s.reject(retParamsError, "模拟交易只支持Full止盈止损模式"), nil
+	}
+	return s.ok(map[string]string{"tpSlMode": tpSlMode}), nil
+}
+
+// SetRiskLimit 模拟账户不支持设置风险限额
+func (s *Service) SetRiskLimit(ctx context.Context, category, symbol string, riskId int) (*model.Response, error) {
+	return nil, unsupported("设置风险限额")
+}
+
+// GetExecutions 查询模拟成交记录（单页）
+func (s *Service) GetExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &model.ExecutionList{Category: query.Category, List: s.filterExecutions(query, pageLimit(query.Limit, defaultExecutionLimit))}, nil
+}
+
+// GetAllExecutions 查询全部模拟成交记录
+func (s *Service) GetAllExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &model.ExecutionList{Category: query.Category, List: s.filterExecutions(query, 0)}, nil
+}
+
+// GetClosedPnl 查询平仓盈亏（单页）
+func (s *Service) GetClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &model.ClosedPnlList{Category: query.Category, List: s.filterClosedPnl(query, pageLimit(query.Limit, defaultExecutionLimit))}, nil
+}
+
+// GetAllClosedPnl 查询全部平仓盈亏
+func (s *Service) GetAllClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &model.ClosedPnlList{Category: query.Category, List: s.filterClosedPnl(query, 0)}, nil
+}
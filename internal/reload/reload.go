@@ -0,0 +1,234 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 默认检查配置文件变化的间隔
+const DefaultWatchInterval = 5 * time.Second
+
+// 只有重启才能生效的配置
+// 包括监听地址、TLS、存储、是否启用认证、Bybit环境和地址、主网交易开关、模拟交易、请求录制，以及账户的增删、母账户标记和默认账户
+var restartPaths = []string{
+	"server",
+	"storage",
+	"auth.enabled",
+	"auth.insecure",
+	"bybit.environment",
+	"bybit.baseUrl",
+	"bybit.wsUrl",
+	"bybit.allowMainnetTrading",
+	"bybit.debug",
+	"bybit.master",
+	"bybit.defaultAccount",
+	"bybit.accounts",
+	"paper",
+	"recording",
+	"history",
+	"scanner",
+	"alerts",
+}
+
+// 账户下可以在运行中修改的字段
+var accountHotFields = map[string]bool{
+	"apiKey":    true,
+	"apiSecret": true,
+	"rateLimit": true,
+	"burst":     true,
+}
+
+// Component 是可以在运行中应用新配置的组件
+// Prepare检查新配置并做好准备，但不能改变运行状态；全部组件都准备成功后才依次调用返回的commit
+type Component struct {
+	Name    string
+	Prepare func(cfg *config.Config) (commit func(), err error)
+}
+
+// Result 是一次重新加载的结果
+type Result struct {
+	Changed  []string `json:"changed"`  // 已生效的配置项
+	Rejected []string `json:"rejected"` // 需要重启才能生效、本次没有应用的配置项
+}
+
+// Manager 负责重新加载配置并应用到各个组件
+type Manager struct {
+	opts   config.Options
+	logger *logger.Logger
+
+	mu         sync.Mutex
+	current    *config.Config
+	components []Component
+}
+
+// New 创建配置重新加载管理器，opts与启动时加载配置的参数相同，current为当前生效的配置
+func New(opts config.Options, current *config.Config, log *logger.Logger) *Manager {
+	return &Manager{
+		opts:    opts,
+		logger:  log,
+		current: current,
+	}
+}
+
+// Register 注册一个组件，组件按注册顺序应用新配置
+func (m *Manager) Register(component Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component)
+}
+
+// Current 返回当前生效的配置
+func (m *Manager) Current() *config.Config {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current
+}
+
+// Reload 重新加载配置并应用可以在运行中修改的部分
+// 新配置无效或任一组件准备失败时不做任何修改；需要重启的修改会被忽略并记录在Result.Rejected中
+func (m *Manager) Reload() (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loaded, err := config.Load(m.opts)
+	if err != nil {
+		m.logger.Error("重新加载配置失败，继续使用原配置: %v", err)
+		return nil, err
+	}
+
+	result := &Result{Changed: []string{}, Rejected: []string{}}
+	for _, path := range config.Diff(m.current, loaded) {
+		if requiresRestart(path) {
+			result.Rejected = append(result.Rejected, path)
+		} else {
+			result.Changed = append(result.Changed, path)
+		}
+	}
+	for _, path := range result.Rejected {
+		m.logger.Warn("配置项%s需要重启服务才能生效，本次未应用", path)
+	}
+	if len(result.Changed) == 0 {
+		m.logger.Info("重新加载配置: 没有可以在运行中应用的修改")
+		return result, nil
+	}
+
+	next := keepRestartOnly(m.current, loaded)
+
+	commits := make([]func(), 0, len(m.components))
+	for _, component := range m.components {
+		commit, err := component.Prepare(next)
+		if err != nil {
+			m.logger.Error("重新加载配置失败，继续使用原配置: %s: %v", component.Name, err)
+			return nil, fmt.Errorf("%s: %v", component.Name, err)
+		}
+		if commit != nil {
+			commits = append(commits, commit)
+		}
+	}
+	for _, commit := range commits {
+		commit()
+	}
+
+	m.current = next
+	m.logger.Info("配置已重新加载，生效的修改: %s", strings.Join(result.Changed, ", "))
+	return result, nil
+}
+
+// Watch 定期检查配置文件的修改时间，文件变化后自动重新加载，直到ctx结束
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) {
+	if m.opts.File == "" {
+		return
+	}
+
+	last := modTime(m.opts.File)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := modTime(m.opts.File)
+			if current.Equal(last) {
+				continue
+			}
+			last = current
+			m.logger.Info("检测到配置文件变化: %s", m.opts.File)
+			m.Reload()
+		}
+	}
+}
+
+// 获取文件修改时间，文件不存在时返回零值
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// 判断配置项是否需要重启才能生效
+func requiresRestart(path string) bool {
+	// 已有账户的密钥和限流可以在运行中修改
+	if strings.HasPrefix(path, "bybit.accounts.") {
+		parts := strings.Split(path, ".")
+		return len(parts) != 4 || !accountHotFields[parts[3]]
+	}
+
+	for _, prefix := range restartPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// 以新配置为基础，把需要重启才能生效的部分恢复为当前值
+func keepRestartOnly(current, loaded *config.Config) *config.Config {
+	next := *loaded
+	next.Server = current.Server
+	next.Storage = current.Storage
+	next.Auth.Enabled = current.Auth.Enabled
+	next.Auth.Insecure = current.Auth.Insecure
+	next.Bybit.Environment = current.Bybit.Environment
+	next.Bybit.BaseURL = current.Bybit.BaseURL
+	next.Bybit.WSURL = current.Bybit.WSURL
+	next.Bybit.AllowMainnetTrading = current.Bybit.AllowMainnetTrading
+	next.Bybit.Debug = current.Bybit.Debug
+	next.Bybit.Master = current.Bybit.Master
+	next.Bybit.DefaultAccount = current.Bybit.DefaultAccount
+	next.Paper = current.Paper
+	next.Recording = current.Recording
+	next.History = current.History
+	next.Scanner = current.Scanner
+	next.Alerts = current.Alerts
+
+	// 账户列表保持不变，只更新已有账户的密钥和限流
+	if len(current.Bybit.Accounts) == 0 {
+		next.Bybit.Accounts = nil
+		return &next
+	}
+	accounts := make([]config.AccountConfig, len(current.Bybit.Accounts))
+	for i, account := range current.Bybit.Accounts {
+		accounts[i] = account
+		for _, updated := range loaded.Bybit.Accounts {
+			if updated.Name == account.Name {
+				accounts[i].APIKey = updated.APIKey
+				accounts[i].APISecret = updated.APISecret
+				accounts[i].RateLimit = updated.RateLimit
+				accounts[i].Burst = updated.Burst
+			}
+		}
+	}
+	next.Bybit.Accounts = accounts
+	return &next
+}
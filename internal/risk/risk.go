@@ -0,0 +1,103 @@
+package risk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bybit-mcp/internal/config"
+)
+
+// Error 表示请求不符合风控限制
+type Error struct {
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return "风控检查未通过: " + e.Reason
+}
+
+// 创建风控错误
+func reject(format string, args ...interface{}) error {
+	return &Error{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Checker 在下单和修改仓位设置前检查交易对白名单、订单数量、订单金额和杠杆
+// 限制可以在运行中通过Update替换
+type Checker struct {
+	mu      sync.RWMutex
+	allowed map[string]bool
+	maxQty  map[string]float64
+	cfg     config.RiskConfig
+}
+
+// New 创建风控检查器
+func New(cfg config.RiskConfig) *Checker {
+	c := &Checker{}
+	c.Update(cfg)
+	return c
+}
+
+// Update 替换风控限制，交易对不区分大小写
+func (c *Checker) Update(cfg config.RiskConfig) {
+	allowed := make(map[string]bool, len(cfg.AllowedSymbols))
+	for _, symbol := range cfg.AllowedSymbols {
+		allowed[strings.ToUpper(symbol)] = true
+	}
+	maxQty := make(map[string]float64, len(cfg.MaxOrderQty))
+	for symbol, limit := range cfg.MaxOrderQty {
+		maxQty[strings.ToUpper(symbol)] = limit
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allowed = allowed
+	c.maxQty = maxQty
+	c.cfg = cfg
+}
+
+// CheckSymbol 检查交易对是否在白名单中，未配置白名单时全部允许
+func (c *Checker) CheckSymbol(symbol string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkSymbol(symbol)
+}
+
+// CheckOrder 检查新订单或修改后的订单，qty和price为0表示未指定
+// 只有指定了价格的订单检查金额上限
+func (c *Checker) CheckOrder(symbol string, qty, price float64) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if err := c.checkSymbol(symbol); err != nil {
+		return err
+	}
+	if limit, ok := c.maxQty[strings.ToUpper(symbol)]; ok && qty > limit {
+		return reject("%s的订单数量%g超过上限%g", symbol, qty, limit)
+	}
+	if c.cfg.MaxOrderValue > 0 && price > 0 && qty*price > c.cfg.MaxOrderValue {
+		return reject("%s的订单金额%g超过上限%g", symbol, qty*price, c.cfg.MaxOrderValue)
+	}
+	return nil
+}
+
+// CheckLeverage 检查交易对和杠杆倍数
+func (c *Checker) CheckLeverage(symbol string, leverage float64) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if err := c.checkSymbol(symbol); err != nil {
+		return err
+	}
+	if c.cfg.MaxLeverage > 0 && leverage > c.cfg.MaxLeverage {
+		return reject("%s的杠杆%g超过上限%g", symbol, leverage, c.cfg.MaxLeverage)
+	}
+	return nil
+}
+
+func (c *Checker) checkSymbol(symbol string) error {
+	if len(c.allowed) > 0 && !c.allowed[strings.ToUpper(symbol)] {
+		return reject("交易对%s不在允许交易的列表中", symbol)
+	}
+	return nil
+}
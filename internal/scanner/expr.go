@@ -0,0 +1,383 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 表达式的语法：
+//
+//	expr    = or
+//	or      = and { "||" and }
+//	and     = cmp { "&&" cmp }
+//	cmp     = sum [ ("<" | "<=" | ">" | ">=" | "==" | "!=") sum ]
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = ("!" | "-") unary | primary
+//	primary = number | field | func "(" args ")" | "(" expr ")"
+//
+// 比较和逻辑运算的结果为1或0，任何一侧为NaN（字段缺失或历史不足）时比较结果为0
+
+// 表达式节点
+type node interface {
+	eval(env *env) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(*env) float64 { return float64(n) }
+
+type fieldNode int
+
+func (n fieldNode) eval(env *env) float64 { return env.field(int(n)) }
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) eval(env *env) float64 {
+	x := n.x.eval(env)
+	if n.op == "-" {
+		return -x
+	}
+	return truth(!isTrue(x))
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n *binaryNode) eval(env *env) float64 {
+	l := n.l.eval(env)
+	// 逻辑运算短路求值
+	switch n.op {
+	case "&&":
+		return truth(isTrue(l) && isTrue(n.r.eval(env)))
+	case "||":
+		return truth(isTrue(l) || isTrue(n.r.eval(env)))
+	}
+	r := n.r.eval(env)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return math.NaN()
+		}
+		return l / r
+	}
+	if math.IsNaN(l) || math.IsNaN(r) {
+		return 0
+	}
+	switch n.op {
+	case "<":
+		return truth(l < r)
+	case "<=":
+		return truth(l <= r)
+	case ">":
+		return truth(l > r)
+	case ">=":
+		return truth(l >= r)
+	case "==":
+		return truth(l == r)
+	default:
+		return truth(l != r)
+	}
+}
+
+// 数学函数：abs、min、max
+type mathNode struct {
+	fn   string
+	args []node
+}
+
+func (n *mathNode) eval(env *env) float64 {
+	switch n.fn {
+	case "abs":
+		return math.Abs(n.args[0].eval(env))
+	case "min":
+		return math.Min(n.args[0].eval(env), n.args[1].eval(env))
+	default:
+		return math.Max(n.args[0].eval(env), n.args[1].eval(env))
+	}
+}
+
+// 历史函数：pct、change、avg，参数为字段和回看的分钟数
+type historyNode struct {
+	fn      string
+	field   int
+	minutes float64
+}
+
+func (n *historyNode) eval(env *env) float64 {
+	return env.history(n.fn, n.field, n.minutes)
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func isTrue(v float64) bool {
+	return v != 0 && !math.IsNaN(v)
+}
+
+// 历史函数
+var historyFuncs = map[string]bool{"pct": true, "change": true, "avg": true}
+
+// 数学函数和参数个数
+var mathFuncs = map[string]int{"abs": 1, "min": 2, "max": 2}
+
+// 词法单元
+type token struct {
+	kind string // num、ident、op或eof
+	text string
+	pos  int
+}
+
+// 把表达式拆分为词法单元
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '-' || src[i] == '+') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: "num", text: src[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: "ident", text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "<=", ">=", "==", "!=", "<", ">", "+", "-", "*", "/", "!", "(", ")", ","} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("位置%d: 无法识别的字符%q", i+1, src[i])
+			}
+			tokens = append(tokens, token{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: "eof", pos: len(src)}), nil
+}
+
+// 递归下降解析器
+type parser struct {
+	tokens []token
+	pos    int
+	maxAge float64 // 历史函数中最大的回看分钟数
+}
+
+// 解析过滤或排序表达式，同时返回历史函数中最大的回看分钟数
+func compile(src string) (node, float64, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, 0, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, 0, fmt.Errorf("位置%d: 多余的%q", t.pos+1, t.text)
+	}
+	return n, p.maxAge, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+// 下一个词法单元是ops中的运算符时消耗并返回它
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != "op" {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return fmt.Errorf("位置%d: 需要%q", t.pos+1, op)
+	}
+	return nil
+}
+
+// 解析左结合的二元运算
+func (p *parser) binary(operand func() (node, error), ops ...string) (node, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return l, nil
+		}
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binary(p.parseCmp, "&&")
+}
+
+func (p *parser) parseCmp() (node, error) {
+	l, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("<=", ">=", "==", "!=", "<", ">")
+	if !ok {
+		return l, nil
+	}
+	r, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return &binaryNode{op: op, l: l, r: r}, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.binary(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() (node, error) {
+	return p.binary(p.parseUnary, "*", "/")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case "num":
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("位置%d: 无效的数字%q", t.pos+1, t.text)
+		}
+		return numberNode(v), nil
+	case "ident":
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		index, ok := fieldIndex[t.text]
+		if !ok {
+			return nil, fmt.Errorf("位置%d: 未知的字段%q，可选值: %s", t.pos+1, t.text, strings.Join(Fields(), "、"))
+		}
+		return fieldNode(index), nil
+	case "op":
+		if t.text == "(" {
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		}
+	}
+	if t.kind == "eof" {
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	return nil, fmt.Errorf("位置%d: 不应出现%q", t.pos+1, t.text)
+}
+
+// 解析函数调用，左括号已经消耗
+func (p *parser) parseCall(name token) (node, error) {
+	if historyFuncs[name.text] {
+		field := p.next()
+		index, ok := fieldIndex[field.text]
+		if field.kind != "ident" || !ok {
+			return nil, fmt.Errorf("位置%d: %s的第一个参数必须是字段", field.pos+1, name.text)
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		minutes := p.next()
+		v, err := strconv.ParseFloat(minutes.text, 64)
+		if minutes.kind != "num" || err != nil || v <= 0 {
+			return nil, fmt.Errorf("位置%d: %s的第二个参数必须是大于0的分钟数", minutes.pos+1, name.text)
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		p.maxAge = math.Max(p.maxAge, v)
+		return &historyNode{fn: name.text, field: index, minutes: v}, nil
+	}
+
+	count, ok := mathFuncs[name.text]
+	if !ok {
+		return nil, fmt.Errorf("位置%d: 未知的函数%q，可选值: pct、change、avg、abs、min、max", name.pos+1, name.text)
+	}
+	n := &mathNode{fn: name.text}
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, arg)
+	}
+	return n, p.expect(")")
+}
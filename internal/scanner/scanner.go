@@ -0,0 +1,422 @@
+// Package scanner 定时获取一个产品类别全部交易对的行情，保存最近一段时间的历史，
+// 按用户给出的表达式筛选和排序交易对
+//
+// 例如筛选过去一小时上涨超过5%、持仓量增加且资金费率高于0.01%的永续合约：
+//
+//	pct(lastPrice, 60) > 0.05 && pct(openInterest, 60) > 0 && fundingRate > 0.0001
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/service"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 单次扫描返回结果的默认和最大数量
+const (
+	defaultLimit = 20
+	maxLimit     = 200
+)
+
+// 未启用定时获取时，扫描最多使用多久之前的行情，超过时重新获取
+const minRefresh = 10 * time.Second
+
+// 默认的排序表达式
+const defaultSort = "turnover24h"
+
+// 行情字段，前面是market/tickers返回的原始字段，后面是派生字段
+// 变化率都是小数，与price24hPcnt一致，例如0.05表示5%
+var fields = []string{
+	"lastPrice", "markPrice", "indexPrice", "bid1Price", "ask1Price",
+	"prevPrice24h", "price24hPcnt", "highPrice24h", "lowPrice24h", "prevPrice1h",
+	"volume24h", "turnover24h", "openInterest", "openInterestValue", "fundingRate",
+	"price1hPcnt", // lastPrice相对prevPrice1h的变化率
+	"premium",     // markPrice相对indexPrice的溢价率
+	"spread",      // 买一卖一价差相对中间价的比例
+	"range24h",    // highPrice24h相对lowPrice24h的振幅
+}
+
+// 原始字段的数量
+const rawFields = 15
+
+// 字段名到下标
+var fieldIndex = func() map[string]int {
+	index := make(map[string]int, len(fields))
+	for i, name := range fields {
+		index[name] = i
+	}
+	return index
+}()
+
+// Fields 返回表达式中可以使用的全部字段
+func Fields() []string {
+	return append([]string(nil), fields...)
+}
+
+// 一个交易对在某次获取时的行情，缺失的字段为NaN
+type sample struct {
+	time   int64
+	values []float64
+}
+
+// 一个产品类别的行情历史
+type categoryState struct {
+	category string
+
+	mu      sync.Mutex
+	polls   []int64             // 每次获取的时间
+	symbols map[string][]sample // 按时间正序
+}
+
+// Scanner 定时获取行情并按表达式筛选交易对
+type Scanner struct {
+	market     service.MarketDataService
+	logger     *logger.Logger
+	categories []string
+	interval   time.Duration
+	window     time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*categoryState
+}
+
+// New 创建扫描器，interval为定时获取的间隔，为0时只在扫描时获取；window为保存的历史时长
+func New(market service.MarketDataService, categories []string, interval, window time.Duration, log *logger.Logger) *Scanner {
+	return &Scanner{
+		market:     market,
+		logger:     log,
+		categories: categories,
+		interval:   interval,
+		window:     window,
+		now:        time.Now,
+		states:     map[string]*categoryState{},
+	}
+}
+
+// Run 按间隔获取配置的全部产品类别的行情，直到上下文结束
+func (s *Scanner) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, category := range s.categories {
+			if err := s.poll(ctx, s.state(category)); err != nil {
+				s.logger.Warn("获取%s行情失败: %v", category, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 检查产品类别，期权行情需要按币种获取，不支持扫描
+func validCategory(category string) bool {
+	return category == bybitapi.CategorySpot || category == bybitapi.CategoryLinear || category == bybitapi.CategoryInverse
+}
+
+// 返回产品类别的行情历史，不存在时创建
+func (s *Scanner) state(category string) *categoryState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[category]
+	if !ok {
+		state = &categoryState{category: category, symbols: map[string][]sample{}}
+		s.states[category] = state
+	}
+	return state
+}
+
+// 获取一次全部交易对的行情并移除过期的历史
+func (s *Scanner) poll(ctx context.Context, state *categoryState) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return s.pollLocked(ctx, state)
+}
+
+// 获取行情，调用方持有state.mu
+func (s *Scanner) pollLocked(ctx context.Context, state *categoryState) error {
+	resp, err := s.market.GetTickers(ctx, state.category, "")
+	if err != nil {
+		return err
+	}
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return err
+	}
+	var tickers []map[string]interface{}
+	if len(page.List) > 0 {
+		if err := json.Unmarshal(page.List, &tickers); err != nil {
+			return fmt.Errorf("解析行情失败: %v", err)
+		}
+	}
+
+	now := s.now().UnixMilli()
+	seen := map[string]bool{}
+	for _, ticker := range tickers {
+		symbol, _ := ticker["symbol"].(string)
+		if symbol == "" {
+			continue
+		}
+		seen[symbol] = true
+		state.symbols[symbol] = append(state.symbols[symbol], sample{time: now, values: tickerValues(ticker)})
+	}
+	state.polls = append(state.polls, now)
+
+	// 多保留一个间隔，保证回看整个窗口时能找到窗口开始之前的行情
+	cutoff := now - (s.window + s.refresh()).Milliseconds()
+	for symbol, samples := range state.symbols {
+		if !seen[symbol] {
+			delete(state.symbols, symbol)
+			continue
+		}
+		i := sort.Search(len(samples), func(i int) bool { return samples[i].time >= cutoff })
+		state.symbols[symbol] = append([]sample(nil), samples[i:]...)
+	}
+	i := sort.Search(len(state.polls), func(i int) bool { return state.polls[i] >= cutoff })
+	state.polls = append([]int64(nil), state.polls[i:]...)
+	return nil
+}
+
+// 扫描时行情的最长有效时间
+func (s *Scanner) refresh() time.Duration {
+	if s.interval > 0 {
+		return 2 * s.interval
+	}
+	return minRefresh
+}
+
+// 解析行情字段并计算派生字段
+func tickerValues(ticker map[string]interface{}) []float64 {
+	values := make([]float64, len(fields))
+	for i, name := range fields[:rawFields] {
+		values[i] = math.NaN()
+		if text, ok := ticker[name].(string); ok && text != "" {
+			if v, err := strconv.ParseFloat(text, 64); err == nil {
+				values[i] = v
+			}
+		}
+	}
+	get := func(name string) float64 { return values[fieldIndex[name]] }
+	ratio := func(a, b float64) float64 {
+		if b == 0 {
+			return math.NaN()
+		}
+		return a/b - 1
+	}
+	values[fieldIndex["price1hPcnt"]] = ratio(get("lastPrice"), get("prevPrice1h"))
+	values[fieldIndex["premium"]] = ratio(get("markPrice"), get("indexPrice"))
+	values[fieldIndex["range24h"]] = ratio(get("highPrice24h"), get("lowPrice24h"))
+	values[fieldIndex["spread"]] = math.NaN()
+	if bid, ask := get("bid1Price"), get("ask1Price"); bid > 0 && ask > 0 {
+		values[fieldIndex["spread"]] = (ask - bid) / ((ask + bid) / 2)
+	}
+	return values
+}
+
+// 表达式求值时一个交易对的行情
+type env struct {
+	samples []sample
+}
+
+func (e *env) latest() sample {
+	return e.samples[len(e.samples)-1]
+}
+
+func (e *env) field(i int) float64 {
+	return e.latest().values[i]
+}
+
+// 计算历史函数：pct为相对minutes分钟前的变化率，change为变化量，avg为最近minutes分钟的平均值
+// 历史不足minutes分钟时返回NaN
+func (e *env) history(fn string, field int, minutes float64) float64 {
+	latest := e.latest()
+	cutoff := latest.time - int64(minutes*float64(time.Minute/time.Millisecond))
+	if fn == "avg" {
+		if e.samples[0].time > cutoff {
+			return math.NaN()
+		}
+		sum, count := 0.0, 0
+		for i := len(e.samples) - 1; i >= 0 && e.samples[i].time >= cutoff; i-- {
+			if v := e.samples[i].values[field]; !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		if count == 0 {
+			return math.NaN()
+		}
+		return sum / float64(count)
+	}
+
+	// 找到不晚于cutoff的最近一次行情
+	i := sort.Search(len(e.samples), func(i int) bool { return e.samples[i].time > cutoff })
+	if i == 0 {
+		return math.NaN()
+	}
+	past, current := e.samples[i-1].values[field], latest.values[field]
+	if fn == "change" {
+		return current - past
+	}
+	if past == 0 {
+		return math.NaN()
+	}
+	return current/past - 1
+}
+
+// Query 是一次扫描的条件
+type Query struct {
+	Category  string // spot、linear或inverse
+	Filter    string // 过滤表达式，为空时不过滤
+	Sort      string // 排序表达式，默认为turnover24h
+	Ascending bool   // 是否升序，默认降序
+	Limit     int    // 返回数量，默认20，最多200
+}
+
+// Item 是一个符合条件的交易对，Fields只包括有值的字段，Score为排序表达式的值，无法计算时为空
+type Item struct {
+	Symbol string             `json:"symbol"`
+	Score  *float64           `json:"score"`
+	Fields map[string]float64 `json:"fields"`
+}
+
+// Result 是扫描结果
+type Result struct {
+	Category       string  `json:"category"`
+	Time           int64   `json:"time"`           // 最近一次获取行情的时间
+	Samples        int     `json:"samples"`        // 保存的行情次数
+	HistoryMinutes float64 `json:"historyMinutes"` // 保存的历史时长（分钟）
+	Scanned        int     `json:"scanned"`        // 参与扫描的交易对数量
+	Matched        int     `json:"matched"`        // 符合条件的交易对数量
+	Items          []Item  `json:"items"`
+}
+
+// 编译后的扫描条件
+type plan struct {
+	filter node // 为nil时不过滤
+	score  node
+	limit  int
+}
+
+// 检查扫描条件并编译表达式
+func (s *Scanner) plan(query Query) (*plan, error) {
+	if !validCategory(query.Category) {
+		return nil, fmt.Errorf("扫描只支持spot、linear和inverse")
+	}
+	p := &plan{limit: query.Limit}
+	maxAge := 0.0
+	if strings.TrimSpace(query.Filter) != "" {
+		var err error
+		if p.filter, maxAge, err = compile(query.Filter); err != nil {
+			return nil, fmt.Errorf("过滤表达式错误: %v", err)
+		}
+	}
+	sortExpr := query.Sort
+	if strings.TrimSpace(sortExpr) == "" {
+		sortExpr = defaultSort
+	}
+	score, sortAge, err := compile(sortExpr)
+	if err != nil {
+		return nil, fmt.Errorf("排序表达式错误: %v", err)
+	}
+	p.score = score
+	if window := s.window.Minutes(); math.Max(maxAge, sortAge) > window {
+		return nil, fmt.Errorf("回看时间不能超过保存的历史时长%v分钟", window)
+	}
+	if p.limit <= 0 {
+		p.limit = defaultLimit
+	} else if p.limit > maxLimit {
+		p.limit = maxLimit
+	}
+	return p, nil
+}
+
+// Validate 检查产品类别和表达式是否有效
+func (s *Scanner) Validate(query Query) error {
+	_, err := s.plan(query)
+	return err
+}
+
+// Scan 按表达式筛选和排序交易对
+// 最近的行情超过有效时间（定时获取间隔的2倍，未启用定时获取时为10秒）时先重新获取
+func (s *Scanner) Scan(ctx context.Context, query Query) (*Result, error) {
+	p, err := s.plan(query)
+	if err != nil {
+		return nil, err
+	}
+
+	state := s.state(query.Category)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	now := s.now().UnixMilli()
+	if len(state.polls) == 0 || now-state.polls[len(state.polls)-1] >= s.refresh().Milliseconds() {
+		if err := s.pollLocked(ctx, state); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Result{Category: query.Category, Items: []Item{}, Samples: len(state.polls)}
+	if len(state.polls) > 0 {
+		result.Time = state.polls[len(state.polls)-1]
+		result.HistoryMinutes = float64(result.Time-state.polls[0]) / float64(time.Minute/time.Millisecond)
+	}
+	for symbol, samples := range state.symbols {
+		if len(samples) == 0 || samples[len(samples)-1].time != result.Time {
+			continue
+		}
+		result.Scanned++
+		e := &env{samples: samples}
+		if p.filter != nil && !isTrue(p.filter.eval(e)) {
+			continue
+		}
+		item := Item{Symbol: symbol, Fields: map[string]float64{}}
+		if v := p.score.eval(e); !math.IsNaN(v) && !math.IsInf(v, 0) {
+			item.Score = &v
+		}
+		for i, name := range fields {
+			if v := e.field(i); !math.IsNaN(v) {
+				item.Fields[name] = v
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+	result.Matched = len(result.Items)
+
+	// 无法计算分数的排在最后，分数相同时按交易对排序
+	sort.Slice(result.Items, func(i, j int) bool {
+		a, b := result.Items[i], result.Items[j]
+		switch {
+		case a.Score == nil || b.Score == nil:
+			if (a.Score == nil) != (b.Score == nil) {
+				return b.Score == nil
+			}
+		case *a.Score != *b.Score:
+			if query.Ascending {
+				return *a.Score < *b.Score
+			}
+			return *a.Score > *b.Score
+		}
+		return a.Symbol < b.Symbol
+	})
+	if len(result.Items) > p.limit {
+		result.Items = result.Items[:p.limit]
+	}
+	return result, nil
+}
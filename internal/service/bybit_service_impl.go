@@ -2,17 +2,23 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/bybit-mcp/internal/analytics"
 	"github.com/bybit-mcp/internal/api/account"
 	"github.com/bybit-mcp/internal/api/asset"
 	"github.com/bybit-mcp/internal/api/market"
 	"github.com/bybit-mcp/internal/api/order"
 	"github.com/bybit-mcp/internal/api/position"
+	"github.com/bybit-mcp/internal/api/user"
 	"github.com/bybit-mcp/internal/model"
 	"github.com/bybit-mcp/pkg/bybitapi"
 	"github.com/bybit-mcp/pkg/logger"
 )
 
+// 绩效报告默认统计的天数
+const defaultReportDays = 30
+
 // BybitServiceImpl 实现了BybitService接口
 type BybitServiceImpl struct {
 	client          *bybitapi.Client
@@ -21,24 +27,26 @@ type BybitServiceImpl struct {
 	positionService *position.PositionService
 	accountService  *account.AccountService
 	assetService    *asset.AssetService
+	userService     *user.UserService
 	logger          *logger.Logger
 }
 
-// NewBybitService 创建一个新的Bybit服务实现
-func NewBybitService(apiKey, apiSecret, logLevel, logOutput string) BybitService {
+// NewBybitService 创建一个新的Bybit服务实现，请求发送到env指定的环境
+// 各个API服务共用log，调用方修改log的级别和输出位置后对全部服务生效
+func NewBybitService(apiKey, apiSecret string, env bybitapi.Environment, log *logger.Logger) BybitService {
 	// 创建API客户端
 	client := bybitapi.NewClient(apiKey, apiSecret)
+	client.SetEnvironment(env)
 
-	// 创建日志记录器
-	logger := logger.New(logLevel, logOutput)
-	logger.Info("初始化Bybit MCP服务")
+	log.Info("初始化Bybit MCP服务")
 
 	// 创建各个API服务
-	marketService := market.NewMarketService(client, logLevel, logOutput)
-	orderService := order.NewOrderService(client, logLevel, logOutput)
-	positionService := position.NewPositionService(client, logLevel, logOutput)
-	accountService := account.NewAccountService(client, logLevel, logOutput)
-	assetService := asset.NewAssetService(client, logLevel, logOutput)
+	marketService := market.NewMarketService(client, log)
+	orderService := order.NewOrderService(client, log)
+	positionService := position.NewPositionService(client, log)
+	accountService := account.NewAccountService(client, log)
+	assetService := asset.NewAssetService(client, log)
+	userService := user.NewUserService(client, log)
 
 	return &BybitServiceImpl{
 		client:          client,
@@ -47,7 +55,8 @@ func NewBybitService(apiKey, apiSecret, logLevel, logOutput string) BybitService
 		positionService: positionService,
 		accountService:  accountService,
 		assetService:    assetService,
-		logger:          logger,
+		userService:     userService,
+		logger:          log,
 	}
 }
 
@@ -77,6 +86,84 @@ func (s *BybitServiceImpl) GetInstruments(ctx context.Context, category, symbol,
 	return s.marketService.GetInstruments(ctx, category, symbol, status)
 }
 
+// GetRecentTrades 获取最近成交
+func (s *BybitServiceImpl) GetRecentTrades(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	s.logger.Debug("调用GetRecentTrades服务: category=%s, symbol=%s", category, symbol)
+	return s.marketService.GetRecentTrades(ctx, category, symbol, limit)
+}
+
+// GetMarkPriceKline 获取标记价格K线
+func (s *BybitServiceImpl) GetMarkPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	s.logger.Debug("调用GetMarkPriceKline服务: category=%s, symbol=%s, interval=%s", category, symbol, interval)
+	return s.marketService.GetMarkPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetIndexPriceKline 获取指数价格K线
+func (s *BybitServiceImpl) GetIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	s.logger.Debug("调用GetIndexPriceKline服务: category=%s, symbol=%s, interval=%s", category, symbol, interval)
+	return s.marketService.GetIndexPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetPremiumIndexPriceKline 获取溢价指数K线
+func (s *BybitServiceImpl) GetPremiumIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	s.logger.Debug("调用GetPremiumIndexPriceKline服务: category=%s, symbol=%s, interval=%s", category, symbol, interval)
+	return s.marketService.GetPremiumIndexPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetFundingRateHistory 获取资金费率历史
+func (s *BybitServiceImpl) GetFundingRateHistory(ctx context.Context, category, symbol string, startTime, endTime int64, limit int) (*model.Response, error) {
+	s.logger.Debug("调用GetFundingRateHistory服务: category=%s, symbol=%s", category, symbol)
+	return s.marketService.GetFundingRateHistory(ctx, category, symbol, startTime, endTime, limit)
+}
+
+// GetOpenInterest 获取持仓量
+func (s *BybitServiceImpl) GetOpenInterest(ctx context.Context, category, symbol, intervalTime string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	s.logger.Debug("调用GetOpenInterest服务: category=%s, symbol=%s, intervalTime=%s", category, symbol, intervalTime)
+	return s.marketService.GetOpenInterest(ctx, category, symbol, intervalTime, startTime, endTime, limit, cursor)
+}
+
+// GetLongShortRatio 获取多空持仓人数比
+func (s *BybitServiceImpl) GetLongShortRatio(ctx context.Context, category, symbol, period string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	s.logger.Debug("调用GetLongShortRatio服务: category=%s, symbol=%s, period=%s", category, symbol, period)
+	return s.marketService.GetLongShortRatio(ctx, category, symbol, period, startTime, endTime, limit, cursor)
+}
+
+// GetHistoricalVolatility 获取期权历史波动率
+func (s *BybitServiceImpl) GetHistoricalVolatility(ctx context.Context, category, baseCoin string, period int, startTime, endTime int64) (*model.Response, error) {
+	s.logger.Debug("调用GetHistoricalVolatility服务: category=%s, baseCoin=%s", category, baseCoin)
+	return s.marketService.GetHistoricalVolatility(ctx, category, baseCoin, period, startTime, endTime)
+}
+
+// GetInsurance 获取保险基金余额
+func (s *BybitServiceImpl) GetInsurance(ctx context.Context, coin string) (*model.Response, error) {
+	s.logger.Debug("调用GetInsurance服务: coin=%s", coin)
+	return s.marketService.GetInsurance(ctx, coin)
+}
+
+// GetRiskLimit 获取风险限额档位
+func (s *BybitServiceImpl) GetRiskLimit(ctx context.Context, category, symbol, cursor string) (*model.Response, error) {
+	s.logger.Debug("调用GetRiskLimit服务: category=%s, symbol=%s", category, symbol)
+	return s.marketService.GetRiskLimit(ctx, category, symbol, cursor)
+}
+
+// GetDeliveryPrice 获取交割价格
+func (s *BybitServiceImpl) GetDeliveryPrice(ctx context.Context, category, symbol, baseCoin string, limit int, cursor string) (*model.Response, error) {
+	s.logger.Debug("调用GetDeliveryPrice服务: category=%s, symbol=%s", category, symbol)
+	return s.marketService.GetDeliveryPrice(ctx, category, symbol, baseCoin, limit, cursor)
+}
+
+// GetOptionTickers 获取期权行情
+func (s *BybitServiceImpl) GetOptionTickers(ctx context.Context, baseCoin, expDate string) (*model.Response, error) {
+	s.logger.Debug("调用GetOptionTickers服务: baseCoin=%s, expDate=%s", baseCoin, expDate)
+	return s.marketService.GetOptionTickers(ctx, baseCoin, expDate)
+}
+
+// GetServerTime 获取服务器时间
+func (s *BybitServiceImpl) GetServerTime(ctx context.Context) (*model.Response, error) {
+	s.logger.Debug("调用GetServerTime服务")
+	return s.marketService.GetServerTime(ctx)
+}
+
 // 订单管理API
 
 // CreateOrder 创建订单
@@ -91,6 +178,12 @@ func (s *BybitServiceImpl) CancelOrder(ctx context.Context, category, symbol, or
 	return s.orderService.CancelOrder(ctx, category, symbol, orderId, orderLinkId)
 }
 
+// CancelAllOrders 取消全部订单
+func (s *BybitServiceImpl) CancelAllOrders(ctx context.Context, category, symbol, settleCoin string) (*model.Response, error) {
+	s.logger.Debug("调用CancelAllOrders服务: category=%s, symbol=%s, settleCoin=%s", category, symbol, settleCoin)
+	return s.orderService.CancelAllOrders(ctx, category, symbol, settleCoin)
+}
+
 // GetOrders 获取订单列表
 func (s *BybitServiceImpl) GetOrders(ctx context.Context, category, symbol, orderId, orderLinkId, orderStatus string, limit int) (*model.Response, error) {
 	s.logger.Debug("调用GetOrders服务: category=%s, symbol=%s, status=%s", category, symbol, orderStatus)
@@ -103,6 +196,46 @@ func (s *BybitServiceImpl) AmendOrder(ctx context.Context, category, symbol, ord
 	return s.orderService.AmendOrder(ctx, category, symbol, orderId, orderLinkId, qty, price, options)
 }
 
+// GetOpenOrders 获取当前委托（单页）
+func (s *BybitServiceImpl) GetOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	s.logger.Debug("调用GetOpenOrders服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	return s.orderService.GetOpenOrders(ctx, query)
+}
+
+// GetOrderHistory 获取历史订单（单页）
+func (s *BybitServiceImpl) GetOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	s.logger.Debug("调用GetOrderHistory服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	return s.orderService.GetOrderHistory(ctx, query)
+}
+
+// GetAllOpenOrders 翻页获取全部当前委托
+func (s *BybitServiceImpl) GetAllOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	s.logger.Debug("调用GetAllOpenOrders服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	result := &model.OrderList{Category: query.Category, List: []model.Order{}}
+	err := s.orderService.WalkOpenOrders(ctx, query, func(orders []model.Order) error {
+		result.List = append(result.List, orders...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAllOrderHistory 翻页获取全部历史订单
+func (s *BybitServiceImpl) GetAllOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	s.logger.Debug("调用GetAllOrderHistory服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	result := &model.OrderList{Category: query.Category, List: []model.Order{}}
+	err := s.orderService.WalkOrderHistory(ctx, query, func(orders []model.Order) error {
+		result.List = append(result.List, orders...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // 仓位管理API
 
 // GetPositions 获取仓位列表
@@ -129,6 +262,58 @@ func (s *BybitServiceImpl) SwitchPositionMode(ctx context.Context, category, sym
 	return s.positionService.SwitchPositionMode(ctx, category, symbol, mode)
 }
 
+// SetTpSlMode 设置止盈止损模式
+func (s *BybitServiceImpl) SetTpSlMode(ctx context.Context, category, symbol, tpSlMode string) (*model.Response, error) {
+	s.logger.Debug("调用SetTpSlMode服务: category=%s, symbol=%s, tpSlMode=%s", category, symbol, tpSlMode)
+	return s.positionService.SetTpSlMode(ctx, category, symbol, tpSlMode)
+}
+
+// SetRiskLimit 设置风险限额档位
+func (s *BybitServiceImpl) SetRiskLimit(ctx context.Context, category, symbol string, riskId int) (*model.Response, error) {
+	s.logger.Debug("调用SetRiskLimit服务: category=%s, symbol=%s, riskId=%d", category, symbol, riskId)
+	return s.positionService.SetRiskLimit(ctx, category, symbol, riskId)
+}
+
+// GetExecutions 获取成交记录（单页）
+func (s *BybitServiceImpl) GetExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	s.logger.Debug("调用GetExecutions服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	return s.positionService.GetExecutions(ctx, query)
+}
+
+// GetAllExecutions 翻页获取全部成交记录
+func (s *BybitServiceImpl) GetAllExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	s.logger.Debug("调用GetAllExecutions服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	result := &model.ExecutionList{Category: query.Category, List: []model.Execution{}}
+	err := s.positionService.WalkExecutions(ctx, query, func(executions []model.Execution) error {
+		result.List = append(result.List, executions...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetClosedPnl 获取平仓盈亏（单页）
+func (s *BybitServiceImpl) GetClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	s.logger.Debug("调用GetClosedPnl服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	return s.positionService.GetClosedPnl(ctx, query)
+}
+
+// GetAllClosedPnl 翻页获取全部平仓盈亏
+func (s *BybitServiceImpl) GetAllClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	s.logger.Debug("调用GetAllClosedPnl服务: category=%s, symbol=%s", query.Category, query.Symbol)
+	result := &model.ClosedPnlList{Category: query.Category, List: []model.ClosedPnl{}}
+	err := s.positionService.WalkClosedPnl(ctx, query, func(records []model.ClosedPnl) error {
+		result.List = append(result.List, records...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // 账户管理API
 
 // GetWalletBalance 获取钱包余额
@@ -175,8 +360,154 @@ func (s *BybitServiceImpl) GetTransferHistory(ctx context.Context, transferId, c
 	return s.assetService.GetTransferHistory(ctx, transferId, coin, status, startTime, endTime, limit)
 }
 
+// GetDepositHistory 获取充值记录
+func (s *BybitServiceImpl) GetDepositHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	s.logger.Debug("调用GetDepositHistory服务: coin=%s", coin)
+	return s.assetService.GetDepositHistory(ctx, coin, startTime, endTime, limit)
+}
+
+// GetWithdrawalHistory 获取提现记录
+func (s *BybitServiceImpl) GetWithdrawalHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	s.logger.Debug("调用GetWithdrawalHistory服务: coin=%s", coin)
+	return s.assetService.GetWithdrawalHistory(ctx, coin, startTime, endTime, limit)
+}
+
 // Withdraw 提现
 func (s *BybitServiceImpl) Withdraw(ctx context.Context, coin, chain, address, tag, amount string, options map[string]string) (*model.Response, error) {
 	s.logger.Debug("调用Withdraw服务: coin=%s, chain=%s, amount=%s", coin, chain, amount)
 	return s.assetService.Withdraw(ctx, coin, chain, address, tag, amount, options)
-}
\ No newline at end of file
+}
+
+// UniversalTransfer 母子账户之间划转
+func (s *BybitServiceImpl) UniversalTransfer(ctx context.Context, req *model.UniversalTransferRequest) (*model.Response, error) {
+	s.logger.Debug("调用UniversalTransfer服务: coin=%s, amount=%s, fromMemberId=%s, toMemberId=%s", req.Coin, req.Amount, req.FromMemberId, req.ToMemberId)
+	return s.assetService.UniversalTransfer(ctx, req)
+}
+
+// ListAllTransfers 获取账户内划转和母子账户间划转记录
+func (s *BybitServiceImpl) ListAllTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	s.logger.Debug("调用ListAllTransfers服务: coin=%s, status=%s, startTime=%d, endTime=%d", coin, status, startTime, endTime)
+	return s.assetService.ListAllTransfers(ctx, coin, status, startTime, endTime)
+}
+
+// 子账户管理API
+
+// CreateSubMember 创建子账户
+func (s *BybitServiceImpl) CreateSubMember(ctx context.Context, req *model.SubMemberRequest) (*model.Response, error) {
+	s.logger.Debug("调用CreateSubMember服务: username=%s", req.Username)
+	return s.userService.CreateSubMember(ctx, req)
+}
+
+// ListSubMembers 获取子账户列表
+func (s *BybitServiceImpl) ListSubMembers(ctx context.Context) (*model.Response, error) {
+	s.logger.Debug("调用ListSubMembers服务")
+	return s.userService.ListSubMembers(ctx)
+}
+
+// CreateSubAPIKey 为子账户创建API密钥
+func (s *BybitServiceImpl) CreateSubAPIKey(ctx context.Context, req *model.SubAPIKeyRequest) (*model.Response, error) {
+	s.logger.Debug("调用CreateSubAPIKey服务: subuid=%d", req.Subuid)
+	return s.userService.CreateSubAPIKey(ctx, req)
+}
+
+// ListSubAPIKeys 获取子账户的API密钥列表
+func (s *BybitServiceImpl) ListSubAPIKeys(ctx context.Context, subMemberId string) (*model.Response, error) {
+	s.logger.Debug("调用ListSubAPIKeys服务: subMemberId=%s", subMemberId)
+	return s.userService.ListSubAPIKeys(ctx, subMemberId)
+}
+
+// DeleteSubAPIKey 吊销子账户的API密钥
+func (s *BybitServiceImpl) DeleteSubAPIKey(ctx context.Context, apiKey string) (*model.Response, error) {
+	s.logger.Debug("调用DeleteSubAPIKey服务")
+	return s.userService.DeleteSubAPIKey(ctx, apiKey)
+}
+
+// FreezeSubMember 冻结或解冻子账户
+func (s *BybitServiceImpl) FreezeSubMember(ctx context.Context, subuid string, frozen bool) (*model.Response, error) {
+	s.logger.Debug("调用FreezeSubMember服务: subuid=%s, frozen=%v", subuid, frozen)
+	return s.userService.FreezeSubMember(ctx, subuid, frozen)
+}
+
+// 绩效分析API
+
+// GetPerformanceReport 汇总成交、平仓盈亏、资金费用和划转记录生成绩效报告
+func (s *BybitServiceImpl) GetPerformanceReport(ctx context.Context, query *model.PerformanceQuery) (*model.PerformanceReport, error) {
+	s.logger.Debug("调用GetPerformanceReport服务: category=%s, symbol=%s, startTime=%d, endTime=%d", query.Category, query.Symbol, query.StartTime, query.EndTime)
+
+	// 复制查询条件，补充默认时间区间时不修改调用方的参数
+	copied := *query
+	query = &copied
+
+	// 未指定时间区间时默认统计最近30天
+	if query.EndTime <= 0 {
+		query.EndTime = time.Now().UnixMilli()
+	}
+	if query.StartTime <= 0 {
+		query.StartTime = query.EndTime - defaultReportDays*24*60*60*1000
+	}
+
+	input := &analytics.Input{}
+
+	// 成交记录（包含资金费用）
+	err := s.positionService.WalkExecutions(ctx, &model.ExecutionQuery{
+		Category:  query.Category,
+		Symbol:    query.Symbol,
+		StartTime: query.StartTime,
+		EndTime:   query.EndTime,
+		Limit:     100,
+	}, func(executions []model.Execution) error {
+		input.Executions = append(input.Executions, executions...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 平仓盈亏
+	err = s.positionService.WalkClosedPnl(ctx, &model.ClosedPnlQuery{
+		Category:  query.Category,
+		Symbol:    query.Symbol,
+		StartTime: query.StartTime,
+		EndTime:   query.EndTime,
+		Limit:     100,
+	}, func(records []model.ClosedPnl) error {
+		input.ClosedPnl = append(input.ClosedPnl, records...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 划转记录，包括母子账户间划转，按本账户UID判断方向
+	input.Transfers, err = s.assetService.ListAllTransfers(ctx, "", "SUCCESS", query.StartTime, query.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	input.MemberId, err = s.userService.GetMemberId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 当前仓位，现货没有仓位
+	// 未指定交易对和结算币种时，USDT和USDC正向合约需要分别查询
+	if query.Category != bybitapi.CategorySpot {
+		settleCoins := []string{query.SettleCoin}
+		if query.SettleCoin == "" && query.Symbol == "" && query.Category == bybitapi.CategoryLinear {
+			settleCoins = []string{"USDT", "USDC"}
+		}
+		for _, settleCoin := range settleCoins {
+			positions, err := s.positionService.ListPositions(ctx, query.Category, query.Symbol, settleCoin)
+			if err != nil {
+				return nil, err
+			}
+			input.Positions = append(input.Positions, positions...)
+		}
+	}
+
+	// 日收盘价，用于计算每日未实现盈亏
+	if err := analytics.LoadDailyCloses(ctx, s.marketService.GetKline, query.Category, input, query.StartTime, query.EndTime); err != nil {
+		return nil, err
+	}
+
+	return analytics.BuildReport(query, input), nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// persistingService 在BybitService之上把查询到的订单和成交记录写入存储
+// 写入失败只记录日志，不影响调用结果
+type persistingService struct {
+	BybitService
+	store  storage.Store
+	logger *logger.Logger
+}
+
+// NewPersistingService 创建一个写入存储的BybitService包装
+func NewPersistingService(inner BybitService, store storage.Store, log *logger.Logger) BybitService {
+	return &persistingService{
+		BybitService: inner,
+		store:        store,
+		logger:       log,
+	}
+}
+
+// 保存订单列表
+func (s *persistingService) saveOrders(ctx context.Context, list *model.OrderList) {
+	if list == nil || len(list.List) == 0 {
+		return
+	}
+	if err := s.store.SaveOrders(ctx, list.Category, list.List); err != nil {
+		s.logger.Warn("保存订单失败: %v", err)
+	}
+}
+
+// 保存成交记录
+func (s *persistingService) saveExecutions(ctx context.Context, list *model.ExecutionList) {
+	if list == nil || len(list.List) == 0 {
+		return
+	}
+	if err := s.store.SaveExecutions(ctx, list.List); err != nil {
+		s.logger.Warn("保存成交记录失败: %v", err)
+	}
+}
+
+// GetAllOpenOrders 翻页获取全部当前委托并保存
+func (s *persistingService) GetAllOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	list, err := s.BybitService.GetAllOpenOrders(ctx, query)
+	if err == nil {
+		s.saveOrders(ctx, list)
+	}
+	return list, err
+}
+
+// GetAllOrderHistory 翻页获取全部历史订单并保存
+func (s *persistingService) GetAllOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	list, err := s.BybitService.GetAllOrderHistory(ctx, query)
+	if err == nil {
+		s.saveOrders(ctx, list)
+	}
+	return list, err
+}
+
+// GetExecutions 获取成交记录并保存
+func (s *persistingService) GetExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	list, err := s.BybitService.GetExecutions(ctx, query)
+	if err == nil {
+		s.saveExecutions(ctx, list)
+	}
+	return list, err
+}
+
+// GetAllExecutions 翻页获取全部成交记录并保存
+func (s *persistingService) GetAllExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	list, err := s.BybitService.GetAllExecutions(ctx, query)
+	if err == nil {
+		s.saveExecutions(ctx, list)
+	}
+	return list, err
+}
@@ -0,0 +1,801 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/pkg/ratelimit"
+)
+
+// 上下文中保存账户名称的键
+type accountKey struct{}
+
+// WithAccount 在上下文中设置要使用的账户名称
+func WithAccount(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, accountKey{}, name)
+}
+
+// AccountFromContext 获取上下文中的账户名称，未设置时返回空字符串
+func AccountFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(accountKey{}).(string)
+	return name
+}
+
+// 路由表中的一个账户
+type routedAccount struct {
+	service BybitService
+	limiter *ratelimit.Limiter
+	master  bool
+}
+
+// Router 根据上下文中的账户名称把调用转发到对应账户的BybitService
+// 每个账户有独立的API客户端和限流器，未指定账户时使用默认账户
+type Router struct {
+	mu          sync.RWMutex
+	accounts    map[string]*routedAccount
+	names       []string
+	defaultName string
+}
+
+// NewRouter 创建一个新的账户路由，defaultName为空时使用第一个添加的账户
+func NewRouter(defaultName string) *Router {
+	return &Router{
+		accounts:    map[string]*routedAccount{},
+		defaultName: defaultName,
+	}
+}
+
+// Add 添加一个账户，limiter为nil表示不限流，master表示是否为母账户
+func (r *Router) Add(name string, service BybitService, limiter *ratelimit.Limiter, master bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.accounts[name] = &routedAccount{service: service, limiter: limiter, master: master}
+	if r.defaultName == "" {
+		r.defaultName = name
+	}
+}
+
+// Update 在运行中替换账户的服务实例并调整限流速率，用于轮换API密钥
+// service为nil时保留原服务实例
+func (r *Router) Update(name string, service BybitService, rate float64, burst int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[name]
+	if !ok {
+		return fmt.Errorf("未知账户: %s", name)
+	}
+
+	next := *account
+	if service != nil {
+		next.service = service
+	}
+	if next.limiter == nil {
+		next.limiter = ratelimit.New(rate, burst)
+	} else {
+		next.limiter.SetRate(rate, burst)
+	}
+	r.accounts[name] = &next
+	return nil
+}
+
+// Accounts 返回全部账户名称，按添加顺序排列
+func (r *Router) Accounts() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.names))
+	copy(names, r.names)
+	return names
+}
+
+// DefaultAccount 返回默认账户名称
+func (r *Router) DefaultAccount() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.defaultName
+}
+
+// Has 判断账户是否存在
+func (r *Router) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.accounts[name]
+	return ok
+}
+
+// IsMaster 判断账户是否为母账户，name为空时判断默认账户
+func (r *Router) IsMaster(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.defaultName
+	}
+
+	account, ok := r.accounts[name]
+	return ok && account.master
+}
+
+// Account 返回指定账户的服务，name为空时返回默认账户
+// 调用前会等待该账户的限流器
+func (r *Router) Account(ctx context.Context, name string) (BybitService, error) {
+	r.mu.RLock()
+	if name == "" {
+		name = r.defaultName
+	}
+	account, ok := r.accounts[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知账户: %s", name)
+	}
+	if err := account.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return account.service, nil
+}
+
+// 选择上下文中指定的账户
+func (r *Router) pick(ctx context.Context) (BybitService, error) {
+	return r.Account(ctx, AccountFromContext(ctx))
+}
+
+// ==================== 跨账户汇总 ====================
+
+// AggregatePositions 汇总accounts中账户的仓位，单个账户失败时记录错误并继续
+// accounts通常由Accounts返回并按调用方权限过滤，查询期间新增的账户不参与本次汇总
+func (r *Router) AggregatePositions(ctx context.Context, accounts []string, category, settleCoin string) (*model.AggregatedPositions, error) {
+	result := &model.AggregatedPositions{
+		Category: category,
+		Accounts: []model.AccountPositions{},
+		BySymbol: []model.SymbolExposure{},
+	}
+	exposures := map[string]*model.SymbolExposure{}
+
+	for _, name := range accounts {
+		item := model.AccountPositions{Account: name, Positions: []model.Position{}}
+		positions, err := r.accountPositions(ctx, name, category, settleCoin)
+		if err != nil {
+			item.Error = err.Error()
+			result.Accounts = append(result.Accounts, item)
+			continue
+		}
+		item.Positions = positions
+
+		for _, position := range positions {
+			size := parseFloat(position.Size)
+			if size == 0 {
+				continue
+			}
+			exposure, ok := exposures[position.Symbol]
+			if !ok {
+				exposure = &model.SymbolExposure{Symbol: position.Symbol}
+				exposures[position.Symbol] = exposure
+			}
+			if position.Side == "Sell" {
+				exposure.ShortSize += size
+				exposure.NetSize -= size
+			} else {
+				exposure.LongSize += size
+				exposure.NetSize += size
+			}
+			exposure.PositionValue += parseFloat(position.PositionValue)
+			exposure.UnrealisedPnl += parseFloat(position.UnrealisedPnl)
+			exposure.Accounts++
+		}
+		result.Accounts = append(result.Accounts, item)
+	}
+
+	for _, exposure := range exposures {
+		result.BySymbol = append(result.BySymbol, *exposure)
+	}
+	sort.Slice(result.BySymbol, func(i, j int) bool {
+		return result.BySymbol[i].Symbol < result.BySymbol[j].Symbol
+	})
+	return result, nil
+}
+
+// 获取单个账户的仓位列表
+func (r *Router) accountPositions(ctx context.Context, name, category, settleCoin string) ([]model.Position, error) {
+	svc, err := r.Account(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.GetPositions(ctx, category, "", settleCoin, "")
+	if err != nil {
+		return nil, err
+	}
+	var positions []model.Position
+	if err := decodeList(resp, &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// AggregateBalances 汇总accounts中账户的钱包余额，单个账户失败时记录错误并继续
+func (r *Router) AggregateBalances(ctx context.Context, accounts []string, accountType string) (*model.AggregatedBalances, error) {
+	result := &model.AggregatedBalances{
+		AccountType: accountType,
+		Accounts:    []model.AccountBalance{},
+		ByCoin:      []model.CoinTotal{},
+	}
+	totals := map[string]*model.CoinTotal{}
+
+	for _, name := range accounts {
+		item := model.AccountBalance{Account: name, Balances: []model.WalletBalance{}}
+		balances, err := r.accountBalances(ctx, name, accountType)
+		if err != nil {
+			item.Error = err.Error()
+			result.Accounts = append(result.Accounts, item)
+			continue
+		}
+		item.Balances = balances
+
+		for _, balance := range balances {
+			result.TotalEquity += parseFloat(balance.TotalEquity)
+			for _, coin := range balance.Coin {
+				total, ok := totals[coin.Coin]
+				if !ok {
+					total = &model.CoinTotal{Coin: coin.Coin}
+					totals[coin.Coin] = total
+				}
+				total.Equity += parseFloat(coin.Equity)
+				total.WalletBalance += parseFloat(coin.WalletBalance)
+				total.AvailableToWithdraw += parseFloat(coin.AvailableToWithdraw)
+			}
+		}
+		result.Accounts = append(result.Accounts, item)
+	}
+
+	for _, total := range totals {
+		result.ByCoin = append(result.ByCoin, *total)
+	}
+	sort.Slice(result.ByCoin, func(i, j int) bool {
+		return result.ByCoin[i].Coin < result.ByCoin[j].Coin
+	})
+	return result, nil
+}
+
+// 获取单个账户的钱包余额
+func (r *Router) accountBalances(ctx context.Context, name, accountType string) ([]model.WalletBalance, error) {
+	svc, err := r.Account(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.GetWalletBalance(ctx, accountType, "")
+	if err != nil {
+		return nil, err
+	}
+	var balances []model.WalletBalance
+	if err := decodeList(resp, &balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// 解析数值字符串，空字符串或格式错误时返回0
+func parseFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+// ==================== BybitService转发 ====================
+
+// 市场数据API
+
+// GetKline 获取K线数据
+func (r *Router) GetKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetOrderbook 获取订单簿数据
+func (r *Router) GetOrderbook(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetOrderbook(ctx, category, symbol, limit)
+}
+
+// GetTickers 获取行情数据
+func (r *Router) GetTickers(ctx context.Context, category, symbol string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetTickers(ctx, category, symbol)
+}
+
+// GetInstruments 获取交易对信息
+func (r *Router) GetInstruments(ctx context.Context, category, symbol, status string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetInstruments(ctx, category, symbol, status)
+}
+
+// GetRecentTrades 获取最近成交
+func (r *Router) GetRecentTrades(ctx context.Context, category, symbol string, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetRecentTrades(ctx, category, symbol, limit)
+}
+
+// GetMarkPriceKline 获取标记价格K线
+func (r *Router) GetMarkPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetMarkPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetIndexPriceKline 获取指数价格K线
+func (r *Router) GetIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetIndexPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetPremiumIndexPriceKline 获取溢价指数K线
+func (r *Router) GetPremiumIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetPremiumIndexPriceKline(ctx, category, symbol, interval, limit, start, end)
+}
+
+// GetFundingRateHistory 获取资金费率历史
+func (r *Router) GetFundingRateHistory(ctx context.Context, category, symbol string, startTime, endTime int64, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetFundingRateHistory(ctx, category, symbol, startTime, endTime, limit)
+}
+
+// GetOpenInterest 获取持仓量
+func (r *Router) GetOpenInterest(ctx context.Context, category, symbol, intervalTime string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetOpenInterest(ctx, category, symbol, intervalTime, startTime, endTime, limit, cursor)
+}
+
+// GetLongShortRatio 获取多空持仓人数比
+func (r *Router) GetLongShortRatio(ctx context.Context, category, symbol, period string, startTime, endTime int64, limit int, cursor string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetLongShortRatio(ctx, category, symbol, period, startTime, endTime, limit, cursor)
+}
+
+// GetHistoricalVolatility 获取期权历史波动率
+func (r *Router) GetHistoricalVolatility(ctx context.Context, category, baseCoin string, period int, startTime, endTime int64) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetHistoricalVolatility(ctx, category, baseCoin, period, startTime, endTime)
+}
+
+// GetInsurance 获取保险基金余额
+func (r *Router) GetInsurance(ctx context.Context, coin string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetInsurance(ctx, coin)
+}
+
+// GetRiskLimit 获取风险限额档位
+func (r *Router) GetRiskLimit(ctx context.Context, category, symbol, cursor string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetRiskLimit(ctx, category, symbol, cursor)
+}
+
+// GetDeliveryPrice 获取交割价格
+func (r *Router) GetDeliveryPrice(ctx context.Context, category, symbol, baseCoin string, limit int, cursor string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetDeliveryPrice(ctx, category, symbol, baseCoin, limit, cursor)
+}
+
+// GetOptionTickers 获取期权行情
+func (r *Router) GetOptionTickers(ctx context.Context, baseCoin, expDate string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetOptionTickers(ctx, baseCoin, expDate)
+}
+
+// GetServerTime 获取服务器时间
+func (r *Router) GetServerTime(ctx context.Context) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetServerTime(ctx)
+}
+
+// 订单管理API
+
+// CreateOrder 创建订单
+func (r *Router) CreateOrder(ctx context.Context, category, symbol, side, orderType string, qty float64, price float64, options map[string]string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.CreateOrder(ctx, category, symbol, side, orderType, qty, price, options)
+}
+
+// CancelOrder 取消订单
+func (r *Router) CancelOrder(ctx context.Context, category, symbol, orderId, orderLinkId string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.CancelOrder(ctx, category, symbol, orderId, orderLinkId)
+}
+
+// CancelAllOrders 取消全部订单
+func (r *Router) CancelAllOrders(ctx context.Context, category, symbol, settleCoin string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.CancelAllOrders(ctx, category, symbol, settleCoin)
+}
+
+// GetOrders 获取订单列表
+func (r *Router) GetOrders(ctx context.Context, category, symbol, orderId, orderLinkId, orderStatus string, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetOrders(ctx, category, symbol, orderId, orderLinkId, orderStatus, limit)
+}
+
+// AmendOrder 修改订单
+func (r *Router) AmendOrder(ctx context.Context, category, symbol, orderId, orderLinkId string, qty float64, price float64, options map[string]string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.AmendOrder(ctx, category, symbol, orderId, orderLinkId, qty, price, options)
+}
+
+// GetOpenOrders 获取当前委托（单页）
+func (r *Router) GetOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetOpenOrders(ctx, query)
+}
+
+// GetOrderHistory 获取历史订单（单页）
+func (r *Router) GetOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetOrderHistory(ctx, query)
+}
+
+// GetAllOpenOrders 翻页获取全部当前委托
+func (r *Router) GetAllOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetAllOpenOrders(ctx, query)
+}
+
+// GetAllOrderHistory 翻页获取全部历史订单
+func (r *Router) GetAllOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetAllOrderHistory(ctx, query)
+}
+
+// 仓位管理API
+
+// GetPositions 获取仓位列表
+func (r *Router) GetPositions(ctx context.Context, category, symbol, settleCoin, positionIdx string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetPositions(ctx, category, symbol, settleCoin, positionIdx)
+}
+
+// SetLeverage 设置杠杆
+func (r *Router) SetLeverage(ctx context.Context, category, symbol string, buyLeverage, sellLeverage float64) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.SetLeverage(ctx, category, symbol, buyLeverage, sellLeverage)
+}
+
+// SetTradingStop 设置止盈止损
+func (r *Router) SetTradingStop(ctx context.Context, category, symbol string, takeProfit, stopLoss float64, options map[string]string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.SetTradingStop(ctx, category, symbol, takeProfit, stopLoss, options)
+}
+
+// SwitchPositionMode 切换持仓模式
+func (r *Router) SwitchPositionMode(ctx context.Context, category, symbol, mode string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.SwitchPositionMode(ctx, category, symbol, mode)
+}
+
+// SetTpSlMode 设置止盈止损模式
+func (r *Router) SetTpSlMode(ctx context.Context, category, symbol, tpSlMode string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.SetTpSlMode(ctx, category, symbol, tpSlMode)
+}
+
+// SetRiskLimit 设置风险限额档位
+func (r *Router) SetRiskLimit(ctx context.Context, category, symbol string, riskId int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.SetRiskLimit(ctx, category, symbol, riskId)
+}
+
+// GetExecutions 获取成交记录（单页）
+func (r *Router) GetExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetExecutions(ctx, query)
+}
+
+// GetAllExecutions 翻页获取全部成交记录
+func (r *Router) GetAllExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetAllExecutions(ctx, query)
+}
+
+// GetClosedPnl 获取平仓盈亏（单页）
+func (r *Router) GetClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetClosedPnl(ctx, query)
+}
+
+// GetAllClosedPnl 翻页获取全部平仓盈亏
+func (r *Router) GetAllClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetAllClosedPnl(ctx, query)
+}
+
+// 账户管理API
+
+// GetWalletBalance 获取钱包余额
+func (r *Router) GetWalletBalance(ctx context.Context, accountType, coin string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetWalletBalance(ctx, accountType, coin)
+}
+
+// GetFeeRate 获取手续费率
+func (r *Router) GetFeeRate(ctx context.Context, category, symbol string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetFeeRate(ctx, category, symbol)
+}
+
+// GetAccountInfo 获取账户信息
+func (r *Router) GetAccountInfo(ctx context.Context) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetAccountInfo(ctx)
+}
+
+// SetMarginMode 设置保证金模式
+func (r *Router) SetMarginMode(ctx context.Context, marginMode string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.SetMarginMode(ctx, marginMode)
+}
+
+// 资产管理API
+
+// GetCoinBalance 获取币种余额
+func (r *Router) GetCoinBalance(ctx context.Context, coin, accountType string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetCoinBalance(ctx, coin, accountType)
+}
+
+// TransferAsset 资产划转
+func (r *Router) TransferAsset(ctx context.Context, transferId, coin, amount, fromAccountType, toAccountType string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.TransferAsset(ctx, transferId, coin, amount, fromAccountType, toAccountType)
+}
+
+// GetTransferHistory 获取划转历史
+func (r *Router) GetTransferHistory(ctx context.Context, transferId, coin, status string, startTime, endTime int64, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetTransferHistory(ctx, transferId, coin, status, startTime, endTime, limit)
+}
+
+// GetDepositHistory 获取充值记录
+func (r *Router) GetDepositHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetDepositHistory(ctx, coin, startTime, endTime, limit)
+}
+
+// GetWithdrawalHistory 获取提现记录
+func (r *Router) GetWithdrawalHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetWithdrawalHistory(ctx, coin, startTime, endTime, limit)
+}
+
+// Withdraw 提现
+func (r *Router) Withdraw(ctx context.Context, coin, chain, address, tag, amount string, options map[string]string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.Withdraw(ctx, coin, chain, address, tag, amount, options)
+}
+
+// UniversalTransfer 母子账户之间划转
+func (r *Router) UniversalTransfer(ctx context.Context, req *model.UniversalTransferRequest) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.UniversalTransfer(ctx, req)
+}
+
+// ListAllTransfers 获取账户内划转和母子账户间划转记录
+func (r *Router) ListAllTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ListAllTransfers(ctx, coin, status, startTime, endTime)
+}
+
+// 子账户管理API（仅限母账户）
+
+// CreateSubMember 创建子账户
+func (r *Router) CreateSubMember(ctx context.Context, req *model.SubMemberRequest) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.CreateSubMember(ctx, req)
+}
+
+// ListSubMembers 获取子账户列表
+func (r *Router) ListSubMembers(ctx context.Context) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ListSubMembers(ctx)
+}
+
+// CreateSubAPIKey 为子账户创建API密钥
+func (r *Router) CreateSubAPIKey(ctx context.Context, req *model.SubAPIKeyRequest) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.CreateSubAPIKey(ctx, req)
+}
+
+// ListSubAPIKeys 获取子账户的API密钥列表
+func (r *Router) ListSubAPIKeys(ctx context.Context, subMemberId string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ListSubAPIKeys(ctx, subMemberId)
+}
+
+// DeleteSubAPIKey 吊销子账户的API密钥
+func (r *Router) DeleteSubAPIKey(ctx context.Context, apiKey string) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.DeleteSubAPIKey(ctx, apiKey)
+}
+
+// FreezeSubMember 冻结或解冻子账户
+func (r *Router) FreezeSubMember(ctx context.Context, subuid string, frozen bool) (*model.Response, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.FreezeSubMember(ctx, subuid, frozen)
+}
+
+// 绩效分析API
+
+// GetPerformanceReport 汇总成交、平仓盈亏、资金费用和划转记录生成绩效报告
+func (r *Router) GetPerformanceReport(ctx context.Context, query *model.PerformanceQuery) (*model.PerformanceReport, error) {
+	svc, err := r.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return svc.GetPerformanceReport(ctx, query)
+}
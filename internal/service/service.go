@@ -6,26 +6,55 @@ import (
 	"github.com/bybit-mcp/internal/model"
 )
 
-// BybitService 是Bybit MCP服务的接口定义
-// 它包含了所有Bybit V5 API的主要功能模块
-type BybitService interface {
-	// 市场数据API
+// MarketDataService 是公共行情接口，不需要API密钥
+type MarketDataService interface {
 	GetKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error)
 	GetOrderbook(ctx context.Context, category, symbol string, limit int) (*model.Response, error)
 	GetTickers(ctx context.Context, category, symbol string) (*model.Response, error)
 	GetInstruments(ctx context.Context, category, symbol, status string) (*model.Response, error)
+	GetRecentTrades(ctx context.Context, category, symbol string, limit int) (*model.Response, error)
+	GetMarkPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error)
+	GetIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error)
+	GetPremiumIndexPriceKline(ctx context.Context, category, symbol, interval string, limit int, start, end int64) (*model.Response, error)
+	GetFundingRateHistory(ctx context.Context, category, symbol string, startTime, endTime int64, limit int) (*model.Response, error)
+	GetOpenInterest(ctx context.Context, category, symbol, intervalTime string, startTime, endTime int64, limit int, cursor string) (*model.Response, error)
+	GetLongShortRatio(ctx context.Context, category, symbol, period string, startTime, endTime int64, limit int, cursor string) (*model.Response, error)
+	GetHistoricalVolatility(ctx context.Context, category, baseCoin string, period int, startTime, endTime int64) (*model.Response, error)
+	GetInsurance(ctx context.Context, coin string) (*model.Response, error)
+	GetRiskLimit(ctx context.Context, category, symbol, cursor string) (*model.Response, error)
+	GetDeliveryPrice(ctx context.Context, category, symbol, baseCoin string, limit int, cursor string) (*model.Response, error)
+	GetOptionTickers(ctx context.Context, baseCoin, expDate string) (*model.Response, error)
+	GetServerTime(ctx context.Context) (*model.Response, error)
+}
+
+// BybitService 是Bybit MCP服务的接口定义
+// 它包含了所有Bybit V5 API的主要功能模块
+type BybitService interface {
+	// 市场数据API
+	MarketDataService
 
 	// 订单管理API
 	CreateOrder(ctx context.Context, category, symbol, side, orderType string, qty float64, price float64, options map[string]string) (*model.Response, error)
 	CancelOrder(ctx context.Context, category, symbol, orderId, orderLinkId string) (*model.Response, error)
+	CancelAllOrders(ctx context.Context, category, symbol, settleCoin string) (*model.Response, error)
 	GetOrders(ctx context.Context, category, symbol, orderId, orderLinkId, orderStatus string, limit int) (*model.Response, error)
 	AmendOrder(ctx context.Context, category, symbol, orderId, orderLinkId string, qty float64, price float64, options map[string]string) (*model.Response, error)
+	GetOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.Response, error)
+	GetOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.Response, error)
+	GetAllOpenOrders(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error)
+	GetAllOrderHistory(ctx context.Context, query *model.OrderQuery) (*model.OrderList, error)
 
 	// 仓位管理API
 	GetPositions(ctx context.Context, category, symbol, settleCoin, positionIdx string) (*model.Response, error)
 	SetLeverage(ctx context.Context, category, symbol string, buyLeverage, sellLeverage float64) (*model.Response, error)
 	SetTradingStop(ctx context.Context, category, symbol string, takeProfit, stopLoss float64, options map[string]string) (*model.Response, error)
 	SwitchPositionMode(ctx context.Context, category, symbol, mode string) (*model.Response, error)
+	SetTpSlMode(ctx context.Context, category, symbol, tpSlMode string) (*model.Response, error)
+	SetRiskLimit(ctx context.Context, category, symbol string, riskId int) (*model.Response, error)
+	GetExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error)
+	GetAllExecutions(ctx context.Context, query *model.ExecutionQuery) (*model.ExecutionList, error)
+	GetClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error)
+	GetAllClosedPnl(ctx context.Context, query *model.ClosedPnlQuery) (*model.ClosedPnlList, error)
 
 	// 账户管理API
 	GetWalletBalance(ctx context.Context, accountType, coin string) (*model.Response, error)
@@ -37,7 +66,22 @@ type BybitService interface {
 	GetCoinBalance(ctx context.Context, coin, accountType string) (*model.Response, error)
 	TransferAsset(ctx context.Context, transferId, coin, amount, fromAccountType, toAccountType string) (*model.Response, error)
 	GetTransferHistory(ctx context.Context, transferId, coin, status string, startTime, endTime int64, limit int) (*model.Response, error)
+	GetDepositHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error)
+	GetWithdrawalHistory(ctx context.Context, coin string, startTime, endTime int64, limit int) (*model.Response, error)
 	Withdraw(ctx context.Context, coin, chain, address, tag, amount string, options map[string]string) (*model.Response, error)
+	UniversalTransfer(ctx context.Context, req *model.UniversalTransferRequest) (*model.Response, error)
+	ListAllTransfers(ctx context.Context, coin, status string, startTime, endTime int64) ([]model.Transfer, error)
+
+	// 子账户管理API（仅限母账户）
+	CreateSubMember(ctx context.Context, req *model.SubMemberRequest) (*model.Response, error)
+	ListSubMembers(ctx context.Context) (*model.Response, error)
+	CreateSubAPIKey(ctx context.Context, req *model.SubAPIKeyRequest) (*model.Response, error)
+	ListSubAPIKeys(ctx context.Context, subMemberId string) (*model.Response, error)
+	DeleteSubAPIKey(ctx context.Context, apiKey string) (*model.Response, error)
+	FreezeSubMember(ctx context.Context, subuid string, frozen bool) (*model.Response, error)
+
+	// 绩效分析API
+	GetPerformanceReport(ctx context.Context, query *model.PerformanceQuery) (*model.PerformanceReport, error)
 }
 
 // 以下是旧版接口定义，已被整合到BybitService接口中
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bybit-mcp/internal/api/pagination"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/bybitapi"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// Snapshotter 定时保存仓位和钱包余额快照
+type Snapshotter struct {
+	service    BybitService
+	store      storage.Store
+	logger     *logger.Logger
+	interval   time.Duration
+	categories []string
+	accounts   []string
+}
+
+// NewSnapshotter 创建一个新的快照任务
+func NewSnapshotter(service BybitService, store storage.Store, interval time.Duration, log *logger.Logger) *Snapshotter {
+	return &Snapshotter{
+		service:    service,
+		store:      store,
+		logger:     log,
+		interval:   interval,
+		categories: []string{bybitapi.CategoryLinear, bybitapi.CategoryInverse, bybitapi.CategoryOption},
+		accounts:   []string{"UNIFIED"},
+	}
+}
+
+// Run 按间隔执行快照，直到上下文结束
+func (s *Snapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Snapshot(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot 立即执行一次快照
+func (s *Snapshotter) Snapshot(ctx context.Context) {
+	now := time.Now().UnixMilli()
+
+	for _, category := range s.categories {
+		settleCoin := ""
+		if category == bybitapi.CategoryLinear {
+			settleCoin = "USDT"
+		}
+		resp, err := s.service.GetPositions(ctx, category, "", settleCoin, "")
+		if err != nil {
+			s.logger.Warn("获取仓位快照失败: category=%s, err=%v", category, err)
+			continue
+		}
+
+		var positions []model.Position
+		if err := decodeList(resp, &positions); err != nil {
+			s.logger.Warn("解析仓位快照失败: category=%s, err=%v", category, err)
+			continue
+		}
+
+		snapshot := &storage.PositionSnapshot{TakenAt: now, Category: category, Positions: positions}
+		if err := s.store.SavePositionSnapshot(ctx, snapshot); err != nil {
+			s.logger.Warn("保存仓位快照失败: %v", err)
+		}
+	}
+
+	for _, accountType := range s.accounts {
+		resp, err := s.service.GetWalletBalance(ctx, accountType, "")
+		if err != nil {
+			s.logger.Warn("获取钱包快照失败: accountType=%s, err=%v", accountType, err)
+			continue
+		}
+
+		var balances []model.WalletBalance
+		if err := decodeList(resp, &balances); err != nil {
+			s.logger.Warn("解析钱包快照失败: accountType=%s, err=%v", accountType, err)
+			continue
+		}
+
+		snapshot := &storage.WalletSnapshot{TakenAt: now, AccountType: accountType, Balances: balances}
+		if err := s.store.SaveWalletSnapshot(ctx, snapshot); err != nil {
+			s.logger.Warn("保存钱包快照失败: %v", err)
+		}
+	}
+}
+
+// 解析响应中result.list字段
+func decodeList(resp *model.Response, v interface{}) error {
+	page, err := pagination.DecodeResult(resp)
+	if err != nil {
+		return err
+	}
+	if len(page.List) == 0 {
+		return nil
+	}
+	return json.Unmarshal(page.List, v)
+}
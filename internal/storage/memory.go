@@ -0,0 +1,369 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+)
+
+// MemoryStore 是基于内存的存储实现，进程退出后数据丢失
+type MemoryStore struct {
+	mu         sync.RWMutex
+	orders     map[string]OrderRecord
+	executions map[string]model.Execution
+	positions  []PositionSnapshot
+	wallets    []WalletSnapshot
+	audits     []AuditRecord
+	withdraws  map[string]model.WithdrawalRequest
+	whitelist  map[string]int64
+	alerts     map[string]model.Alert
+}
+
+// NewMemoryStore 创建一个新的内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		orders:     map[string]OrderRecord{},
+		executions: map[string]model.Execution{},
+		withdraws:  map[string]model.WithdrawalRequest{},
+		whitelist:  map[string]int64{},
+		alerts:     map[string]model.Alert{},
+	}
+}
+
+// SaveOrders 保存订单，相同订单ID的记录会被覆盖
+func (m *MemoryStore) SaveOrders(ctx context.Context, category string, orders []model.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	for _, order := range orders {
+		m.orders[order.OrderId] = OrderRecord{Category: category, Order: order, SavedAt: now}
+	}
+	return nil
+}
+
+// ListOrders 查询订单，按创建时间倒序
+func (m *MemoryStore) ListOrders(ctx context.Context, filter OrderFilter) ([]OrderRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := []OrderRecord{}
+	for _, record := range m.orders {
+		created, _ := strconv.ParseInt(record.Order.CreatedTime, 10, 64)
+		if filter.Category != "" && record.Category != filter.Category {
+			continue
+		}
+		if filter.Symbol != "" && record.Order.Symbol != filter.Symbol {
+			continue
+		}
+		if filter.OrderStatus != "" && record.Order.OrderStatus != filter.OrderStatus {
+			continue
+		}
+		if !inRange(created, filter.StartTime, filter.EndTime) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		ci, _ := strconv.ParseInt(records[i].Order.CreatedTime, 10, 64)
+		cj, _ := strconv.ParseInt(records[j].Order.CreatedTime, 10, 64)
+		if ci != cj {
+			return ci > cj
+		}
+		return records[i].Order.OrderId > records[j].Order.OrderId
+	})
+
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+// SaveExecutions 保存成交记录，相同成交ID的记录会被忽略
+func (m *MemoryStore) SaveExecutions(ctx context.Context, executions []model.Execution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, exec := range executions {
+		if _, ok := m.executions[exec.ExecId]; !ok {
+			m.executions[exec.ExecId] = exec
+		}
+	}
+	return nil
+}
+
+// ListExecutions 查询成交记录，按成交时间倒序
+func (m *MemoryStore) ListExecutions(ctx context.Context, filter ExecutionFilter) ([]model.Execution, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	executions := []model.Execution{}
+	for _, exec := range m.executions {
+		if filter.Category != "" && exec.Category != filter.Category {
+			continue
+		}
+		if filter.Symbol != "" && exec.Symbol != filter.Symbol {
+			continue
+		}
+		if filter.ExecType != "" && exec.ExecType != filter.ExecType {
+			continue
+		}
+		if !inRange(exec.ExecTime, filter.StartTime, filter.EndTime) {
+			continue
+		}
+		executions = append(executions, exec)
+	}
+
+	sort.Slice(executions, func(i, j int) bool {
+		if executions[i].ExecTime != executions[j].ExecTime {
+			return executions[i].ExecTime > executions[j].ExecTime
+		}
+		return executions[i].ExecId > executions[j].ExecId
+	})
+
+	if filter.Limit > 0 && len(executions) > filter.Limit {
+		executions = executions[:filter.Limit]
+	}
+	return executions, nil
+}
+
+// SavePositionSnapshot 保存仓位快照
+func (m *MemoryStore) SavePositionSnapshot(ctx context.Context, snapshot *PositionSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot.ID = int64(len(m.positions) + 1)
+	m.positions = append(m.positions, *snapshot)
+	return nil
+}
+
+// ListPositionSnapshots 查询仓位快照，按时间倒序
+func (m *MemoryStore) ListPositionSnapshots(ctx context.Context, filter SnapshotFilter) ([]PositionSnapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshots := []PositionSnapshot{}
+	for i := len(m.positions) - 1; i >= 0; i-- {
+		snapshot := m.positions[i]
+		if filter.Key != "" && snapshot.Category != filter.Key {
+			continue
+		}
+		if !inRange(snapshot.TakenAt, filter.StartTime, filter.EndTime) {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+		if filter.Limit > 0 && len(snapshots) >= filter.Limit {
+			break
+		}
+	}
+	return snapshots, nil
+}
+
+// SaveWalletSnapshot 保存钱包快照
+func (m *MemoryStore) SaveWalletSnapshot(ctx context.Context, snapshot *WalletSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot.ID = int64(len(m.wallets) + 1)
+	m.wallets = append(m.wallets, *snapshot)
+	return nil
+}
+
+// ListWalletSnapshots 查询钱包快照，按时间倒序
+func (m *MemoryStore) ListWalletSnapshots(ctx context.Context, filter SnapshotFilter) ([]WalletSnapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshots := []WalletSnapshot{}
+	for i := len(m.wallets) - 1; i >= 0; i-- {
+		snapshot := m.wallets[i]
+		if filter.Key != "" && snapshot.AccountType != filter.Key {
+			continue
+		}
+		if !inRange(snapshot.TakenAt, filter.StartTime, filter.EndTime) {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+		if filter.Limit > 0 && len(snapshots) >= filter.Limit {
+			break
+		}
+	}
+	return snapshots, nil
+}
+
+// AppendAudit 追加审计记录
+func (m *MemoryStore) AppendAudit(ctx context.Context, record *AuditRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record.ID = int64(len(m.audits) + 1)
+	m.audits = append(m.audits, *record)
+	return nil
+}
+
+// ListAudit 查询审计记录，按ID升序
+func (m *MemoryStore) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := []AuditRecord{}
+	for _, record := range m.audits {
+		if record.ID <= filter.AfterID {
+			continue
+		}
+		if filter.Caller != "" && record.Caller != filter.Caller {
+			continue
+		}
+		if filter.Method != "" && record.Method != filter.Method {
+			continue
+		}
+		if !inRange(record.Time, filter.StartTime, filter.EndTime) {
+			continue
+		}
+		records = append(records, record)
+		if filter.Limit > 0 && len(records) >= filter.Limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+// LastAudit 返回最新的审计记录，没有记录时返回nil
+func (m *MemoryStore) LastAudit(ctx context.Context) (*AuditRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.audits) == 0 {
+		return nil, nil
+	}
+	record := m.audits[len(m.audits)-1]
+	return &record, nil
+}
+
+// SaveWithdrawal 保存提现申请，相同ID的申请会被覆盖
+func (m *MemoryStore) SaveWithdrawal(ctx context.Context, request *model.WithdrawalRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.withdraws[request.ID] = *request
+	return nil
+}
+
+// GetWithdrawal 获取提现申请，不存在时返回nil
+func (m *MemoryStore) GetWithdrawal(ctx context.Context, id string) (*model.WithdrawalRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	request, ok := m.withdraws[id]
+	if !ok {
+		return nil, nil
+	}
+	return &request, nil
+}
+
+// ListWithdrawals 查询提现申请，按申请时间倒序
+func (m *MemoryStore) ListWithdrawals(ctx context.Context, filter WithdrawalFilter) ([]model.WithdrawalRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	requests := []model.WithdrawalRequest{}
+	for _, request := range m.withdraws {
+		if filter.Status != "" && request.Status != filter.Status {
+			continue
+		}
+		if filter.Coin != "" && request.Coin != filter.Coin {
+			continue
+		}
+		if !inRange(request.RequestedAt, filter.StartTime, filter.EndTime) {
+			continue
+		}
+		requests = append(requests, request)
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].RequestedAt != requests[j].RequestedAt {
+			return requests[i].RequestedAt > requests[j].RequestedAt
+		}
+		return requests[i].ID > requests[j].ID
+	})
+
+	if filter.Limit > 0 && len(requests) > filter.Limit {
+		requests = requests[:filter.Limit]
+	}
+	return requests, nil
+}
+
+// WhitelistFirstSeen 返回白名单地址首次出现的时间
+func (m *MemoryStore) WhitelistFirstSeen(ctx context.Context, key string, now int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if seen, ok := m.whitelist[key]; ok {
+		return seen, nil
+	}
+	m.whitelist[key] = now
+	return now, nil
+}
+
+// PruneWhitelist 删除不在keep中的白名单地址记录
+func (m *MemoryStore) PruneWhitelist(ctx context.Context, keep []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := make(map[string]bool, len(keep))
+	for _, key := range keep {
+		kept[key] = true
+	}
+	for key := range m.whitelist {
+		if !kept[key] {
+			delete(m.whitelist, key)
+		}
+	}
+	return nil
+}
+
+// SaveAlert 保存告警规则，相同ID的规则会被覆盖
+func (m *MemoryStore) SaveAlert(ctx context.Context, alert *model.Alert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.alerts[alert.ID] = *alert
+	return nil
+}
+
+// DeleteAlert 删除告警规则，不存在时不返回错误
+func (m *MemoryStore) DeleteAlert(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.alerts, id)
+	return nil
+}
+
+// ListAlerts 返回全部告警规则，按创建时间正序
+func (m *MemoryStore) ListAlerts(ctx context.Context) ([]model.Alert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	alerts := make([]model.Alert, 0, len(m.alerts))
+	for _, alert := range m.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].CreatedAt != alerts[j].CreatedAt {
+			return alerts[i].CreatedAt < alerts[j].CreatedAt
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+	return alerts, nil
+}
+
+// Close 关闭存储
+func (m *MemoryStore) Close() error {
+	return nil
+}
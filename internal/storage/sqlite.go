@@ -0,0 +1,611 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bybit-mcp/internal/model"
+
+	// 纯Go实现的SQLite驱动，无需CGO
+	_ "modernc.org/sqlite"
+)
+
+// 数据库迁移脚本，按版本顺序执行，已发布的脚本不可修改
+var migrations = []string{
+	// 版本1：初始表结构
+	`CREATE TABLE orders (
+		order_id     TEXT PRIMARY KEY,
+		category     TEXT NOT NULL,
+		symbol       TEXT NOT NULL,
+		order_status TEXT NOT NULL,
+		created_time INTEGER NOT NULL,
+		saved_at     INTEGER NOT NULL,
+		data         TEXT NOT NULL
+	);
+	CREATE INDEX idx_orders_symbol ON orders (category, symbol, created_time);
+
+	CREATE TABLE executions (
+		exec_id   TEXT PRIMARY KEY,
+		category  TEXT NOT NULL,
+		symbol    TEXT NOT NULL,
+		exec_type TEXT NOT NULL,
+		exec_time INTEGER NOT NULL,
+		data      TEXT NOT NULL
+	);
+	CREATE INDEX idx_executions_symbol ON executions (category, symbol, exec_time);
+
+	CREATE TABLE position_snapshots (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		taken_at INTEGER NOT NULL,
+		category TEXT NOT NULL,
+		data     TEXT NOT NULL
+	);
+	CREATE INDEX idx_position_snapshots_time ON position_snapshots (category, taken_at);
+
+	CREATE TABLE wallet_snapshots (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		taken_at     INTEGER NOT NULL,
+		account_type TEXT NOT NULL,
+		data         TEXT NOT NULL
+	);
+	CREATE INDEX idx_wallet_snapshots_time ON wallet_snapshots (account_type, taken_at);
+
+	CREATE TABLE audit_records (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		time       INTEGER NOT NULL,
+		caller     TEXT NOT NULL,
+		method     TEXT NOT NULL,
+		request    TEXT NOT NULL,
+		ret_code   INTEGER NOT NULL,
+		ret_msg    TEXT NOT NULL,
+		error      TEXT NOT NULL,
+		latency_ms INTEGER NOT NULL
+	);
+	CREATE INDEX idx_audit_records_time ON audit_records (time);`,
+
+	// 版本2：审计记录增加风控结果和哈希链
+	`ALTER TABLE audit_records ADD COLUMN risk_result TEXT NOT NULL DEFAULT '';
+	ALTER TABLE audit_records ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+	ALTER TABLE audit_records ADD COLUMN hash TEXT NOT NULL DEFAULT '';`,
+
+	// 版本3：提现申请和白名单地址首次出现时间
+	`CREATE TABLE withdrawal_requests (
+		id           TEXT PRIMARY KEY,
+		coin         TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		requested_at INTEGER NOT NULL,
+		data         TEXT NOT NULL
+	);
+	CREATE INDEX idx_withdrawal_requests_status ON withdrawal_requests (status, requested_at);
+
+	CREATE TABLE whitelist_entries (
+		key        TEXT PRIMARY KEY,
+		first_seen INTEGER NOT NULL
+	);`,
+
+	// 版本4：告警规则
+	`CREATE TABLE alerts (
+		id         TEXT PRIMARY KEY,
+		account    TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		data       TEXT NOT NULL
+	);`,
+}
+
+// SQLiteStore 是基于SQLite的存储实现
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite 打开SQLite数据库并执行迁移
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未配置SQLite数据库路径")
+	}
+
+	// 确保目录存在
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("无法创建数据目录: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("无法打开数据库: %v", err)
+	}
+	// SQLite同一时间只允许一个写连接
+	db.SetMaxOpenConns(1)
+
+	store := &SQLiteStore{db: db}
+	if err := store.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// 执行尚未应用的迁移脚本
+func (s *SQLiteStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("无法创建迁移表: %v", err)
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("无法读取迁移版本: %v", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("数据库版本%d高于程序支持的版本%d", current, len(migrations))
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("无法开始迁移事务: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行迁移%d失败: %v", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UnixMilli()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("记录迁移%d失败: %v", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交迁移%d失败: %v", version, err)
+		}
+	}
+
+	return nil
+}
+
+// 构建WHERE子句
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// 添加等值条件，值为空时忽略
+func (w *whereBuilder) eq(column, value string) {
+	if value != "" {
+		w.conds = append(w.conds, column+" = ?")
+		w.args = append(w.args, value)
+	}
+}
+
+// 添加时间区间条件，0表示不限制
+func (w *whereBuilder) between(column string, start, end int64) {
+	if start > 0 {
+		w.conds = append(w.conds, column+" >= ?")
+		w.args = append(w.args, start)
+	}
+	if end > 0 {
+		w.conds = append(w.conds, column+" <= ?")
+		w.args = append(w.args, end)
+	}
+}
+
+// 生成WHERE、ORDER BY和LIMIT子句
+func (w *whereBuilder) sql(orderBy string, limit int) string {
+	var b strings.Builder
+	if len(w.conds) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(w.conds, " AND "))
+	}
+	b.WriteString(" ORDER BY ")
+	b.WriteString(orderBy)
+	if limit > 0 {
+		b.WriteString(" LIMIT ")
+		b.WriteString(strconv.Itoa(limit))
+	}
+	return b.String()
+}
+
+// SaveOrders 保存订单，相同订单ID的记录会被更新
+func (s *SQLiteStore) SaveOrders(ctx context.Context, category string, orders []model.Order) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixMilli()
+	for _, order := range orders {
+		data, err := json.Marshal(order)
+		if err != nil {
+			return err
+		}
+		created, _ := strconv.ParseInt(order.CreatedTime, 10, 64)
+		if _, err := tx.ExecContext(ctx, `INSERT INTO orders (order_id, category, symbol, order_status, created_time, saved_at, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (order_id) DO UPDATE SET order_status = excluded.order_status, saved_at = excluded.saved_at, data = excluded.data`,
+			order.OrderId, category, order.Symbol, order.OrderStatus, created, now, string(data)); err != nil {
+			return fmt.Errorf("保存订单失败: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListOrders 查询订单，按创建时间倒序
+func (s *SQLiteStore) ListOrders(ctx context.Context, filter OrderFilter) ([]OrderRecord, error) {
+	var w whereBuilder
+	w.eq("category", filter.Category)
+	w.eq("symbol", filter.Symbol)
+	w.eq("order_status", filter.OrderStatus)
+	w.between("created_time", filter.StartTime, filter.EndTime)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT category, saved_at, data FROM orders`+w.sql("created_time DESC, order_id DESC", filter.Limit), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %v", err)
+	}
+	defer rows.Close()
+
+	records := []OrderRecord{}
+	for rows.Next() {
+		var record OrderRecord
+		var data string
+		if err := rows.Scan(&record.Category, &record.SavedAt, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &record.Order); err != nil {
+			return nil, fmt.Errorf("解析订单数据失败: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// SaveExecutions 保存成交记录，相同成交ID的记录会被忽略
+func (s *SQLiteStore) SaveExecutions(ctx context.Context, executions []model.Execution) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, exec := range executions {
+		data, err := json.Marshal(exec)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO executions (exec_id, category, symbol, exec_type, exec_time, data)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			exec.ExecId, exec.Category, exec.Symbol, exec.ExecType, exec.ExecTime, string(data)); err != nil {
+			return fmt.Errorf("保存成交记录失败: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListExecutions 查询成交记录，按成交时间倒序
+func (s *SQLiteStore) ListExecutions(ctx context.Context, filter ExecutionFilter) ([]model.Execution, error) {
+	var w whereBuilder
+	w.eq("category", filter.Category)
+	w.eq("symbol", filter.Symbol)
+	w.eq("exec_type", filter.ExecType)
+	w.between("exec_time", filter.StartTime, filter.EndTime)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM executions`+w.sql("exec_time DESC, exec_id DESC", filter.Limit), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询成交记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	executions := []model.Execution{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var exec model.Execution
+		if err := json.Unmarshal([]byte(data), &exec); err != nil {
+			return nil, fmt.Errorf("解析成交数据失败: %v", err)
+		}
+		executions = append(executions, exec)
+	}
+	return executions, rows.Err()
+}
+
+// SavePositionSnapshot 保存仓位快照
+func (s *SQLiteStore) SavePositionSnapshot(ctx context.Context, snapshot *PositionSnapshot) error {
+	data, err := json.Marshal(snapshot.Positions)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO position_snapshots (taken_at, category, data) VALUES (?, ?, ?)`,
+		snapshot.TakenAt, snapshot.Category, string(data))
+	if err != nil {
+		return fmt.Errorf("保存仓位快照失败: %v", err)
+	}
+	snapshot.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListPositionSnapshots 查询仓位快照，按时间倒序
+func (s *SQLiteStore) ListPositionSnapshots(ctx context.Context, filter SnapshotFilter) ([]PositionSnapshot, error) {
+	var w whereBuilder
+	w.eq("category", filter.Key)
+	w.between("taken_at", filter.StartTime, filter.EndTime)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, taken_at, category, data FROM position_snapshots`+w.sql("taken_at DESC, id DESC", filter.Limit), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询仓位快照失败: %v", err)
+	}
+	defer rows.Close()
+
+	snapshots := []PositionSnapshot{}
+	for rows.Next() {
+		var snapshot PositionSnapshot
+		var data string
+		if err := rows.Scan(&snapshot.ID, &snapshot.TakenAt, &snapshot.Category, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &snapshot.Positions); err != nil {
+			return nil, fmt.Errorf("解析仓位快照失败: %v", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// SaveWalletSnapshot 保存钱包快照
+func (s *SQLiteStore) SaveWalletSnapshot(ctx context.Context, snapshot *WalletSnapshot) error {
+	data, err := json.Marshal(snapshot.Balances)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO wallet_snapshots (taken_at, account_type, data) VALUES (?, ?, ?)`,
+		snapshot.TakenAt, snapshot.AccountType, string(data))
+	if err != nil {
+		return fmt.Errorf("保存钱包快照失败: %v", err)
+	}
+	snapshot.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListWalletSnapshots 查询钱包快照，按时间倒序
+func (s *SQLiteStore) ListWalletSnapshots(ctx context.Context, filter SnapshotFilter) ([]WalletSnapshot, error) {
+	var w whereBuilder
+	w.eq("account_type", filter.Key)
+	w.between("taken_at", filter.StartTime, filter.EndTime)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, taken_at, account_type, data FROM wallet_snapshots`+w.sql("taken_at DESC, id DESC", filter.Limit), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询钱包快照失败: %v", err)
+	}
+	defer rows.Close()
+
+	snapshots := []WalletSnapshot{}
+	for rows.Next() {
+		var snapshot WalletSnapshot
+		var data string
+		if err := rows.Scan(&snapshot.ID, &snapshot.TakenAt, &snapshot.AccountType, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &snapshot.Balances); err != nil {
+			return nil, fmt.Errorf("解析钱包快照失败: %v", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// AppendAudit 追加审计记录
+func (s *SQLiteStore) AppendAudit(ctx context.Context, record *AuditRecord) error {
+	result, err := s.db.ExecContext(ctx, `INSERT INTO audit_records (time, caller, method, request, risk_result, ret_code, ret_msg, error, latency_ms, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Time, record.Caller, record.Method, record.Request, record.RiskResult, record.RetCode, record.RetMsg, record.Error, record.LatencyMs, record.PrevHash, record.Hash)
+	if err != nil {
+		return fmt.Errorf("保存审计记录失败: %v", err)
+	}
+	record.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListAudit 查询审计记录，按ID升序
+func (s *SQLiteStore) ListAudit(ctx context.Context, filter AuditFilter) ([]AuditRecord, error) {
+	var w whereBuilder
+	if filter.AfterID > 0 {
+		w.conds = append(w.conds, "id > ?")
+		w.args = append(w.args, filter.AfterID)
+	}
+	w.eq("caller", filter.Caller)
+	w.eq("method", filter.Method)
+	w.between("time", filter.StartTime, filter.EndTime)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_records`+w.sql("id ASC", filter.Limit), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询审计记录失败: %v", err)
+	}
+	defer rows.Close()
+
+	records := []AuditRecord{}
+	for rows.Next() {
+		var r AuditRecord
+		if err := scanAudit(rows, &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// 审计记录查询的列
+const auditColumns = `id, time, caller, method, request, risk_result, ret_code, ret_msg, error, latency_ms, prev_hash, hash`
+
+// 读取一行审计记录
+func scanAudit(row interface{ Scan(...interface{}) error }, r *AuditRecord) error {
+	return row.Scan(&r.ID, &r.Time, &r.Caller, &r.Method, &r.Request, &r.RiskResult, &r.RetCode, &r.RetMsg, &r.Error, &r.LatencyMs, &r.PrevHash, &r.Hash)
+}
+
+// LastAudit 返回最新的审计记录，没有记录时返回nil
+func (s *SQLiteStore) LastAudit(ctx context.Context) (*AuditRecord, error) {
+	var r AuditRecord
+	err := scanAudit(s.db.QueryRowContext(ctx, `SELECT `+auditColumns+` FROM audit_records ORDER BY id DESC LIMIT 1`), &r)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询最新审计记录失败: %v", err)
+	}
+	return &r, nil
+}
+
+// Close 关闭数据库连接
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveWithdrawal 保存提现申请，相同ID的申请会被更新
+func (s *SQLiteStore) SaveWithdrawal(ctx context.Context, request *model.WithdrawalRequest) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO withdrawal_requests (id, coin, status, requested_at, data)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, data = excluded.data`,
+		request.ID, request.Coin, request.Status, request.RequestedAt, string(data)); err != nil {
+		return fmt.Errorf("保存提现申请失败: %v", err)
+	}
+	return nil
+}
+
+// GetWithdrawal 获取提现申请，不存在时返回nil
+func (s *SQLiteStore) GetWithdrawal(ctx context.Context, id string) (*model.WithdrawalRequest, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM withdrawal_requests WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询提现申请失败: %v", err)
+	}
+
+	var request model.WithdrawalRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return nil, fmt.Errorf("解析提现申请失败: %v", err)
+	}
+	return &request, nil
+}
+
+// ListWithdrawals 查询提现申请，按申请时间倒序
+func (s *SQLiteStore) ListWithdrawals(ctx context.Context, filter WithdrawalFilter) ([]model.WithdrawalRequest, error) {
+	var w whereBuilder
+	w.eq("status", filter.Status)
+	w.eq("coin", filter.Coin)
+	w.between("requested_at", filter.StartTime, filter.EndTime)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM withdrawal_requests`+w.sql("requested_at DESC, id DESC", filter.Limit), w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询提现申请失败: %v", err)
+	}
+	defer rows.Close()
+
+	requests := []model.WithdrawalRequest{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var request model.WithdrawalRequest
+		if err := json.Unmarshal([]byte(data), &request); err != nil {
+			return nil, fmt.Errorf("解析提现申请失败: %v", err)
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+// WhitelistFirstSeen 返回白名单地址首次出现的时间，首次调用时记录为now
+func (s *SQLiteStore) WhitelistFirstSeen(ctx context.Context, key string, now int64) (int64, error) {
+	if _, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO whitelist_entries (key, first_seen) VALUES (?, ?)`, key, now); err != nil {
+		return 0, fmt.Errorf("记录白名单地址失败: %v", err)
+	}
+
+	var seen int64
+	if err := s.db.QueryRowContext(ctx, `SELECT first_seen FROM whitelist_entries WHERE key = ?`, key).Scan(&seen); err != nil {
+		return 0, fmt.Errorf("查询白名单地址失败: %v", err)
+	}
+	return seen, nil
+}
+
+// PruneWhitelist 删除不在keep中的白名单地址记录
+func (s *SQLiteStore) PruneWhitelist(ctx context.Context, keep []string) error {
+	query := `DELETE FROM whitelist_entries`
+	args := make([]interface{}, len(keep))
+	if len(keep) > 0 {
+		query += ` WHERE key NOT IN (?` + strings.Repeat(`, ?`, len(keep)-1) + `)`
+		for i, key := range keep {
+			args[i] = key
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("清理白名单地址失败: %v", err)
+	}
+	return nil
+}
+
+// SaveAlert 保存告警规则，相同ID的规则会被更新
+func (s *SQLiteStore) SaveAlert(ctx context.Context, alert *model.Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO alerts (id, account, created_at, data)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data`,
+		alert.ID, alert.Account, alert.CreatedAt, string(data)); err != nil {
+		return fmt.Errorf("保存告警规则失败: %v", err)
+	}
+	return nil
+}
+
+// DeleteAlert 删除告警规则，不存在时不返回错误
+func (s *SQLiteStore) DeleteAlert(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM alerts WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("删除告警规则失败: %v", err)
+	}
+	return nil
+}
+
+// ListAlerts 返回全部告警规则，按创建时间正序
+func (s *SQLiteStore) ListAlerts(ctx context.Context) ([]model.Alert, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM alerts ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询告警规则失败: %v", err)
+	}
+	defer rows.Close()
+
+	alerts := []model.Alert{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var alert model.Alert
+		if err := json.Unmarshal([]byte(data), &alert); err != nil {
+			return nil, fmt.Errorf("解析告警规则失败: %v", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+)
+
+// 存储驱动
+const (
+	DriverSQLite = "sqlite" // 嵌入式SQLite
+	DriverMemory = "memory" // 内存存储，重启后丢失，主要用于测试
+)
+
+// Store 是持久化存储的接口定义
+// 保存订单、成交、仓位和钱包快照、请求审计记录、提现申请和告警规则
+type Store interface {
+	// 订单
+	SaveOrders(ctx context.Context, category string, orders []model.Order) error
+	ListOrders(ctx context.Context, filter OrderFilter) ([]OrderRecord, error)
+
+	// 成交记录
+	SaveExecutions(ctx context.Context, executions []model.Execution) error
+	ListExecutions(ctx context.Context, filter ExecutionFilter) ([]model.Execution, error)
+
+	// 仓位快照
+	SavePositionSnapshot(ctx context.Context, snapshot *PositionSnapshot) error
+	ListPositionSnapshots(ctx context.Context, filter SnapshotFilter) ([]PositionSnapshot, error)
+
+	// 钱包快照
+	SaveWalletSnapshot(ctx context.Context, snapshot *WalletSnapshot) error
+	ListWalletSnapshots(ctx context.Context, filter SnapshotFilter) ([]WalletSnapshot, error)
+
+	// 审计记录
+	AppendAudit(ctx context.Context, record *AuditRecord) error
+	ListAudit(ctx context.Context, filter AuditFilter) ([]AuditRecord, error)
+	LastAudit(ctx context.Context) (*AuditRecord, error)
+
+	// 提现申请
+	SaveWithdrawal(ctx context.Context, request *model.WithdrawalRequest) error
+	GetWithdrawal(ctx context.Context, id string) (*model.WithdrawalRequest, error)
+	ListWithdrawals(ctx context.Context, filter WithdrawalFilter) ([]model.WithdrawalRequest, error)
+
+	// WhitelistFirstSeen 返回白名单地址首次出现的时间，首次调用时记录为now
+	WhitelistFirstSeen(ctx context.Context, key string, now int64) (int64, error)
+	// PruneWhitelist 删除不在keep中的白名单地址记录，地址重新加入白名单时重新计算冷却期
+	PruneWhitelist(ctx context.Context, keep []string) error
+
+	// 告警规则
+	SaveAlert(ctx context.Context, alert *model.Alert) error
+	DeleteAlert(ctx context.Context, id string) error
+	ListAlerts(ctx context.Context) ([]model.Alert, error)
+
+	// Close 关闭存储
+	Close() error
+}
+
+// OrderRecord 是保存的订单记录
+type OrderRecord struct {
+	Category string      `json:"category"` // 产品类型
+	Order    model.Order `json:"order"`    // 订单信息
+	SavedAt  int64       `json:"savedAt"`  // 最后保存时间（毫秒）
+}
+
+// PositionSnapshot 是某一时刻的仓位快照
+type PositionSnapshot struct {
+	ID        int64            `json:"id"`        // 快照ID
+	TakenAt   int64            `json:"takenAt"`   // 快照时间（毫秒）
+	Category  string           `json:"category"`  // 产品类型
+	Positions []model.Position `json:"positions"` // 仓位列表
+}
+
+// WalletSnapshot 是某一时刻的钱包余额快照
+type WalletSnapshot struct {
+	ID          int64                 `json:"id"`          // 快照ID
+	TakenAt     int64                 `json:"takenAt"`     // 快照时间（毫秒）
+	AccountType string                `json:"accountType"` // 账户类型
+	Balances    []model.WalletBalance `json:"balances"`    // 钱包余额
+}
+
+// AuditRecord 是一次请求的审计记录
+type AuditRecord struct {
+	ID         int64  `json:"id"`         // 记录ID
+	Time       int64  `json:"time"`       // 请求时间（毫秒）
+	Caller     string `json:"caller"`     // 调用方身份
+	Method     string `json:"method"`     // RPC方法名
+	Request    string `json:"request"`    // 请求内容（JSON）
+	RiskResult string `json:"riskResult"` // 风控检查结果
+	RetCode    int    `json:"retCode"`    // Bybit返回码
+	RetMsg     string `json:"retMsg"`     // Bybit返回消息
+	Error      string `json:"error"`      // 错误信息
+	LatencyMs  int64  `json:"latencyMs"`  // 耗时（毫秒）
+	PrevHash   string `json:"prevHash"`   // 上一条记录的哈希
+	Hash       string `json:"hash"`       // 本条记录的哈希
+}
+
+// OrderFilter 是订单查询条件
+type OrderFilter struct {
+	Category    string // 产品类型
+	Symbol      string // 交易对
+	OrderStatus string // 订单状态
+	StartTime   int64  // 订单创建时间起点（毫秒）
+	EndTime     int64  // 订单创建时间终点（毫秒）
+	Limit       int    // 最大返回数量，0表示不限制
+}
+
+// ExecutionFilter 是成交记录查询条件
+type ExecutionFilter struct {
+	Category  string // 产品类型
+	Symbol    string // 交易对
+	ExecType  string // 成交类型
+	StartTime int64  // 成交时间起点（毫秒）
+	EndTime   int64  // 成交时间终点（毫秒）
+	Limit     int    // 最大返回数量，0表示不限制
+}
+
+// SnapshotFilter 是快照查询条件
+type SnapshotFilter struct {
+	Key       string // 产品类型（仓位快照）或账户类型（钱包快照）
+	StartTime int64  // 快照时间起点（毫秒）
+	EndTime   int64  // 快照时间终点（毫秒）
+	Limit     int    // 最大返回数量，0表示不限制
+}
+
+// AuditFilter 是审计记录查询条件
+type AuditFilter struct {
+	AfterID   int64  // 只返回ID大于该值的记录
+	Caller    string // 调用方身份
+	Method    string // RPC方法名
+	StartTime int64  // 请求时间起点（毫秒）
+	EndTime   int64  // 请求时间终点（毫秒）
+	Limit     int    // 最大返回数量，0表示不限制
+}
+
+// WithdrawalFilter 是提现申请查询条件
+type WithdrawalFilter struct {
+	Status    string // 状态
+	Coin      string // 币种
+	StartTime int64  // 申请时间起点（毫秒）
+	EndTime   int64  // 申请时间终点（毫秒）
+	Limit     int    // 最大返回数量，0表示不限制
+}
+
+// 判断时间是否在区间内，0表示不限制
+func inRange(ts, start, end int64) bool {
+	if start > 0 && ts < start {
+		return false
+	}
+	if end > 0 && ts > end {
+		return false
+	}
+	return true
+}
+
+// Open 根据配置创建存储
+func Open(cfg config.StorageConfig) (Store, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", DriverMemory:
+		return NewMemoryStore(), nil
+	case DriverSQLite:
+		return OpenSQLite(cfg.Path)
+	default:
+		return nil, fmt.Errorf("不支持的存储驱动: %s", cfg.Driver)
+	}
+}
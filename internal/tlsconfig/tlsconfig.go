@@ -0,0 +1,165 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 默认检查证书文件变化的间隔
+const DefaultReloadInterval = 30 * time.Second
+
+// Reloader 加载服务端证书和客户端CA，并在文件变化时自动重新加载
+// 新证书只对之后建立的连接生效，已有连接不受影响
+type Reloader struct {
+	cfg    config.TLSConfig
+	logger *logger.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// New 根据配置加载证书，证书或CA无效时返回错误
+func New(cfg config.TLSConfig, log *logger.Logger) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("未配置TLS证书或私钥")
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, fmt.Errorf("要求客户端证书时必须配置客户端CA")
+	}
+	if _, err := MinVersion(cfg.MinVersion); err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{cfg: cfg, logger: log}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// MinVersion 解析最低TLS版本，为空时使用TLS 1.2
+func MinVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("不支持的TLS版本: %s，可选1.2或1.3", version)
+	}
+}
+
+// Reload 重新加载证书和客户端CA，失败时保留原有证书
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("无法加载TLS证书: %v", err)
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("无法读取客户端CA: %v", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("客户端CA文件中没有有效证书: %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTimes = r.currentModTimes()
+	r.mu.Unlock()
+	return nil
+}
+
+// 读取证书文件的修改时间
+func (r *Reloader) currentModTimes() map[string]time.Time {
+	times := map[string]time.Time{}
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			times[path] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// 判断证书文件是否有变化
+func (r *Reloader) changed() bool {
+	current := r.currentModTimes()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for path, modTime := range current {
+		if !modTime.Equal(r.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 按间隔检查证书文件，发生变化时重新加载，直到上下文结束
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			// 证书和私钥可能没有同时写完，下一次检查时重试
+			r.logger.Warn("重新加载TLS证书失败，继续使用原证书: %v", err)
+			continue
+		}
+		r.logger.Info("已重新加载TLS证书")
+	}
+}
+
+// TLSConfig 返回服务端使用的TLS配置，每次握手时读取最新的证书和客户端CA
+func (r *Reloader) TLSConfig() *tls.Config {
+	minVersion, _ := MinVersion(r.cfg.MinVersion)
+	return &tls.Config{
+		MinVersion: minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			cfg := &tls.Config{
+				MinVersion:   minVersion,
+				Certificates: []tls.Certificate{*r.cert},
+				NextProtos:   []string{"h2"},
+			}
+			if r.clientCA != nil {
+				cfg.ClientCAs = r.clientCA
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				if r.cfg.RequireClientCert {
+					cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+			return cfg, nil
+		},
+	}
+}
@@ -0,0 +1,344 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/pkg/logger"
+)
+
+// 测试用的证书颁发机构
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// 签发证书，返回PEM编码的证书和私钥
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if usage == x509.ExtKeyUsageServerAuth {
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// 签发客户端证书并转换为tls.Certificate
+func (ca *testCA) clientCert(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, name, x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 在临时目录中写入服务端证书、私钥和客户端CA，返回对应的配置
+func writeServerFiles(t *testing.T, serverCA, clientCA *testCA, serverName string) config.TLSConfig {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.TLSConfig{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}
+	certPEM, keyPEM := serverCA.issue(t, serverName, x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	if clientCA != nil {
+		cfg.ClientCAFile = filepath.Join(dir, "client-ca.crt")
+		writeFile(t, cfg.ClientCAFile, clientCA.pem)
+	}
+	return cfg
+}
+
+func newReloader(t *testing.T, cfg config.TLSConfig) *Reloader {
+	t.Helper()
+	r, err := New(cfg, logger.New("fatal", "stderr"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return r
+}
+
+// 握手结果：服务端看到的客户端证书和客户端看到的服务端证书
+type handshakeResult struct {
+	serverName string
+	clientName string
+}
+
+// 在本地端口上完成一次TLS握手，返回服务端或客户端的第一个错误
+func handshake(t *testing.T, r *Reloader, ca *testCA, clientCerts ...tls.Certificate) (*handshakeResult, error) {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.TLSConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	result := &handshakeResult{}
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		tlsConn := conn.(*tls.Conn)
+		if err := tlsConn.Handshake(); err != nil {
+			serverErr <- err
+			return
+		}
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			result.clientName = certs[0].Subject.CommonName
+		}
+		// 等待客户端关闭，TLS 1.3的客户端证书错误在服务端握手时才会发现
+		buf := make([]byte, 1)
+		_, _ = tlsConn.Read(buf)
+		serverErr <- nil
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCfg := &tls.Config{
+		RootCAs:    roots,
+		ServerName: "localhost",
+		NextProtos: []string{"h2"},
+	}
+	if len(clientCerts) > 0 {
+		// 总是发送证书，否则客户端会跳过不在服务端可接受CA列表中的证书
+		clientCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &clientCerts[0], nil
+		}
+	}
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	if err != nil {
+		<-serverErr
+		return nil, err
+	}
+	result.serverName = conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	conn.Close()
+
+	if err := <-serverErr; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	ca := newCA(t, "server-ca")
+	valid := writeServerFiles(t, ca, nil, "server")
+
+	tests := []struct {
+		name string
+		cfg  config.TLSConfig
+	}{
+		{"missing cert", config.TLSConfig{KeyFile: valid.KeyFile}},
+		{"client cert without CA", config.TLSConfig{CertFile: valid.CertFile, KeyFile: valid.KeyFile, RequireClientCert: true}},
+		{"bad min version", config.TLSConfig{CertFile: valid.CertFile, KeyFile: valid.KeyFile, MinVersion: "1.1"}},
+		{"missing file", config.TLSConfig{CertFile: valid.CertFile + ".missing", KeyFile: valid.KeyFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg, logger.New("fatal", "stderr")); err == nil {
+				t.Fatal("New() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	ca := newCA(t, "server-ca")
+	for _, version := range []string{"1.2", "1.3"} {
+		t.Run(version, func(t *testing.T) {
+			cfg := writeServerFiles(t, ca, nil, "server")
+			cfg.MinVersion = version
+			result, err := handshake(t, newReloader(t, cfg), ca)
+			if err != nil {
+				t.Fatalf("handshake error = %v", err)
+			}
+			if result.serverName != "server" {
+				t.Errorf("server certificate = %q, want server", result.serverName)
+			}
+		})
+	}
+}
+
+func TestClientCertRequired(t *testing.T) {
+	serverCA := newCA(t, "server-ca")
+	clientCA := newCA(t, "client-ca")
+	otherCA := newCA(t, "other-ca")
+
+	cfg := writeServerFiles(t, serverCA, clientCA, "server")
+	cfg.RequireClientCert = true
+	r := newReloader(t, cfg)
+
+	if _, err := handshake(t, r, serverCA); err == nil {
+		t.Error("handshake without client certificate succeeded, want error")
+	}
+	if _, err := handshake(t, r, serverCA, otherCA.clientCert(t, "intruder")); err == nil {
+		t.Error("handshake with untrusted client certificate succeeded, want error")
+	}
+	result, err := handshake(t, r, serverCA, clientCA.clientCert(t, "ops"))
+	if err != nil {
+		t.Fatalf("handshake with client certificate error = %v", err)
+	}
+	if result.clientName != "ops" {
+		t.Errorf("client certificate = %q, want ops", result.clientName)
+	}
+}
+
+func TestClientCertOptional(t *testing.T) {
+	serverCA := newCA(t, "server-ca")
+	clientCA := newCA(t, "client-ca")
+	otherCA := newCA(t, "other-ca")
+
+	r := newReloader(t, writeServerFiles(t, serverCA, clientCA, "server"))
+
+	result, err := handshake(t, r, serverCA)
+	if err != nil {
+		t.Fatalf("handshake without client certificate error = %v", err)
+	}
+	if result.clientName != "" {
+		t.Errorf("client certificate = %q, want none", result.clientName)
+	}
+
+	result, err = handshake(t, r, serverCA, clientCA.clientCert(t, "ops"))
+	if err != nil {
+		t.Fatalf("handshake with client certificate error = %v", err)
+	}
+	if result.clientName != "ops" {
+		t.Errorf("client certificate = %q, want ops", result.clientName)
+	}
+
+	// 提供了证书就必须能通过验证
+	if _, err := handshake(t, r, serverCA, otherCA.clientCert(t, "intruder")); err == nil {
+		t.Error("handshake with untrusted client certificate succeeded, want error")
+	}
+}
+
+func TestReloadAfterRewrite(t *testing.T) {
+	ca := newCA(t, "server-ca")
+	cfg := writeServerFiles(t, ca, nil, "server")
+	r := newReloader(t, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx, 10*time.Millisecond)
+
+	// 写入新证书，并把修改时间调后以免文件系统的时间精度不够
+	certPEM, keyPEM := ca.issue(t, "renewed", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{cfg.CertFile, cfg.KeyFile} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		result, err := handshake(t, r, ca)
+		if err != nil {
+			t.Fatalf("handshake error = %v", err)
+		}
+		if result.serverName == "renewed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server certificate = %q after rewrite, want renewed", result.serverName)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadKeepsCertificateOnError(t *testing.T) {
+	ca := newCA(t, "server-ca")
+	cfg := writeServerFiles(t, ca, nil, "server")
+	r := newReloader(t, cfg)
+
+	// 只写完证书、私钥还是旧的时重新加载失败，继续使用原证书
+	certPEM, _ := ca.issue(t, "renewed", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM)
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload() with mismatched key error = nil, want error")
+	}
+
+	result, err := handshake(t, r, ca)
+	if err != nil {
+		t.Fatalf("handshake error = %v", err)
+	}
+	if result.serverName != "server" {
+		t.Errorf("server certificate = %q, want server", result.serverName)
+	}
+}
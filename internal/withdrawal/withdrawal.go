@@ -0,0 +1,397 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// 待审批提现的默认有效期
+const DefaultApprovalTTL = 60 * time.Minute
+
+// 计算每日上限的时间窗口
+const dayWindow = 24 * time.Hour
+
+var (
+	// ErrNotFound 表示提现申请不存在
+	ErrNotFound = errors.New("提现申请不存在")
+
+	// ErrSelfApproval 表示审批人与申请人相同
+	ErrSelfApproval = errors.New("不能审批自己提交的提现申请")
+
+	// ErrAccountDenied 表示审批人无权使用申请中的账户
+	ErrAccountDenied = errors.New("无权审批该账户的提现申请")
+)
+
+// PolicyError 表示提现不符合提现策略或申请状态不允许该操作
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return "提现被拒绝: " + e.Reason
+}
+
+// 创建策略错误
+func reject(format string, args ...interface{}) error {
+	return &PolicyError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// SubmitFunc 把已通过检查的提现提交到Bybit
+type SubmitFunc func(ctx context.Context, request *model.WithdrawalRequest) (*model.Response, error)
+
+// Manager 在提现前执行白名单、金额上限、冷却期检查和二次审批
+type Manager struct {
+	cfg    config.WithdrawalConfig
+	store  storage.Store
+	logger *logger.Logger
+	submit SubmitFunc
+
+	// 检查上限和修改申请状态时加锁，避免并发提现超出每日上限或重复提交
+	mu sync.Mutex
+}
+
+// New 创建提现管理器
+func New(cfg config.WithdrawalConfig, store storage.Store, log *logger.Logger, submit SubmitFunc) *Manager {
+	return &Manager{
+		cfg:    cfg,
+		store:  store,
+		logger: log,
+		submit: submit,
+	}
+}
+
+// Init 记录白名单中每个地址首次出现的时间，冷却期从这一时间开始计算
+// 已移出白名单的地址的记录会被删除，重新加入时重新计算冷却期
+// 应在启动时调用，否则新地址的冷却期从第一次提现申请时才开始
+func (m *Manager) Init(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.cfg.Whitelist))
+	for _, entry := range m.cfg.Whitelist {
+		keys = append(keys, whitelistKey(entry.Coin, entry.Chain, entry.Address, entry.Tag))
+	}
+	if err := m.store.PruneWhitelist(ctx, keys); err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	for _, key := range keys {
+		if _, err := m.store.WhitelistFirstSeen(ctx, key, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update 在运行中替换提现策略，新加入白名单的地址从此时开始计算冷却期
+// 已创建的待审批申请在审批时按新策略重新检查
+func (m *Manager) Update(ctx context.Context, cfg config.WithdrawalConfig) error {
+	m.mu.Lock()
+	m.cfg = cfg
+	m.mu.Unlock()
+	return m.Init(ctx)
+}
+
+// ApprovalRequired 判断提现是否需要二次审批
+func (m *Manager) ApprovalRequired() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.cfg.DisableApproval
+}
+
+// Request 检查提现策略并创建提现申请
+// 需要审批时申请保存为待审批状态，否则立即提交，此时同时返回Bybit的响应
+func (m *Manager) Request(ctx context.Context, request *model.WithdrawalRequest) (*model.WithdrawalRequest, *model.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if err := m.check(ctx, request, now); err != nil {
+		m.logger.Warn("提现申请被拒绝: caller=%s, coin=%s, amount=%s, err=%v", request.RequestedBy, request.Coin, request.Amount, err)
+		return nil, nil, err
+	}
+
+	request.ID = uuid.NewString()
+	request.RequestedAt = now.UnixMilli()
+	request.ExpiresAt = now.Add(m.approvalTTL()).UnixMilli()
+	request.Status = model.WithdrawalPending
+
+	if !m.cfg.DisableApproval {
+		if err := m.store.SaveWithdrawal(ctx, request); err != nil {
+			return nil, nil, err
+		}
+		m.logger.Info("提现申请等待审批: id=%s, caller=%s, coin=%s, amount=%s", request.ID, request.RequestedBy, request.Coin, request.Amount)
+		return request, nil, nil
+	}
+
+	resp, err := m.doSubmit(ctx, request)
+	return request, resp, err
+}
+
+// Approve 审批并提交提现申请，审批人不能是申请人，且必须有权使用申请中的账户
+// 提交前重新检查提现策略，白名单或上限在申请后发生变化时拒绝提交
+func (m *Manager) Approve(ctx context.Context, id string, approver *auth.Identity) (*model.WithdrawalRequest, *model.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	request, err := m.pending(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if approver.Name == request.RequestedBy {
+		return nil, nil, ErrSelfApproval
+	}
+	if !approver.CanUseAccount(request.Account) {
+		return nil, nil, ErrAccountDenied
+	}
+
+	request.ReviewedBy = approver.Name
+	request.ReviewedAt = time.Now().UnixMilli()
+	if err := m.check(ctx, request, time.Now()); err != nil {
+		request.Status = model.WithdrawalRejected
+		request.Error = err.Error()
+		if saveErr := m.store.SaveWithdrawal(ctx, request); saveErr != nil {
+			m.logger.Error("保存提现申请失败: id=%s, err=%v", request.ID, saveErr)
+		}
+		return request, nil, err
+	}
+
+	resp, err := m.doSubmit(ctx, request)
+	return request, resp, err
+}
+
+// Reject 拒绝待审批的提现申请，审批人必须有权使用申请中的账户
+func (m *Manager) Reject(ctx context.Context, id string, reviewer *auth.Identity, reason string) (*model.WithdrawalRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	request, err := m.pending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewer.CanUseAccount(request.Account) {
+		return nil, ErrAccountDenied
+	}
+
+	request.Status = model.WithdrawalRejected
+	request.ReviewedBy = reviewer.Name
+	request.ReviewedAt = time.Now().UnixMilli()
+	request.Error = reason
+	if err := m.store.SaveWithdrawal(ctx, request); err != nil {
+		return nil, err
+	}
+	m.logger.Info("提现申请已拒绝: id=%s, reviewer=%s", request.ID, reviewer.Name)
+	return request, nil
+}
+
+// List 查询提现申请，已超时的待审批申请会被标记为超时
+func (m *Manager) List(ctx context.Context, filter storage.WithdrawalFilter) ([]model.WithdrawalRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests, err := m.store.ListWithdrawals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	for i := range requests {
+		if err := m.expire(ctx, &requests[i], now); err != nil {
+			return nil, err
+		}
+	}
+
+	// 按待审批状态查询时去掉刚刚超时的申请
+	if filter.Status == model.WithdrawalPending {
+		pending := requests[:0]
+		for _, request := range requests {
+			if request.Status == model.WithdrawalPending {
+				pending = append(pending, request)
+			}
+		}
+		requests = pending
+	}
+	return requests, nil
+}
+
+// Account 返回提现申请中的账户
+func (m *Manager) Account(ctx context.Context, id string) (string, error) {
+	request, err := m.store.GetWithdrawal(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if request == nil {
+		return "", ErrNotFound
+	}
+	return request.Account, nil
+}
+
+// 读取待审批的申请，不存在、已处理或已超时时返回错误
+func (m *Manager) pending(ctx context.Context, id string) (*model.WithdrawalRequest, error) {
+	request, err := m.store.GetWithdrawal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, ErrNotFound
+	}
+	if err := m.expire(ctx, request, time.Now().UnixMilli()); err != nil {
+		return nil, err
+	}
+	if request.Status != model.WithdrawalPending {
+		return nil, reject("申请%s的状态为%s，不能审批", request.ID, request.Status)
+	}
+	return request, nil
+}
+
+// 把超过有效期的待审批申请标记为超时
+func (m *Manager) expire(ctx context.Context, request *model.WithdrawalRequest, now int64) error {
+	if request.Status != model.WithdrawalPending || now <= request.ExpiresAt {
+		return nil
+	}
+	request.Status = model.WithdrawalExpired
+	return m.store.SaveWithdrawal(ctx, request)
+}
+
+// 提交提现并保存结果
+// 提交前先把申请保存为提交中，保存失败时不提交，避免申请仍为待审批状态而被再次审批和重复提交
+func (m *Manager) doSubmit(ctx context.Context, request *model.WithdrawalRequest) (*model.Response, error) {
+	request.Status = model.WithdrawalSubmitting
+	if err := m.store.SaveWithdrawal(ctx, request); err != nil {
+		m.logger.Error("保存提现申请失败，未提交: id=%s, err=%v", request.ID, err)
+		return nil, err
+	}
+
+	resp, err := m.submit(ctx, request)
+	switch {
+	case err != nil:
+		request.Status = model.WithdrawalFailed
+		request.Error = err.Error()
+	case resp.RetCode != 0:
+		request.Status = model.WithdrawalFailed
+		request.Error = resp.RetMsg
+	default:
+		request.Status = model.WithdrawalSubmitted
+		if result, ok := resp.Result.(map[string]interface{}); ok {
+			request.WithdrawId = fmt.Sprint(result["id"])
+		}
+	}
+
+	if saveErr := m.store.SaveWithdrawal(ctx, request); saveErr != nil {
+		m.logger.Error("保存提现结果失败，申请保持提交中状态，需要人工核对: id=%s, status=%s, err=%v", request.ID, request.Status, saveErr)
+	}
+	m.logger.Info("提现已提交: id=%s, status=%s, withdrawId=%s", request.ID, request.Status, request.WithdrawId)
+	return resp, err
+}
+
+// 检查白名单、冷却期、单笔上限和每日上限
+func (m *Manager) check(ctx context.Context, request *model.WithdrawalRequest, now time.Time) error {
+	amount, err := strconv.ParseFloat(request.Amount, 64)
+	if err != nil || amount <= 0 {
+		return reject("无效的提现数量: %s", request.Amount)
+	}
+
+	entry := m.whitelisted(request)
+	if entry == nil {
+		return reject("地址不在白名单中: coin=%s, chain=%s, address=%s", request.Coin, request.Chain, request.Address)
+	}
+
+	if m.cfg.CooldownHours > 0 {
+		seen, err := m.store.WhitelistFirstSeen(ctx, whitelistKey(entry.Coin, entry.Chain, entry.Address, entry.Tag), now.UnixMilli())
+		if err != nil {
+			return err
+		}
+		ready := time.UnixMilli(seen).Add(time.Duration(m.cfg.CooldownHours) * time.Hour)
+		if now.Before(ready) {
+			return reject("白名单地址仍在冷却期内，%s之后才能提现", ready.Format(time.RFC3339))
+		}
+	}
+
+	coin := strings.ToUpper(request.Coin)
+	if limit, ok := m.limit(m.cfg.MaxPerTransaction, coin); ok && amount > limit {
+		return reject("超过单笔提现上限: %s > %g %s", request.Amount, limit, coin)
+	}
+
+	if limit, ok := m.limit(m.cfg.MaxPerDay, coin); ok {
+		used, err := m.usedToday(ctx, request, now)
+		if err != nil {
+			return err
+		}
+		if used+amount > limit {
+			return reject("超过每日提现上限: 已使用%g，本次%s，上限%g %s", used, request.Amount, limit, coin)
+		}
+	}
+	return nil
+}
+
+// 统计24小时内已提交、提交中和待审批的提现数量，不包括request本身
+func (m *Manager) usedToday(ctx context.Context, request *model.WithdrawalRequest, now time.Time) (float64, error) {
+	requests, err := m.store.ListWithdrawals(ctx, storage.WithdrawalFilter{
+		StartTime: now.Add(-dayWindow).UnixMilli(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	used := 0.0
+	for _, r := range requests {
+		if r.ID == request.ID || !strings.EqualFold(r.Coin, request.Coin) {
+			continue
+		}
+		if r.Status == model.WithdrawalSubmitted || r.Status == model.WithdrawalSubmitting || (r.Status == model.WithdrawalPending && now.UnixMilli() <= r.ExpiresAt) {
+			amount, _ := strconv.ParseFloat(r.Amount, 64)
+			used += amount
+		}
+	}
+	return used, nil
+}
+
+// 查找匹配的白名单地址，币种和链不区分大小写，地址和标签必须完全一致
+func (m *Manager) whitelisted(request *model.WithdrawalRequest) *config.WhitelistEntry {
+	for i := range m.cfg.Whitelist {
+		entry := &m.cfg.Whitelist[i]
+		if strings.EqualFold(entry.Coin, request.Coin) &&
+			strings.EqualFold(entry.Chain, request.Chain) &&
+			entry.Address == request.Address &&
+			entry.Tag == request.Tag {
+			return entry
+		}
+	}
+	return nil
+}
+
+// 读取币种的上限，配置中的币种不区分大小写
+func (m *Manager) limit(limits map[string]float64, coin string) (float64, bool) {
+	for c, limit := range limits {
+		if strings.EqualFold(c, coin) {
+			return limit, true
+		}
+	}
+	return 0, false
+}
+
+// 待审批提现的有效期
+func (m *Manager) approvalTTL() time.Duration {
+	if m.cfg.ApprovalTTL > 0 {
+		return time.Duration(m.cfg.ApprovalTTL) * time.Minute
+	}
+	return DefaultApprovalTTL
+}
+
+// 白名单地址的唯一键
+func whitelistKey(coin, chain, address, tag string) string {
+	return strings.Join([]string{strings.ToUpper(coin), strings.ToUpper(chain), address, tag}, "|")
+}
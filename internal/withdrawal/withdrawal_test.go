@@ -0,0 +1,280 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bybit-mcp/internal/auth"
+	"github.com/bybit-mcp/internal/config"
+	"github.com/bybit-mcp/internal/model"
+	"github.com/bybit-mcp/internal/storage"
+	"github.com/bybit-mcp/pkg/logger"
+	"google.golang.org/grpc/metadata"
+)
+
+// 白名单中的地址
+const (
+	whitelistAddress = "TWhitelisted"
+	otherAddress     = "TUnknown"
+)
+
+// 记录提交次数的SubmitFunc
+type submitter struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *submitter) submit(ctx context.Context, request *model.WithdrawalRequest) (*model.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return &model.Response{RetCode: 0, Result: map[string]interface{}{"id": "10000001"}}, nil
+}
+
+func (s *submitter) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// 保存提交中状态失败的存储
+type failingStore struct {
+	storage.Store
+}
+
+func (s *failingStore) SaveWithdrawal(ctx context.Context, request *model.WithdrawalRequest) error {
+	if request.Status == model.WithdrawalSubmitting {
+		return errors.New("disk full")
+	}
+	return s.Store.SaveWithdrawal(ctx, request)
+}
+
+// 创建使用内存存储的提现管理器
+func newTestManager(t *testing.T, cfg config.WithdrawalConfig, store storage.Store) (*Manager, *submitter) {
+	t.Helper()
+	if len(cfg.Whitelist) == 0 {
+		cfg.Whitelist = []config.WhitelistEntry{{Coin: "USDT", Chain: "TRX", Address: whitelistAddress}}
+	}
+	s := &submitter{}
+	m := New(cfg, store, logger.New(logger.FatalLevel, "stderr"), s.submit)
+	if err := m.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return m, s
+}
+
+// 按配置的客户端创建认证后的身份
+func identities(t *testing.T) map[string]*auth.Identity {
+	t.Helper()
+	a, err := auth.New(config.AuthConfig{Clients: []config.ClientConfig{
+		{Name: "alice", Token: "alice", Role: auth.RoleTreasurer},
+		{Name: "bob", Token: "bob", Role: auth.RoleTreasurer},
+		{Name: "carol", Token: "carol", Role: auth.RoleTreasurer, Accounts: []string{"sub"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := map[string]*auth.Identity{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+name))
+		identity, err := a.Authenticate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[name] = identity
+	}
+	return result
+}
+
+// 白名单地址上的提现申请
+func usdt(amount string) *model.WithdrawalRequest {
+	return &model.WithdrawalRequest{
+		Account:     "main",
+		Coin:        "USDT",
+		Chain:       "TRX",
+		Address:     whitelistAddress,
+		Amount:      amount,
+		RequestedBy: "alice",
+	}
+}
+
+func TestRequestPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.WithdrawalConfig
+		previous []string // 之前已创建的待审批申请金额
+		request  *model.WithdrawalRequest
+		wantErr  bool
+	}{
+		{name: "whitelisted", request: usdt("100")},
+		{name: "case-insensitive coin and chain", request: &model.WithdrawalRequest{Coin: "usdt", Chain: "trx", Address: whitelistAddress, Amount: "1"}},
+		{name: "not whitelisted", request: &model.WithdrawalRequest{Coin: "USDT", Chain: "TRX", Address: otherAddress, Amount: "1"}, wantErr: true},
+		{name: "wrong chain", request: &model.WithdrawalRequest{Coin: "USDT", Chain: "ETH", Address: whitelistAddress, Amount: "1"}, wantErr: true},
+		{name: "invalid amount", request: usdt("abc"), wantErr: true},
+		{name: "zero amount", request: usdt("0"), wantErr: true},
+		{name: "within per-transaction limit", cfg: config.WithdrawalConfig{MaxPerTransaction: map[string]float64{"usdt": 100}}, request: usdt("100")},
+		{name: "over per-transaction limit", cfg: config.WithdrawalConfig{MaxPerTransaction: map[string]float64{"USDT": 100}}, request: usdt("100.01"), wantErr: true},
+		{name: "within daily limit", cfg: config.WithdrawalConfig{MaxPerDay: map[string]float64{"USDT": 300}}, previous: []string{"100", "100"}, request: usdt("100")},
+		{name: "over daily limit", cfg: config.WithdrawalConfig{MaxPerDay: map[string]float64{"USDT": 300}}, previous: []string{"100", "150"}, request: usdt("100"), wantErr: true},
+		{name: "cooldown", cfg: config.WithdrawalConfig{CooldownHours: 24}, request: usdt("1"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, tt.cfg, storage.NewMemoryStore())
+			ctx := context.Background()
+			for _, amount := range tt.previous {
+				if _, _, err := m.Request(ctx, usdt(amount)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			request, _, err := m.Request(ctx, tt.request)
+			if tt.wantErr {
+				var policyErr *PolicyError
+				if !errors.As(err, &policyErr) {
+					t.Fatalf("Request err = %v, want PolicyError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Request err = %v", err)
+			}
+			if request.Status != model.WithdrawalPending || request.ID == "" {
+				t.Errorf("Request = %+v, want pending with ID", request)
+			}
+		})
+	}
+}
+
+func TestApprove(t *testing.T) {
+	ids := identities(t)
+
+	tests := []struct {
+		name     string
+		approver string
+		wantErr  error
+	}{
+		{"other treasurer", "bob", nil},
+		{"self approval", "alice", ErrSelfApproval},
+		{"account not allowed", "carol", ErrAccountDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s := newTestManager(t, config.WithdrawalConfig{}, storage.NewMemoryStore())
+			ctx := context.Background()
+			request, _, err := m.Request(ctx, usdt("10"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			approved, _, err := m.Approve(ctx, request.ID, ids[tt.approver])
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Approve err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if s.count() != 0 {
+					t.Errorf("submit calls = %d, want 0", s.count())
+				}
+				return
+			}
+			if approved.Status != model.WithdrawalSubmitted || approved.WithdrawId != "10000001" || approved.ReviewedBy != tt.approver {
+				t.Errorf("Approve = %+v", approved)
+			}
+
+			// 已提交的申请不能再次审批
+			if _, _, err := m.Approve(ctx, request.ID, ids["bob"]); err == nil {
+				t.Error("second Approve succeeded")
+			}
+			if s.count() != 1 {
+				t.Errorf("submit calls = %d, want 1", s.count())
+			}
+		})
+	}
+}
+
+func TestApproveRechecksPolicy(t *testing.T) {
+	m, s := newTestManager(t, config.WithdrawalConfig{}, storage.NewMemoryStore())
+	ctx := context.Background()
+	request, _, err := m.Request(ctx, usdt("10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 申请后地址被移出白名单
+	err = m.Update(ctx, config.WithdrawalConfig{Whitelist: []config.WhitelistEntry{{Coin: "USDT", Chain: "TRX", Address: otherAddress}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected, _, err := m.Approve(ctx, request.ID, identities(t)["bob"])
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Approve err = %v, want PolicyError", err)
+	}
+	if rejected.Status != model.WithdrawalRejected || s.count() != 0 {
+		t.Errorf("Approve = %+v, submit calls = %d", rejected, s.count())
+	}
+}
+
+func TestApproveNotSubmittedWhenSaveFails(t *testing.T) {
+	store := &failingStore{Store: storage.NewMemoryStore()}
+	m, s := newTestManager(t, config.WithdrawalConfig{}, store)
+	ctx := context.Background()
+	request, _, err := m.Request(ctx, usdt("10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := m.Approve(ctx, request.ID, identities(t)["bob"]); err == nil {
+		t.Fatal("Approve succeeded although the submitting status was not saved")
+	}
+	if s.count() != 0 {
+		t.Errorf("submit calls = %d, want 0", s.count())
+	}
+	saved, err := store.GetWithdrawal(ctx, request.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != model.WithdrawalPending {
+		t.Errorf("saved status = %s, want pending", saved.Status)
+	}
+}
+
+func TestReject(t *testing.T) {
+	m, s := newTestManager(t, config.WithdrawalConfig{}, storage.NewMemoryStore())
+	ids := identities(t)
+	ctx := context.Background()
+	request, _, err := m.Request(ctx, usdt("10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Reject(ctx, request.ID, ids["carol"], "no"); !errors.Is(err, ErrAccountDenied) {
+		t.Errorf("Reject by carol err = %v, want ErrAccountDenied", err)
+	}
+	rejected, err := m.Reject(ctx, request.ID, ids["bob"], "unexpected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.Status != model.WithdrawalRejected || rejected.Error != "unexpected" {
+		t.Errorf("Reject = %+v", rejected)
+	}
+	if _, _, err := m.Approve(ctx, request.ID, ids["bob"]); err == nil || s.count() != 0 {
+		t.Errorf("Approve after Reject err = %v, submit calls = %d", err, s.count())
+	}
+	if _, _, err := m.Approve(ctx, "missing", ids["bob"]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Approve missing err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDisableApprovalSubmitsImmediately(t *testing.T) {
+	m, s := newTestManager(t, config.WithdrawalConfig{DisableApproval: true}, storage.NewMemoryStore())
+	request, resp, err := m.Request(context.Background(), usdt("10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || request.Status != model.WithdrawalSubmitted || s.count() != 1 {
+		t.Errorf("Request = %+v, resp = %+v, submit calls = %d", request, resp, s.count())
+	}
+}
@@ -10,19 +10,20 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
 const (
-	// API基础URL
+	// 主网API基础URL，其他环境见environment.go
 	BaseURL = "https://api.bybit.com"
 	
 	// API版本
 	APIVersion = "v5"
 
+	// 请求有效时间窗口（毫秒）
+	RecvWindow = "5000"
+
 	// 产品类别
 	CategorySpot    = "spot"    // 现货
 	CategoryLinear  = "linear"  // USDT永续
@@ -37,6 +38,7 @@ type Client struct {
 	APISecret  string
 	HTTPClient *http.Client
 	Debug      bool
+	Env        Environment
 }
 
 // NewClient 创建一个新的Bybit API客户端
@@ -47,6 +49,16 @@ func NewClient(apiKey, apiSecret string) *Client {
 		APISecret:  apiSecret,
 		HTTPClient: &http.Client{Timeout: 10 * time.Second},
 		Debug:      false,
+		Env:        environments[EnvMainnet],
+	}
+}
+
+// SetEnvironment 设置接入环境，REST请求发送到该环境的地址
+func (c *Client) SetEnvironment(env Environment) {
+	c.BaseURL = env.RESTURL
+	c.Env = env
+	if env.Transport != nil {
+		c.HTTPClient.Transport = env.Transport
 	}
 }
 
@@ -55,38 +67,29 @@ func (c *Client) SetDebug(debug bool) {
 	c.Debug = debug
 }
 
-// 生成签名
-func (c *Client) generateSignature(params map[string]string, timestamp int64) string {
-	// 按键排序
-	var keys []string
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// 构建查询字符串
-	var queryString strings.Builder
-	queryString.WriteString(strconv.FormatInt(timestamp, 10))
-	queryString.WriteString(c.APIKey)
-
-	for _, k := range keys {
-		queryString.WriteString(k)
-		queryString.WriteString(params[k])
-	}
-
-	// 计算HMAC-SHA256签名
+// 按V5规则生成签名：timestamp + apiKey + recvWindow + 查询字符串（GET）或请求体（POST）
+func (c *Client) generateSignature(timestamp, payload string) string {
 	h := hmac.New(sha256.New, []byte(c.APISecret))
-	h.Write([]byte(queryString.String()))
+	h.Write([]byte(timestamp + c.APIKey + RecvWindow + payload))
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// 添加认证头，payload为参与签名的查询字符串或请求体
+func (c *Client) setAuthHeaders(req *http.Request, payload string) {
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	req.Header.Set("X-BAPI-API-KEY", c.APIKey)
+	req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+	req.Header.Set("X-BAPI-RECV-WINDOW", RecvWindow)
+	req.Header.Set("X-BAPI-SIGN", c.generateSignature(timestamp, payload))
+}
+
 // SendRequest 发送请求
 func (c *Client) SendRequest(method, endpoint string, params map[string]string, auth bool) ([]byte, error) {
 	// 构建URL
 	apiURL := fmt.Sprintf("%s/%s/%s", c.BaseURL, APIVersion, endpoint)
 
 	var req *http.Request
-	var err error
+	var payload string
 
 	if method == "GET" {
 		// 构建查询参数
@@ -95,9 +98,11 @@ func (c *Client) SendRequest(method, endpoint string, params map[string]string,
 			for k, v := range params {
 				queryParams.Add(k, v)
 			}
-			apiURL = fmt.Sprintf("%s?%s", apiURL, queryParams.Encode())
+			payload = queryParams.Encode()
+			apiURL = fmt.Sprintf("%s?%s", apiURL, payload)
 		}
 
+		var err error
 		req, err = http.NewRequest(method, apiURL, nil)
 		if err != nil {
 			return nil, err
@@ -108,6 +113,7 @@ func (c *Client) SendRequest(method, endpoint string, params map[string]string,
 		if err != nil {
 			return nil, err
 		}
+		payload = string(jsonParams)
 
 		req, err = http.NewRequest(method, apiURL, bytes.NewBuffer(jsonParams))
 		if err != nil {
@@ -118,14 +124,47 @@ func (c *Client) SendRequest(method, endpoint string, params map[string]string,
 
 	// 添加认证头
 	if auth {
-		timestamp := time.Now().UnixMilli()
-		signature := c.generateSignature(params, timestamp)
+		c.setAuthHeaders(req, payload)
+	}
+
+	return c.do(req)
+}
+
+// Get 发送GET请求，参数放在查询字符串中
+func (c *Client) Get(endpoint string, params map[string]string, auth bool) ([]byte, error) {
+	return c.SendRequest("GET", endpoint, params, auth)
+}
 
-		req.Header.Set("X-BAPI-API-KEY", c.APIKey)
-		req.Header.Set("X-BAPI-TIMESTAMP", strconv.FormatInt(timestamp, 10))
-		req.Header.Set("X-BAPI-SIGN", signature)
+// Post 发送POST请求，参数放在JSON请求体中
+func (c *Client) Post(endpoint string, params map[string]string, auth bool) ([]byte, error) {
+	return c.SendRequest("POST", endpoint, params, auth)
+}
+
+// PostJSON 发送请求体包含嵌套对象的POST请求
+// SendRequest只支持字符串参数，创建子账户API密钥等接口需要传递对象或数组
+func (c *Client) PostJSON(endpoint string, body interface{}, auth bool) ([]byte, error) {
+	apiURL := fmt.Sprintf("%s/%s/%s", c.BaseURL, APIVersion, endpoint)
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
 	}
 
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if auth {
+		c.setAuthHeaders(req, string(payload))
+	}
+
+	return c.do(req)
+}
+
+// 发送请求并读取响应
+func (c *Client) do(req *http.Request) ([]byte, error) {
 	// 发送请求
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -0,0 +1,151 @@
+package bybitapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// 环境名称
+const (
+	EnvMainnet = "mainnet" // 主网
+	EnvTestnet = "testnet" // 测试网
+	EnvDemo    = "demo"    // 模拟交易
+	EnvBytick  = "bytick"  // 主网备用域名
+	EnvNL      = "nl"      // 荷兰站
+	EnvTR      = "tr"      // 土耳其站
+	EnvKZ      = "kz"      // 哈萨克斯坦站
+	EnvHK      = "hk"      // 香港站
+	EnvCustom  = "custom"  // 自定义地址
+)
+
+// Environment 是一个Bybit环境的接入地址
+type Environment struct {
+	Name         string `json:"name"`         // 环境名称
+	RESTURL      string `json:"restUrl"`      // REST API地址
+	PublicWSURL  string `json:"publicWsUrl"`  // 公共行情WebSocket地址
+	PrivateWSURL string `json:"privateWsUrl"` // 私有WebSocket地址
+	Mainnet      bool   `json:"mainnet"`      // 是否使用真实资金
+
+	// Transport 不为nil时替换HTTP客户端的Transport，用于录制和回放请求
+	Transport http.RoundTripper `json:"-"`
+}
+
+// 内置环境
+var environments = map[string]Environment{
+	EnvMainnet: {
+		Name:         EnvMainnet,
+		RESTURL:      "https://api.bybit.com",
+		PublicWSURL:  "wss://stream.bybit.com/v5/public",
+		PrivateWSURL: "wss://stream.bybit.com/v5/private",
+		Mainnet:      true,
+	},
+	EnvTestnet: {
+		Name:         EnvTestnet,
+		RESTURL:      "https://api-testnet.bybit.com",
+		PublicWSURL:  "wss://stream-testnet.bybit.com/v5/public",
+		PrivateWSURL: "wss://stream-testnet.bybit.com/v5/private",
+	},
+	// 模拟交易使用主网行情，订单和资金在独立的模拟账户中
+	EnvDemo: {
+		Name:         EnvDemo,
+		RESTURL:      "https://api-demo.bybit.com",
+		PublicWSURL:  "wss://stream.bybit.com/v5/public",
+		PrivateWSURL: "wss://stream-demo.bybit.com/v5/private",
+	},
+	EnvBytick: {
+		Name:         EnvBytick,
+		RESTURL:      "https://api.bytick.com",
+		PublicWSURL:  "wss://stream.bytick.com/v5/public",
+		PrivateWSURL: "wss://stream.bytick.com/v5/private",
+		Mainnet:      true,
+	},
+	EnvNL: {
+		Name:         EnvNL,
+		RESTURL:      "https://api.bybit.nl",
+		PublicWSURL:  "wss://stream.bybit.nl/v5/public",
+		PrivateWSURL: "wss://stream.bybit.nl/v5/private",
+		Mainnet:      true,
+	},
+	EnvTR: {
+		Name:         EnvTR,
+		RESTURL:      "https://api.bybit-tr.com",
+		PublicWSURL:  "wss://stream.bybit-tr.com/v5/public",
+		PrivateWSURL: "wss://stream.bybit-tr.com/v5/private",
+		Mainnet:      true,
+	},
+	EnvKZ: {
+		Name:         EnvKZ,
+		RESTURL:      "https://api.bybit.kz",
+		PublicWSURL:  "wss://stream.bybit.kz/v5/public",
+		PrivateWSURL: "wss://stream.bybit.kz/v5/private",
+		Mainnet:      true,
+	},
+	EnvHK: {
+		Name:         EnvHK,
+		RESTURL:      "https://api.byhkbit.com",
+		PublicWSURL:  "wss://stream.byhkbit.com/v5/public",
+		PrivateWSURL: "wss://stream.byhkbit.com/v5/private",
+		Mainnet:      true,
+	},
+}
+
+// Environments 返回全部内置环境名称，按名称排序
+func Environments() []string {
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveEnvironment 根据环境名称和自定义地址确定接入地址
+// name为空时按baseURL匹配内置环境，都为空时使用主网；custom或无法识别的地址视为主网，以免误用真实资金
+func ResolveEnvironment(name, baseURL, wsURL string) (Environment, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	name = strings.ToLower(name)
+
+	switch name {
+	case "":
+		if baseURL == "" {
+			return environments[EnvMainnet], nil
+		}
+		for _, env := range environments {
+			if env.RESTURL == baseURL {
+				return env, nil
+			}
+		}
+		return customEnvironment(baseURL, wsURL), nil
+	case EnvCustom:
+		if baseURL == "" {
+			return Environment{}, fmt.Errorf("环境为custom时必须配置baseUrl")
+		}
+		return customEnvironment(baseURL, wsURL), nil
+	}
+
+	env, ok := environments[name]
+	if !ok {
+		return Environment{}, fmt.Errorf("未知的环境%q，可选值: %s、%s", name, strings.Join(Environments(), "、"), EnvCustom)
+	}
+	if baseURL != "" && baseURL != env.RESTURL {
+		return Environment{}, fmt.Errorf("baseUrl %s与环境%s的地址%s不一致，使用自定义地址时请把环境设为custom", baseURL, name, env.RESTURL)
+	}
+	return env, nil
+}
+
+// 自定义地址，wsURL为空时不提供WebSocket地址
+func customEnvironment(baseURL, wsURL string) Environment {
+	wsURL = strings.TrimRight(wsURL, "/")
+	env := Environment{
+		Name:    EnvCustom,
+		RESTURL: baseURL,
+		Mainnet: true,
+	}
+	if wsURL != "" {
+		env.PublicWSURL = wsURL + "/v5/public"
+		env.PrivateWSURL = wsURL + "/v5/private"
+	}
+	return env
+}
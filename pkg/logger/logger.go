@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -29,6 +30,7 @@ var levelMap = map[string]int{
 
 // Logger 是日志记录器
 type Logger struct {
+	mu     sync.RWMutex
 	level  string
 	output io.Writer
 	logger *log.Logger
@@ -36,11 +38,39 @@ type Logger struct {
 
 // Output 返回日志输出位置
 func (l *Logger) Output() io.Writer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return l.output
 }
 
 // New 创建一个新的日志记录器
 func New(level, output string) *Logger {
+	out := openOutput(output)
+	return &Logger{
+		level:  strings.ToLower(level),
+		output: out,
+		logger: log.New(out, "", log.LstdFlags),
+	}
+}
+
+// Configure 在运行中修改日志级别和输出位置，之前打开的日志文件会被关闭
+func (l *Logger) Configure(level, output string) {
+	out := openOutput(output)
+
+	l.mu.Lock()
+	old := l.output
+	l.level = strings.ToLower(level)
+	l.output = out
+	l.logger.SetOutput(out)
+	l.mu.Unlock()
+
+	if file, ok := old.(*os.File); ok && file != out && file != os.Stdout && file != os.Stderr {
+		file.Close()
+	}
+}
+
+// 打开日志输出，文件无法打开时使用标准输出
+func openOutput(output string) io.Writer {
 	var out io.Writer
 	switch strings.ToLower(output) {
 	case "stdout":
@@ -57,17 +87,14 @@ func New(level, output string) *Logger {
 			out = file
 		}
 	}
-
-	return &Logger{
-		level:  strings.ToLower(level),
-		output: out,
-		logger: log.New(out, "", log.LstdFlags),
-	}
+	return out
 }
 
 // 检查是否应该记录该级别的日志
 func (l *Logger) shouldLog(level string) bool {
+	l.mu.RLock()
 	currentLevel, ok := levelMap[l.level]
+	l.mu.RUnlock()
 	if !ok {
 		currentLevel = levelMap[InfoLevel] // 默认为info级别
 	}
@@ -128,7 +155,7 @@ var defaultLogger = New(InfoLevel, "stdout")
 
 // SetDefaultLogger 设置默认日志记录器
 func SetDefaultLogger(level, output string) {
-	defaultLogger = New(level, output)
+	defaultLogger.Configure(level, output)
 }
 
 // Debug 使用默认日志记录器记录调试级别日志